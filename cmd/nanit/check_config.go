@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/app"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+)
+
+// checkDialTimeout - how long the config check waits for the MQTT broker to accept a connection
+const checkDialTimeout = 5 * time.Second
+
+// configCheck - single line of the config check report
+type configCheck struct {
+	name   string
+	err    error
+	detail string
+}
+
+// handleCheckConfig validates the configuration and the environment without starting the app (CLI command)
+// Prints a pass/fail report, exits with a non-zero status if any check failed.
+func handleCheckConfig(envFiles []string) {
+	checks := runConfigChecks(envFiles)
+
+	failed := 0
+	for _, check := range checks {
+		if check.err != nil {
+			failed++
+			fmt.Printf("[FAIL] %s: %v\n", check.name, check.err)
+		} else {
+			fmt.Printf("[PASS] %s: %s\n", check.name, check.detail)
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("\n%d of %d checks failed.\n", failed, len(checks))
+		os.Exit(1)
+	}
+
+	fmt.Printf("\nAll %d checks passed.\n", len(checks))
+}
+
+// runConfigChecks runs the checks in startup order, checks depending on valid options are skipped if parsing fails
+func runConfigChecks(envFiles []string) []configCheck {
+	checks := make([]configCheck, 0)
+	checks = append(checks, checkEnvFiles(envFiles))
+
+	dataDir, err := resolveDataDir()
+	if err != nil {
+		return append(checks, configCheck{name: "Data directory", err: err})
+	}
+	checks = append(checks, checkDataDir(dataDir))
+
+	// Invalid variables are reported all at once instead of exiting on the first one
+	var retryErr error
+	retryErrs := utils.CollectEnvVarErrors(func() {
+		_, retryErr = retryConfigFromEnv()
+	})
+	if retryErr != nil {
+		retryErrs = append(retryErrs, retryErr)
+	}
+	checks = append(checks, envVarChecks("Retry settings", retryErrs)...)
+
+	var opts app.Opts
+	var optsErr error
+	optsErrs := utils.CollectEnvVarErrors(func() {
+		opts, optsErr = loadOpts(dataDirectoriesFor(dataDir), sessionFilePath(dataDir), passwordFilePath(dataDir))
+	})
+	if optsErr != nil {
+		optsErrs = append(optsErrs, optsErr)
+	}
+	checks = append(checks, envVarChecks("Environment variables", optsErrs)...)
+	if len(optsErrs) > 0 {
+		return checks
+	}
+
+	if opts.History.Enabled {
+		checks = append(checks, checkHistoryDB(opts.History.DBPath))
+	}
+
+	checks = append(checks, checkListen("HTTP port", fmt.Sprintf(":%d", opts.HTTPPort)))
+
+	if opts.RTMP != nil {
+		checks = append(checks, checkListen("RTMP listen address", opts.RTMP.ListenAddr))
+		checks = append(checks, checkExecutable("ffmpeg"))
+		checks = append(checks, checkExecutable("ffprobe"))
+	}
+
+	if opts.MQTT != nil {
+		checks = append(checks, checkMQTTBroker(opts.MQTT.BrokerURL))
+	}
+
+	return checks
+}
+
+// envVarChecks returns a failed check per problem found in the variables, a passed one if there is none
+func envVarChecks(name string, errs []error) []configCheck {
+	if len(errs) == 0 {
+		return []configCheck{{name: name, detail: "valid"}}
+	}
+
+	checks := make([]configCheck, 0, len(errs))
+	for _, err := range errs {
+		checks = append(checks, configCheck{name: name, err: err})
+	}
+
+	return checks
+}
+
+// checkDataDir verifies the data directory is writable, or can be created if missing
+func checkDataDir(dataDir string) configCheck {
+	check := configCheck{name: "Data directory"}
+
+	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
+		if check.err = checkDirWritable(filepath.Dir(dataDir)); check.err == nil {
+			check.detail = fmt.Sprintf("%s (will be created)", dataDir)
+		}
+		return check
+	}
+
+	for _, dir := range appDataDirs(dataDirectoriesFor(dataDir)) {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+
+		if check.err = checkDirWritable(dir); check.err != nil {
+			return check
+		}
+	}
+
+	check.detail = dataDir
+	return check
+}
+
+// checkHistoryDB verifies the history database is writable, or its directory can be created if missing
+func checkHistoryDB(dbPath string) configCheck {
+	check := configCheck{name: "History database"}
+
+	dir := filepath.Dir(dbPath)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if check.err = checkDirWritable(filepath.Dir(dir)); check.err == nil {
+			check.detail = fmt.Sprintf("%s (directory will be created)", dbPath)
+		}
+		return check
+	}
+
+	if check.err = checkFileWritable(dbPath); check.err == nil {
+		check.detail = dbPath
+	}
+	return check
+}
+
+// checkListen verifies the address can be bound, the listener is closed right away
+func checkListen(name string, addr string) configCheck {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return configCheck{name: name, err: fmt.Errorf("unable to listen on %s: %w", addr, err)}
+	}
+	listener.Close()
+
+	return configCheck{name: name, detail: fmt.Sprintf("%s is available", addr)}
+}
+
+// checkExecutable verifies the executable is on the PATH
+func checkExecutable(name string) configCheck {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return configCheck{name: name, err: fmt.Errorf("not found on PATH: %w", err)}
+	}
+
+	return configCheck{name: name, detail: path}
+}
+
+// checkMQTTBroker verifies the broker accepts TCP connections, credentials are not verified
+func checkMQTTBroker(brokerURL string) configCheck {
+	check := configCheck{name: "MQTT broker"}
+
+	parsed, err := url.Parse(brokerURL)
+	if err != nil {
+		check.err = err
+		return check
+	}
+
+	addr := parsed.Host
+	if parsed.Port() == "" {
+		addr = net.JoinHostPort(parsed.Hostname(), defaultMQTTPort(parsed.Scheme))
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, checkDialTimeout)
+	if err != nil {
+		check.err = fmt.Errorf("unable to reach %s: %w", addr, err)
+		return check
+	}
+	conn.Close()
+
+	check.detail = fmt.Sprintf("%s is reachable", addr)
+	return check
+}
+
+// defaultMQTTPort returns the port used by the MQTT client when the broker URL doesn't specify one
+func defaultMQTTPort(scheme string) string {
+	switch scheme {
+	case "ssl", "tls", "mqtts", "tcps":
+		return "8883"
+	case "ws":
+		return "80"
+	case "wss":
+		return "443"
+	default:
+		return "1883"
+	}
+}
+
+// checkEnvFiles reports the loaded .env files, variables of them resolved to empty values are listed (not a failure)
+func checkEnvFiles(envFiles []string) configCheck {
+	if len(envFiles) == 0 {
+		return configCheck{name: "Environment files", detail: "none loaded, using only environment variables"}
+	}
+
+	detail := strings.Join(envFiles, ", ")
+	if empty := utils.EmptyDotEnvVars(envFiles); len(empty) > 0 {
+		detail += fmt.Sprintf(" (empty: %s)", strings.Join(empty, ", "))
+	}
+
+	return configCheck{name: "Environment files", detail: detail}
+}
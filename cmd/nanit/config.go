@@ -0,0 +1,394 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/app"
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/indiefan/home_assistant_nanit/pkg/history"
+	"github.com/indiefan/home_assistant_nanit/pkg/mqtt"
+	"github.com/indiefan/home_assistant_nanit/pkg/notify"
+	"github.com/indiefan/home_assistant_nanit/pkg/resilience"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+)
+
+// ffmpegLogLevels - accepted values of NANIT_FFMPEG_LOGLEVEL
+var ffmpegLogLevels = map[string]bool{
+	"quiet": true, "panic": true, "fatal": true, "error": true, "warning": true,
+	"info": true, "verbose": true, "debug": true, "trace": true,
+}
+
+// loadOpts builds the run options from the environment, returns an error describing the first invalid value
+// Note: malformed booleans, integers and durations are still reported by the utils.EnvVar* helpers.
+func loadOpts(dataDirs app.DataDirectories, sessionFile string, passwordFile string) (app.Opts, error) {
+	// Absolute stream links are derived from the request (honoring X-Forwarded-* headers) unless configured
+	publicBaseURL := utils.EnvVarStr("NANIT_PUBLIC_BASE_URL", "")
+	if publicBaseURL != "" {
+		if parsed, err := url.Parse(publicBaseURL); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return app.Opts{}, fmt.Errorf("invalid NANIT_PUBLIC_BASE_URL '%s'. Expected format: 'scheme://host[:port]' (e.g., 'https://nanit.example.com')", publicBaseURL)
+		}
+	}
+
+	// Nanit token is renewed in the background 5 minutes before its estimated expiry by default
+	tokenRefreshLead := utils.EnvVarSeconds("NANIT_TOKEN_REFRESH_LEAD", 5*time.Minute)
+	if tokenRefreshLead < 0 || tokenRefreshLead >= client.AuthTokenTimelife {
+		return app.Opts{}, fmt.Errorf("invalid NANIT_TOKEN_REFRESH_LEAD %v, must be between 0 and the token lifetime (%v)", tokenRefreshLead, client.AuthTokenTimelife)
+	}
+
+	httpPort := utils.EnvVarInt("NANIT_HTTP_PORT", 8080)
+	if httpPort < 1 || httpPort > 65535 {
+		return app.Opts{}, fmt.Errorf("invalid NANIT_HTTP_PORT %d, must be between 1 and 65535", httpPort)
+	}
+
+	historyDBPath, err := resolveHistoryDBPath(dataDirs)
+	if err != nil {
+		return app.Opts{}, fmt.Errorf("invalid NANIT_HISTORY_DB_PATH: %w", err)
+	}
+
+	// 30 second default cooldown between propagated events of the same type
+	eventCooldown := utils.EnvVarSeconds("NANIT_EVENT_COOLDOWN", 30*time.Second)
+
+	opts := app.Opts{
+		Version: GitCommit,
+		NanitCredentials: app.NanitCredentials{
+			Email:        utils.EnvVarStr("NANIT_EMAIL", ""),
+			Password:     utils.EnvVarStrOrFile("NANIT_PASSWORD", ""),
+			RefreshToken: utils.EnvVarStrOrFile("NANIT_REFRESH_TOKEN", ""),
+		},
+		SessionFile:      sessionFile,
+		TokenRefreshLead: tokenRefreshLead,
+		// Authorization is retried every 5 minutes by default if it failed on startup
+		StartupAuthRetryInterval: utils.EnvVarSeconds("NANIT_STARTUP_AUTH_RETRY_INTERVAL", 5*time.Minute),
+		DataDirectories:          dataDirs,
+		Health: app.HealthOpts{
+			// MQTT and Nanit API outages don't affect readiness by default
+			RequireMQTT:     utils.EnvVarBool("NANIT_READINESS_REQUIRE_MQTT", false),
+			RequireNanitAPI: utils.EnvVarBool("NANIT_READINESS_REQUIRE_NANIT_API", false),
+			// Liveness doesn't depend on the services by default
+			LivenessUnhealthyThreshold: utils.EnvVarSeconds("NANIT_LIVENESS_UNHEALTHY_THRESHOLD", 0),
+		},
+		CamLog: app.CamLogOpts{
+			// Keep the 20 newest camera logs by default
+			MaxFiles: utils.EnvVarInt("NANIT_CAMLOG_MAX_FILES", 20),
+			// Keep camera logs for 7 days by default
+			MaxAge: time.Duration(utils.EnvVarInt("NANIT_CAMLOG_RETENTION_DAYS", 7)) * 24 * time.Hour,
+			// 50 MB default limit of a single upload
+			MaxUploadBytes: int64(utils.EnvVarInt("NANIT_CAMLOG_MAX_SIZE_MB", 50)) << 20,
+		},
+		HTTPEnabled:   true,
+		HTTPPort:      httpPort,
+		PublicBaseURL: publicBaseURL,
+		// Same-origin only by default
+		CORSAllowedOrigins: utils.EnvVarStrList("NANIT_CORS_ALLOWED_ORIGINS"),
+		// 64 KB default limit of JSON API request bodies
+		MaxRequestBodyBytes: int64(utils.EnvVarInt("NANIT_HTTP_MAX_BODY_KB", 64)) << 10,
+		// Below the 10 second grace period of docker stop by default
+		ShutdownTimeout: utils.EnvVarSeconds("NANIT_SHUTDOWN_TIMEOUT", 8*time.Second),
+		EventPolling: app.EventPollingOpts{
+			// Event message polling disabled by default
+			Enabled: utils.EnvVarBool("NANIT_EVENTS_POLLING", false),
+			// 30 second default polling interval
+			PollingInterval: utils.EnvVarSeconds("NANIT_EVENTS_POLLING_INTERVAL", 30*time.Second),
+			// 300 second (5 min) default message timeout (unseen messages are ignored once they are this old)
+			MessageTimeout: utils.EnvVarSeconds("NANIT_EVENTS_MESSAGE_TIMEOUT", 300*time.Second),
+			// Per baby overrides, e.g. NANIT_EVENTS_POLLING_BABY_<BABY_UID>=true
+			PerBaby: utils.EnvVarBoolsWithPrefix("NANIT_EVENTS_POLLING_BABY_"),
+		},
+		WebsocketKeepalive: app.WebsocketKeepaliveOpts{
+			// Keepalive message every 20 seconds by default
+			Interval: utils.EnvVarSeconds("NANIT_WS_KEEPALIVE_INTERVAL", client.DefaultKeepaliveInterval),
+			// Stale connection detection disabled by default
+			Timeout: utils.EnvVarSeconds("NANIT_WS_KEEPALIVE_TIMEOUT", 0),
+		},
+		StreamRetry: app.StreamRetryOpts{
+			// Failed stream requests are retried after 60 seconds, backing off to 10 minutes
+			Interval:    utils.EnvVarSeconds("NANIT_STREAM_RETRY_INTERVAL", 60*time.Second),
+			MaxInterval: utils.EnvVarSeconds("NANIT_STREAM_RETRY_MAX_INTERVAL", 10*time.Minute),
+			// ±20% so cameras don't retry in lockstep
+			Jitter: utils.EnvVarFloat("NANIT_STREAM_RETRY_JITTER", 0.2),
+			// Monitor stops after 30 minutes without failures
+			IdleTimeout: utils.EnvVarSeconds("NANIT_STREAM_RETRY_IDLE_TIMEOUT", 30*time.Minute),
+		},
+		PollFallback: app.PollFallbackOpts{
+			// REST polling while the WebSocket is down disabled by default
+			Enabled: utils.EnvVarBool("NANIT_SENSOR_POLL_FALLBACK", false),
+			// Start polling once the WebSocket is down for 2 minutes by default
+			Threshold: utils.EnvVarSeconds("NANIT_SENSOR_POLL_FALLBACK_THRESHOLD", 2*time.Minute),
+			// 60 second default polling interval
+			Interval: utils.EnvVarSeconds("NANIT_SENSOR_POLL_FALLBACK_INTERVAL", 60*time.Second),
+		},
+		EventCooldown: app.EventCooldownOpts{
+			Default: eventCooldown,
+			// Per event type overrides, fall back to NANIT_EVENT_COOLDOWN
+			PerType: map[string]time.Duration{
+				notify.EventMotion: utils.EnvVarSeconds("NANIT_EVENT_COOLDOWN_MOTION", eventCooldown),
+				notify.EventSound:  utils.EnvVarSeconds("NANIT_EVENT_COOLDOWN_SOUND", eventCooldown),
+			},
+		},
+		// Motion/sound is reported as active for 30 seconds after the latest event by default
+		EventActiveWindow: utils.EnvVarSeconds("NANIT_EVENT_ACTIVE_WINDOW", 30*time.Second),
+		// Flapping camera connections are reported at most once a minute by default
+		StreamEventCooldown: utils.EnvVarSeconds("NANIT_STREAM_EVENT_COOLDOWN", 60*time.Second),
+		// Device info older than 10 minutes is reported stale by default
+		DeviceInfoMaxAge: utils.EnvVarSeconds("NANIT_DEVICE_INFO_MAX_AGE", 10*time.Minute),
+		// Raw camera commands are for experimenting, disabled by default
+		RawCommandsEnabled: utils.EnvVarBool("NANIT_RAW_COMMANDS_ENABLED", false),
+		SensorChangeThresholds: baby.SensorChangeThresholds{
+			// Every temperature and humidity change is propagated by default
+			TemperatureMilli: int32(math.Round(utils.EnvVarFloat("NANIT_SENSOR_TEMPERATURE_CHANGE_THRESHOLD", 0) * 1000)),
+			HumidityMilli:    int32(math.Round(utils.EnvVarFloat("NANIT_SENSOR_HUMIDITY_CHANGE_THRESHOLD", 0) * 1000)),
+		},
+		History: app.HistoryOpts{
+			// Historical tracking enabled by default
+			Enabled: utils.EnvVarBool("NANIT_HISTORY_ENABLED", true),
+			// Keep data for 30 days by default
+			RetentionDays: utils.EnvVarInt("NANIT_HISTORY_RETENTION_DAYS", 30),
+			// Auto-cleanup enabled by default
+			CleanupEnabled: utils.EnvVarBool("NANIT_HISTORY_CLEANUP_ENABLED", true),
+			// Store at most one sensor reading per 30 seconds by default, significant changes are stored right away
+			SampleInterval: utils.EnvVarSeconds("NANIT_HISTORY_SAMPLE_INTERVAL", 30*time.Second),
+			// Day/night state is carried over any gap by default (as before the limit existed), opt in to count long gaps as unknown
+			DayNightMaxGap: utils.EnvVarSeconds("NANIT_HISTORY_DAY_NIGHT_MAX_GAP", 0),
+			// Sound events up to 5 minutes apart are merged into one crying episode by default
+			CryEpisodeGap: utils.EnvVarSeconds("NANIT_HISTORY_CRY_EPISODE_GAP", history.DefaultCryEpisodeGap),
+			// Only calibrated sensor values are stored by default
+			StoreRawSensorData: utils.EnvVarBool("NANIT_HISTORY_STORE_RAW_SENSOR", false),
+			// history.db in the history directory unless NANIT_HISTORY_DB_PATH is set
+			DBPath: historyDBPath,
+		},
+		WebAuth: app.WebAuthOpts{
+			// Web password protection always available
+			Enabled: true,
+			// Password file always in data directory
+			PasswordFile: passwordFile,
+		},
+	}
+
+	if opts.ShutdownTimeout < 0 {
+		return app.Opts{}, fmt.Errorf("invalid NANIT_SHUTDOWN_TIMEOUT %v, must be 0 (wait indefinitely) or greater", opts.ShutdownTimeout.Seconds())
+	}
+
+	if opts.MaxRequestBodyBytes < 0 {
+		return app.Opts{}, fmt.Errorf("invalid NANIT_HTTP_MAX_BODY_KB %d, must be 0 (unlimited) or greater", opts.MaxRequestBodyBytes>>10)
+	}
+
+	if opts.DeviceInfoMaxAge < time.Second {
+		return app.Opts{}, fmt.Errorf("invalid NANIT_DEVICE_INFO_MAX_AGE %v, must be at least 1 second", opts.DeviceInfoMaxAge.Seconds())
+	}
+
+	if opts.WebsocketKeepalive.Interval <= 0 {
+		return app.Opts{}, fmt.Errorf("invalid NANIT_WS_KEEPALIVE_INTERVAL %v, must be at least 1 second", opts.WebsocketKeepalive.Interval.Seconds())
+	}
+
+	if opts.WebsocketKeepalive.Timeout < 0 || (opts.WebsocketKeepalive.Timeout > 0 && opts.WebsocketKeepalive.Timeout <= opts.WebsocketKeepalive.Interval) {
+		return app.Opts{}, fmt.Errorf("invalid NANIT_WS_KEEPALIVE_TIMEOUT %v, must be 0 (disabled) or longer than NANIT_WS_KEEPALIVE_INTERVAL", opts.WebsocketKeepalive.Timeout.Seconds())
+	}
+
+	if opts.StartupAuthRetryInterval < 0 {
+		return app.Opts{}, fmt.Errorf("invalid NANIT_STARTUP_AUTH_RETRY_INTERVAL %v, must be 0 (disabled) or greater", opts.StartupAuthRetryInterval.Seconds())
+	}
+
+	if opts.StreamRetry.Interval < time.Second {
+		return app.Opts{}, fmt.Errorf("invalid NANIT_STREAM_RETRY_INTERVAL %v, must be at least 1 second", opts.StreamRetry.Interval.Seconds())
+	}
+
+	if opts.StreamRetry.MaxInterval < opts.StreamRetry.Interval {
+		return app.Opts{}, fmt.Errorf("invalid NANIT_STREAM_RETRY_MAX_INTERVAL %v, must be at least NANIT_STREAM_RETRY_INTERVAL", opts.StreamRetry.MaxInterval.Seconds())
+	}
+
+	if opts.StreamRetry.Jitter < 0 || opts.StreamRetry.Jitter >= 1 {
+		return app.Opts{}, fmt.Errorf("invalid NANIT_STREAM_RETRY_JITTER %v, must be between 0 and 1 (exclusive)", opts.StreamRetry.Jitter)
+	}
+
+	if opts.StreamRetry.IdleTimeout < 0 {
+		return app.Opts{}, fmt.Errorf("invalid NANIT_STREAM_RETRY_IDLE_TIMEOUT %v, must be 0 (never stop) or greater", opts.StreamRetry.IdleTimeout.Seconds())
+	}
+
+	if opts.PollFallback.Enabled && opts.PollFallback.Interval <= 0 {
+		return app.Opts{}, fmt.Errorf("invalid NANIT_SENSOR_POLL_FALLBACK_INTERVAL %v, must be at least 1 second", opts.PollFallback.Interval.Seconds())
+	}
+
+	if opts.SensorChangeThresholds.TemperatureMilli < 0 {
+		return app.Opts{}, fmt.Errorf("invalid NANIT_SENSOR_TEMPERATURE_CHANGE_THRESHOLD %v, must be 0 (disabled) or greater", float64(opts.SensorChangeThresholds.TemperatureMilli)/1000)
+	}
+
+	if opts.SensorChangeThresholds.HumidityMilli < 0 {
+		return app.Opts{}, fmt.Errorf("invalid NANIT_SENSOR_HUMIDITY_CHANGE_THRESHOLD %v, must be 0 (disabled) or greater", float64(opts.SensorChangeThresholds.HumidityMilli)/1000)
+	}
+
+	if utils.EnvVarBool("NANIT_RTMP_ENABLED", true) {
+		publicAddr := utils.EnvVarStr("NANIT_RTMP_ADDR", "")
+		if publicAddr == "" {
+			return app.Opts{}, fmt.Errorf("missing required environment variable NANIT_RTMP_ADDR (or set NANIT_RTMP_ENABLED=false)")
+		}
+
+		rtmpPort, err := parseRTMPAddr(publicAddr)
+		if err != nil {
+			return app.Opts{}, fmt.Errorf("invalid NANIT_RTMP_ADDR '%s': %w. Expected format: 'host:port' (e.g., '192.168.1.100:1935', 'nanit.lan:1935' or '[fd00::10]:1935')", publicAddr, err)
+		}
+
+		// All interfaces on the port of the public address by default
+		listenAddr := utils.EnvVarStr("NANIT_RTMP_LISTEN_ADDR", ":"+rtmpPort)
+		if _, port, err := net.SplitHostPort(listenAddr); err != nil {
+			return app.Opts{}, fmt.Errorf("invalid NANIT_RTMP_LISTEN_ADDR '%s': %w. Expected format: '[host]:port' (e.g., '192.168.1.100:1935' or ':1935')", listenAddr, err)
+		} else if portNum, err := strconv.Atoi(port); err != nil || portNum < 1 || portNum > 65535 {
+			return app.Opts{}, fmt.Errorf("invalid NANIT_RTMP_LISTEN_ADDR '%s': port must be a number between 1 and 65535", listenAddr)
+		}
+
+		opts.RTMP = &app.RTMPOpts{
+			ListenAddr: listenAddr,
+			PublicAddr: publicAddr,
+			AutoStart:  utils.EnvVarBool("NANIT_RTMP_AUTO_START", true),
+			// Per baby overrides, e.g. NANIT_RTMP_AUTO_START_<BABY_UID>=false keeps that camera idle
+			AutoStartOverrides: utils.EnvVarBoolsWithPrefix("NANIT_RTMP_AUTO_START_"),
+			// 2 second default delay before requesting the stream from the cam
+			StreamStartDelay: utils.EnvVarSeconds("NANIT_STREAM_START_DELAY", 2*time.Second),
+			// 1 second default lead time after the stream goes live before starting HLS
+			HLSStartDelay: utils.EnvVarSeconds("NANIT_HLS_START_DELAY", 1*time.Second),
+			// 30 second default wait for the stream to go live
+			HLSStartTimeout: utils.EnvVarSeconds("NANIT_HLS_START_TIMEOUT", 30*time.Second),
+			// 10 second default, FFmpeg exits on a stalled input and the transcoder restarts it
+			FFmpegRWTimeout: utils.EnvVarSeconds("NANIT_FFMPEG_RW_TIMEOUT", 10*time.Second),
+			FFmpegReconnect: utils.EnvVarBool("NANIT_FFMPEG_RECONNECT", true),
+			// Unlimited by default
+			HLSMaxConcurrent: utils.EnvVarInt("NANIT_HLS_MAX_CONCURRENT", 0),
+			// 5 segments (~10 seconds) at the live edge by default
+			HLSLiveSegments: utils.EnvVarInt("NANIT_HLS_LIVE_SEGMENTS", 5),
+			// DVR playlist disabled by default
+			HLSDVRWindow: utils.EnvVarSeconds("NANIT_HLS_DVR_WINDOW", 0),
+			// Segments are deleted once they drop out of the playlists by default
+			HLSSegmentRetention: utils.EnvVarSeconds("NANIT_HLS_SEGMENT_RETENTION", 0),
+			// FFmpeg's own default (info) unless configured
+			FFmpegLogLevel: utils.EnvVarStr("NANIT_FFMPEG_LOGLEVEL", ""),
+			// FFmpeg output is only kept in memory for diagnostics by default
+			FFmpegLogFile: utils.EnvVarBool("NANIT_FFMPEG_LOG_FILE", false),
+			// 10 MB default size of a log file before it is rotated
+			FFmpegLogMaxSize: int64(utils.EnvVarInt("NANIT_FFMPEG_LOG_MAX_SIZE_MB", 10)) << 20,
+			// 10 second default, brief WebSocket drops don't restart the stream
+			DisconnectGracePeriod: utils.EnvVarSeconds("NANIT_DISCONNECT_GRACE_PERIOD", 10*time.Second),
+		}
+
+		// Mobile stream by default, as requested by the Nanit app
+		streamIdentifier := strings.ToUpper(utils.EnvVarStr("NANIT_STREAM_IDENTIFIER", "MOBILE"))
+		if id, ok := client.StreamIdentifier_value[streamIdentifier]; ok {
+			opts.RTMP.StreamIdentifier = client.StreamIdentifier(id)
+		} else {
+			return app.Opts{}, fmt.Errorf("invalid NANIT_STREAM_IDENTIFIER '%s', must be one of MOBILE, DVR or ANALYTICS", streamIdentifier)
+		}
+
+		if opts.RTMP.HLSMaxConcurrent < 0 {
+			return app.Opts{}, fmt.Errorf("invalid NANIT_HLS_MAX_CONCURRENT %d, must be 0 (unlimited) or greater", opts.RTMP.HLSMaxConcurrent)
+		}
+
+		if opts.RTMP.FFmpegLogLevel != "" && !ffmpegLogLevels[opts.RTMP.FFmpegLogLevel] {
+			return app.Opts{}, fmt.Errorf("invalid NANIT_FFMPEG_LOGLEVEL '%s', must be one of quiet, panic, fatal, error, warning, info, verbose, debug or trace", opts.RTMP.FFmpegLogLevel)
+		}
+
+		if opts.RTMP.FFmpegLogMaxSize < 0 {
+			return app.Opts{}, fmt.Errorf("invalid NANIT_FFMPEG_LOG_MAX_SIZE_MB %d, must be 0 (no rotation) or greater", opts.RTMP.FFmpegLogMaxSize>>20)
+		}
+
+		if opts.RTMP.HLSLiveSegments < 1 {
+			return app.Opts{}, fmt.Errorf("invalid NANIT_HLS_LIVE_SEGMENTS %d, must be 1 or greater", opts.RTMP.HLSLiveSegments)
+		}
+
+		if opts.RTMP.HLSDVRWindow < 0 {
+			return app.Opts{}, fmt.Errorf("invalid NANIT_HLS_DVR_WINDOW %v, must be 0 (disabled) or greater", opts.RTMP.HLSDVRWindow)
+		}
+
+		if opts.RTMP.HLSSegmentRetention != 0 && opts.RTMP.HLSSegmentRetention < opts.RTMP.HLSDVRWindow {
+			return app.Opts{}, fmt.Errorf("invalid NANIT_HLS_SEGMENT_RETENTION %v, must not be shorter than NANIT_HLS_DVR_WINDOW %v", opts.RTMP.HLSSegmentRetention, opts.RTMP.HLSDVRWindow)
+		}
+	}
+
+	if utils.EnvVarBool("NANIT_MQTT_ENABLED", false) {
+		brokerURL := utils.EnvVarStr("NANIT_MQTT_BROKER_URL", "")
+		if brokerURL == "" {
+			return app.Opts{}, fmt.Errorf("missing required environment variable NANIT_MQTT_BROKER_URL (or set NANIT_MQTT_ENABLED=false)")
+		}
+
+		if parsed, err := url.Parse(brokerURL); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return app.Opts{}, fmt.Errorf("invalid NANIT_MQTT_BROKER_URL '%s'. Expected format: 'scheme://host:port' (e.g., 'tcp://192.168.1.10:1883')", brokerURL)
+		}
+
+		// QoS 0 (at most once) by default
+		qos := utils.EnvVarInt("NANIT_MQTT_QOS", 0)
+		if qos < 0 || qos > 2 {
+			return app.Opts{}, fmt.Errorf("invalid NANIT_MQTT_QOS %d. Allowed values: 0, 1, 2", qos)
+		}
+
+		opts.MQTT = &mqtt.Opts{
+			BrokerURL:   brokerURL,
+			ClientID:    utils.EnvVarStr("NANIT_MQTT_CLIENT_ID", "nanit"),
+			Username:    utils.EnvVarStr("NANIT_MQTT_USERNAME", ""),
+			Password:    utils.EnvVarStrOrFile("NANIT_MQTT_PASSWORD", ""),
+			TopicPrefix: utils.EnvVarStr("NANIT_MQTT_PREFIX", "nanit"),
+			QoS:         byte(qos),
+			// State topics are retained by default, motion/sound events never are
+			RetainState: utils.EnvVarBool("NANIT_MQTT_RETAIN", true),
+		}
+	}
+
+	if webhookURL := utils.EnvVarStr("NANIT_WEBHOOK_URL", ""); webhookURL != "" {
+		opts.Notify = &notify.Opts{
+			WebhookURL:      webhookURL,
+			PayloadTemplate: utils.EnvVarStr("NANIT_WEBHOOK_TEMPLATE", ""),
+		}
+	}
+
+	return opts, nil
+}
+
+// parseRTMPAddr validates the address the cameras dial and returns its port
+// The host must be an IP address (IPv6 in brackets) or a resolvable hostname.
+func parseRTMPAddr(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+
+	if portNum, err := strconv.Atoi(port); err != nil || portNum < 1 || portNum > 65535 {
+		return "", fmt.Errorf("port '%s' must be a number between 1 and 65535", port)
+	}
+
+	if host == "" {
+		return "", fmt.Errorf("host is missing, the cameras need an address they can reach")
+	}
+
+	if net.ParseIP(host) == nil {
+		if _, err := net.LookupHost(host); err != nil {
+			return "", fmt.Errorf("host '%s' is neither an IP address nor a resolvable hostname", host)
+		}
+	}
+
+	return port, nil
+}
+
+// retryConfigFromEnv builds the default retry configuration
+func retryConfigFromEnv() (resilience.RetryConfig, error) {
+	config := resilience.DefaultRetryConfig()
+
+	// 3 retries (4 attempts) by default
+	config.MaxRetries = utils.EnvVarInt("NANIT_RETRY_MAX", config.MaxRetries)
+	// 1 second default delay before the first retry, doubled on each further retry
+	config.InitialDelay = utils.EnvVarSeconds("NANIT_RETRY_BASE_DELAY", config.InitialDelay)
+	// 30 second default cap of the delay
+	config.MaxDelay = utils.EnvVarSeconds("NANIT_RETRY_MAX_DELAY", config.MaxDelay)
+
+	if config.MaxRetries < 0 {
+		return config, fmt.Errorf("invalid NANIT_RETRY_MAX %d, must be 0 or greater", config.MaxRetries)
+	}
+
+	if config.MaxDelay < config.InitialDelay {
+		return config, fmt.Errorf("invalid NANIT_RETRY_MAX_DELAY %v, must not be lower than NANIT_RETRY_BASE_DELAY (%v)", config.MaxDelay, config.InitialDelay)
+	}
+
+	return config, nil
+}
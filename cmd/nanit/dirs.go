@@ -1,22 +1,21 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/rs/zerolog/log"
 	"github.com/indiefan/home_assistant_nanit/pkg/app"
+	"github.com/indiefan/home_assistant_nanit/pkg/history"
 	"github.com/indiefan/home_assistant_nanit/pkg/utils"
 )
 
-func ensureDataDirectories() (app.DataDirectories, error) {
-	relDataDir := utils.EnvVarStr("NANIT_DATA_DIR", "/data")
-
-	absDataDir, filePathErr := filepath.Abs(relDataDir)
-	if filePathErr != nil {
-		log.Error().Str("path", relDataDir).Err(filePathErr).Msg("Unable to retrieve absolute file path")
-		return app.DataDirectories{}, fmt.Errorf("failed to get absolute path for data directory '%s': %w", relDataDir, filePathErr)
+func ensureDataDirectories() (app.DataDirectories, *utils.FileLock, error) {
+	absDataDir, err := resolveDataDir()
+	if err != nil {
+		return app.DataDirectories{}, nil, err
 	}
 
 	// Create base data directory if it does not exist
@@ -25,10 +24,19 @@ func ensureDataDirectories() (app.DataDirectories, error) {
 		mkdirErr := os.MkdirAll(absDataDir, 0755)
 		if mkdirErr != nil {
 			log.Error().Str("path", absDataDir).Err(mkdirErr).Msg("Unable to create data directory")
-			return app.DataDirectories{}, fmt.Errorf("failed to create data directory '%s': %w", absDataDir, mkdirErr)
+			return app.DataDirectories{}, nil, fmt.Errorf("failed to create data directory '%s': %w", absDataDir, mkdirErr)
 		}
 	}
 
+	// Another instance using the same data dir would corrupt the history DB, session and HLS files
+	lockFile := filepath.Join(absDataDir, dataDirLockFile)
+	lock, err := utils.LockFile(lockFile)
+	if errors.Is(err, utils.ErrLocked) {
+		return app.DataDirectories{}, nil, fmt.Errorf("data directory '%s' is in use by another instance of the app, stop it first: %w", absDataDir, err)
+	} else if err != nil {
+		return app.DataDirectories{}, nil, fmt.Errorf("failed to lock data directory '%s': %w", absDataDir, err)
+	}
+
 	// Create data dir skeleton
 	for _, subdirName := range []string{"video", "log", "history"} {
 		absSubdir := filepath.Join(absDataDir, subdirName)
@@ -37,17 +45,137 @@ func ensureDataDirectories() (app.DataDirectories, error) {
 			mkdirErr := os.Mkdir(absSubdir, 0755)
 			if mkdirErr != nil {
 				log.Error().Str("path", absSubdir).Err(mkdirErr).Msg("Unable to create subdirectory")
-				return app.DataDirectories{}, fmt.Errorf("failed to create subdirectory '%s': %w", absSubdir, mkdirErr)
+				lock.Unlock()
+				return app.DataDirectories{}, nil, fmt.Errorf("failed to create subdirectory '%s': %w", absSubdir, mkdirErr)
 			} else {
 				log.Info().Str("dir", absSubdir).Msgf("Directory created ./%v", subdirName)
 			}
 		}
 	}
 
+	return dataDirectoriesFor(absDataDir), lock, nil
+}
+
+// dataDirLockFile - lock file held in the data directory while the app runs
+const dataDirLockFile = ".nanit.lock"
+
+// dataDirectoriesFor returns the data dir skeleton under given base directory
+func dataDirectoriesFor(absDataDir string) app.DataDirectories {
 	return app.DataDirectories{
 		BaseDir:    absDataDir,
 		VideoDir:   filepath.Join(absDataDir, "video"),
 		LogDir:     filepath.Join(absDataDir, "log"),
 		HistoryDir: filepath.Join(absDataDir, "history"),
-	}, nil
+	}
+}
+
+// resolveDataDir returns absolute path of the data directory (NANIT_DATA_DIR), without creating it
+func resolveDataDir() (string, error) {
+	relDataDir := utils.EnvVarStr("NANIT_DATA_DIR", "/data")
+
+	absDataDir, filePathErr := filepath.Abs(relDataDir)
+	if filePathErr != nil {
+		log.Error().Str("path", relDataDir).Err(filePathErr).Msg("Unable to retrieve absolute file path")
+		return "", fmt.Errorf("failed to get absolute path for data directory '%s': %w", relDataDir, filePathErr)
+	}
+
+	return absDataDir, nil
+}
+
+// sessionFilePath returns path of the app session file, stored in the data directory by default
+func sessionFilePath(dataDir string) string {
+	return utils.EnvVarStr("NANIT_SESSION_FILE", filepath.Join(dataDir, "session.json"))
+}
+
+// passwordFilePath returns path of the web password file, always stored in the data directory
+func passwordFilePath(dataDir string) string {
+	return filepath.Join(dataDir, "web_password.json")
+}
+
+// resolveHistoryDBPath returns absolute path of the history database, history.db in the history directory by default
+func resolveHistoryDBPath(dataDirs app.DataDirectories) (string, error) {
+	dbPath := utils.EnvVarStr("NANIT_HISTORY_DB_PATH", filepath.Join(dataDirs.HistoryDir, history.DefaultDBFilename))
+
+	absDBPath, err := filepath.Abs(dbPath)
+	if err != nil {
+		return "", err
+	}
+
+	if info, err := os.Stat(absDBPath); err == nil && info.IsDir() {
+		return "", fmt.Errorf("'%s' is a directory, expected path of the database file", absDBPath)
+	}
+
+	return absDBPath, nil
+}
+
+// ensureHistoryDBPath creates the parent directories of the history database and verifies it can be written to
+func ensureHistoryDBPath(dbPath string) error {
+	dir := filepath.Dir(dbPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create history database directory '%s': %w", dir, err)
+	}
+
+	return checkFileWritable(dbPath)
+}
+
+// appDataDirs lists the directories of the data dir skeleton
+func appDataDirs(dirs app.DataDirectories) []string {
+	return []string{dirs.BaseDir, dirs.VideoDir, dirs.LogDir, dirs.HistoryDir}
+}
+
+// validateWritablePaths verifies that the data directories and files can be written to, so that
+// misconfigured volumes are reported at startup instead of failing on the first write
+func validateWritablePaths(dirs app.DataDirectories, files ...string) error {
+	for _, dir := range appDataDirs(dirs) {
+		if err := checkDirWritable(dir); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+
+		if err := checkFileWritable(file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkFileWritable verifies that an existing file can be written to, or can be created in its directory
+func checkFileWritable(file string) error {
+	absFile, filePathErr := filepath.Abs(file)
+	if filePathErr != nil {
+		return fmt.Errorf("failed to get absolute path for '%s': %w", file, filePathErr)
+	}
+
+	if _, err := os.Stat(absFile); err == nil {
+		// Existing files must be writable, open without truncating
+		f, openErr := os.OpenFile(absFile, os.O_WRONLY, 0)
+		if openErr != nil {
+			return fmt.Errorf("file '%s' is not writable: %w", absFile, openErr)
+		}
+		f.Close()
+	} else if err := checkDirWritable(filepath.Dir(absFile)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkDirWritable attempts to create (and remove) a temporary file in the directory
+func checkDirWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("directory '%s' is not writable: %w", dir, err)
+	}
+
+	name := f.Name()
+	f.Close()
+	os.Remove(name)
+
+	return nil
 }
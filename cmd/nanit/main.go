@@ -5,12 +5,12 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"regexp"
+	"syscall"
 	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/indiefan/home_assistant_nanit/pkg/app"
-	"github.com/indiefan/home_assistant_nanit/pkg/mqtt"
+	"github.com/indiefan/home_assistant_nanit/pkg/resilience"
 	"github.com/indiefan/home_assistant_nanit/pkg/utils"
 	"github.com/indiefan/home_assistant_nanit/pkg/webauth"
 )
@@ -18,93 +18,81 @@ import (
 func main() {
 	// Parse command line arguments
 	var resetPassword = flag.Bool("reset-password", false, "Reset web password protection (removes password file)")
+	var resetSession = flag.Bool("reset-session", false, "Forget the Nanit login (removes session file)")
+	var checkConfig = flag.Bool("check-config", false, "Validate the configuration and environment without starting the app")
 	flag.Parse()
 
 	initLogger()
 	logAppVersion()
-	utils.LoadDotEnvFile()
+	envFiles := utils.LoadDotEnvFile()
 	setLogLevel()
 
 	// Handle CLI commands
-	if *resetPassword {
-		handleResetPassword()
+	if *checkConfig {
+		handleCheckConfig(envFiles)
 		return
 	}
 
-	opts := app.Opts{
-		NanitCredentials: app.NanitCredentials{
-			Email:        utils.EnvVarStr("NANIT_EMAIL", ""),
-			Password:     utils.EnvVarStr("NANIT_PASSWORD", ""),
-			RefreshToken: utils.EnvVarStr("NANIT_REFRESH_TOKEN", ""),
-		},
-		SessionFile:     utils.EnvVarStr("NANIT_SESSION_FILE", "/data/session.json"),
-		DataDirectories: func() app.DataDirectories {
-			dirs, err := ensureDataDirectories()
-			if err != nil {
-				log.Error().Err(err).Msg("Failed to ensure data directories")
-				os.Exit(1)
-			}
-			return dirs
-		}(),
-		HTTPEnabled:     true,
-		HTTPPort:        utils.EnvVarInt("NANIT_HTTP_PORT", 8080),
-		EventPolling: app.EventPollingOpts{
-			// Event message polling disabled by default
-			Enabled: utils.EnvVarBool("NANIT_EVENTS_POLLING", false),
-			// 30 second default polling interval
-			PollingInterval: utils.EnvVarSeconds("NANIT_EVENTS_POLLING_INTERVAL", 30*time.Second),
-			// 300 second (5 min) default message timeout (unseen messages are ignored once they are this old)
-			MessageTimeout: utils.EnvVarSeconds("NANIT_EVENTS_MESSAGE_TIMEOUT", 300*time.Second),
-		},
-		History: app.HistoryOpts{
-			// Historical tracking enabled by default
-			Enabled: utils.EnvVarBool("NANIT_HISTORY_ENABLED", true),
-			// Keep data for 30 days by default
-			RetentionDays: utils.EnvVarInt("NANIT_HISTORY_RETENTION_DAYS", 30),
-			// Auto-cleanup enabled by default
-			CleanupEnabled: utils.EnvVarBool("NANIT_HISTORY_CLEANUP_ENABLED", true),
-		},
-		WebAuth: app.WebAuthOpts{
-			// Web password protection always available
-			Enabled: true,
-			// Password file always in data directory
-			PasswordFile: "/data/web_password.json",
-		},
-	}
-
-	if utils.EnvVarBool("NANIT_RTMP_ENABLED", true) {
-		publicAddr := utils.EnvVarReqStr("NANIT_RTMP_ADDR")
-		m := regexp.MustCompile("(:[0-9]+)$").FindStringSubmatch(publicAddr)
-		if len(m) != 2 {
-			log.Error().
-				Str("value", publicAddr).
-				Msg("Invalid NANIT_RTMP_ADDR format. Expected format: 'hostname:port' (e.g., '192.168.1.100:1935')")
+	if *resetPassword || *resetSession {
+		dataDir, err := resolveDataDir()
+		if err != nil {
+			fmt.Printf("Error resolving data directory: %v\n", err)
 			os.Exit(1)
 		}
 
-		opts.RTMP = &app.RTMPOpts{
-			ListenAddr: m[1],
-			PublicAddr: publicAddr,
-			AutoStart:  utils.EnvVarBool("NANIT_RTMP_AUTO_START", true),
+		if *resetPassword {
+			handleResetPassword(passwordFilePath(dataDir))
+		}
+		if *resetSession {
+			handleResetSession(sessionFilePath(dataDir))
 		}
+		return
+	}
+
+	dataDirs, dataDirLock, err := ensureDataDirectories()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to ensure data directories")
+		os.Exit(1)
+	}
+	defer dataDirLock.Unlock()
+
+	sessionFile := sessionFilePath(dataDirs.BaseDir)
+	passwordFile := passwordFilePath(dataDirs.BaseDir)
+
+	if err := validateWritablePaths(dataDirs, sessionFile, passwordFile); err != nil {
+		log.Error().Err(err).Msg("Configured data paths are not writable")
+		os.Exit(1)
 	}
 
-	if utils.EnvVarBool("NANIT_MQTT_ENABLED", false) {
-		opts.MQTT = &mqtt.Opts{
-			BrokerURL:   utils.EnvVarReqStr("NANIT_MQTT_BROKER_URL"),
-			ClientID:    utils.EnvVarStr("NANIT_MQTT_CLIENT_ID", "nanit"),
-			Username:    utils.EnvVarStr("NANIT_MQTT_USERNAME", ""),
-			Password:    utils.EnvVarStr("NANIT_MQTT_PASSWORD", ""),
-			TopicPrefix: utils.EnvVarStr("NANIT_MQTT_PREFIX", "nanit"),
+	retryConfig, err := retryConfigFromEnv()
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid retry configuration")
+		os.Exit(1)
+	}
+
+	// Retry defaults shared by the components using resilience.DefaultRetryConfig
+	resilience.SetDefaultRetryConfig(retryConfig)
+
+	opts, err := loadOpts(dataDirs, sessionFile, passwordFile)
+	if err != nil {
+		log.Error().Err(err).Strs("env_files", envFiles).Msg("Invalid configuration")
+		os.Exit(1)
+	}
+
+	if opts.History.Enabled {
+		if err := ensureHistoryDBPath(opts.History.DBPath); err != nil {
+			log.Error().Err(err).Msg("History database path is not writable")
+			os.Exit(1)
 		}
 	}
 
-	if opts.EventPolling.Enabled {
+	if opts.EventPolling.IsEnabledForAny() {
 		log.Info().Msgf("Event polling enabled with an interval of %v", opts.EventPolling.PollingInterval)
 	}
 
 	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt)
+	// Docker stops containers with SIGTERM, which is ignored by PID 1 without a handler
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
 
 	instance, err := app.NewApp(opts)
 	if err != nil {
@@ -124,9 +112,26 @@ func main() {
 		close(waitForCleanup)
 	}()
 
+	// No deadline if the timeout is disabled
+	var shutdownDeadline <-chan time.Time
+	if opts.ShutdownTimeout > 0 {
+		shutdownDeadline = time.After(opts.ShutdownTimeout)
+	}
+
 	select {
 	case <-interrupt:
-		log.Fatal().Msg("Received another interrupt signal, forcing termination without clean up")
+		log.Fatal().Strs("pending", instance.PendingTasks()).Msg("Received another interrupt signal, forcing termination without clean up")
+	case <-shutdownDeadline:
+		log.Error().
+			Dur("timeout", opts.ShutdownTimeout).
+			Strs("pending", instance.PendingTasks()).
+			Msg("Clean up did not finish in time, forcing termination")
+		// FFmpeg would otherwise outlive the app and keep the cameras' RTMP streams busy
+		if killed := instance.KillTranscoders(); killed > 0 {
+			log.Warn().Int("processes", killed).Msg("Killed remaining FFmpeg processes")
+		}
+		dataDirLock.Unlock()
+		os.Exit(1)
 	case <-waitForCleanup:
 		log.Info().Msg("Clean exit")
 		return
@@ -134,9 +139,9 @@ func main() {
 }
 
 // handleResetPassword removes the web password file (CLI command)
-func handleResetPassword() {
-	passwordFile := "/data/web_password.json"
-	
+func handleResetPassword(passwordFile string) {
+	fmt.Printf("Password file: %s\n", passwordFile)
+
 	webAuth := webauth.NewWebAuth(passwordFile)
 	
 	if !webAuth.IsPasswordSet() {
@@ -153,3 +158,19 @@ func handleResetPassword() {
 	fmt.Println("Web password protection has been disabled successfully.")
 	fmt.Println("You can now access the web interface without a password.")
 }
+
+// handleResetSession removes the app session file with the Nanit tokens (CLI command)
+func handleResetSession(sessionFile string) {
+	fmt.Printf("Session file: %s\n", sessionFile)
+
+	if err := os.Remove(sessionFile); os.IsNotExist(err) {
+		fmt.Println("No session is currently stored.")
+		return
+	} else if err != nil {
+		fmt.Printf("Error removing session: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Nanit session has been removed successfully.")
+	fmt.Println("Log in again through the web interface on the next start.")
+}
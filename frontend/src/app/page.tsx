@@ -17,6 +17,7 @@ export default function Dashboard() {
   const [password, setPassword] = useState('')
   const [loginError, setLoginError] = useState('')
   const [isLoggingIn, setIsLoggingIn] = useState(false)
+  const [isRefreshing, setIsRefreshing] = useState(false)
   
   // Check web authentication status first
   const { data: webAuthStatus, mutate: mutateWebAuth } = useSWR<WebAuthStatusResponse>(
@@ -29,7 +30,7 @@ export default function Dashboard() {
   )
 
   // Check Nanit authentication status (only if web auth passes)
-  const { data: authStatus } = useSWR<AuthStatusResponse>(
+  const { data: authStatus, mutate: mutateAuthStatus } = useSWR<AuthStatusResponse>(
     webAuthStatus?.authenticated ? '/auth/status' : null,
     () => api.getAuthStatus(),
     {
@@ -75,6 +76,18 @@ export default function Dashboard() {
     }
   }
 
+  const handleRefreshBabies = async () => {
+    setIsRefreshing(true)
+    try {
+      await api.refreshBabies()
+      await mutateAuthStatus()
+    } catch (error) {
+      console.error('Failed to refresh babies:', error)
+    } finally {
+      setIsRefreshing(false)
+    }
+  }
+
   // Show password login screen if required
   if (showPasswordLogin) {
     return (
@@ -153,11 +166,22 @@ export default function Dashboard() {
         <div className="text-center py-12">
           <div className="card max-w-md mx-auto p-8">
             <h2 className="text-xl font-semibold text-nanit-gray-600 mb-2">
-              No babies configured
+              {authStatus?.authenticated ? authStatus.message : 'No babies configured'}
             </h2>
             <p className="text-nanit-gray-500">
-              Make sure you have authenticated and configured your Nanit account.
+              {authStatus?.authenticated
+                ? 'Set up the camera in the Nanit app, then refresh the list.'
+                : 'Make sure you have authenticated and configured your Nanit account.'}
             </p>
+            {authStatus?.authenticated && (
+              <button
+                onClick={handleRefreshBabies}
+                disabled={isRefreshing}
+                className="btn btn-primary mt-4 disabled:opacity-50"
+              >
+                {isRefreshing ? 'Refreshing...' : 'Refresh cameras'}
+              </button>
+            )}
           </div>
         </div>
       </MainLayout>
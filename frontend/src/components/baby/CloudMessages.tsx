@@ -0,0 +1,71 @@
+'use client'
+
+import { useEffect, useState } from 'react'
+import { api } from '@/lib/api'
+import { formatRelativeTime } from '@/lib/utils'
+import type { NanitMessage } from '@/types/api'
+import LoadingSpinner from '@/components/ui/LoadingSpinner'
+
+interface CloudMessagesProps {
+  babyUid: string
+}
+
+// Events recorded by the Nanit cloud, for reconciling with the locally tracked history
+export default function CloudMessages({ babyUid }: CloudMessagesProps) {
+  const [messages, setMessages] = useState<NanitMessage[]>([])
+  const [isLoading, setIsLoading] = useState(true)
+  const [isError, setIsError] = useState(false)
+
+  useEffect(() => {
+    let cancelled = false
+
+    setIsLoading(true)
+    api.getNanitMessages(babyUid)
+      .then((response) => {
+        if (!cancelled) {
+          setMessages(response.messages)
+          setIsError(false)
+        }
+      })
+      .catch((error) => {
+        console.error('Failed to load Nanit messages:', error)
+        if (!cancelled) {
+          setIsError(true)
+        }
+      })
+      .finally(() => {
+        if (!cancelled) {
+          setIsLoading(false)
+        }
+      })
+
+    return () => {
+      cancelled = true
+    }
+  }, [babyUid])
+
+  return (
+    <div className="bg-white border border-nanit-gray-200 rounded-lg p-4">
+      <h4 className="font-semibold text-nanit-gray-800 mb-4">Nanit Cloud Events</h4>
+
+      {isLoading ? (
+        <div className="flex justify-center py-4">
+          <LoadingSpinner size="sm" />
+        </div>
+      ) : isError ? (
+        <div className="text-sm text-red-700">Failed to load events from Nanit.</div>
+      ) : messages.length === 0 ? (
+        <div className="text-sm text-nanit-gray-500">No events recorded by Nanit.</div>
+      ) : (
+        <ul className="divide-y divide-nanit-gray-100 text-sm">
+          {messages.map((message) => (
+            <li key={message.id} className="flex justify-between py-2">
+              <span className="font-medium text-nanit-gray-700">{message.type}</span>
+              <span className="text-nanit-gray-500">{formatRelativeTime(new Date(message.time * 1000))}</span>
+            </li>
+          ))}
+        </ul>
+      )}
+    </div>
+  )
+}
@@ -7,6 +7,7 @@ import { useHistoricalData } from '@/hooks/useHistoricalData'
 import { useTemperatureUnit } from '@/hooks/useTemperatureUnit'
 import type { Baby } from '@/types/api'
 import LoadingSpinner from '@/components/ui/LoadingSpinner'
+import CloudMessages from '@/components/baby/CloudMessages'
 
 // Import Chart.js setup
 import '@/lib/chartSetup'
@@ -138,6 +139,9 @@ export default function HistoricalData({ baby }: HistoricalDataProps) {
           <h4 className="font-semibold text-nanit-gray-800 mb-4">Day/Night Pattern</h4>
           <DayNightChart analytics={analytics || null} isLoading={isLoading} />
         </div>
+
+        {/* Events recorded by the Nanit cloud */}
+        <CloudMessages babyUid={baby.uid} />
       </div>
 
       {/* Summary Stats */}
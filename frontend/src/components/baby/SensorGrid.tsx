@@ -8,13 +8,14 @@ import type { Baby } from '@/types/api'
 interface SensorCardProps {
   title: string
   value: string
+  subtitle?: string
   type: 'temperature' | 'humidity' | 'night-mode' | 'night-light'
   tooltip?: string
   onClick?: () => void
   className?: string
 }
 
-function SensorCard({ title, value, type, tooltip, onClick, className }: SensorCardProps) {
+function SensorCard({ title, value, subtitle, type, tooltip, onClick, className }: SensorCardProps) {
   return (
     <div 
       className={`sensor-card ${type} ${onClick ? 'cursor-pointer' : ''} ${className}`}
@@ -30,6 +31,11 @@ function SensorCard({ title, value, type, tooltip, onClick, className }: SensorC
       <div className="text-xl font-bold text-nanit-gray-800">
         {value}
       </div>
+      {subtitle && (
+        <div className="text-xs text-nanit-gray-500 mt-1">
+          {subtitle}
+        </div>
+      )}
     </div>
   )
 }
@@ -85,12 +91,17 @@ export default function SensorGrid({ baby }: SensorGridProps) {
     )
   }
 
+  // Values restored from history until the camera pushes fresh sensor data
+  const staleSubtitle = baby.sensor_data_stale && baby.sensor_data_timestamp
+    ? `as of ${formatRelativeTime(new Date(baby.sensor_data_timestamp * 1000))}`
+    : undefined
 
   return (
     <div className="grid grid-cols-2 md:grid-cols-4 gap-4">
       <SensorCard
         title="Temperature"
         value={formatTemperature(baby.temperature)}
+        subtitle={staleSubtitle}
         type="temperature"
         onClick={toggleUnit}
         tooltip="Click to toggle °C/°F"
@@ -99,6 +110,7 @@ export default function SensorGrid({ baby }: SensorGridProps) {
       <SensorCard
         title="Humidity"
         value={formatHumidity(baby.humidity)}
+        subtitle={staleSubtitle}
         type="humidity"
       />
       
@@ -3,7 +3,7 @@
 import { useState } from 'react';
 import { useRouter } from 'next/navigation';
 import type { AuthStatusResponse } from '@/types/api';
-import { api } from '@/lib/api';
+import { api, ApiError } from '@/lib/api';
 
 interface WebAuthStatus {
   password_protection_enabled: boolean;
@@ -29,6 +29,7 @@ export default function AuthenticationSettings({
   const router = useRouter();
   const [showResetConfirmation, setShowResetConfirmation] = useState(false);
   const [resetLoading, setResetLoading] = useState(false);
+  const [refreshLoading, setRefreshLoading] = useState(false);
   
   // Password form state
   const [showPasswordForm, setShowPasswordForm] = useState(false);
@@ -61,6 +62,38 @@ export default function AuthenticationSettings({
     }
   };
 
+  const handleRefreshToken = async () => {
+    setRefreshLoading(true);
+    onMessage({ type: 'success', text: '' }); // Clear previous messages
+
+    try {
+      const result = await api.refreshAuth();
+
+      if (result.success) {
+        onMessage({
+          type: 'success',
+          text: result.fell_back_to_login
+            ? 'Refresh token had expired, signed in again with your credentials'
+            : 'Nanit session refreshed',
+        });
+        await onAuthStatusUpdate();
+      }
+    } catch (error: any) {
+      if (error instanceof ApiError && error.code === 'relogin_required') {
+        onMessage({ type: 'error', text: 'Your Nanit session has expired, please re-authenticate' });
+        router.push('/setup');
+        return;
+      }
+
+      onMessage({
+        type: 'error',
+        text: error.message || 'Failed to refresh the Nanit session'
+      });
+    } finally {
+      setRefreshLoading(false);
+    }
+  };
+
   const handleReAuthenticate = () => {
     router.push('/setup');
   };
@@ -142,15 +175,29 @@ export default function AuthenticationSettings({
                     )}
                   </div>
                 )}
+                {authStatus.nanit_api_reachable === false && (
+                  <p className="mt-2 text-xs text-amber-600">
+                    {authStatus.nanit_api_message || 'Nanit API is currently unreachable'}
+                  </p>
+                )}
               </div>
               <div className="flex space-x-2">
                 {authStatus.authenticated ? (
-                  <button
-                    onClick={() => setShowResetConfirmation(true)}
-                    className="px-4 py-2 bg-red-600 text-white rounded-md hover:bg-red-700 focus:outline-none focus:ring-2 focus:ring-red-500"
-                  >
-                    Reset Authentication
-                  </button>
+                  <>
+                    <button
+                      onClick={handleRefreshToken}
+                      disabled={refreshLoading}
+                      className="px-4 py-2 text-gray-700 bg-gray-200 rounded-md hover:bg-gray-300 focus:outline-none focus:ring-2 focus:ring-gray-500 disabled:opacity-50"
+                    >
+                      {refreshLoading ? 'Refreshing...' : 'Refresh Token'}
+                    </button>
+                    <button
+                      onClick={() => setShowResetConfirmation(true)}
+                      className="px-4 py-2 bg-red-600 text-white rounded-md hover:bg-red-700 focus:outline-none focus:ring-2 focus:ring-red-500"
+                    >
+                      Reset Authentication
+                    </button>
+                  </>
                 ) : (
                   <button
                     onClick={handleReAuthenticate}
@@ -1,6 +1,9 @@
+import { useEffect } from 'react'
 import useSWR from 'swr'
 import { api } from '@/lib/api'
-import type { StatusResponse } from '@/types/api'
+import type { StatusResponse, StatusUpdate } from '@/types/api'
+
+const SOCKET_RECONNECT_DELAY = 5000
 
 export function useStatus(enabled: boolean = true) {
   const { data, error, isLoading, mutate } = useSWR<StatusResponse>(
@@ -13,6 +16,38 @@ export function useStatus(enabled: boolean = true) {
     }
   )
 
+  // Refresh right away on state transitions instead of waiting for the next poll
+  useEffect(() => {
+    if (!enabled) return
+
+    let socket: WebSocket | null = null
+    let reconnectTimer: ReturnType<typeof setTimeout> | undefined
+    let closed = false
+
+    const connect = () => {
+      socket = new WebSocket(api.statusSocketURL())
+      socket.onmessage = (event) => {
+        const update: StatusUpdate = JSON.parse(event.data)
+        if (update.stream_state || update.stream_request_state || update.websocket_alive !== undefined) {
+          mutate()
+        }
+      }
+      socket.onclose = () => {
+        if (!closed) {
+          reconnectTimer = setTimeout(connect, SOCKET_RECONNECT_DELAY)
+        }
+      }
+    }
+
+    connect()
+
+    return () => {
+      closed = true
+      clearTimeout(reconnectTimer)
+      socket?.close()
+    }
+  }, [enabled, mutate])
+
   // Debug logging to help troubleshoot motion/sound timestamp issues
   if (data) {
     console.log('🔍 API Status Response:', data)
@@ -1,6 +1,15 @@
 import { useState, useEffect } from 'react'
+import { api } from '@/lib/api'
+import type { TemperatureUnit } from '@/types/api'
 
-export type TemperatureUnit = 'celsius' | 'fahrenheit'
+export type { TemperatureUnit }
+
+const applyUnit = (newUnit: TemperatureUnit) => {
+  localStorage.setItem('temperatureUnit', newUnit)
+
+  // Dispatch custom event to notify other components immediately
+  window.dispatchEvent(new CustomEvent('temperatureUnitChanged', { detail: newUnit }))
+}
 
 export function useTemperatureUnit() {
   const [unit, setUnit] = useState<TemperatureUnit>('celsius')
@@ -12,6 +21,17 @@ export function useTemperatureUnit() {
       setUnit(saved)
     }
 
+    // The unit stored on the server is shared by all devices of the account
+    api.getUnits()
+      .then((settings) => {
+        if (settings.temperature_unit !== localStorage.getItem('temperatureUnit')) {
+          applyUnit(settings.temperature_unit)
+        }
+      })
+      .catch(() => {
+        // Keep the local preference if the server is unreachable
+      })
+
     // Listen for localStorage changes from other components
     const handleStorageChange = (e: StorageEvent) => {
       if (e.key === 'temperatureUnit' && e.newValue) {
@@ -41,10 +61,11 @@ export function useTemperatureUnit() {
   const toggleUnit = () => {
     const newUnit = unit === 'celsius' ? 'fahrenheit' : 'celsius'
     setUnit(newUnit)
-    localStorage.setItem('temperatureUnit', newUnit)
-    
-    // Dispatch custom event to notify other components immediately
-    window.dispatchEvent(new CustomEvent('temperatureUnitChanged', { detail: newUnit }))
+    applyUnit(newUnit)
+
+    api.setUnits({ temperature_unit: newUnit }).catch((err) => {
+      console.error('Failed to save temperature unit:', err)
+    })
   }
 
   const convertTemperature = (celsius: number): number => {
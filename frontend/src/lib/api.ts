@@ -1,5 +1,6 @@
 import type {
   StatusResponse,
+  Baby,
   DeviceInfoResponse,
   SensorDataResponse,
   // EventsDataResponse,  // Disabled motion/sound activity
@@ -13,17 +14,39 @@ import type {
   Verify2FAResponse,
   AuthStatusResponse,
   AuthResetResponse,
+  AuthRefreshResponse,
+  ReloginRequest,
+  ReloginResponse,
   StreamStartRequest,
+  StreamMode,
   StreamStartResponse,
   StreamStatusResponse,
   WebAuthStatusResponse,
   WebAuthResponse,
   HealthResponse,
+  NanitMessagesResponse,
+  ModeResponse,
+  UnitsSettings,
+  ErrorResponse,
 } from '@/types/api'
 
 // In production, API calls go directly to the same host since Go serves the frontend
 const API_BASE = '';
 
+// ApiError carries the machine-readable error returned by the backend
+export class ApiError extends Error {
+  constructor(
+    public status: number,
+    public code?: string,
+    public type?: string,
+    public retryable: boolean = false,
+    message?: string
+  ) {
+    super(message ?? `API Error: ${status}`);
+    this.name = 'ApiError';
+  }
+}
+
 class ApiClient {
   private async request<T>(
     endpoint: string,
@@ -41,15 +64,33 @@ class ApiClient {
     });
 
     if (!response.ok) {
-      throw new Error(`API Error: ${response.status} ${response.statusText}`);
+      const body: Partial<ErrorResponse> = await response.json().catch(() => ({}));
+      throw new ApiError(
+        response.status,
+        body.code ?? body.error,
+        body.type,
+        body.retryable ?? false,
+        body.message ?? `API Error: ${response.status} ${response.statusText}`
+      );
     }
 
     return response.json();
   }
 
   // Status and Baby Data
+  async getMode(): Promise<ModeResponse> {
+    return this.request<ModeResponse>('/mode');
+  }
+
+  // Temperatures are requested in Celsius, the dashboard converts them to the selected unit
   async getStatus(): Promise<StatusResponse> {
-    return this.request<StatusResponse>('/status');
+    return this.request<StatusResponse>('/status?unit=celsius');
+  }
+
+  // Real-time state transitions, see useStatus
+  statusSocketURL(): string {
+    const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+    return `${protocol}//${window.location.host}${API_BASE}/api/ws/status`;
   }
 
   async getDeviceInfo(babyUid: string): Promise<DeviceInfoResponse> {
@@ -67,6 +108,7 @@ class ApiClient {
       start: startTime.toString(),
       end: endTime.toString(),
       limit: limit.toString(),
+      unit: 'celsius',
     });
     return this.request<SensorDataResponse>(`/history/sensor/${babyUid}?${params}`);
   }
@@ -100,6 +142,7 @@ class ApiClient {
     const params = new URLSearchParams({
       start: startTime.toString(),
       end: endTime.toString(),
+      unit: 'celsius',
     });
     return this.request<HistorySummary>(`/history/summary/${babyUid}?${params}`);
   }
@@ -123,6 +166,11 @@ class ApiClient {
     });
   }
 
+  // Events recorded by the Nanit cloud (independent of the local history)
+  async getNanitMessages(babyUid: string, limit = 20): Promise<NanitMessagesResponse> {
+    return this.request<NanitMessagesResponse>(`/nanit/messages/${babyUid}?limit=${limit}`);
+  }
+
   // Control Commands
   async toggleNightLight(babyUid: string): Promise<ControlResponse> {
     const payload: ControlRequest = {
@@ -173,6 +221,24 @@ class ApiClient {
     });
   }
 
+  async refreshBabies(): Promise<{ babies: Baby[]; count: number }> {
+    return this.request('/babies/refresh', {
+      method: 'POST',
+    });
+  }
+
+  // Account-wide temperature unit
+  async getUnits(): Promise<UnitsSettings> {
+    return this.request<UnitsSettings>('/settings/units');
+  }
+
+  async setUnits(settings: UnitsSettings): Promise<UnitsSettings> {
+    return this.request<UnitsSettings>('/settings/units', {
+      method: 'PUT',
+      body: JSON.stringify(settings),
+    });
+  }
+
   async getAuthStatus(): Promise<AuthStatusResponse> {
     return this.request<AuthStatusResponse>('/auth/status');
   }
@@ -183,9 +249,24 @@ class ApiClient {
     });
   }
 
+  // Forces renewal of the Nanit session, fails with ApiError code 'relogin_required' when the refresh token is dead
+  async refreshAuth(): Promise<AuthRefreshResponse> {
+    return this.request<AuthRefreshResponse>('/auth/refresh', {
+      method: 'POST',
+    });
+  }
+
+  // Signs in to Nanit again once the refresh token is dead, accounts with 2FA answer with mfa_required first
+  async relogin(request: ReloginRequest = {}): Promise<ReloginResponse> {
+    return this.request<ReloginResponse>('/auth/relogin', {
+      method: 'POST',
+      body: JSON.stringify(request),
+    });
+  }
+
   // Streaming
-  async startStream(babyUid: string): Promise<StreamStartResponse> {
-    const payload: StreamStartRequest = { baby_uid: babyUid };
+  async startStream(babyUid: string, mode: StreamMode = 'video'): Promise<StreamStartResponse> {
+    const payload: StreamStartRequest = { baby_uid: babyUid, mode };
     return this.request<StreamStartResponse>('/stream/start/', {
       method: 'POST',
       body: JSON.stringify(payload),
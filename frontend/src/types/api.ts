@@ -10,11 +10,31 @@ export interface Baby {
   standby?: boolean;
   websocket_alive: boolean;
   stream_state?: string;
+  stream_slot_held?: boolean;
+  sensor_data_stale?: boolean;
+  sensor_data_timestamp?: number;
+  sensor_calibrated?: boolean;
+  raw_temperature?: number;
+  raw_humidity?: number;
+  connected_since?: number | null;
+  last_disconnect?: number | null;
+  uptime_24h?: number | null;
+  nanit_name?: string;
+  sort_order?: number;
+  hidden?: boolean;
+}
+
+export type TemperatureUnit = 'celsius' | 'fahrenheit';
+
+export interface UnitsSettings {
+  temperature_unit: TemperatureUnit;
 }
 
 export interface StatusResponse {
   timestamp: number;
+  temperature_unit: TemperatureUnit;
   babies: Baby[];
+  stream_slot_holders?: string[];
 }
 
 export interface DeviceInfo {
@@ -32,6 +52,8 @@ export interface DeviceInfo {
   temp_high_threshold?: number;
   humidity_low_threshold?: number;
   humidity_high_threshold?: number;
+  motion_threshold?: number;
+  sound_threshold?: number;
   mobile_bitrate?: number;
   mobile_fps?: number;
   dvr_bitrate?: number;
@@ -54,6 +76,9 @@ export interface DeviceInfoResponse {
   camera_uid: string;
   timestamp: number;
   device_info: DeviceInfo;
+  stale: boolean;
+  age_seconds: number | null;
+  refresh_requested: boolean;
   connection_status: {
     websocket_alive: boolean;
     stream_state: string;
@@ -64,6 +89,7 @@ export interface DeviceInfoResponse {
 export interface SensorReading {
   timestamp: number;
   temperature_celsius?: number;
+  temperature?: number; // In temperature_unit of the response
   humidity_percent?: number;
   is_night?: boolean;
 }
@@ -74,6 +100,7 @@ export interface SensorDataResponse {
   end_time: number;
   readings: SensorReading[];
   count: number;
+  temperature_unit: TemperatureUnit;
 }
 
 // Motion/Sound activity interfaces - disabled for now
@@ -99,6 +126,7 @@ export interface HistorySummary {
   avg_temperature?: number;
   min_temperature?: number;
   max_temperature?: number;
+  temperature_unit: TemperatureUnit;
   avg_humidity?: number;
   min_humidity?: number;
   max_humidity?: number;
@@ -165,12 +193,17 @@ export interface Verify2FAResponse {
 }
 
 export interface AuthStatusResponse {
+  status?: 'authenticated' | 'not_authenticated' | 'reauth_required';
+  reauth_required?: boolean;
   authenticated: boolean;
   message: string;
   email?: string;
   babies_count?: number;
+  suggestion?: string;
   services_running?: boolean;
   auth_time?: number;
+  nanit_api_reachable?: boolean;
+  nanit_api_message?: string;
 }
 
 export interface AuthResetResponse {
@@ -178,15 +211,42 @@ export interface AuthResetResponse {
   message: string;
 }
 
+export interface AuthRefreshResponse {
+  success: boolean;
+  method: 'refresh_token' | 'login';
+  fell_back_to_login: boolean;
+  auth_time: number;
+}
+
+export interface ReloginRequest {
+  email?: string;
+  password?: string;
+  mfa_token?: string;
+  mfa_code?: string;
+}
+
+export interface ReloginResponse {
+  success: boolean;
+  message: string;
+  mfa_required?: boolean;
+  mfa_token?: string;
+  auth_time?: number;
+}
+
 // Stream Types
+export type StreamMode = 'video' | 'audio';
+
 export interface StreamStartRequest {
   baby_uid: string;
+  mode?: StreamMode;
 }
 
 export interface StreamStartResponse {
   success: boolean;
   baby_uid: string;
+  mode: StreamMode;
   hls_url: string;
+  hls_url_absolute: string;
   message: string;
 }
 
@@ -199,7 +259,13 @@ export interface StreamStatusResponse {
   baby_uid: string;
   status: string;
   message: string;
+  mode?: StreamMode;
+  is_paused?: boolean;
+  start_time?: number; // Unix seconds, 0 if never started
+  uptime_seconds?: number; // 0 if not running
+  stream_identifier?: 'MOBILE' | 'DVR' | 'ANALYTICS'; // Camera stream relayed to the RTMP server
   stream_error?: StreamError;
+  next_retry_at?: number; // Unix seconds of the next retry while blocked
 }
 
 // Web Authentication Types
@@ -232,4 +298,48 @@ export interface HealthResponse {
   overall_health: 'healthy' | 'degraded' | 'unhealthy' | 'starting';
   details: HealthDetails;
   timestamp: number;
-}
\ No newline at end of file
+}
+export interface NanitMessage {
+  id: number;
+  baby_uid: string;
+  type: string;
+  time: number;
+  created_at?: string;
+  data?: unknown;
+}
+
+export interface NanitMessagesResponse {
+  baby_uid: string;
+  messages: NanitMessage[];
+  count: number;
+  limit: number;
+  fetched_at: number;
+}
+
+export interface ErrorResponse {
+  error: string;
+  type: 'authentication' | 'configuration' | 'network' | 'storage' | 'validation' | 'external_service';
+  code: string;
+  message: string;
+  retryable: boolean;
+  context?: Record<string, unknown>;
+}
+
+export interface StatusUpdate {
+  type: 'state';
+  baby_uid: string;
+  timestamp: number;
+  stream_state?: 'alive' | 'unhealthy' | 'unknown';
+  stream_request_state?: 'not_requested' | 'requested' | 'request_failed';
+  websocket_alive?: boolean;
+  temperature?: number;
+  humidity?: number;
+  is_night?: boolean;
+}
+
+export interface ModeResponse {
+  mode: 'web_only' | 'monitoring' | 'paused';
+  monitoring: boolean;
+  paused: boolean;
+  message: string;
+}
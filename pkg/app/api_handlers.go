@@ -1,8 +1,11 @@
 package app
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -12,63 +15,275 @@ import (
 
 	"github.com/rs/zerolog/log"
 	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	apperrors "github.com/indiefan/home_assistant_nanit/pkg/errors"
+	"github.com/indiefan/home_assistant_nanit/pkg/health"
+	"github.com/indiefan/home_assistant_nanit/pkg/mqtt"
+	"github.com/indiefan/home_assistant_nanit/pkg/history"
+	"github.com/indiefan/home_assistant_nanit/pkg/resilience"
 	"github.com/indiefan/home_assistant_nanit/pkg/session"
 	"github.com/indiefan/home_assistant_nanit/pkg/streaming"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
 )
 
 // API handler for current status
-func handleStatusAPI(w http.ResponseWriter, r *http.Request, babies []baby.Baby, stateManager *baby.StateManager) {
+func handleStatusAPI(w http.ResponseWriter, r *http.Request, babies []baby.Baby, stateManager *baby.StateManager, displayConfig *baby.DisplayConfigStore, activeWindow time.Duration, mode Mode) {
 	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	unit, ok := resolveTemperatureUnit(w, r, displayConfig)
+	if !ok {
+		return
+	}
+
 	status := map[string]interface{}{
-		"timestamp": time.Now().Unix(),
-		"babies":    make([]interface{}, 0),
+		"timestamp":           time.Now().Unix(),
+		"temperature_unit":    unit,
+		"babies":              make([]interface{}, 0),
+		"stream_slot_holders": make([]string, 0),
+		"mode":                mode,
+		"monitoring_paused":   mode == Mode_Paused,
+	}
+
+	// Single snapshot, so all babies are reported at the same point in time
+	babyStates := stateManager.GetAllBabyStates()
+
+	for _, d := range displayConfig.Apply(babies) {
+		b := d.Baby
+		babyState := babyStates[b.UID]
+		babyStatus := buildBabyStatus(b, &babyState, stateManager.GetConnectionStats(b.UID), activeWindow, unit, displayConfig.Get(b.UID))
+		babyStatus["nanit_name"] = d.NanitName
+		babyStatus["sort_order"] = d.SortOrder
+		babyStatus["hidden"] = d.Hidden
+		status["babies"] = append(status["babies"].([]interface{}), babyStatus)
+		if babyState.GetStreamRequestState() == baby.StreamRequestState_Requested {
+			status["stream_slot_holders"] = append(status["stream_slot_holders"].([]string), b.UID)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// buildBabyStatus builds the status payload of a single baby
+// Motion/sound is reported as active if the latest event is not older than activeWindow, temperature is converted to unit.
+// Sensor values are calibrated by the offsets of the config, the values reported by the camera are included then.
+func buildBabyStatus(b baby.Baby, babyState *baby.State, connectionStats baby.ConnectionStats, activeWindow time.Duration, unit baby.TemperatureUnit, config baby.DisplayConfig) map[string]interface{} {
+	temperature := babyState.GetTemperature()
+	if babyState.TemperatureMilli != nil {
+		temperature = unit.FromCelsius(temperature)
+	}
+
+	status := map[string]interface{}{
+		"uid":              b.UID,
+		"name":             b.Name,
+		"camera_uid":       b.CameraUID,
+		"temperature":      temperature,
+		"temperature_unit": unit,
+		"humidity":         babyState.GetHumidity(),
+		"is_night":         babyState.IsNight,
+		"night_light":      babyState.GetNightLight(),
+		"standby":          babyState.GetStandby(),
+		"websocket_alive":  babyState.GetIsWebsocketAlive(),
+		"stream_state":     babyState.GetStreamState(),
+		"stream_slot_held": babyState.GetStreamRequestState() == baby.StreamRequestState_Requested,
+		"sensor_data_stale": babyState.GetSensorDataStale(),
+		"sensor_calibrated": config.IsCalibrated(),
+		"motion_active":    babyState.IsMotionActive(activeWindow),
+		"sound_active":     babyState.IsSoundActive(activeWindow),
+		"connected_since":  connectionStats.ConnectedSince,
+		"last_disconnect":  connectionStats.LastDisconnect,
+		"uptime_24h":       connectionStats.Uptime24h,
+	}
+
+	// Sensor values restored from history carry their original timestamp
+	if babyState.GetSensorDataStale() && babyState.SensorDataTimestamp != nil {
+		status["sensor_data_timestamp"] = *babyState.SensorDataTimestamp
+	}
+
+	if config.IsCalibrated() {
+		if babyState.RawTemperatureMilli != nil {
+			status["raw_temperature"] = unit.FromCelsius(float64(*babyState.RawTemperatureMilli) / 1000)
+		}
+		if babyState.RawHumidityMilli != nil {
+			status["raw_humidity"] = float64(*babyState.RawHumidityMilli) / 1000
+		}
+	}
+
+	return status
+}
+
+// API handler for the consolidated dashboard payload (babies, states, device info, alerts and health in one response)
+func handleDashboardAPI(w http.ResponseWriter, r *http.Request, babies []baby.Baby, app *App) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	babyUIDFilter := r.URL.Query().Get("baby_uid")
+	includeAcked := r.URL.Query().Get("include_acked") == "true"
+
+	unit, ok := resolveTemperatureUnit(w, r, app.DisplayConfig)
+	if !ok {
+		return
+	}
+
+	dashboard := map[string]interface{}{
+		"timestamp":        time.Now().Unix(),
+		"temperature_unit": unit,
+		"babies":           make([]interface{}, 0),
 	}
 
+	// Single snapshot shared by all the babies and sections
+	babyStates := app.BabyStateManager.GetAllBabyStates()
+
+	found := false
 	for _, b := range babies {
-		babyState := stateManager.GetBabyState(b.UID)
-		babyStatus := map[string]interface{}{
-			"uid":              b.UID,
-			"name":             b.Name,
-			"camera_uid":       b.CameraUID,
-			"temperature":      babyState.GetTemperature(),
-			"humidity":         babyState.GetHumidity(),
-			"is_night":         babyState.IsNight,
-			"night_light":      babyState.GetNightLight(),
-			"standby":          babyState.GetStandby(),
-			"websocket_alive":  babyState.GetIsWebsocketAlive(),
-			"stream_state":     babyState.GetStreamState(),
+		if babyUIDFilter != "" && b.UID != babyUIDFilter {
+			continue
 		}
-		status["babies"] = append(status["babies"].([]interface{}), babyStatus)
+		found = true
+
+		babyStateCopy := babyStates[b.UID]
+		babyState := &babyStateCopy
+
+		babyDashboard := map[string]interface{}{
+			"uid":         b.UID,
+			"name":        b.Name,
+			"camera_uid":  b.CameraUID,
+			"status":      buildBabyStatus(b, babyState, app.BabyStateManager.GetConnectionStats(b.UID), app.Opts.EventActiveWindow, unit, app.DisplayConfig.Get(b.UID)),
+			"device_info": buildDeviceInfoResponse(b, babyState, app, includeAcked),
+			"health":      buildHealthResponse(b.UID, babyState, app),
+		}
+		dashboard["babies"] = append(dashboard["babies"].([]interface{}), babyDashboard)
+	}
+
+	if babyUIDFilter != "" && !found {
+		http.Error(w, "Baby not found", http.StatusNotFound)
+		return
 	}
 
+	dashboard["count"] = len(dashboard["babies"].([]interface{}))
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(status)
+	json.NewEncoder(w).Encode(dashboard)
 }
 
-// API handler for babies list
-func handleBabiesAPI(w http.ResponseWriter, r *http.Request, babies []baby.Baby, stateManager *baby.StateManager) {
+// API handler for babies list, custom display names and order applied
+func handleBabiesAPI(w http.ResponseWriter, r *http.Request, babies []baby.Baby, displayConfig *baby.DisplayConfigStore) {
 	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	result := map[string]interface{}{
-		"babies": babies,
+		"babies": displayConfig.Apply(babies),
 		"count":  len(babies),
 	}
 
+	writeJSONWithETag(w, r, result, result)
+}
+
+// maxDisplayNameLength - limit of custom display names (in characters)
+const maxDisplayNameLength = 64
+
+// API handler for the display config of a baby: /api/babies/config/{baby_uid}
+// PUT replaces the whole config, an empty name restores the Nanit name.
+// Sensor calibration is kept, it is managed by /api/babies/{baby_uid}/calibration.
+func handleBabyDisplayConfigAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "GET" && r.Method != "PUT" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	babyUID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/babies/config/"), "/")
+	if babyUID == "" {
+		writeBabyUIDRequired(w)
+		return
+	}
+
+	if findBaby(app.getBabies(), babyUID) == nil {
+		writeBabyNotFound(w, babyUID)
+		return
+	}
+
+	if r.Method == "PUT" {
+		var config baby.DisplayConfig
+		if !app.decodeJSON(w, r, &config) {
+			return
+		}
+
+		current := app.DisplayConfig.Get(babyUID)
+		config.TempOffset = current.TempOffset
+		config.HumidityOffset = current.HumidityOffset
+
+		config.Name = strings.TrimSpace(config.Name)
+		if len([]rune(config.Name)) > maxDisplayNameLength {
+			writeError(w, apperrors.NewValidationError("name_too_long", fmt.Sprintf("Name must not be longer than %d characters", maxDisplayNameLength), nil), http.StatusBadRequest)
+			return
+		}
+
+		if err := app.DisplayConfig.Set(babyUID, config); err != nil {
+			log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to save display config")
+			writeError(w, apperrors.NewStorageError("display_config_save_failed", "Failed to save display config", err), http.StatusInternalServerError)
+			return
+		}
+
+		log.Info().Str("baby_uid", babyUID).Str("name", config.Name).Msg("Display config updated")
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"baby_uid": babyUID,
+		"config":   app.DisplayConfig.Get(babyUID),
+	})
+}
+
+// API handler forcing a fresh fetch of the baby list
+func handleBabiesRefreshAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	babies, err := app.refreshBabies()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to refresh babies")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":   "refresh_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"babies": babies,
+		"count":  len(babies),
+	})
+}
+
+// findBaby returns a copy of the baby with the given UID, nil if not found
+// Note: copies the element rather than taking the address of the range variable so the result never aliases the loop
+func findBaby(babies []baby.Baby, babyUID string) *baby.Baby {
+	for i := range babies {
+		if babies[i].UID == babyUID {
+			found := babies[i]
+			return &found
+		}
+	}
+
+	return nil
 }
 
 // API handler for control commands
 func handleControlAPI(w http.ResponseWriter, r *http.Request, controlType string, babies []baby.Baby, stateManager *baby.StateManager, app *App) {
 	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
 
@@ -77,34 +292,30 @@ func handleControlAPI(w http.ResponseWriter, r *http.Request, controlType string
 		Action  string `json:"action"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if !app.decodeJSON(w, r, &requestData) {
 		return
 	}
 
 	if requestData.BabyUID == "" {
-		http.Error(w, "baby_uid is required", http.StatusBadRequest)
+		writeBabyUIDRequired(w)
 		return
 	}
 
 	// Verify baby exists
-	var targetBaby *baby.Baby
-	for _, b := range babies {
-		if b.UID == requestData.BabyUID {
-			targetBaby = &b
-			break
-		}
-	}
+	targetBaby := findBaby(babies, requestData.BabyUID)
 
 	if targetBaby == nil {
-		http.Error(w, "Baby not found", http.StatusNotFound)
+		writeBabyNotFound(w, requestData.BabyUID)
 		return
 	}
 
 	// Get WebSocket connection
 	conn := app.getConnection(requestData.BabyUID)
 	if conn == nil {
-		http.Error(w, "WebSocket not connected", http.StatusServiceUnavailable)
+		if writeMonitoringNotStarted(w, app) {
+			return
+		}
+		writeError(w, apperrors.NewNetworkError("camera_not_connected", "WebSocket not connected", nil).WithContext("baby_uid", requestData.BabyUID), http.StatusServiceUnavailable)
 		return
 	}
 
@@ -116,33 +327,33 @@ func handleControlAPI(w http.ResponseWriter, r *http.Request, controlType string
 	case "night-light":
 		if requestData.Action == "toggle" {
 			newState := !currentState.GetNightLight()
-			sendLightCommand(newState, conn)
+			sendLightCommand(requestData.BabyUID, newState, conn)
 			
 			log.Info().
 				Str("baby_uid", requestData.BabyUID).
 				Bool("new_state", newState).
 				Msg("Night light toggle command sent")
 		} else {
-			http.Error(w, "Invalid action for night-light", http.StatusBadRequest)
+			writeError(w, apperrors.NewValidationError("invalid_action", "Invalid action for night-light", nil).WithContext("action", requestData.Action), http.StatusBadRequest)
 			return
 		}
 
 	case "standby":
 		if requestData.Action == "toggle" {
 			newState := !currentState.GetStandby()
-			sendStandbyCommand(newState, conn)
+			sendStandbyCommand(requestData.BabyUID, newState, conn)
 			
 			log.Info().
 				Str("baby_uid", requestData.BabyUID).
 				Bool("new_state", newState).
 				Msg("Standby toggle command sent")
 		} else {
-			http.Error(w, "Invalid action for standby", http.StatusBadRequest)
+			writeError(w, apperrors.NewValidationError("invalid_action", "Invalid action for standby", nil).WithContext("action", requestData.Action), http.StatusBadRequest)
 			return
 		}
 
 	default:
-		http.Error(w, "Unknown control type", http.StatusBadRequest)
+		writeError(w, apperrors.NewValidationError("unknown_control", "Unknown control type", nil), http.StatusBadRequest)
 		return
 	}
 
@@ -159,13 +370,6 @@ func handleControlAPI(w http.ResponseWriter, r *http.Request, controlType string
 	json.NewEncoder(w).Encode(response)
 }
 
-// DeviceAlert represents an error or warning for the device
-type DeviceAlert struct {
-	Type     string `json:"type"`     // "error" or "warning"
-	Message  string `json:"message"`
-	Category string `json:"category"`
-}
-
 // DeviceInfoResponse represents the full device information response
 type DeviceInfoResponse struct {
 	BabyUID          string                 `json:"baby_uid"`
@@ -173,12 +377,16 @@ type DeviceInfoResponse struct {
 	CameraUID        string                 `json:"camera_uid"`
 	Timestamp        int64                  `json:"timestamp"`
 	DeviceInfo       *baby.DeviceInfo       `json:"device_info"`
+	Stale            bool                   `json:"stale"`             // Camera didn't confirm the info recently or since it connected
+	AgeSeconds       *int64                 `json:"age_seconds"`       // Seconds since the camera last confirmed the info, nil if it never did
+	RefreshRequested bool                   `json:"refresh_requested"` // Camera was asked for fresh info, poll again shortly
 	ConnectionStatus map[string]interface{} `json:"connection_status"`
 	Alerts           []DeviceAlert          `json:"alerts"`
 }
 
 // Device info endpoint handler
-func handleDeviceInfoAPI(w http.ResponseWriter, r *http.Request, babies []baby.Baby, stateManager *baby.StateManager) {
+// Acknowledged alerts are left out unless ?include_acked=true. Stale info is requested from the camera again.
+func handleDeviceInfoAPI(w http.ResponseWriter, r *http.Request, babies []baby.Baby, app *App) {
 	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -194,13 +402,7 @@ func handleDeviceInfoAPI(w http.ResponseWriter, r *http.Request, babies []baby.B
 	babyUID := path
 
 	// Find the baby
-	var targetBaby *baby.Baby
-	for _, b := range babies {
-		if b.UID == babyUID {
-			targetBaby = &b
-			break
-		}
-	}
+	targetBaby := findBaby(babies, babyUID)
 
 	if targetBaby == nil {
 		http.Error(w, "Baby not found", http.StatusNotFound)
@@ -208,94 +410,55 @@ func handleDeviceInfoAPI(w http.ResponseWriter, r *http.Request, babies []baby.B
 	}
 
 	// Get current state with device info
-	babyState := stateManager.GetBabyState(babyUID)
-	deviceInfo := babyState.GetDeviceInfo()
-
-	// Build connection status
-	connectionStatus := map[string]interface{}{
-		"websocket_alive": babyState.GetIsWebsocketAlive(),
-		"stream_state":    getStreamStateString(babyState.StreamState),
-	}
-
-	// Build alerts based on current state
-	var alerts []DeviceAlert
-
-	// Check for websocket connection issues
-	if !babyState.GetIsWebsocketAlive() {
-		alerts = append(alerts, DeviceAlert{
-			Type:     "error",
-			Message:  "Camera is disconnected from Nanit servers",
-			Category: "connectivity",
-		})
+	babyState := app.BabyStateManager.GetBabyState(babyUID)
+	response := buildDeviceInfoResponse(*targetBaby, babyState, app, r.URL.Query().Get("include_acked") == "true")
+	if response.Stale {
+		response.RefreshRequested = app.requestDeviceInfoRefresh(babyUID)
 	}
 
-	// Check for streaming errors
-	if deviceInfo.StreamingError != nil && *deviceInfo.StreamingError != "" {
-		alerts = append(alerts, DeviceAlert{
-			Type:     "error",
-			Message:  *deviceInfo.StreamingError,
-			Category: "streaming",
-		})
+	// Generation time, age and alerts being seen again don't change the version of the device info
+	etagSource := response
+	etagSource.Timestamp = 0
+	etagSource.AgeSeconds = nil
+	etagSource.RefreshRequested = false
+	etagSource.Alerts = make([]DeviceAlert, len(response.Alerts))
+	for i, alert := range response.Alerts {
+		alert.LastSeen = 0
+		etagSource.Alerts[i] = alert
 	}
 
-	// Check stream state for issues
-	if babyState.StreamState != nil {
-		switch *babyState.StreamState {
-		case baby.StreamState_Unhealthy:
-			alerts = append(alerts, DeviceAlert{
-				Type:     "warning",
-				Message:  "Video streaming is experiencing issues",
-				Category: "streaming",
-			})
-		case baby.StreamState_Unknown:
-			alerts = append(alerts, DeviceAlert{
-				Type:     "warning",
-				Message:  "Video stream status unknown",
-				Category: "streaming",
-			})
-		}
-	}
+	// Return full device info response
+	writeJSONWithETag(w, r, response, etagSource)
+}
 
-	// Check for connection limit issues (streaming blocked by too many mobile apps)
-	if babyState.GetStreamRequestState() == baby.StreamRequestState_RequestFailed {
-		alerts = append(alerts, DeviceAlert{
-			Type:     "warning",
-			Message:  "Streaming blocked: Too many Nanit mobile apps connected. Close the official Nanit app on your phone/tablet to enable streaming here.",
-			Category: "connection_limit",
-		})
-	}
+// buildDeviceInfoResponse builds the device information payload including alerts of a single baby
+// Acknowledged alerts are dropped unless includeAcked.
+func buildDeviceInfoResponse(b baby.Baby, babyState *baby.State, app *App, includeAcked bool) DeviceInfoResponse {
+	// Device info is shared with the state manager, the response gets its own copy
+	snapshot := babyState.DeepCopy()
+	deviceInfo := snapshot.GetDeviceInfo()
 
-	// Check for device warnings
-	if deviceInfo.SleepMode != nil && *deviceInfo.SleepMode {
-		alerts = append(alerts, DeviceAlert{
-			Type:     "warning",
-			Message:  "Camera is in sleep mode",
-			Category: "device_state",
-		})
-	}
+	now := time.Now()
+	age, stale := deviceInfoAge(babyState.DeviceInfo, app.BabyStateManager.GetConnectionStats(b.UID).ConnectedSince, app.Opts.DeviceInfoMaxAge, now)
 
-	if deviceInfo.UpgradeDownloaded != nil && *deviceInfo.UpgradeDownloaded {
-		alerts = append(alerts, DeviceAlert{
-			Type:     "warning",
-			Message:  "Firmware update available for installation",
-			Category: "firmware",
-		})
+	// Build connection status
+	connectionStatus := map[string]interface{}{
+		"websocket_alive": babyState.GetIsWebsocketAlive(),
+		"stream_state":    getStreamStateString(babyState.StreamState),
 	}
 
 	// Build full response
-	response := DeviceInfoResponse{
-		BabyUID:          targetBaby.UID,
-		BabyName:         targetBaby.Name,
-		CameraUID:        targetBaby.CameraUID,
-		Timestamp:        time.Now().Unix(),
+	return DeviceInfoResponse{
+		BabyUID:          b.UID,
+		BabyName:         b.Name,
+		CameraUID:        b.CameraUID,
+		Timestamp:        now.Unix(),
 		DeviceInfo:       deviceInfo,
+		Stale:            stale,
+		AgeSeconds:       age,
 		ConnectionStatus: connectionStatus,
-		Alerts:           alerts,
+		Alerts:           app.trackedDeviceAlerts(b.UID, buildDeviceAlerts(babyState, app.streamIdentifier()), includeAcked),
 	}
-
-	// Return full device info response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
 }
 
 // Helper function to convert stream state to string
@@ -317,7 +480,7 @@ func getStreamStateString(streamState *baby.StreamState) string {
 }
 
 // Authentication API handlers
-func handleAuthLoginAPI(w http.ResponseWriter, r *http.Request) {
+func handleAuthLoginAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	log.Info().Msg("=== Starting login attempt ===")
 	
 	if r.Method != "POST" {
@@ -331,9 +494,8 @@ func handleAuthLoginAPI(w http.ResponseWriter, r *http.Request) {
 		Password string `json:"password"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		log.Error().Err(err).Msg("Failed to decode login request JSON")
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if !app.decodeJSON(w, r, &requestData) {
+		log.Warn().Msg("Invalid login request body")
 		return
 	}
 
@@ -385,8 +547,6 @@ func handleAuthLoginAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Info().Interface("response", nanitResponse).Msg("Nanit API response body")
-
 	// Status 201 = success without 2FA, Status 482 = 2FA required
 	if response.StatusCode != 201 && response.StatusCode != 482 {
 		errorMsg := "Login failed"
@@ -424,18 +584,17 @@ func handleAuthVerify2FAAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	}
 
 	var requestData struct {
-		Email    string      `json:"email"`
-		Password string      `json:"password"`
-		MFAToken interface{} `json:"mfa_token"`
-		MFACode  string      `json:"mfa_code"`
+		Email    string `json:"email"`
+		Password string `json:"password"`
+		MFAToken string `json:"mfa_token"`
+		MFACode  string `json:"mfa_code"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if !app.decodeJSON(w, r, &requestData) {
 		return
 	}
 
-	if requestData.Email == "" || requestData.Password == "" || requestData.MFACode == "" {
+	if requestData.Email == "" || requestData.Password == "" || requestData.MFAToken == "" || requestData.MFACode == "" {
 		http.Error(w, "All fields are required", http.StatusBadRequest)
 		return
 	}
@@ -453,7 +612,7 @@ func handleAuthVerify2FAAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	log.Info().Str("mfa_code", requestData.MFACode).Msg("Sending 2FA verification request")
 
 	verifyJSON, _ := json.Marshal(verifyData)
-	log.Info().Str("payload", string(verifyJSON)).Msg("Sending verification request to Nanit API")
+	log.Info().Msg("Sending verification request to Nanit API")
 	
 	req, err := http.NewRequest("POST", "https://api.nanit.com/login", strings.NewReader(string(verifyJSON)))
 	if err != nil {
@@ -486,8 +645,6 @@ func handleAuthVerify2FAAPI(w http.ResponseWriter, r *http.Request, app *App) {
 		return
 	}
 
-	log.Info().Interface("response", nanitResponse).Msg("Nanit verification API response body")
-
 	if response.StatusCode != 201 {
 		errorMsg := "Verification failed"
 		if errDetail, ok := nanitResponse["error"].(string); ok {
@@ -510,16 +667,16 @@ func handleAuthVerify2FAAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	}
 
 	// Save session data (similar to init-nanit.sh)
-	sessionData := map[string]interface{}{
-		"revision":     3, // Keep in sync with session.go
-		"authToken":    requestData.MFAToken,
-		"refreshToken": refreshToken,
+	sessionData := session.Session{
+		Revision:     session.Revision,
+		AuthToken:    requestData.MFAToken,
+		RefreshToken: refreshToken,
 	}
 
 	sessionJSON, _ := json.Marshal(sessionData)
 	sessionFile := app.Opts.SessionFile
 	
-	if err := os.WriteFile(sessionFile, sessionJSON, 0600); err != nil {
+	if err := utils.WriteFileAtomic(sessionFile, sessionJSON, 0600); err != nil {
 		log.Error().Err(err).Str("file", sessionFile).Msg("Failed to save session file")
 		http.Error(w, "Failed to save authentication", http.StatusInternalServerError)
 		return
@@ -545,6 +702,15 @@ func handleAuthVerify2FAAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	json.NewEncoder(w).Encode(result)
 }
 
+// Reported when the Nanit session expired and has to be renewed by POST /api/auth/relogin
+const reauthRequiredMessage = "Nanit session expired, please sign in again"
+
+// Reported when the Nanit account has no cameras yet, monitoring picks them up once the babies list is refreshed
+const (
+	noBabiesMessage    = "No cameras associated with this account"
+	noBabiesSuggestion = "Set up the camera in the Nanit app, then refresh the babies list (POST /api/babies/refresh)"
+)
+
 func handleAuthStatusAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -577,11 +743,12 @@ func handleAuthStatusAPI(w http.ResponseWriter, r *http.Request, app *App) {
 			}
 			
 			// Count babies
-			babiesCount = len(app.SessionStore.Session.Babies)
+			babies := app.getBabies()
+			babiesCount = len(babies)
 			
 			// Check if services are running (at least one baby has active WebSocket)
 			if babiesCount > 0 {
-				for _, baby := range app.SessionStore.Session.Babies {
+				for _, baby := range babies {
 					state := app.BabyStateManager.GetBabyState(baby.UID)
 					if state.GetIsWebsocketAlive() {
 						servicesRunning = true
@@ -596,13 +763,40 @@ func handleAuthStatusAPI(w http.ResponseWriter, r *http.Request, app *App) {
 		message = "No authentication found"
 	}
 
+	if isAuthenticated && babiesCount == 0 {
+		message = noBabiesMessage
+	}
+
+	// Refresh token is dead and the app can't sign in on its own, the frontend prompts for the credentials
+	status := "not_authenticated"
+	reauthRequired := app.RestClient != nil && app.RestClient.ReauthRequired()
+	if reauthRequired {
+		status = "reauth_required"
+		message = reauthRequiredMessage
+	} else if isAuthenticated {
+		status = "authenticated"
+	}
+
 	result := map[string]interface{}{
+		"status":            status,
+		"reauth_required":   reauthRequired,
 		"authenticated":     isAuthenticated,
 		"message":           message,
 		"email":             email,
 		"babies_count":      babiesCount,
 		"services_running":  servicesRunning,
 	}
+
+	if isAuthenticated && babiesCount == 0 {
+		result["suggestion"] = noBabiesSuggestion
+	}
+	
+	// Distinguishes a broken setup from Nanit being down
+	nanitAPIReachable, nanitAPIMessage := app.getNanitAPIStatus()
+	result["nanit_api_reachable"] = nanitAPIReachable
+	if !nanitAPIReachable {
+		result["nanit_api_message"] = nanitAPIMessage
+	}
 	
 	if authTime != nil {
 		result["auth_time"] = authTime.Unix()
@@ -612,6 +806,125 @@ func handleAuthStatusAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	json.NewEncoder(w).Encode(result)
 }
 
+// API handler forcing renewal of the Nanit session
+// Reports whether the refresh token was still valid, a fallback to full login means the user has to sign in again when it fails.
+func handleAuthRefreshAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "POST" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	if app.RestClient == nil || app.RestClient.SessionStore == nil {
+		writeError(w, apperrors.NewAuthError("not_authenticated", "Not authenticated with Nanit", nil), http.StatusUnauthorized)
+		return
+	}
+
+	method, err := app.RestClient.AuthorizeWithMethod()
+	fellBackToLogin := method == client.AuthMethodLogin
+
+	if err != nil {
+		log.Warn().Err(err).Str("method", string(method)).Msg("Forced Nanit token refresh failed")
+
+		if fellBackToLogin {
+			writeError(w, apperrors.NewAuthError("relogin_required", "Refresh token is no longer valid, please sign in again", err).
+				WithContext("fell_back_to_login", true), http.StatusUnauthorized)
+			return
+		}
+
+		writeError(w, apperrors.NewNetworkError("token_refresh_failed", "Unable to refresh the Nanit session", err), http.StatusBadGateway)
+		return
+	}
+
+	if app.SessionStore != nil && app.SessionStore != app.RestClient.SessionStore {
+		app.SessionStore.Session = app.RestClient.SessionStore.Session
+	}
+
+	authTime := app.RestClient.SessionStore.Session.AuthTime
+	log.Info().Str("method", string(method)).Msg("Forced Nanit token refresh succeeded")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":            true,
+		"method":             method,
+		"fell_back_to_login": fellBackToLogin,
+		"auth_time":          authTime.Unix(),
+	})
+}
+
+// API handler signing in to Nanit again once the refresh token is dead: /api/auth/relogin
+// Credentials missing in the body fall back to the configured ones. Accounts with 2FA get mfa_required with the MFA token first,
+// the login is completed by repeating the request with mfa_token and the e-mailed mfa_code.
+// POST body: {"email": "...", "password": "...", "mfa_token": "...", "mfa_code": "..."}
+func handleAuthReloginAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "POST" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	if app.RestClient == nil {
+		writeError(w, apperrors.NewConfigError("client_unavailable", "Nanit client is not configured", nil), http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+		MFAToken string `json:"mfa_token"`
+		MFACode  string `json:"mfa_code"`
+	}
+	if !app.decodeOptionalJSON(w, r, &req) {
+		return
+	}
+
+	if req.Email == "" && req.Password == "" {
+		req.Email = app.RestClient.Email
+		req.Password = app.RestClient.Password
+	}
+
+	if req.Email == "" || req.Password == "" {
+		writeError(w, apperrors.NewValidationError("credentials_required", "E-mail and password are required", nil), http.StatusBadRequest)
+		return
+	}
+
+	// Signs in with the store shared by the app (also after a reset), the session is updated in place
+	mfaToken, err := app.RestClient.LoginWithCredentials(client.LoginRequest{
+		Email:    req.Email,
+		Password: req.Password,
+		MFAToken: req.MFAToken,
+		MFACode:  req.MFACode,
+	})
+
+	switch {
+	case errors.Is(err, client.ErrMFARequired) && mfaToken != "":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":      false,
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+			"message":      "Please check your email for verification code and repeat the request with it.",
+		})
+		return
+	case errors.Is(err, client.ErrMFARequired), errors.Is(err, client.ErrInvalidCredentials):
+		log.Warn().Err(err).Msg("Nanit relogin rejected")
+		writeError(w, apperrors.NewAuthError("invalid_credentials", "Nanit has not accepted the credentials", err), http.StatusUnauthorized)
+		return
+	case err != nil:
+		log.Error().Err(err).Msg("Nanit relogin failed")
+		writeError(w, apperrors.NewNetworkError("relogin_failed", "Unable to sign in to Nanit", err), http.StatusBadGateway)
+		return
+	}
+
+	log.Info().Str("email", req.Email).Msg("Signed in to Nanit again, restarting services")
+	go app.restartServicesAfterRelogin()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"message":   "Signed in to Nanit, services are restarting",
+		"auth_time": app.RestClient.SessionStore.Session.AuthTime.Unix(),
+	})
+}
+
 func handleAuthResetAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	if r.Method != "DELETE" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -621,13 +934,10 @@ func handleAuthResetAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	// Get session file path
 	sessionFile := app.Opts.SessionFile
 	
-	// Stop all monitoring services first
+	// Stop all monitoring services first, the cameras can't be reached without the session
 	log.Info().Msg("Stopping monitoring services for authentication reset")
-	
-	// Stop HLS transcoding for all babies
-	if app.HLSManager != nil {
+	if !app.stopMonitoring() && app.HLSManager != nil {
 		app.HLSManager.StopAll()
-		log.Info().Msg("Stopped all HLS transcoding")
 	}
 	
 	// Clear session store in memory
@@ -649,10 +959,12 @@ func handleAuthResetAPI(w http.ResponseWriter, r *http.Request, app *App) {
 		}
 	}
 	
-	// Clear REST client credentials
+	// Clear REST client credentials, its store is kept so that signing in again persists the session
 	if app.RestClient != nil {
 		app.RestClient.RefreshToken = ""
-		app.RestClient.SessionStore = session.NewSessionStore()
+		if app.RestClient.SessionStore != nil && app.RestClient.SessionStore != app.SessionStore {
+			app.RestClient.SessionStore.Session = &session.Session{Revision: session.Revision}
+		}
 		log.Info().Msg("Cleared REST client credentials")
 	}
 	
@@ -667,26 +979,50 @@ func handleAuthResetAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	json.NewEncoder(w).Encode(result)
 }
 
-// Streaming and historical API handlers - simplified implementations
+// hlsPlaylistRetryAfter - seconds a player should wait before requesting a playlist that is not written yet
+// (length of one HLS segment)
+const hlsPlaylistRetryAfter = 2
+
+// writeHLSError writes JSON error of the HLS endpoint, HEAD requests get the same status and headers without a body
+func writeHLSError(w http.ResponseWriter, r *http.Request, statusCode int, response interface{}) {
+	body, _ := json.Marshal(response)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(statusCode)
+
+	if r.Method != "HEAD" {
+		w.Write(body)
+	}
+}
+
 func handleHLSStreamAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	// HEAD is used by players (preload) and uptime checkers to probe playlist/segment availability
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeMethodNotAllowed(w)
+		return
+	}
+
 	// Extract baby UID from URL path: /api/stream/hls/{baby_uid}/playlist.m3u8
 	path := strings.TrimPrefix(r.URL.Path, "/api/stream/hls/")
 	parts := strings.Split(path, "/")
 	
 	if len(parts) < 2 {
-		http.Error(w, "Invalid stream path", http.StatusBadRequest)
+		writeError(w, apperrors.NewValidationError("invalid_stream_path", "Invalid stream path, expected /api/stream/hls/{baby_uid}/{file}", nil), http.StatusBadRequest)
 		return
 	}
 	
-	babyUID := parts[0]
-	fileName := parts[1]
-	
+	serveHLSFile(w, r, app, parts[0], parts[1])
+}
+
+// serveHLSFile serves playlist or segment of the transcoder registered under babyUID
+// Video and audio-only transcoders of a baby run side by side, the file name tells which one produces it.
+func serveHLSFile(w http.ResponseWriter, r *http.Request, app *App, babyUID string, fileName string) {
 	// Get transcoder for this baby
-	transcoder, exists := app.HLSManager.GetTranscoder(babyUID)
+	transcoder, exists := app.HLSManager.GetTranscoderMode(babyUID, streaming.ModeOfFile(fileName))
 	if !exists {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{
+		writeHLSError(w, r, http.StatusNotFound, map[string]string{
 			"error": "no_transcoder",
 			"message": "No stream transcoder found for this baby",
 		})
@@ -696,8 +1032,6 @@ func handleHLSStreamAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	if !transcoder.IsRunning() {
 		// Get error details if available
 		status, streamError := transcoder.GetStatus()
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
 		
 		response := map[string]interface{}{
 			"error": "transcoder_not_running",
@@ -709,20 +1043,66 @@ func handleHLSStreamAPI(w http.ResponseWriter, r *http.Request, app *App) {
 			response["stream_error"] = streamError
 		}
 		
-		json.NewEncoder(w).Encode(response)
+		// Failed transcoders don't come up by waiting, players should stop polling
+		statusCode := http.StatusServiceUnavailable
+		if status == streaming.StatusError {
+			statusCode = http.StatusBadGateway
+		}
+		
+		writeHLSError(w, r, statusCode, response)
 		return
 	}
 	
+	// FFmpeg writes a single playlist covering the DVR window, the live playlist is its tail
+	liveSegments := 0
+	playlistName := filepath.Base(transcoder.GetPlaylistPath())
+	if fileName == streaming.DVRPlaylistName {
+		if !transcoder.HasDVRPlaylist() {
+			writeHLSError(w, r, http.StatusNotFound, map[string]string{
+				"error":   "dvr_disabled",
+				"message": "DVR playlist is not enabled, set NANIT_HLS_DVR_WINDOW",
+			})
+			return
+		}
+		fileName = playlistName
+	} else if fileName == playlistName {
+		liveSegments = transcoder.LivePlaylistSegments()
+	}
+	
 	// Serve the HLS file
 	filePath := filepath.Join(transcoder.GetHLSDir(), fileName)
 	
 	// Check if file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
 		// Check transcoder status to provide better error info
 		status, streamError := transcoder.GetStatus()
-		
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
+
+		// FFmpeg failed (possibly retrying in the background), the playlist won't appear by polling for it
+		if strings.HasSuffix(fileName, ".m3u8") && status == streaming.StatusError {
+			writeHLSError(w, r, http.StatusBadGateway, map[string]interface{}{
+				"error":        "transcoder_error",
+				"status":       string(status),
+				"message":      "Stream transcoder failed",
+				"file":         fileName,
+				"stream_error": streamError,
+			})
+			return
+		}
+
+		// FFmpeg needs a few seconds to write the first segment of a starting stream, players back off and
+		// retry on 503 with Retry-After instead of giving up on a 404
+		if strings.HasSuffix(fileName, ".m3u8") {
+			w.Header().Set("Retry-After", strconv.Itoa(hlsPlaylistRetryAfter))
+			writeHLSError(w, r, http.StatusServiceUnavailable, map[string]interface{}{
+				"error":       "playlist_not_ready",
+				"status":      string(status),
+				"message":     "Stream is starting, playlist not available yet",
+				"file":        fileName,
+				"retry_after": hlsPlaylistRetryAfter,
+			})
+			return
+		}
 		
 		response := map[string]interface{}{
 			"error": "file_not_found",
@@ -735,7 +1115,7 @@ func handleHLSStreamAPI(w http.ResponseWriter, r *http.Request, app *App) {
 			response["stream_error"] = streamError
 		}
 		
-		json.NewEncoder(w).Encode(response)
+		writeHLSError(w, r, http.StatusNotFound, response)
 		return
 	}
 	
@@ -748,54 +1128,237 @@ func handleHLSStreamAPI(w http.ResponseWriter, r *http.Request, app *App) {
 		w.Header().Set("Cache-Control", "max-age=3600")
 	}
 	
-	// Enable CORS for HLS
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET")
-	
-	// Serve the file
-	http.ServeFile(w, r, filePath)
-}
-
-func handleStreamStartAPI(w http.ResponseWriter, r *http.Request, app *App) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	
-	var requestData struct {
-		BabyUID string `json:"baby_uid"`
+	// Enable CORS for HLS (unless the CORS policy already allowed the origin)
+	if w.Header().Get("Access-Control-Allow-Origin") == "" {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
 	}
+	w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD")
 	
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if liveSegments > 0 && err == nil {
+		playlist, err := os.ReadFile(filePath)
+		if err != nil {
+			writeHLSError(w, r, http.StatusInternalServerError, map[string]string{
+				"error":   "playlist_read_failed",
+				"message": "Failed to read HLS playlist",
+			})
+			return
+		}
+		
+		http.ServeContent(w, r, fileName, info.ModTime(), bytes.NewReader(streaming.TrimPlaylist(playlist, liveSegments)))
 		return
 	}
 	
-	if requestData.BabyUID == "" {
-		http.Error(w, "baby_uid is required", http.StatusBadRequest)
+	// Serve the file (for HEAD, ServeFile writes content length and the other headers only)
+	http.ServeFile(w, r, filePath)
+}
+
+// mosaicPlaylistPath - relative URL of the mosaic HLS playlist
+const mosaicPlaylistPath = "/api/stream/mosaic/playlist.m3u8"
+
+// API handler of the multi-camera mosaic stream:
+// POST /api/stream/mosaic/start, POST /api/stream/mosaic/stop, GET /api/stream/mosaic/playlist.m3u8 (and segments)
+func handleStreamMosaicAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	action := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/stream/mosaic/"), "/")
+
+	switch action {
+	case "start":
+		handleStreamMosaicStartAPI(w, r, app)
+	case "stop":
+		if r.Method != "POST" {
+			writeMethodNotAllowed(w)
+			return
+		}
+
+		app.HLSManager.StopMosaic()
+		log.Info().Msg("Mosaic transcoding stopped")
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Mosaic stopped successfully",
+		})
+	default:
+		if r.Method != "GET" && r.Method != "HEAD" {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeMethodNotAllowed(w)
+			return
+		}
+
+		serveHLSFile(w, r, app, streaming.MosaicUID, action)
+	}
+}
+
+// handleStreamMosaicStartAPI starts the mosaic of the dashboard cameras, cameras not publishing get a placeholder tile
+// The set of live cameras is taken at start, start the mosaic again to pick up cameras that came online since.
+func handleStreamMosaicStartAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "POST" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	if app.Opts.RTMP == nil {
+		writeError(w, apperrors.NewConfigError("rtmp_not_configured", "RTMP not configured", nil), http.StatusServiceUnavailable)
+		return
+	}
+
+	if writeMonitoringNotStarted(w, app) {
+		return
+	}
+
+	tiles := make([]streaming.MosaicTile, 0)
+	for _, b := range app.DisplayConfig.Apply(app.getBabies()) {
+		if b.Hidden {
+			continue
+		}
+
+		tile := streaming.MosaicTile{BabyUID: b.UID}
+		if app.BabyStateManager.GetBabyState(b.UID).GetStreamState() == baby.StreamState_Alive {
+			tile.URL = app.getLocalStreamURL(b.UID)
+			tile.Live = true
+		}
+
+		tiles = append(tiles, tile)
+	}
+
+	if len(tiles) == 0 {
+		writeError(w, apperrors.NewValidationError("no_cameras", "No cameras to show in the mosaic", nil), http.StatusNotFound)
+		return
+	}
+
+	if err := app.HLSManager.StartMosaic(tiles); errors.Is(err, streaming.ErrTranscoderLimitReached) {
+		writeError(w, apperrors.NewConfigError("transcoder_limit_reached", "Maximum number of concurrent streams reached, stop another stream first", err), http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		log.Error().Err(err).Msg("Failed to start mosaic transcoding")
+		writeError(w, apperrors.NewExternalError("stream_start_failed", "Failed to start mosaic", err), http.StatusInternalServerError)
+		return
+	}
+
+	log.Info().Int("tiles", len(tiles)).Msg("Mosaic transcoding started")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":          true,
+		"tiles":            tiles,
+		"hls_url":          mosaicPlaylistPath,
+		"hls_url_absolute": absoluteURL(r, app, mosaicPlaylistPath),
+		"message":          "Mosaic started successfully",
+	})
+}
+
+// hlsPlaylistPath returns the relative URL of the HLS playlist of the baby in given mode
+func hlsPlaylistPath(babyUID string, mode streaming.StreamMode) string {
+	return fmt.Sprintf("/api/stream/hls/%s/%s", babyUID, mode.PlaylistName())
+}
+
+// absoluteURL builds an absolute URL of the path under which this app is reachable by the client.
+// Configured public base URL takes precedence, then X-Forwarded-Proto/X-Forwarded-Host set by a reverse proxy.
+func absoluteURL(r *http.Request, app *App, path string) string {
+	if app.Opts.PublicBaseURL != "" {
+		return strings.TrimSuffix(app.Opts.PublicBaseURL, "/") + path
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := firstHeaderValue(r, "X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	host := r.Host
+	if forwardedHost := firstHeaderValue(r, "X-Forwarded-Host"); forwardedHost != "" {
+		host = forwardedHost
+	}
+
+	return fmt.Sprintf("%s://%s%s", scheme, host, path)
+}
+
+// firstHeaderValue returns the first entry of a comma separated header (proxies append their own values)
+func firstHeaderValue(r *http.Request, name string) string {
+	value, _, _ := strings.Cut(r.Header.Get(name), ",")
+	return strings.TrimSpace(value)
+}
+
+// streamRequest - stream start/stop request
+type streamRequest struct {
+	BabyUID string `json:"baby_uid"`
+	Mode    string `json:"mode"` // "video" (default) or "audio", stop stops both if empty
+}
+
+// decodeStreamRequest reads a stream start/stop request, the baby UID is taken from the path (preferred) or the JSON body
+// The body is optional if the path holds the baby UID. Writes the error response and returns false if the request isn't valid.
+func (app *App) decodeStreamRequest(w http.ResponseWriter, r *http.Request, prefix string) (streamRequest, bool) {
+	var request streamRequest
+	if !app.decodeOptionalJSON(w, r, &request) {
+		return request, false
+	}
+
+	if babyUID := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/"); babyUID != "" {
+		request.BabyUID = babyUID
+	}
+
+	if request.BabyUID == "" {
+		message := fmt.Sprintf("baby_uid is required, use POST %s{baby_uid} or POST %s with JSON body {\"baby_uid\": \"...\"}", prefix, prefix)
+		writeError(w, apperrors.NewValidationError("baby_uid_required", message, nil), http.StatusBadRequest)
+		return request, false
+	}
+
+	return request, true
+}
+
+func handleStreamStartAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "POST" {
+		writeMethodNotAllowed(w)
+		return
+	}
+	
+	request, ok := app.decodeStreamRequest(w, r, "/api/stream/start/")
+	if !ok {
+		return
+	}
+	babyUID := request.BabyUID
+
+	mode, err := streaming.ParseStreamMode(request.Mode)
+	if err != nil {
+		writeError(w, apperrors.NewValidationError("invalid_mode", err.Error(), err).WithContext("baby_uid", babyUID), http.StatusBadRequest)
 		return
 	}
 	
 	// Build RTMP URL for this baby
-	rtmpURL := app.getLocalStreamURL(requestData.BabyUID)
+	rtmpURL := app.getLocalStreamURL(babyUID)
 	if rtmpURL == "" {
-		http.Error(w, "RTMP not configured", http.StatusServiceUnavailable)
+		writeError(w, apperrors.NewConfigError("rtmp_not_configured", "RTMP not configured", nil), http.StatusServiceUnavailable)
+		return
+	}
+
+	// RTMP server only runs while monitoring
+	if writeMonitoringNotStarted(w, app) {
 		return
 	}
 	
 	// Start HLS transcoding
-	if err := app.HLSManager.StartTranscoding(requestData.BabyUID, rtmpURL); err != nil {
-		log.Error().Err(err).Str("baby_uid", requestData.BabyUID).Msg("Failed to start HLS transcoding")
-		http.Error(w, "Failed to start stream", http.StatusInternalServerError)
+	if err := app.HLSManager.StartTranscodingMode(babyUID, rtmpURL, mode); errors.Is(err, streaming.ErrTranscoderLimitReached) {
+		writeError(w, apperrors.NewConfigError("transcoder_limit_reached", "Maximum number of concurrent streams reached, stop another stream first", err).WithContext("baby_uid", babyUID), http.StatusServiceUnavailable)
+		return
+	} else if errors.Is(err, streaming.ErrHLSStorage) {
+		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to start HLS transcoding, HLS directory not writable")
+		writeError(w, apperrors.NewStorageError("hls_storage_unavailable", hlsStorageErrorMessage(app, babyUID), err).WithContext("baby_uid", babyUID), http.StatusInsufficientStorage)
+		return
+	} else if err != nil {
+		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to start HLS transcoding")
+		writeError(w, apperrors.NewExternalError("stream_start_failed", "Failed to start stream", err).WithContext("baby_uid", babyUID), http.StatusInternalServerError)
 		return
 	}
 	
-	log.Info().Str("baby_uid", requestData.BabyUID).Msg("HLS transcoding started")
+	log.Info().Str("baby_uid", babyUID).Str("mode", string(mode)).Msg("HLS transcoding started")
 	
 	result := map[string]interface{}{
 		"success":      true,
-		"baby_uid":     requestData.BabyUID,
-		"hls_url":      fmt.Sprintf("/api/stream/hls/%s/playlist.m3u8", requestData.BabyUID),
+		"baby_uid":     babyUID,
+		"mode":         string(mode),
+		"hls_url":      hlsPlaylistPath(babyUID, mode),
+		"hls_url_absolute": absoluteURL(r, app, hlsPlaylistPath(babyUID, mode)),
 		"message":      "Stream started successfully",
 	}
 	
@@ -805,32 +1368,33 @@ func handleStreamStartAPI(w http.ResponseWriter, r *http.Request, app *App) {
 
 func handleStreamStopAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
 	
-	var requestData struct {
-		BabyUID string `json:"baby_uid"`
-	}
-	
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	request, ok := app.decodeStreamRequest(w, r, "/api/stream/stop/")
+	if !ok {
 		return
 	}
-	
-	if requestData.BabyUID == "" {
-		http.Error(w, "baby_uid is required", http.StatusBadRequest)
-		return
+	babyUID := request.BabyUID
+
+	// Stop HLS transcoding, the given mode only if set
+	if request.Mode != "" {
+		mode, err := streaming.ParseStreamMode(request.Mode)
+		if err != nil {
+			writeError(w, apperrors.NewValidationError("invalid_mode", err.Error(), err).WithContext("baby_uid", babyUID), http.StatusBadRequest)
+			return
+		}
+		app.HLSManager.StopTranscodingMode(babyUID, mode)
+	} else {
+		app.HLSManager.StopTranscoding(babyUID)
 	}
 	
-	// Stop HLS transcoding
-	app.HLSManager.StopTranscoding(requestData.BabyUID)
-	
-	log.Info().Str("baby_uid", requestData.BabyUID).Msg("HLS transcoding stopped")
+	log.Info().Str("baby_uid", babyUID).Msg("HLS transcoding stopped")
 	
 	result := map[string]interface{}{
 		"success":  true,
-		"baby_uid": requestData.BabyUID,
+		"baby_uid": babyUID,
 		"message":  "Stream stopped successfully",
 	}
 	
@@ -838,45 +1402,133 @@ func handleStreamStopAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	json.NewEncoder(w).Encode(result)
 }
 
+// API handler for claiming the Nanit stream slot: /api/stream/claim/{baby_uid}
+func handleStreamClaimAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	handleStreamSlotAPI(w, r, app, "/api/stream/claim/", true)
+}
+
+// API handler for releasing the Nanit stream slot: /api/stream/release/{baby_uid}
+func handleStreamReleaseAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	handleStreamSlotAPI(w, r, app, "/api/stream/release/", false)
+}
+
+func handleStreamSlotAPI(w http.ResponseWriter, r *http.Request, app *App, prefix string, claim bool) {
+	if r.Method != "POST" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	babyUID := strings.TrimPrefix(r.URL.Path, prefix)
+	if babyUID == "" {
+		writeBabyUIDRequired(w)
+		return
+	}
+
+	if findBaby(app.getBabies(), babyUID) == nil {
+		writeBabyNotFound(w, babyUID)
+		return
+	}
+
+	var err error
+	var status int
+	var message string
+	if claim {
+		if writeMonitoringNotStarted(w, app) {
+			return
+		}
+		err = app.claimStreamSlot(babyUID)
+		status = http.StatusAccepted
+		message = "Stream slot claim requested"
+	} else {
+		err = app.releaseStreamSlot(babyUID)
+		status = http.StatusOK
+		message = "Stream slot released"
+	}
+
+	if err != nil {
+		log.Warn().Err(err).Str("baby_uid", babyUID).Bool("claim", claim).Msg("Stream slot request failed")
+		writeError(w, apperrors.NewExternalError("stream_slot_unavailable", err.Error(), err).WithContext("baby_uid", babyUID), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":             true,
+		"baby_uid":            babyUID,
+		"message":             message,
+		"stream_slot_holders": app.getStreamSlotHolders(),
+	})
+}
+
+// hlsStorageErrorMessage returns the storage error message of the baby's transcoder, a generic one if it has none
+func hlsStorageErrorMessage(app *App, babyUID string) string {
+	if transcoder, exists := app.HLSManager.GetTranscoder(babyUID); exists {
+		if _, streamError := transcoder.GetStatus(); streamError != nil && streamError.Type == streaming.ErrorTypeStorage {
+			return streamError.Message
+		}
+	}
+
+	return "HLS directory not writable"
+}
+
 func handleStreamStatusAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	if r.Method != "GET" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
 	
 	// Extract baby UID from URL path: /api/stream/status/{baby_uid}
 	path := strings.TrimPrefix(r.URL.Path, "/api/stream/status/")
 	if path == "" {
-		http.Error(w, "baby_uid is required", http.StatusBadRequest)
+		writeBabyUIDRequired(w)
 		return
 	}
 	
 	babyUID := path
+
+	mode, err := streaming.ParseStreamMode(r.URL.Query().Get("mode"))
+	if err != nil {
+		writeError(w, apperrors.NewValidationError("invalid_mode", err.Error(), err).WithContext("baby_uid", babyUID), http.StatusBadRequest)
+		return
+	}
 	
 	// Check for connection limit issues first
 	babyState := app.BabyStateManager.GetBabyState(babyUID)
 	if babyState.GetStreamRequestState() == baby.StreamRequestState_RequestFailed {
+		errorType, errorMessage := streamBlockedError(app.streamIdentifier())
+		message := "Streaming blocked by connection limit"
+		if errorType != "connection_limit" {
+			message = "Stream request declined by the camera"
+		}
+
 		result := map[string]interface{}{
-			"baby_uid": babyUID,
-			"status":   "blocked",
-			"message":  "Streaming blocked by connection limit",
+			"baby_uid":          babyUID,
+			"status":            "blocked",
+			"message":           message,
+			"stream_identifier": app.streamIdentifier().String(),
 			"stream_error": map[string]interface{}{
-				"type":    "connection_limit",
-				"message": "Too many Nanit mobile apps connected. Close the official Nanit app to enable streaming.",
+				"type":    errorType,
+				"message": errorMessage,
 			},
 		}
+		if nextRetry, ok := app.streamRetryMonitors.getNextRetry(babyUID); ok {
+			result["next_retry_at"] = nextRetry.Unix()
+		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(result)
 		return
 	}
 	
 	// Get transcoder for this baby
-	transcoder, exists := app.HLSManager.GetTranscoder(babyUID)
+	transcoder, exists := app.HLSManager.GetTranscoderMode(babyUID, mode)
 	if !exists {
 		result := map[string]interface{}{
-			"baby_uid": babyUID,
-			"status":   "not_found",
-			"message":  "No transcoder found for this baby",
+			"baby_uid":          babyUID,
+			"mode":              string(mode),
+			"status":            "not_found",
+			"message":           "No transcoder found for this baby",
+			"stream_identifier": app.streamIdentifier().String(),
 		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(result)
@@ -885,32 +1537,128 @@ func handleStreamStatusAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	
 	// Get detailed status information
 	info := transcoder.GetDetailedInfo()
+	info["stream_identifier"] = app.streamIdentifier().String()
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(info)
 }
 
-// Historical data API handlers - simplified implementations that check if feature is enabled
-func handleHistorySensorAPI(w http.ResponseWriter, r *http.Request, app *App) {
+func handleStreamProbeAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "GET" {
+		writeMethodNotAllowed(w)
+		return
+	}
+	
+	// Extract baby UID from URL path: /api/stream/probe/{baby_uid}
+	babyUID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/stream/probe/"), "/")
+	if babyUID == "" {
+		writeBabyUIDRequired(w)
+		return
+	}
+	
+	rtmpURL := app.getLocalStreamURL(babyUID)
+	if rtmpURL == "" {
+		writeError(w, apperrors.NewConfigError("rtmp_not_configured", "RTMP server not configured", nil), http.StatusServiceUnavailable)
+		return
+	}
+	
+	result, err := streaming.ProbeStream(rtmpURL)
+	if err != nil {
+		writeError(w, apperrors.NewExternalError("probe_failed", err.Error(), err).WithContext("baby_uid", babyUID), http.StatusBadGateway)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"baby_uid": babyUID,
+		"healthy":  result.IsHealthy(),
+		"probe":    result,
+	})
+}
+
+// API handler for the messages (motion, sound, ...) recorded by the Nanit cloud: /api/nanit/messages/{baby_uid}?limit=
+func handleNanitMessagesAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+
+	babyUID := strings.TrimPrefix(r.URL.Path, "/api/nanit/messages/")
+	if babyUID == "" {
+		http.Error(w, "baby_uid is required", http.StatusBadRequest)
+		return
+	}
+
+	if findBaby(app.getBabies(), babyUID) == nil {
+		http.Error(w, "Baby not found", http.StatusNotFound)
+		return
+	}
+
+	limit := defaultNanitMessagesLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+		if parsed > maxNanitMessagesLimit {
+			parsed = maxNanitMessagesLimit
+		}
+		limit = parsed
+	}
+
+	if app.RestClient == nil {
+		http.Error(w, "Nanit client not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	messages, fetchedAt, err := app.fetchNanitMessages(babyUID, limit)
+	if err != nil {
+		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to fetch Nanit messages")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":   "fetch_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"baby_uid":   babyUID,
+		"messages":   messages,
+		"count":      len(messages),
+		"limit":      limit,
+		"fetched_at": fetchedAt.Unix(),
+	})
+}
+
+func handleHistorySensorAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "GET" {
+		writeMethodNotAllowed(w)
+		return
+	}
 	
 	if !app.HistoryTracker.IsEnabled() {
-		http.Error(w, "Historical tracking disabled", http.StatusServiceUnavailable)
+		writeHistoryDisabled(w)
 		return
 	}
 	
 	// Extract baby UID from URL path: /api/history/sensor/{baby_uid}
 	path := strings.TrimPrefix(r.URL.Path, "/api/history/sensor/")
 	if path == "" {
-		http.Error(w, "baby_uid is required", http.StatusBadRequest)
+		writeBabyUIDRequired(w)
 		return
 	}
 	
 	babyUID := path
 	
+	unit, ok := resolveTemperatureUnit(w, r, app.DisplayConfig)
+	if !ok {
+		return
+	}
+	
 	// Parse query parameters
 	query := r.URL.Query()
 	
@@ -930,41 +1678,51 @@ func handleHistorySensorAPI(w http.ResponseWriter, r *http.Request, app *App) {
 		}
 	}
 	
-	// Use smart sampling based on timeframe duration
-	readings, err := app.HistoryTracker.GetSensorReadingsWithSampling(babyUID, startTime, endTime)
+	stream, err := newJSONArrayStream(w, map[string]interface{}{
+		"baby_uid":         babyUID,
+		"start_time":       startTime,
+		"end_time":         endTime,
+		"temperature_unit": unit,
+	}, "readings")
 	if err != nil {
-		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to get sensor readings")
-		http.Error(w, "Failed to retrieve sensor data", http.StatusInternalServerError)
+		writeError(w, apperrors.NewStorageError("history_query_failed", "Failed to retrieve sensor data", err), http.StatusInternalServerError)
 		return
 	}
 	
-	response := map[string]interface{}{
-		"baby_uid":   babyUID,
-		"start_time": startTime,
-		"end_time":   endTime,
-		"readings":   readings,
-		"count":      len(readings),
+	// Use smart sampling based on timeframe duration, readings are written as they are read
+	err = app.HistoryTracker.StreamSensorReadingsWithSampling(babyUID, startTime, endTime, func(reading history.SensorReading) error {
+		return stream.Write(sensorReadingResponse{
+			SensorReading: reading,
+			Temperature:   unit.FromCelsiusPtr(reading.TemperatureCelsius),
+		})
+	})
+	if err == nil {
+		err = stream.Close()
 	}
 	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	if err != nil {
+		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to get sensor readings")
+		if !stream.Started() {
+			writeError(w, apperrors.NewStorageError("history_query_failed", "Failed to retrieve sensor data", err), http.StatusInternalServerError)
+		}
+	}
 }
 
 func handleHistoryEventsAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	if r.Method != "GET" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
 	
 	if !app.HistoryTracker.IsEnabled() {
-		http.Error(w, "Historical tracking disabled", http.StatusServiceUnavailable)
+		writeHistoryDisabled(w)
 		return
 	}
 	
 	// Extract baby UID from URL path
 	path := strings.TrimPrefix(r.URL.Path, "/api/history/events/")
 	if path == "" {
-		http.Error(w, "baby_uid is required", http.StatusBadRequest)
+		writeBabyUIDRequired(w)
 		return
 	}
 	
@@ -995,46 +1753,57 @@ func handleHistoryEventsAPI(w http.ResponseWriter, r *http.Request, app *App) {
 		}
 	}
 	
-	events, err := app.HistoryTracker.GetEvents(babyUID, startTime, endTime, eventType, limit)
-	if err != nil {
-		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to get events")
-		http.Error(w, "Failed to retrieve event data", http.StatusInternalServerError)
-		return
-	}
-	
-	response := map[string]interface{}{
+	stream, err := newJSONArrayStream(w, map[string]interface{}{
 		"baby_uid":   babyUID,
 		"start_time": startTime,
 		"end_time":   endTime,
 		"event_type": eventType,
-		"events":     events,
-		"count":      len(events),
+	}, "events")
+	if err != nil {
+		writeError(w, apperrors.NewStorageError("history_query_failed", "Failed to retrieve event data", err), http.StatusInternalServerError)
+		return
 	}
 	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	err = app.HistoryTracker.StreamEvents(babyUID, startTime, endTime, eventType, limit, func(event history.Event) error {
+		return stream.Write(event)
+	})
+	if err == nil {
+		err = stream.Close()
+	}
+	
+	if err != nil {
+		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to get events")
+		if !stream.Started() {
+			writeError(w, apperrors.NewStorageError("history_query_failed", "Failed to retrieve event data", err), http.StatusInternalServerError)
+		}
+	}
 }
 
 func handleHistorySummaryAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	if r.Method != "GET" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
 	
 	if !app.HistoryTracker.IsEnabled() {
-		http.Error(w, "Historical tracking disabled", http.StatusServiceUnavailable)
+		writeHistoryDisabled(w)
 		return
 	}
 	
 	// Extract baby UID from URL path
 	path := strings.TrimPrefix(r.URL.Path, "/api/history/summary/")
 	if path == "" {
-		http.Error(w, "baby_uid is required", http.StatusBadRequest)
+		writeBabyUIDRequired(w)
 		return
 	}
 	
 	babyUID := path
 	
+	unit, ok := resolveTemperatureUnit(w, r, app.DisplayConfig)
+	if !ok {
+		return
+	}
+	
 	// Parse query parameters with defaults
 	query := r.URL.Query()
 	endTime := time.Now().Unix()
@@ -1055,29 +1824,29 @@ func handleHistorySummaryAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	summary, err := app.HistoryTracker.GetSummary(babyUID, startTime, endTime)
 	if err != nil {
 		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to get summary")
-		http.Error(w, "Failed to retrieve summary data", http.StatusInternalServerError)
+		writeError(w, apperrors.NewStorageError("history_query_failed", "Failed to retrieve summary data", err), http.StatusInternalServerError)
 		return
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(summary)
+	json.NewEncoder(w).Encode(convertSummary(*summary, unit))
 }
 
 func handleHistoryDayNightAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	if r.Method != "GET" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
 	
 	if !app.HistoryTracker.IsEnabled() {
-		http.Error(w, "Historical tracking disabled", http.StatusServiceUnavailable)
+		writeHistoryDisabled(w)
 		return
 	}
 	
 	// Extract baby UID from URL path
 	path := strings.TrimPrefix(r.URL.Path, "/api/history/day-night/")
 	if path == "" {
-		http.Error(w, "baby_uid is required", http.StatusBadRequest)
+		writeBabyUIDRequired(w)
 		return
 	}
 	
@@ -1103,7 +1872,7 @@ func handleHistoryDayNightAPI(w http.ResponseWriter, r *http.Request, app *App)
 	dayNightData, err := app.HistoryTracker.GetDayNightAnalytics(babyUID, startTime, endTime)
 	if err != nil {
 		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to get day/night data")
-		http.Error(w, "Failed to retrieve day/night data", http.StatusInternalServerError)
+		writeError(w, apperrors.NewStorageError("history_query_failed", "Failed to retrieve day/night data", err), http.StatusInternalServerError)
 		return
 	}
 	
@@ -1118,21 +1887,70 @@ func handleHistoryDayNightAPI(w http.ResponseWriter, r *http.Request, app *App)
 	json.NewEncoder(w).Encode(response)
 }
 
+// API handler for the crying episodes of a baby: /api/history/cry-summary/{baby_uid}?start=&end=
+func handleHistoryCrySummaryAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "GET" {
+		writeMethodNotAllowed(w)
+		return
+	}
+	
+	if !app.HistoryTracker.IsEnabled() {
+		writeHistoryDisabled(w)
+		return
+	}
+	
+	// Extract baby UID from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/api/history/cry-summary/")
+	if path == "" {
+		writeBabyUIDRequired(w)
+		return
+	}
+	
+	babyUID := path
+	
+	// Parse query parameters with defaults
+	query := r.URL.Query()
+	endTime := time.Now().Unix()
+	startTime := endTime - (24 * 60 * 60)
+	
+	if startStr := query.Get("start"); startStr != "" {
+		if parsedStart, err := parseTimeParam(startStr); err == nil {
+			startTime = parsedStart
+		}
+	}
+	
+	if endStr := query.Get("end"); endStr != "" {
+		if parsedEnd, err := parseTimeParam(endStr); err == nil {
+			endTime = parsedEnd
+		}
+	}
+	
+	summary, err := app.HistoryTracker.GetCrySummary(babyUID, startTime, endTime)
+	if err != nil {
+		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to get cry summary")
+		writeError(w, apperrors.NewStorageError("history_query_failed", "Failed to retrieve cry summary", err), http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
 func handleHistoryResetAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
 	
 	if !app.HistoryTracker.IsEnabled() {
-		http.Error(w, "Historical tracking disabled", http.StatusServiceUnavailable)
+		writeHistoryDisabled(w)
 		return
 	}
 	
 	// Extract baby UID from URL path
 	path := strings.TrimPrefix(r.URL.Path, "/api/history/reset/")
 	if path == "" {
-		http.Error(w, "baby_uid is required", http.StatusBadRequest)
+		writeBabyUIDRequired(w)
 		return
 	}
 	
@@ -1141,7 +1959,7 @@ func handleHistoryResetAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	_, err := app.HistoryTracker.ResetData(babyUID)
 	if err != nil {
 		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to reset history data")
-		http.Error(w, "Failed to reset history data", http.StatusInternalServerError)
+		writeError(w, apperrors.NewStorageError("history_reset_failed", "Failed to reset history data", err), http.StatusInternalServerError)
 		return
 	}
 	
@@ -1157,6 +1975,212 @@ func handleHistoryResetAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// historyDeleteTypes - tables deleted by DELETE /api/history/{baby_uid} by the type query parameter
+var historyDeleteTypes = map[string]string{
+	"sensor":        history.TableSensorReadings,
+	"events":        history.TableEvents,
+	"state_changes": history.TableStateChanges,
+}
+
+// API handler deleting part of the history of a baby: DELETE /api/history/{baby_uid}?type=events&start=...&end=...
+// type is sensor, events or state_changes (all of them if left out), start and end are unix timestamps or RFC3339
+// and default to the beginning of the history and now. Returns the number of deleted rows by type.
+func handleHistoryDeleteAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "DELETE" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	if !app.HistoryTracker.IsEnabled() {
+		writeHistoryDisabled(w)
+		return
+	}
+
+	babyUID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/history/"), "/")
+	if babyUID == "" || strings.Contains(babyUID, "/") {
+		writeBabyUIDRequired(w)
+		return
+	}
+
+	query := r.URL.Query()
+	types := []string{"sensor", "events", "state_changes"}
+	if deleteType := query.Get("type"); deleteType != "" {
+		if _, ok := historyDeleteTypes[deleteType]; !ok {
+			writeError(w, apperrors.NewValidationError("invalid_type", "type must be one of sensor, events or state_changes", nil).WithContext("type", deleteType), http.StatusBadRequest)
+			return
+		}
+		types = []string{deleteType}
+	}
+
+	startTime, endTime := int64(0), time.Now().Unix()
+	for name, target := range map[string]*int64{"start": &startTime, "end": &endTime} {
+		if value := query.Get(name); value != "" {
+			parsed, err := parseTimeParam(value)
+			if err != nil {
+				writeError(w, apperrors.NewValidationError("invalid_time", name+" must be a unix timestamp or RFC3339 time", err).WithContext(name, value), http.StatusBadRequest)
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	if startTime > endTime {
+		writeError(w, apperrors.NewValidationError("invalid_time_range", "start must not be after end", nil), http.StatusBadRequest)
+		return
+	}
+
+	deleted := make(map[string]int)
+	total := 0
+	for _, deleteType := range types {
+		count, err := app.HistoryTracker.DeleteRange(babyUID, historyDeleteTypes[deleteType], startTime, endTime)
+		if err != nil {
+			log.Error().Err(err).Str("baby_uid", babyUID).Str("type", deleteType).Msg("Failed to delete history data")
+			writeError(w, apperrors.NewStorageError("history_delete_failed", "Failed to delete history data", err).WithContext("baby_uid", babyUID), http.StatusInternalServerError)
+			return
+		}
+		deleted[deleteType] = count
+		total += count
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":       true,
+		"baby_uid":      babyUID,
+		"start_time":    startTime,
+		"end_time":      endTime,
+		"deleted":       deleted,
+		"total_deleted": total,
+	})
+}
+
+// API handler downloading a consistent copy of the SQLite history database: /api/history/db
+func handleHistoryDBAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "GET" {
+		writeMethodNotAllowed(w)
+		return
+	}
+	
+	if !app.HistoryTracker.IsEnabled() {
+		writeHistoryDisabled(w)
+		return
+	}
+	
+	filename, err := app.HistoryTracker.CreateBackup()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create history database backup")
+		writeError(w, apperrors.NewStorageError("history_backup_failed", "Failed to create history database backup", err), http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(filename)
+	
+	f, err := os.Open(filename)
+	if err != nil {
+		writeError(w, apperrors.NewStorageError("history_backup_failed", "Failed to read history database backup", err), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	
+	info, err := f.Stat()
+	if err != nil {
+		writeError(w, apperrors.NewStorageError("history_backup_failed", "Failed to read history database backup", err), http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="history-%s.db"`, time.Now().Format("20060102-150405")))
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	w.Header().Set("Cache-Control", "no-store")
+	
+	if _, err := io.Copy(w, f); err != nil {
+		log.Warn().Err(err).Msg("Failed to send history database backup")
+		return
+	}
+	
+	log.Info().Int64("size", info.Size()).Msg("History database backup downloaded")
+}
+
+// API handler for the state of circuit breakers guarding external services
+func handleCircuitBreakersAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "GET" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	breakers := []map[string]interface{}{}
+	if app.CircuitBreakers != nil {
+		breakers = app.CircuitBreakers.GetAllStats()
+	}
+
+	open := 0
+	for _, stats := range breakers {
+		if stats["state"] != resilience.StateClosed.String() {
+			open++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"circuit_breakers": breakers,
+		"count":            len(breakers),
+		"not_closed_count": open,
+		"timestamp":        time.Now().Unix(),
+	})
+}
+
+func handleStorageAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "GET" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	directories := app.getStorageUsage()
+
+	var totalBytes int64
+	for _, usage := range directories {
+		totalBytes += usage.SizeBytes
+	}
+
+	response := map[string]interface{}{
+		"directories": directories,
+		"total_bytes": totalBytes,
+	}
+
+	if app.HistoryTracker != nil && app.HistoryTracker.IsEnabled() {
+		if dbSize, err := app.HistoryTracker.GetDBSize(); err != nil {
+			log.Warn().Err(err).Msg("Failed to get history database size")
+		} else {
+			response["history_db_bytes"] = dbSize
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func handleStorageCleanupAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "POST" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	result := app.cleanupStorage()
+
+	log.Info().
+		Int("hls_dirs_removed", result.HLSDirsRemoved).
+		Int("camlogs_removed", result.CamLogsRemoved).
+		Bool("history_cleaned", result.HistoryCleaned).
+		Msg("Storage cleanup completed")
+
+	response := map[string]interface{}{
+		"success": true,
+		"result":  result,
+		"directories": app.getStorageUsage(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 // Helper function to parse time parameters
 func parseTimeParam(timeStr string) (int64, error) {
 	// Try parsing as Unix timestamp first
@@ -1203,21 +2227,43 @@ func handleHealthAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	
 	// Get baby state for WebSocket and RTMP status
 	babyState := app.BabyStateManager.GetBabyState(babyUID)
+	response := buildHealthResponse(babyUID, babyState, app)
+
+	// Directly usable stream link for integrations outside the dashboard
+	hlsDetails := response["details"].(map[string]interface{})["hls"].(map[string]interface{})
+	hlsDetails["url_absolute"] = absoluteURL(r, app, hlsDetails["url"].(string))
 	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// buildHealthResponse builds the WebSocket/RTMP/HLS health payload of a single baby
+func buildHealthResponse(babyUID string, babyState *baby.State, app *App) map[string]interface{} {
 	// Get HLS transcoding status
 	var hlsStatus streaming.StreamStatus
 	var hlsError *streaming.StreamError
 	var hlsRunning bool
+	var hlsStartTime, hlsUptime int64
+	hlsMode := streaming.StreamModeVideo
 	
-	if transcoder, exists := app.HLSManager.GetTranscoder(babyUID); exists {
+	// Listen-only babies report their audio transcoder
+	transcoder, exists := app.HLSManager.GetTranscoder(babyUID)
+	if !exists {
+		transcoder, exists = app.HLSManager.GetTranscoderMode(babyUID, streaming.StreamModeAudio)
+	}
+
+	if exists {
 		hlsRunning = transcoder.IsRunning()
 		hlsStatus, hlsError = transcoder.GetStatus()
+		hlsMode = transcoder.GetMode()
+		hlsStartTime, hlsUptime = transcoder.GetStartTime()
 	} else {
 		hlsStatus = streaming.StatusStopped
 		hlsRunning = false
 	}
 	
 	// Determine WebSocket status
+	connectionStats := app.BabyStateManager.GetConnectionStats(babyUID)
 	websocketStatus := "disconnected"
 	if babyState.GetIsWebsocketAlive() {
 		websocketStatus = "connected"
@@ -1233,6 +2279,17 @@ func handleHealthAPI(w http.ResponseWriter, r *http.Request, app *App) {
 		rtmpStatus = "unhealthy"
 	}
 	
+	// Packets flowing doesn't guarantee decodable video (audio only / corrupt), so confirm with ffprobe
+	// Health is polled, the last probe is reported and ffprobe is run in the background once it expires
+	var probe *streaming.ProbeResult
+	if rtmpStatus == "active" {
+		if rtmpURL := app.getLocalStreamURL(babyUID); rtmpURL != "" {
+			if probe = streaming.CachedProbe(rtmpURL); probe != nil && !probe.IsHealthy() {
+				rtmpStatus = "connected_no_video"
+			}
+		}
+	}
+	
 	// Determine HLS status string
 	hlsStatusStr := "stopped"
 	if hlsRunning {
@@ -1248,6 +2305,9 @@ func handleHealthAPI(w http.ResponseWriter, r *http.Request, app *App) {
 		default:
 			hlsStatusStr = "unknown"
 		}
+	} else if hlsError != nil && hlsError.Type == streaming.ErrorTypeStorage {
+		// Storage errors aren't retried, the transcoder stays stopped until the disk is fixed
+		hlsStatusStr = "storage_error"
 	}
 	
 	// Calculate overall health
@@ -1265,8 +2325,11 @@ func handleHealthAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	// Build detailed status
 	details := map[string]interface{}{
 		"websocket": map[string]interface{}{
-			"status": websocketStatus,
-			"alive":  babyState.GetIsWebsocketAlive(),
+			"status":          websocketStatus,
+			"alive":           babyState.GetIsWebsocketAlive(),
+			"connected_since": connectionStats.ConnectedSince,
+			"last_disconnect": connectionStats.LastDisconnect,
+			"uptime_24h":      connectionStats.Uptime24h,
 		},
 		"rtmp": map[string]interface{}{
 			"status":                 rtmpStatus,
@@ -1275,11 +2338,19 @@ func handleHealthAPI(w http.ResponseWriter, r *http.Request, app *App) {
 			"last_video_packet_time": babyState.GetLastVideoPacketTime(),
 		},
 		"hls": map[string]interface{}{
-			"status":     hlsStatusStr,
-			"is_running": hlsRunning,
+			"status":         hlsStatusStr,
+			"is_running":     hlsRunning,
+			"mode":           string(hlsMode),
+			"url":            hlsPlaylistPath(babyUID, hlsMode),
+			"start_time":     hlsStartTime,
+			"uptime_seconds": hlsUptime,
 		},
 	}
 	
+	if probe != nil {
+		details["rtmp"].(map[string]interface{})["probe"] = probe
+	}
+	
 	// Add HLS error if present
 	if hlsError != nil {
 		details["hls"].(map[string]interface{})["error"] = map[string]interface{}{
@@ -1289,45 +2360,135 @@ func handleHealthAPI(w http.ResponseWriter, r *http.Request, app *App) {
 		}
 	}
 	
-	response := map[string]interface{}{
+	return map[string]interface{}{
 		"baby_uid":       babyUID,
 		"overall_health": overallHealth,
 		"details":        details,
 		"timestamp":      time.Now().Unix(),
 	}
-	
+}
+
+// API handler for the application mode (web-only setup vs. camera monitoring)
+func handleModeAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	mode := app.getMode()
+	message := "Monitoring cameras"
+	if mode == Mode_WebOnly {
+		message = "Running in web-only mode, sign in to Nanit to start monitoring"
+	} else if mode == Mode_Paused {
+		message = "Camera monitoring paused, resume it to reconnect to the cameras"
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"mode":       mode,
+		"monitoring": mode == Mode_Monitoring,
+		"paused":     mode == Mode_Paused,
+		"message":    message,
+	})
+}
+
+// writeMonitoringNotStarted writes the error returned by camera endpoints while running in web-only mode
+// Returns true if the error was written
+func writeMonitoringNotStarted(w http.ResponseWriter, app *App) bool {
+	switch app.getMode() {
+	case Mode_Monitoring:
+		return false
+	case Mode_Paused:
+		writeError(w, apperrors.NewConfigError("monitoring_paused", "Camera monitoring is paused, resume it to reach the cameras", nil), http.StatusServiceUnavailable)
+		return true
+	}
+
+	writeError(w, apperrors.NewConfigError("monitoring_not_started", "Camera monitoring is not running (web-only mode), sign in to Nanit first", nil), http.StatusServiceUnavailable)
+	return true
 }
 
 // Basic liveness check endpoint 
-func handleLivenessAPI(w http.ResponseWriter, r *http.Request) {
+func handleLivenessAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	health := map[string]interface{}{
+	liveness := map[string]interface{}{
 		"status":    "healthy",
 		"timestamp": time.Now().Unix(),
 		"uptime":    time.Since(startTime).Seconds(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(health)
+
+	// A required service which stays unhealthy past the threshold means the instance is wedged, let the orchestrator restart it
+	if service, unhealthyFor := app.getStaleRequiredService(); service != "" {
+		liveness["status"] = "unhealthy"
+		liveness["service"] = service
+		liveness["unhealthy_seconds"] = int64(unhealthyFor.Seconds())
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	json.NewEncoder(w).Encode(liveness)
+}
+
+// requiredServices - health services the readiness depends on, according to the configured criteria
+func (app *App) requiredServices() []string {
+	services := []string{}
+	if app.Opts.Health.RequireMQTT && app.MQTTConnection != nil {
+		services = append(services, mqtt.HealthService)
+	}
+	if app.Opts.Health.RequireNanitAPI {
+		services = append(services, client.NanitAPIService)
+	}
+
+	return services
+}
+
+// getStaleRequiredService - returns the first required service unhealthy for longer than the liveness threshold
+func (app *App) getStaleRequiredService() (string, time.Duration) {
+	threshold := app.Opts.Health.LivenessUnhealthyThreshold
+	if threshold <= 0 || app.HealthManager == nil {
+		return "", 0
+	}
+
+	for _, service := range app.requiredServices() {
+		serviceHealth, exists := app.HealthManager.GetServiceHealth(service)
+		if !exists || serviceHealth.Status == health.StatusHealthy {
+			continue
+		}
+
+		// Never healthy services count from startup
+		healthySince := serviceHealth.LastHealthy
+		if healthySince.Before(startTime) {
+			healthySince = startTime
+		}
+
+		if unhealthyFor := time.Since(healthySince); unhealthyFor > threshold {
+			return service, unhealthyFor
+		}
+	}
+
+	return "", 0
 }
 
 // Readiness check endpoint for detailed service health
+// Status is "ready", "setup" (web-only first run waiting for login, still 200 so that orchestrators leave it running)
+// or "not_ready" (503).
 func handleReadinessAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	services := make(map[string]interface{})
 	readiness := map[string]interface{}{
 		"status":    "ready",
+		"ready":     true,
 		"timestamp": time.Now().Unix(),
-		"services":  make(map[string]interface{}),
+		"mode":      app.getMode(),
+		"services":  services,
 	}
 
 	// Check authentication status
@@ -1335,7 +2496,7 @@ func handleReadinessAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	if app.SessionStore != nil && app.SessionStore.Session != nil && app.SessionStore.Session.RefreshToken != "" {
 		authReady = true
 	}
-	readiness["services"].(map[string]interface{})["authentication"] = map[string]interface{}{
+	services["authentication"] = map[string]interface{}{
 		"ready":   authReady,
 		"message": func() string {
 			if authReady {
@@ -1349,23 +2510,27 @@ func handleReadinessAPI(w http.ResponseWriter, r *http.Request, app *App) {
 	babiesReady := false
 	babyCount := 0
 	if app.SessionStore != nil && app.SessionStore.Session != nil {
-		babyCount = len(app.SessionStore.Session.Babies)
+		babyCount = len(app.getBabies())
 		babiesReady = babyCount > 0
 	}
-	readiness["services"].(map[string]interface{})["babies"] = map[string]interface{}{
+	services["babies"] = map[string]interface{}{
 		"ready":      babiesReady,
 		"baby_count": babyCount,
 		"message": func() string {
 			if babiesReady {
 				return fmt.Sprintf("%d babies configured", babyCount)
+			} else if authReady {
+				return noBabiesMessage
 			}
 			return "No babies configured"
 		}(),
 	}
+	readiness["authenticated"] = authReady
+	readiness["babies_count"] = babyCount
 
 	// Check RTMP server status (assume healthy if configured)
 	rtmpReady := app.Opts.RTMP != nil
-	readiness["services"].(map[string]interface{})["rtmp"] = map[string]interface{}{
+	services["rtmp"] = map[string]interface{}{
 		"ready": rtmpReady,
 		"message": func() string {
 			if rtmpReady {
@@ -1375,26 +2540,88 @@ func handleReadinessAPI(w http.ResponseWriter, r *http.Request, app *App) {
 		}(),
 	}
 
-	// Check MQTT status
-	mqttReady := app.MQTTConnection != nil
-	readiness["services"].(map[string]interface{})["mqtt"] = map[string]interface{}{
-		"ready": mqttReady,
+	// Check MQTT status (only affects readiness if required)
+	mqttReady := true
+	if app.MQTTConnection != nil {
+		mqttState := app.MQTTConnection.GetState()
+		mqttReady = mqttState == mqtt.ConnectionState_Connected
+		readiness["mqtt_state"] = mqttState
+		services["mqtt"] = map[string]interface{}{
+			"ready":    mqttReady,
+			"required": app.Opts.Health.RequireMQTT,
+			"state":    mqttState,
+			"message":  fmt.Sprintf("MQTT broker %v", mqttState),
+		}
+	} else {
+		services["mqtt"] = map[string]interface{}{
+			"ready":   false,
+			"message": "MQTT not configured",
+		}
+	}
+
+	// Check Nanit API reachability (local streaming keeps working while Nanit is down, so it only affects readiness if required)
+	nanitAPIReachable, nanitAPIMessage := true, "Health tracking not available"
+	if app.HealthManager != nil {
+		nanitAPIReachable, nanitAPIMessage = app.getNanitAPIStatus()
+	}
+	readiness["nanit_api_reachable"] = nanitAPIReachable
+	services["nanit_api"] = map[string]interface{}{
+		"ready":    nanitAPIReachable,
+		"required": app.Opts.Health.RequireNanitAPI,
+		"message":  nanitAPIMessage,
+	}
+
+	// History is optional, the app keeps running without it
+	historyEnabled := app.HistoryTracker != nil && app.HistoryTracker.IsEnabled()
+	historyService := map[string]interface{}{
+		"ready": historyEnabled,
 		"message": func() string {
-			if mqttReady {
-				return "MQTT configured"
+			if historyEnabled {
+				return "Historical data tracking enabled"
+			} else if app.Opts.History.Enabled {
+				return "Historical data tracking failed to initialize"
 			}
-			return "MQTT not configured"
+			return "Historical data tracking disabled"
 		}(),
 	}
+	if historyEnabled {
+		historyService["db_path"] = app.HistoryTracker.DBPath()
+	}
+	services["history"] = historyService
 
 	// Determine overall readiness
-	overallReady := authReady && babiesReady
-	if !overallReady {
+	failed := []string{}
+	if authReady && !babiesReady {
+		failed = append(failed, "babies")
+	}
+	if app.Opts.Health.RequireMQTT && app.MQTTConnection != nil && !mqttReady {
+		failed = append(failed, "mqtt")
+	}
+	if app.Opts.Health.RequireNanitAPI && !nanitAPIReachable {
+		failed = append(failed, "nanit_api")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if len(failed) > 0 {
 		readiness["status"] = "not_ready"
+		readiness["ready"] = false
+		readiness["failed_services"] = failed
+		if authReady && !babiesReady {
+			readiness["message"] = noBabiesMessage
+			readiness["suggestion"] = noBabiesSuggestion
+		}
 		w.WriteHeader(http.StatusServiceUnavailable)
+	} else if !authReady {
+		// Intentional web-only setup, the instance is up and waiting for the user to log in
+		readiness["status"] = "setup"
+		readiness["ready"] = false
+	} else if app.getMode() == Mode_Paused {
+		// Deliberately disconnected from the cameras, not a failure
+		readiness["status"] = "paused"
+		readiness["ready"] = false
 	}
 
-	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(readiness)
 }
 
@@ -0,0 +1,1061 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/indiefan/home_assistant_nanit/pkg/health"
+	"github.com/indiefan/home_assistant_nanit/pkg/history"
+	"github.com/indiefan/home_assistant_nanit/pkg/resilience"
+	"github.com/indiefan/home_assistant_nanit/pkg/session"
+	"github.com/indiefan/home_assistant_nanit/pkg/streaming"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var testBabies = []baby.Baby{
+	{UID: "baby1", Name: "First", CameraUID: "cam1"},
+	{UID: "baby2", Name: "Second", CameraUID: "cam2"},
+	{UID: "baby3", Name: "Third", CameraUID: "cam3"},
+}
+
+func TestFindBaby(t *testing.T) {
+	found := findBaby(testBabies, "baby2")
+	if assert.NotNil(t, found) {
+		assert.Equal(t, "Second", found.Name)
+		assert.Equal(t, "cam2", found.CameraUID)
+	}
+
+	// Result must not alias the slice element
+	found.Name = "Changed"
+	assert.Equal(t, "Second", testBabies[1].Name)
+
+	assert.Nil(t, findBaby(testBabies, "unknown"))
+	assert.Nil(t, findBaby(nil, "baby1"))
+}
+
+func TestDeviceInfoAPIMatchesBabyNotFirstInList(t *testing.T) {
+	app := &App{BabyStateManager: baby.NewStateManager(), HistoryTracker: &history.Tracker{}}
+
+	for _, babyUID := range []string{"baby2", "baby3"} {
+		req := httptest.NewRequest("GET", "/api/device-info/"+babyUID, nil)
+		w := httptest.NewRecorder()
+
+		handleDeviceInfoAPI(w, req, testBabies, app)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response DeviceInfoResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		assert.Equal(t, babyUID, response.BabyUID)
+		assert.Equal(t, findBaby(testBabies, babyUID).Name, response.BabyName)
+	}
+
+	req := httptest.NewRequest("GET", "/api/device-info/unknown", nil)
+	w := httptest.NewRecorder()
+	handleDeviceInfoAPI(w, req, testBabies, app)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDeviceInfoAndBabiesAPIConditionalGet(t *testing.T) {
+	stateManager := baby.NewStateManager()
+	app := &App{BabyStateManager: stateManager, HistoryTracker: &history.Tracker{}}
+
+	w := httptest.NewRecorder()
+	handleDeviceInfoAPI(w, httptest.NewRequest("GET", "/api/device-info/baby1", nil), testBabies, app)
+	etag := w.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `W/"`))
+
+	// Unchanged device info, the generation timestamp doesn't count
+	req := httptest.NewRequest("GET", "/api/device-info/baby1", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handleDeviceInfoAPI(w, req, testBabies, app)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// Changed device info
+	stateManager.Update("baby1", *baby.NewState().SetWebsocketAlive(true))
+	w = httptest.NewRecorder()
+	handleDeviceInfoAPI(w, req, testBabies, app)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+	w = httptest.NewRecorder()
+	handleBabiesAPI(w, httptest.NewRequest("GET", "/api/babies", nil), testBabies, nil)
+	etag = w.Header().Get("ETag")
+
+	req = httptest.NewRequest("GET", "/api/babies", nil)
+	req.Header.Set("If-None-Match", `"other", `+strings.TrimPrefix(etag, "W/"))
+	w = httptest.NewRecorder()
+	handleBabiesAPI(w, req, testBabies, nil)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
+
+func TestControlAPIMatchesBabyNotFirstInList(t *testing.T) {
+	app := &App{connections: make(map[string]*client.WebsocketConnection)}
+	stateManager := baby.NewStateManager()
+
+	// Baby is found, but not connected
+	req := httptest.NewRequest("POST", "/api/control/night-light", strings.NewReader(`{"baby_uid":"baby3","action":"toggle"}`))
+	w := httptest.NewRecorder()
+	handleControlAPI(w, req, "night-light", testBabies, stateManager, app)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	req = httptest.NewRequest("POST", "/api/control/night-light", strings.NewReader(`{"baby_uid":"unknown","action":"toggle"}`))
+	w = httptest.NewRecorder()
+	handleControlAPI(w, req, "night-light", testBabies, stateManager, app)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestStreamSlotReleaseAndClaim(t *testing.T) {
+	app := &App{
+		Opts:             Opts{RTMP: &RTMPOpts{PublicAddr: "localhost:1935", AutoStart: true}},
+		SessionStore:     &session.Store{Session: &session.Session{Babies: testBabies}},
+		BabyStateManager: baby.NewStateManager(),
+		connections:      make(map[string]*client.WebsocketConnection),
+		releasedStreams:  make(map[string]bool),
+	}
+	app.BabyStateManager.Update("baby2", *baby.NewState().
+		SetStreamRequestState(baby.StreamRequestState_RequestFailed).
+		SetWebsocketAlive(true))
+	assert.True(t, app.shouldRetryStreaming("baby2"))
+
+	req := httptest.NewRequest("POST", "/api/stream/release/baby2", nil)
+	w := httptest.NewRecorder()
+	handleStreamReleaseAPI(w, req, app)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Released stream is not retried in the background
+	assert.True(t, app.isStreamReleased("baby2"))
+	assert.False(t, app.shouldRetryStreaming("baby2"))
+	assert.Equal(t, baby.StreamRequestState_NotRequested, app.BabyStateManager.GetBabyState("baby2").GetStreamRequestState())
+
+	// Claim requires running monitoring
+	req = httptest.NewRequest("POST", "/api/stream/claim/baby2", nil)
+	w = httptest.NewRecorder()
+	handleStreamClaimAPI(w, req, app)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "monitoring_not_started")
+
+	// and connected camera
+	app.setMode(Mode_Monitoring)
+	req = httptest.NewRequest("POST", "/api/stream/claim/baby2", nil)
+	w = httptest.NewRecorder()
+	handleStreamClaimAPI(w, req, app)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "stream_slot_unavailable")
+
+	req = httptest.NewRequest("POST", "/api/stream/claim/unknown", nil)
+	w = httptest.NewRecorder()
+	handleStreamClaimAPI(w, req, app)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req = httptest.NewRequest("GET", "/api/stream/claim/baby2", nil)
+	w = httptest.NewRecorder()
+	handleStreamClaimAPI(w, req, app)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestStreamStatusAPIBlocked(t *testing.T) {
+	app := &App{
+		Opts:             Opts{RTMP: &RTMPOpts{PublicAddr: "localhost:1935", StreamIdentifier: client.StreamIdentifier_MOBILE}},
+		BabyStateManager: baby.NewStateManager(),
+	}
+	app.BabyStateManager.Update("baby1", *baby.NewState().SetStreamRequestState(baby.StreamRequestState_RequestFailed))
+
+	status := func() string {
+		w := httptest.NewRecorder()
+		handleStreamStatusAPI(w, httptest.NewRequest("GET", "/api/stream/status/baby1", nil), app)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	body := status()
+	assert.Contains(t, body, `"status":"blocked"`)
+	assert.Contains(t, body, `"type":"connection_limit"`)
+	assert.Contains(t, body, "Close the official Nanit app")
+
+	// Other streams don't count against the Nanit mobile app connection limit
+	app.Opts.RTMP.StreamIdentifier = client.StreamIdentifier_DVR
+	body = status()
+	assert.Contains(t, body, `"status":"blocked"`)
+	assert.Contains(t, body, `"type":"stream_request_failed"`)
+	assert.Contains(t, body, `"stream_identifier":"DVR"`)
+	assert.NotContains(t, body, "Nanit app")
+}
+
+func TestStreamStartStopAcceptPathOrBody(t *testing.T) {
+	app := &App{HLSManager: streaming.NewHLSManager(t.TempDir())}
+
+	requests := map[string]*http.Request{
+		"path": httptest.NewRequest("POST", "/api/stream/stop/baby2", nil),
+		"body": httptest.NewRequest("POST", "/api/stream/stop/", strings.NewReader(`{"baby_uid":"baby2"}`)),
+		// Path is preferred over the body
+		"both": httptest.NewRequest("POST", "/api/stream/stop/baby2", strings.NewReader(`{"baby_uid":"baby3"}`)),
+	}
+
+	for style, req := range requests {
+		w := httptest.NewRecorder()
+		handleStreamStopAPI(w, req, app)
+		assert.Equal(t, http.StatusOK, w.Code, style)
+
+		var response map[string]interface{}
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&response), style)
+		assert.Equal(t, "baby2", response["baby_uid"], style)
+	}
+
+	// Start resolves the baby UID in both styles before checking RTMP configuration
+	for _, req := range []*http.Request{
+		httptest.NewRequest("POST", "/api/stream/start/baby2", nil),
+		httptest.NewRequest("POST", "/api/stream/start/", strings.NewReader(`{"baby_uid":"baby2"}`)),
+	} {
+		w := httptest.NewRecorder()
+		handleStreamStartAPI(w, req, app)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	}
+
+	// Mode is read from the body next to the baby UID in the path
+	w := httptest.NewRecorder()
+	handleStreamStartAPI(w, httptest.NewRequest("POST", "/api/stream/start/baby2", strings.NewReader(`{"mode":"silent"}`)), app)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_mode")
+
+	// Missing baby UID lists both accepted forms
+	w = httptest.NewRecorder()
+	handleStreamStartAPI(w, httptest.NewRequest("POST", "/api/stream/start/", nil), app)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "/api/stream/start/{baby_uid}")
+	assert.Contains(t, w.Body.String(), "JSON body")
+}
+
+func TestJSONRequestBodyHardening(t *testing.T) {
+	app := &App{
+		Opts:        Opts{MaxRequestBodyBytes: 64},
+		connections: make(map[string]*client.WebsocketConnection),
+		HLSManager:  streaming.NewHLSManager(t.TempDir()),
+	}
+
+	control := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handleControlAPI(w, httptest.NewRequest("POST", "/api/control/night-light", strings.NewReader(body)), "night-light", testBabies, baby.NewStateManager(), app)
+		return w
+	}
+
+	// Unknown fields, malformed JSON and trailing data are rejected with a JSON error
+	for _, body := range []string{
+		`{"baby_uid":"baby1","actoin":"toggle"}`,
+		`{"baby_uid":`,
+		`{"baby_uid":"baby1"}{"action":"toggle"}`,
+		``,
+	} {
+		w := control(body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"), body)
+		assert.Contains(t, w.Body.String(), "invalid_request", body)
+	}
+
+	// Bodies over the limit
+	w := control(`{"baby_uid":"` + strings.Repeat("x", 100) + `"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "request_too_large")
+
+	// Valid body passes on to the handler
+	w = control(`{"baby_uid":"unknown","action":"toggle"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Stream requests keep the body optional, but not invalid
+	w = httptest.NewRecorder()
+	handleStreamStopAPI(w, httptest.NewRequest("POST", "/api/stream/stop/baby2", strings.NewReader(`{"baby":"baby2"}`)), app)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_request")
+}
+
+func TestStreamMosaicAPI(t *testing.T) {
+	app := &App{HLSManager: streaming.NewHLSManager(t.TempDir())}
+
+	start := httptest.NewRecorder()
+	handleStreamMosaicAPI(start, httptest.NewRequest("POST", "/api/stream/mosaic/start", nil), app)
+	assert.Equal(t, http.StatusServiceUnavailable, start.Code)
+	assert.Contains(t, start.Body.String(), "rtmp_not_configured")
+
+	playlist := httptest.NewRecorder()
+	handleStreamMosaicAPI(playlist, httptest.NewRequest("GET", "/api/stream/mosaic/playlist.m3u8", nil), app)
+	assert.Equal(t, http.StatusNotFound, playlist.Code)
+	assert.Contains(t, playlist.Body.String(), "no_transcoder")
+
+	post := httptest.NewRecorder()
+	handleStreamMosaicAPI(post, httptest.NewRequest("POST", "/api/stream/mosaic/playlist.m3u8", nil), app)
+	assert.Equal(t, http.StatusMethodNotAllowed, post.Code)
+
+	// Stopping a mosaic that isn't running succeeds
+	stop := httptest.NewRecorder()
+	handleStreamMosaicAPI(stop, httptest.NewRequest("POST", "/api/stream/mosaic/stop", nil), app)
+	assert.Equal(t, http.StatusOK, stop.Code)
+}
+
+func TestAbsoluteURL(t *testing.T) {
+	app := &App{}
+	path := hlsPlaylistPath("baby1", streaming.StreamModeVideo)
+
+	req := httptest.NewRequest("GET", "/api/health/baby1", nil)
+	req.Host = "192.168.1.10:8080"
+	assert.Equal(t, "http://192.168.1.10:8080/api/stream/hls/baby1/playlist.m3u8", absoluteURL(req, app, path))
+
+	// Reverse proxy headers, first value wins
+	req.Header.Set("X-Forwarded-Proto", "https, http")
+	req.Header.Set("X-Forwarded-Host", "nanit.example.com")
+	assert.Equal(t, "https://nanit.example.com/api/stream/hls/baby1/playlist.m3u8", absoluteURL(req, app, path))
+
+	// Configured base URL takes precedence
+	app.Opts.PublicBaseURL = "https://tv.example.com:8443/"
+	assert.Equal(t, "https://tv.example.com:8443/api/stream/hls/baby1/playlist.m3u8", absoluteURL(req, app, path))
+}
+
+func TestHLSStreamAPIHead(t *testing.T) {
+	app := &App{HLSManager: streaming.NewHLSManager(t.TempDir())}
+
+	get := httptest.NewRecorder()
+	handleHLSStreamAPI(get, httptest.NewRequest("GET", "/api/stream/hls/baby1/playlist.m3u8", nil), app)
+	assert.Equal(t, http.StatusNotFound, get.Code)
+	assert.NotEmpty(t, get.Body.String())
+
+	// Same status and headers, no body
+	head := httptest.NewRecorder()
+	handleHLSStreamAPI(head, httptest.NewRequest("HEAD", "/api/stream/hls/baby1/playlist.m3u8", nil), app)
+	assert.Equal(t, http.StatusNotFound, head.Code)
+	assert.Empty(t, head.Body.String())
+	assert.Equal(t, get.Header().Get("Content-Type"), head.Header().Get("Content-Type"))
+	assert.Equal(t, get.Header().Get("Content-Length"), head.Header().Get("Content-Length"))
+
+	post := httptest.NewRecorder()
+	handleHLSStreamAPI(post, httptest.NewRequest("POST", "/api/stream/hls/baby1/playlist.m3u8", nil), app)
+	assert.Equal(t, http.StatusMethodNotAllowed, post.Code)
+	assert.Equal(t, "GET, HEAD", post.Header().Get("Allow"))
+}
+
+func TestHLSStreamAPIPlaylistNotReady(t *testing.T) {
+	// FFmpeg stand-in that runs without producing any files
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "ffmpeg"), []byte("#!/bin/sh\nexec sleep 30\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	app := &App{HLSManager: streaming.NewHLSManager(t.TempDir())}
+	if err := app.HLSManager.StartTranscoding("baby1", "rtmp://localhost/local/baby1"); err != nil {
+		t.Skipf("fake ffmpeg not runnable: %v", err)
+	}
+	defer app.HLSManager.StopAll()
+
+	playlist := httptest.NewRecorder()
+	handleHLSStreamAPI(playlist, httptest.NewRequest("GET", "/api/stream/hls/baby1/playlist.m3u8", nil), app)
+	assert.Equal(t, http.StatusServiceUnavailable, playlist.Code)
+	assert.Equal(t, "2", playlist.Header().Get("Retry-After"))
+	assert.Contains(t, playlist.Body.String(), "playlist_not_ready")
+
+	// Missing segments are still not found
+	segment := httptest.NewRecorder()
+	handleHLSStreamAPI(segment, httptest.NewRequest("GET", "/api/stream/hls/baby1/segment_0.ts", nil), app)
+	assert.Equal(t, http.StatusNotFound, segment.Code)
+	assert.Empty(t, segment.Header().Get("Retry-After"))
+}
+
+func TestHLSStreamAPIPlaylistTranscoderFailed(t *testing.T) {
+	// FFmpeg stand-in failing right away
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "ffmpeg"), []byte("#!/bin/sh\necho 'Connection refused' >&2\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	app := &App{HLSManager: streaming.NewHLSManager(t.TempDir())}
+	if err := app.HLSManager.StartTranscoding("baby1", "rtmp://localhost/local/baby1"); err != nil {
+		t.Skipf("fake ffmpeg not runnable: %v", err)
+	}
+	defer app.HLSManager.StopAll()
+
+	transcoder, _ := app.HLSManager.GetTranscoder("baby1")
+	assert.Eventually(t, func() bool {
+		status, _ := transcoder.GetStatus()
+		return status == streaming.StatusError
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// Last error is returned instead of asking the player to retry
+	playlist := httptest.NewRecorder()
+	handleHLSStreamAPI(playlist, httptest.NewRequest("GET", "/api/stream/hls/baby1/playlist.m3u8", nil), app)
+	assert.Equal(t, http.StatusBadGateway, playlist.Code)
+	assert.Empty(t, playlist.Header().Get("Retry-After"))
+	assert.Contains(t, playlist.Body.String(), "stream_error")
+}
+
+func TestHLSStreamAPIDVRPlaylist(t *testing.T) {
+	// FFmpeg stand-in writing a playlist of 10 segments to the output (last argument)
+	var playlist strings.Builder
+	playlist.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:2\n#EXT-X-MEDIA-SEQUENCE:0\n")
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&playlist, "#EXTINF:2.000000,\nsegment_%d.ts\n", i)
+	}
+
+	binDir := t.TempDir()
+	script := "#!/bin/sh\nfor arg; do out=\"$arg\"; done\nprintf '" + strings.ReplaceAll(playlist.String(), "\n", "\\n") + "' > \"$out.tmp\"\nmv \"$out.tmp\" \"$out\"\nexec sleep 30\n"
+	if err := os.WriteFile(filepath.Join(binDir, "ffmpeg"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	app := &App{HLSManager: streaming.NewHLSManager(t.TempDir())}
+	app.HLSManager.SetOutputOpts(streaming.OutputOpts{LiveSegments: 3, DVRWindow: time.Minute})
+	if err := app.HLSManager.StartTranscoding("baby1", "rtmp://localhost/local/baby1"); err != nil {
+		t.Skipf("fake ffmpeg not runnable: %v", err)
+	}
+	defer app.HLSManager.StopAll()
+
+	transcoder, _ := app.HLSManager.GetTranscoder("baby1")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(transcoder.GetPlaylistPath()); err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Live playlist is cut to the live edge
+	live := httptest.NewRecorder()
+	handleHLSStreamAPI(live, httptest.NewRequest("GET", "/api/stream/hls/baby1/playlist.m3u8", nil), app)
+	assert.Equal(t, http.StatusOK, live.Code)
+	assert.Equal(t, "application/vnd.apple.mpegurl", live.Header().Get("Content-Type"))
+	assert.Equal(t, 3, strings.Count(live.Body.String(), "#EXTINF:"))
+	assert.Contains(t, live.Body.String(), "#EXT-X-MEDIA-SEQUENCE:7\n")
+
+	// DVR playlist covers the whole window
+	dvr := httptest.NewRecorder()
+	handleHLSStreamAPI(dvr, httptest.NewRequest("GET", "/api/stream/hls/baby1/dvr.m3u8", nil), app)
+	assert.Equal(t, http.StatusOK, dvr.Code)
+	assert.Equal(t, 10, strings.Count(dvr.Body.String(), "#EXTINF:"))
+
+	// Not available unless enabled
+	disabled := &App{HLSManager: streaming.NewHLSManager(t.TempDir())}
+	if err := disabled.HLSManager.StartTranscoding("baby1", "rtmp://localhost/local/baby1"); err == nil {
+		defer disabled.HLSManager.StopAll()
+
+		w := httptest.NewRecorder()
+		handleHLSStreamAPI(w, httptest.NewRequest("GET", "/api/stream/hls/baby1/dvr.m3u8", nil), disabled)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "dvr_disabled")
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := corsMiddleware([]string{"http://homeassistant.local:8123"}, next)
+
+	// Allowed origin is echoed with credentials
+	req := httptest.NewRequest("GET", "/api/status", nil)
+	req.Header.Set("Origin", "http://homeassistant.local:8123")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "http://homeassistant.local:8123", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+
+	// Other origins get no CORS headers
+	req = httptest.NewRequest("GET", "/api/status", nil)
+	req.Header.Set("Origin", "http://evil.example.com")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	// Preflight is answered without reaching the handler
+	req = httptest.NewRequest("OPTIONS", "/api/control/night-light", nil)
+	req.Header.Set("Origin", "http://homeassistant.local:8123")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "POST")
+	assert.Equal(t, "Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+
+	// Wildcard never allows credentials
+	handler = corsMiddleware([]string{"*"}, next)
+	req = httptest.NewRequest("GET", "/api/status", nil)
+	req.Header.Set("Origin", "http://any.example.com")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestModeAPI(t *testing.T) {
+	app := &App{}
+
+	w := httptest.NewRecorder()
+	handleModeAPI(w, httptest.NewRequest("GET", "/api/mode", nil), app)
+	assert.Contains(t, w.Body.String(), `"mode":"web_only"`)
+
+	// Camera endpoints report web-only mode instead of a generic error
+	w = httptest.NewRecorder()
+	assert.True(t, writeMonitoringNotStarted(w, app))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "monitoring_not_started")
+
+	app.setMode(Mode_Monitoring)
+	w = httptest.NewRecorder()
+	handleModeAPI(w, httptest.NewRequest("GET", "/api/mode", nil), app)
+	assert.Contains(t, w.Body.String(), `"monitoring":true`)
+	assert.False(t, writeMonitoringNotStarted(httptest.NewRecorder(), app))
+}
+
+func TestAuthResetAPIStopsMonitoring(t *testing.T) {
+	sessionStore := session.NewSessionStore()
+	sessionStore.Session.Babies = testBabies
+	sessionStore.Session.RefreshToken = "token"
+
+	app := &App{
+		SessionStore:    sessionStore,
+		RestClient:      &client.NanitClient{RefreshToken: "token", SessionStore: sessionStore},
+		monitoredBabies: make(map[string]*monitoredBaby),
+		pendingTasks:    utils.NewPendingTasks(),
+	}
+	app.Opts.SessionFile = filepath.Join(t.TempDir(), "session.json")
+
+	mainContext := make(chan utils.GracefulContext)
+	runner := utils.RunWithGracefulCancel(func(ctx utils.GracefulContext) {
+		mainContext <- ctx
+		<-ctx.Done()
+	})
+	defer runner.Cancel()
+	app.mainContext = <-mainContext
+
+	for _, babyInfo := range testBabies {
+		app.startMonitoringBaby(babyInfo)
+	}
+	app.monitoringStarted = true
+	app.setMode(Mode_Monitoring)
+
+	w := httptest.NewRecorder()
+	handleAuthResetAPI(w, httptest.NewRequest("DELETE", "/api/auth/reset", nil), app)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, Mode_WebOnly, app.getMode())
+	assert.Empty(t, app.getMonitoredBabyUIDs())
+
+	// Session is cleared in place, signing in again persists it to the same file
+	assert.Same(t, sessionStore, app.RestClient.SessionStore)
+	assert.Empty(t, sessionStore.Session.RefreshToken)
+	assert.Empty(t, app.RestClient.RefreshToken)
+	assert.True(t, writeMonitoringNotStarted(httptest.NewRecorder(), app))
+
+	// Signing in again can start the monitoring
+	app.babiesMutex.Lock()
+	assert.False(t, app.monitoringStarted)
+	app.babiesMutex.Unlock()
+}
+
+func TestStorageAPIAndCleanup(t *testing.T) {
+	baseDir := t.TempDir()
+	logDir := filepath.Join(baseDir, "log")
+	assert.NoError(t, os.MkdirAll(logDir, 0755))
+
+	oldLog := filepath.Join(logDir, "camlogs-old.tar.gz")
+	newLog := filepath.Join(logDir, "camlogs-new.tar.gz")
+	assert.NoError(t, os.WriteFile(oldLog, []byte("12345"), 0644))
+	assert.NoError(t, os.WriteFile(newLog, []byte("123"), 0644))
+	old := time.Now().Add(-8 * 24 * time.Hour)
+	assert.NoError(t, os.Chtimes(oldLog, old, old))
+
+	app := &App{
+		Opts: Opts{
+			DataDirectories: DataDirectories{BaseDir: baseDir, LogDir: logDir},
+			CamLog:          CamLogOpts{MaxAge: 7 * 24 * time.Hour},
+		},
+		HLSManager: streaming.NewHLSManager(filepath.Join(baseDir, "hls")),
+	}
+
+	w := httptest.NewRecorder()
+	handleStorageAPI(w, httptest.NewRequest("GET", "/api/storage", nil), app)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Directories map[string]DirUsage `json:"directories"`
+		TotalBytes  int64               `json:"total_bytes"`
+	}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, 2, response.Directories["log"].FileCount)
+	assert.Equal(t, int64(8), response.Directories["log"].SizeBytes)
+	assert.Equal(t, int64(8), response.TotalBytes)
+	// Missing directories report zero usage
+	assert.Equal(t, 0, response.Directories["hls"].FileCount)
+
+	w = httptest.NewRecorder()
+	handleStorageCleanupAPI(w, httptest.NewRequest("GET", "/api/storage/cleanup", nil), app)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"method_not_allowed"`)
+
+	w = httptest.NewRecorder()
+	handleStorageCleanupAPI(w, httptest.NewRequest("POST", "/api/storage/cleanup", nil), app)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"camlogs_removed":1`)
+
+	assert.NoFileExists(t, oldLog)
+	assert.FileExists(t, newLog)
+}
+
+func TestCamLogUploadRetentionAndLimit(t *testing.T) {
+	logDir := t.TempDir()
+	for i, name := range []string{"camlogs-a.tar.gz", "camlogs-b.tar.gz", "camlogs-c.tar.gz"} {
+		path := filepath.Join(logDir, name)
+		assert.NoError(t, os.WriteFile(path, []byte("log"), 0644))
+		modTime := time.Now().Add(-time.Duration(3-i) * time.Hour)
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	app := &App{Opts: Opts{
+		DataDirectories: DataDirectories{LogDir: logDir},
+		CamLog:          CamLogOpts{MaxFiles: 2, MaxUploadBytes: 8},
+	}}
+
+	w := httptest.NewRecorder()
+	handleCamLogUpload(w, httptest.NewRequest("POST", "/log", strings.NewReader("small")), app)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// Only the upload and the newest existing file are kept
+	files, _ := filepath.Glob(filepath.Join(logDir, camLogPattern))
+	assert.Len(t, files, 2)
+	assert.FileExists(t, filepath.Join(logDir, "camlogs-c.tar.gz"))
+
+	w = httptest.NewRecorder()
+	handleCamLogUpload(w, httptest.NewRequest("POST", "/log", strings.NewReader("way too large")), app)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	files, _ = filepath.Glob(filepath.Join(logDir, camLogPattern))
+	assert.Len(t, files, 2)
+}
+
+func TestReadinessDistinguishesSetupFromFailure(t *testing.T) {
+	app := &App{}
+
+	readiness := func() (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		handleReadinessAPI(w, httptest.NewRequest("GET", "/readyz", nil), app)
+
+		var response map[string]interface{}
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		return w.Code, response
+	}
+
+	// First run without login is not a failure
+	code, response := readiness()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "setup", response["status"])
+	assert.Equal(t, false, response["ready"])
+
+	// Logged in, but babies couldn't be loaded
+	app.SessionStore = &session.Store{Session: &session.Session{RefreshToken: "token"}}
+	code, response = readiness()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", response["status"])
+	assert.Contains(t, response["failed_services"], "babies")
+	assert.Equal(t, true, response["authenticated"])
+	assert.Equal(t, float64(0), response["babies_count"])
+	assert.Equal(t, noBabiesMessage, response["message"])
+	assert.Contains(t, response["suggestion"], "/api/babies/refresh")
+
+	app.SessionStore.Session.Babies = testBabies
+	code, response = readiness()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", response["status"])
+	assert.Equal(t, true, response["ready"])
+}
+
+func TestReadinessReportsHistoryDBPath(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "ssd", "nanit", "history.db")
+	tracker, err := history.NewTrackerAt(dbPath, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tracker.Close()
+
+	app := &App{HistoryTracker: tracker}
+
+	w := httptest.NewRecorder()
+	handleReadinessAPI(w, httptest.NewRequest("GET", "/readyz", nil), app)
+
+	var response struct {
+		Services map[string]map[string]interface{} `json:"services"`
+	}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, true, response.Services["history"]["ready"])
+	assert.Equal(t, dbPath, response.Services["history"]["db_path"])
+
+	// Not reported while tracking is disabled
+	app.HistoryTracker = &history.Tracker{}
+	w = httptest.NewRecorder()
+	handleReadinessAPI(w, httptest.NewRequest("GET", "/readyz", nil), app)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, false, response.Services["history"]["ready"])
+	assert.NotContains(t, response.Services["history"], "db_path")
+}
+
+func TestAuthStatusWithoutBabies(t *testing.T) {
+	sessionFile := filepath.Join(t.TempDir(), "session.json")
+	assert.NoError(t, os.WriteFile(sessionFile, []byte("{}"), 0644))
+
+	app := &App{
+		Opts:          Opts{SessionFile: sessionFile},
+		SessionStore:  &session.Store{Session: &session.Session{RefreshToken: "token"}},
+		HealthManager: health.NewHealthManager(),
+	}
+
+	w := httptest.NewRecorder()
+	handleAuthStatusAPI(w, httptest.NewRequest("GET", "/api/auth/status", nil), app)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, true, response["authenticated"])
+	assert.Equal(t, float64(0), response["babies_count"])
+	assert.Equal(t, noBabiesMessage, response["message"])
+	assert.Contains(t, response["suggestion"], "/api/babies/refresh")
+}
+
+func TestLivenessFailsOnStaleRequiredService(t *testing.T) {
+	app := &App{
+		HealthManager: health.NewHealthManager(),
+		Opts:          Opts{Health: HealthOpts{RequireNanitAPI: true, LivenessUnhealthyThreshold: time.Nanosecond}},
+	}
+
+	w := httptest.NewRecorder()
+	handleLivenessAPI(w, httptest.NewRequest("GET", "/healthz", nil), app)
+	assert.Equal(t, http.StatusOK, w.Code, "no health reported yet")
+
+	app.HealthManager.SetServiceUnhealthy(client.NanitAPIService, "unreachable", nil)
+	w = httptest.NewRecorder()
+	handleLivenessAPI(w, httptest.NewRequest("GET", "/healthz", nil), app)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), client.NanitAPIService)
+
+	app.HealthManager.SetServiceHealthy(client.NanitAPIService, "reachable")
+	w = httptest.NewRecorder()
+	handleLivenessAPI(w, httptest.NewRequest("GET", "/healthz", nil), app)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestHandlersReturnTypedErrors(t *testing.T) {
+	app := &App{connections: make(map[string]*client.WebsocketConnection)}
+
+	decode := func(w *httptest.ResponseRecorder) ErrorResponse {
+		var response ErrorResponse
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		return response
+	}
+
+	w := httptest.NewRecorder()
+	handleControlAPI(w, httptest.NewRequest("POST", "/api/control/night-light", strings.NewReader(`{"baby_uid":"unknown","action":"toggle"}`)), "night-light", testBabies, baby.NewStateManager(), app)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	response := decode(w)
+	assert.Equal(t, "baby_not_found", response.Code)
+	assert.Equal(t, response.Code, response.Error)
+	assert.Equal(t, "validation", string(response.Type))
+	assert.Equal(t, "unknown", response.Context["baby_uid"])
+
+	app.setMode(Mode_Monitoring)
+	w = httptest.NewRecorder()
+	handleControlAPI(w, httptest.NewRequest("POST", "/api/control/night-light", strings.NewReader(`{"baby_uid":"baby1","action":"toggle"}`)), "night-light", testBabies, baby.NewStateManager(), app)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	response = decode(w)
+	assert.Equal(t, "camera_not_connected", response.Code)
+	assert.True(t, response.Retryable)
+
+	w = httptest.NewRecorder()
+	handleHistorySensorAPI(w, httptest.NewRequest("GET", "/api/history/sensor/baby1", nil), &App{HistoryTracker: &history.Tracker{}})
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "history_disabled", decode(w).Code)
+}
+
+func TestCircuitBreakersAPI(t *testing.T) {
+	app := &App{CircuitBreakers: resilience.NewCircuitBreakerRegistry()}
+	app.CircuitBreakers.Register(resilience.NewCircuitBreaker("nanit_api", 1, time.Second, time.Minute, 1, 1))
+	app.CircuitBreakers.Register(resilience.NewCircuitBreaker("mqtt", 3, time.Second, time.Minute, 1, 1))
+
+	// One failure opens the nanit_api breaker
+	cb, found := app.CircuitBreakers.Get("nanit_api")
+	if assert.True(t, found) {
+		assert.Error(t, cb.Execute(func() error { return assert.AnError }))
+	}
+
+	w := httptest.NewRecorder()
+	handleCircuitBreakersAPI(w, httptest.NewRequest("GET", "/api/circuit-breakers", nil), app)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		CircuitBreakers []map[string]interface{} `json:"circuit_breakers"`
+		Count           int                      `json:"count"`
+		NotClosedCount  int                      `json:"not_closed_count"`
+	}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, 2, response.Count)
+	assert.Equal(t, 1, response.NotClosedCount)
+	if assert.Len(t, response.CircuitBreakers, 2) {
+		// Sorted by name
+		assert.Equal(t, "mqtt", response.CircuitBreakers[0]["name"])
+		assert.Equal(t, "closed", response.CircuitBreakers[0]["state"])
+		assert.Equal(t, "nanit_api", response.CircuitBreakers[1]["name"])
+		assert.Equal(t, "open", response.CircuitBreakers[1]["state"])
+	}
+
+	w = httptest.NewRecorder()
+	handleCircuitBreakersAPI(w, httptest.NewRequest("POST", "/api/circuit-breakers", nil), app)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestAuthRefreshAPIWithoutSession(t *testing.T) {
+	app := &App{}
+
+	w := httptest.NewRecorder()
+	handleAuthRefreshAPI(w, httptest.NewRequest("GET", "/api/auth/refresh", nil), app)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	handleAuthRefreshAPI(w, httptest.NewRequest("POST", "/api/auth/refresh", nil), app)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"not_authenticated"`)
+}
+
+func TestAuthReloginRequiredWithoutCredentials(t *testing.T) {
+	restClient := &client.NanitClient{SessionStore: session.NewSessionStore()}
+	app := &App{
+		Opts:          Opts{SessionFile: filepath.Join(t.TempDir(), "session.json")},
+		SessionStore:  restClient.SessionStore,
+		RestClient:    restClient,
+		HealthManager: health.NewHealthManager(),
+	}
+
+	// Full login can't proceed without credentials, the user has to sign in
+	assert.True(t, errors.Is(restClient.Authorize(), client.ErrCredentialsRequired))
+	assert.True(t, restClient.ReauthRequired())
+
+	w := httptest.NewRecorder()
+	handleAuthStatusAPI(w, httptest.NewRequest("GET", "/api/auth/status", nil), app)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "reauth_required", response["status"])
+	assert.Equal(t, true, response["reauth_required"])
+	assert.Equal(t, reauthRequiredMessage, response["message"])
+
+	w = httptest.NewRecorder()
+	handleAuthReloginAPI(w, httptest.NewRequest("GET", "/api/auth/relogin", nil), app)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	handleAuthReloginAPI(w, httptest.NewRequest("POST", "/api/auth/relogin", nil), app)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"credentials_required"`)
+
+	w = httptest.NewRecorder()
+	handleAuthReloginAPI(w, httptest.NewRequest("POST", "/api/auth/relogin", strings.NewReader(`{"email":"user@example.com"}`)), app)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBabyDisplayConfigAPI(t *testing.T) {
+	sessionStore := session.NewSessionStore()
+	sessionStore.Session.Babies = testBabies
+	app := &App{
+		SessionStore:  sessionStore,
+		DisplayConfig: baby.NewDisplayConfigStore(filepath.Join(t.TempDir(), "display_config.json")),
+	}
+
+	w := httptest.NewRecorder()
+	handleBabyDisplayConfigAPI(w, httptest.NewRequest("PUT", "/api/babies/config/baby2", strings.NewReader(`{"name":" Nursery ","sort_order":-1}`)), app)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, baby.DisplayConfig{Name: "Nursery", SortOrder: -1}, app.DisplayConfig.Get("baby2"))
+
+	w = httptest.NewRecorder()
+	handleBabyDisplayConfigAPI(w, httptest.NewRequest("PUT", "/api/babies/config/unknown", strings.NewReader(`{}`)), app)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	handleBabyDisplayConfigAPI(w, httptest.NewRequest("PUT", "/api/babies/config/baby1", strings.NewReader(`{"name":"`+strings.Repeat("x", 65)+`"}`)), app)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Custom name and order are merged into the status
+	w = httptest.NewRecorder()
+	handleStatusAPI(w, httptest.NewRequest("GET", "/api/status", nil), app.getBabies(), baby.NewStateManager(), app.DisplayConfig, time.Minute, app.getMode())
+	var status struct {
+		Babies []map[string]interface{} `json:"babies"`
+	}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&status))
+	if assert.Len(t, status.Babies, 3) {
+		assert.Equal(t, "baby2", status.Babies[0]["uid"])
+		assert.Equal(t, "Nursery", status.Babies[0]["name"])
+		assert.Equal(t, "Second", status.Babies[0]["nanit_name"])
+	}
+}
+
+func TestSettingsUnitsAPI(t *testing.T) {
+	sessionStore := session.NewSessionStore()
+	sessionStore.Session.Babies = testBabies
+	app := &App{
+		SessionStore:  sessionStore,
+		DisplayConfig: baby.NewDisplayConfigStore(filepath.Join(t.TempDir(), "display_config.json")),
+	}
+
+	w := httptest.NewRecorder()
+	handleSettingsUnitsAPI(w, httptest.NewRequest("PUT", "/api/settings/units", strings.NewReader(`{"temperature_unit":"kelvin"}`)), app)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	handleSettingsUnitsAPI(w, httptest.NewRequest("PUT", "/api/settings/units", strings.NewReader(`{"temperature_unit":"fahrenheit"}`)), app)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, baby.TemperatureUnitFahrenheit, app.DisplayConfig.GetTemperatureUnit())
+
+	stateManager := baby.NewStateManager()
+	stateManager.Update("baby1", *baby.NewState().SetTemperatureMilli(22000))
+
+	type statusResponse struct {
+		TemperatureUnit string                   `json:"temperature_unit"`
+		Babies          []map[string]interface{} `json:"babies"`
+	}
+
+	// Stored unit is applied by default
+	w = httptest.NewRecorder()
+	handleStatusAPI(w, httptest.NewRequest("GET", "/api/status", nil), app.getBabies(), stateManager, app.DisplayConfig, time.Minute, app.getMode())
+	var status statusResponse
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&status))
+	assert.Equal(t, "fahrenheit", status.TemperatureUnit)
+	assert.Equal(t, 71.6, status.Babies[0]["temperature"])
+
+	// Query parameter overrides the stored unit
+	w = httptest.NewRecorder()
+	handleStatusAPI(w, httptest.NewRequest("GET", "/api/status?unit=celsius", nil), app.getBabies(), stateManager, app.DisplayConfig, time.Minute, app.getMode())
+	status = statusResponse{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&status))
+	assert.Equal(t, "celsius", status.TemperatureUnit)
+	assert.Equal(t, 22.0, status.Babies[0]["temperature"])
+
+	w = httptest.NewRecorder()
+	handleStatusAPI(w, httptest.NewRequest("GET", "/api/status?unit=kelvin", nil), app.getBabies(), stateManager, app.DisplayConfig, time.Minute, app.getMode())
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHistorySensorAPIStreamsReadings(t *testing.T) {
+	tracker, err := history.NewTracker(t.TempDir(), true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tracker.Close()
+
+	assert.NoError(t, tracker.TrackSensorData("baby1", *baby.NewState().SetTemperatureMilli(22000)))
+
+	app := &App{
+		HistoryTracker: tracker,
+		DisplayConfig:  baby.NewDisplayConfigStore(filepath.Join(t.TempDir(), "display_config.json")),
+	}
+
+	w := httptest.NewRecorder()
+	handleHistorySensorAPI(w, httptest.NewRequest("GET", "/api/history/sensor/baby1?unit=fahrenheit", nil), app)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		TemperatureUnit string `json:"temperature_unit"`
+		Readings        []struct {
+			TemperatureCelsius float64 `json:"temperature_celsius"`
+			Temperature        float64 `json:"temperature"`
+		} `json:"readings"`
+		Count int `json:"count"`
+	}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "fahrenheit", response.TemperatureUnit)
+	assert.Equal(t, 1, response.Count)
+	if assert.Len(t, response.Readings, 1) {
+		assert.Equal(t, 22.0, response.Readings[0].TemperatureCelsius)
+		assert.Equal(t, 71.6, response.Readings[0].Temperature)
+	}
+}
+
+func TestHistoryDeleteAPI(t *testing.T) {
+	tracker, err := history.NewTracker(t.TempDir(), true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tracker.Close()
+
+	assert.NoError(t, tracker.TrackEvent("baby1", "motion", 1000))
+	assert.NoError(t, tracker.TrackEvent("baby1", "motion", 2000))
+	assert.NoError(t, tracker.TrackSensorData("baby1", *baby.NewState().SetTemperatureMilli(22000)))
+
+	app := &App{HistoryTracker: tracker}
+
+	request := func(method string, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handleHistoryDeleteAPI(w, httptest.NewRequest(method, target, nil), app)
+		return w
+	}
+
+	assert.Equal(t, http.StatusMethodNotAllowed, request("GET", "/api/history/baby1").Code)
+	assert.Equal(t, http.StatusBadRequest, request("DELETE", "/api/history/baby1?type=alerts").Code)
+	assert.Equal(t, http.StatusBadRequest, request("DELETE", "/api/history/baby1?type=events&start=yesterday").Code)
+	assert.Equal(t, http.StatusBadRequest, request("DELETE", "/api/history/baby1?type=events&start=2000&end=1000").Code)
+
+	// Only events of the range are deleted, the sensor data is kept
+	w := request("DELETE", "/api/history/baby1?type=events&start=1500&end=2500")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Deleted      map[string]int `json:"deleted"`
+		TotalDeleted int            `json:"total_deleted"`
+	}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, map[string]int{"events": 1}, response.Deleted)
+	assert.Equal(t, 1, response.TotalDeleted)
+
+	counts, err := tracker.GetRowCounts()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), counts["events"])
+	assert.Equal(t, int64(1), counts["sensor_readings"])
+}
+
+func TestHistoryDBAPIDownloadsBackup(t *testing.T) {
+	tracker, err := history.NewTracker(t.TempDir(), true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tracker.Close()
+
+	assert.NoError(t, tracker.TrackEvent("baby1", "motion", time.Now().Unix()))
+
+	app := &App{HistoryTracker: tracker}
+
+	w := httptest.NewRecorder()
+	handleHistoryDBAPI(w, httptest.NewRequest("GET", "/api/history/db", nil), app)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/vnd.sqlite3", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), `attachment; filename="history-`)
+	assert.Equal(t, strconv.Itoa(w.Body.Len()), w.Header().Get("Content-Length"))
+	assert.True(t, bytes.HasPrefix(w.Body.Bytes(), []byte("SQLite format 3\x00")))
+
+	w = httptest.NewRecorder()
+	handleHistoryDBAPI(w, httptest.NewRequest("POST", "/api/history/db", nil), app)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	handleHistoryDBAPI(w, httptest.NewRequest("GET", "/api/history/db", nil), &App{HistoryTracker: &history.Tracker{}})
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -2,6 +2,9 @@ package app
 
 import (
 	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -9,9 +12,12 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/indiefan/home_assistant_nanit/pkg/baby"
 	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/indiefan/home_assistant_nanit/pkg/health"
 	"github.com/indiefan/home_assistant_nanit/pkg/history"
 	"github.com/indiefan/home_assistant_nanit/pkg/message"
 	"github.com/indiefan/home_assistant_nanit/pkg/mqtt"
+	"github.com/indiefan/home_assistant_nanit/pkg/notify"
+	"github.com/indiefan/home_assistant_nanit/pkg/resilience"
 	"github.com/indiefan/home_assistant_nanit/pkg/rtmpserver"
 	"github.com/indiefan/home_assistant_nanit/pkg/session"
 	"github.com/indiefan/home_assistant_nanit/pkg/streaming"
@@ -28,10 +34,60 @@ type App struct {
 	MQTTConnection   *mqtt.Connection
 	HLSManager       *streaming.HLSManager
 	HistoryTracker   *history.Tracker
+	CircuitBreakers  *resilience.CircuitBreakerRegistry // Circuit breakers guarding external services, reported by /api/circuit-breakers
+	DisplayConfig    *baby.DisplayConfigStore           // Custom names and dashboard order of the babies
+	QuietHours       *baby.QuietHoursStore              // Windows during which event notifications are suppressed
+	StreamConfig     *baby.StreamConfigStore            // Transcoding profiles of the babies
+	sensorSampler    *history.SensorSampler
+	Notifier         *notify.Notifier
+	HealthManager    *health.HealthManager
 	WebAuth          *webauth.WebAuth
 	connections      map[string]*client.WebsocketConnection
 	connectionsMutex sync.RWMutex
 	mainContext      utils.GracefulContext // Store main application context
+	eventCooldown    *utils.Cooldown       // Debounce of propagated motion/sound events
+	streamEvents     *streamEventTracker   // Stream health of the babies, for reporting transitions
+	streamEventCooldown *utils.Cooldown    // Debounce of propagated stream health events
+	pendingTasks     *utils.PendingTasks   // Child routines and clean up steps still running, reported if the shutdown times out
+	deviceInfoRefresh *utils.Cooldown     // Debounce of the settings requests of stale device info
+	camLogRequests   camLogRequests        // Log uploads requested from the cameras, see handleCameraLogsAPI
+	deviceAlertSync  deviceAlertSync       // Device alerts last persisted, see syncDeviceAlerts
+	streamRetryMonitors streamRetryMonitors // Streaming retry monitors of the babies and their next check
+
+	// Baby monitoring (handleBaby child contexts) by baby UID
+	monitoringStarted    bool       // Baby monitoring has been started (with valid authentication)
+	mqttRunner           utils.GracefulRunner // MQTT connection started with the monitoring (guarded by babiesMutex)
+	rtmpServerStarted    bool       // RTMP server runs until the app exits, it is started once (guarded by babiesMutex)
+	mode                 Mode
+	modeMutex            sync.RWMutex
+	babiesMutex          sync.Mutex // Serializes baby list refreshes and monitoring start
+	monitoredBabies      map[string]*monitoredBaby
+	monitoredBabiesMutex sync.Mutex
+	monitoringPaused     bool // Paused by the user, no baby monitoring is started until resumed (guarded by monitoredBabiesMutex)
+
+	// Stream slots released by the user (not requested automatically until claimed again)
+	releasedStreams      map[string]bool
+	releasedStreamsMutex sync.Mutex
+
+	// Streaming teardowns waiting for the disconnect grace period, closed to cancel on reconnect
+	pendingStreamStops      map[string]chan struct{}
+	pendingStreamStopsMutex sync.Mutex
+
+	nanitMessages nanitMessagesCache // Recently fetched Nanit cloud messages
+}
+
+// Mode - whether the app monitors the cameras or only serves the web UI (e.g. for initial setup)
+type Mode string
+
+const (
+	Mode_WebOnly    Mode = "web_only"
+	Mode_Monitoring Mode = "monitoring"
+	Mode_Paused     Mode = "paused" // Monitoring paused by the user, the cameras are disconnected
+)
+
+// monitoredBaby - handle of a running handleBaby child context
+type monitoredBaby struct {
+	runner utils.GracefulRunner
 }
 
 // NewApp - constructor
@@ -41,8 +97,11 @@ func NewApp(opts Opts) (*App, error) {
 		return nil, fmt.Errorf("failed to initialize session store: %w", err)
 	}
 
+	healthManager := health.NewHealthManager()
+
 	instance := &App{
 		Opts:             opts,
+		HealthManager:    healthManager,
 		BabyStateManager: baby.NewStateManager(),
 		SessionStore:     sessionStore,
 		RestClient: &client.NanitClient{
@@ -50,22 +109,93 @@ func NewApp(opts Opts) (*App, error) {
 			Password:     opts.NanitCredentials.Password,
 			RefreshToken: opts.NanitCredentials.RefreshToken,
 			SessionStore: sessionStore,
+			HealthManager: healthManager,
+			RefreshLead:  opts.TokenRefreshLead,
 		},
 		HLSManager:  streaming.NewHLSManager(opts.DataDirectories.BaseDir + "/hls"),
 		WebAuth:     webauth.NewWebAuth(opts.WebAuth.PasswordFile),
 		connections: make(map[string]*client.WebsocketConnection),
+		mode:        Mode_WebOnly,
+		monitoredBabies: make(map[string]*monitoredBaby),
+		releasedStreams: make(map[string]bool),
+		pendingStreamStops: make(map[string]chan struct{}),
+		eventCooldown: utils.NewCooldown(opts.EventCooldown.Default, opts.EventCooldown.PerType),
+		streamEvents:  newStreamEventTracker(),
+		streamEventCooldown: utils.NewCooldown(opts.StreamEventCooldown, nil),
+		pendingTasks:  utils.NewPendingTasks(),
+		deviceInfoRefresh: utils.NewCooldown(deviceInfoRefreshCooldown, nil),
+		sensorSampler: history.NewSensorSampler(opts.History.SampleInterval),
+		CircuitBreakers: resilience.NewCircuitBreakerRegistry(),
+		DisplayConfig:   baby.NewDisplayConfigStore(filepath.Join(opts.DataDirectories.BaseDir, "display_config.json")),
+		QuietHours:      baby.NewQuietHoursStore(filepath.Join(opts.DataDirectories.BaseDir, "quiet_hours.json")),
+		StreamConfig:    baby.NewStreamConfigStore(filepath.Join(opts.DataDirectories.BaseDir, "stream_config.json")),
+	}
+
+	instance.BabyStateManager.SetSensorChangeThresholds(opts.SensorChangeThresholds)
+
+	if err := instance.DisplayConfig.Load(); err != nil {
+		// Continue with the Nanit provided names, the file is rewritten on the next change
+		log.Error().Err(err).Str("filename", instance.DisplayConfig.Filename).Msg("Failed to load display config")
+	}
+
+	if err := instance.QuietHours.Load(); err != nil {
+		// Continue without quiet hours, the file is rewritten on the next change
+		log.Error().Err(err).Str("filename", instance.QuietHours.Filename).Msg("Failed to load quiet hours")
+	}
+
+	if err := instance.StreamConfig.Load(); err != nil {
+		// Continue with the camera's own profile, the file is rewritten on the next change
+		log.Error().Err(err).Str("filename", instance.StreamConfig.Filename).Msg("Failed to load stream config")
+	}
+	instance.applyStreamConfigs()
+
+	if opts.RTMP != nil {
+		instance.HLSManager.SetInputOpts(streaming.InputOpts{
+			RWTimeout: opts.RTMP.FFmpegRWTimeout,
+			Reconnect: opts.RTMP.FFmpegReconnect,
+		})
+		instance.HLSManager.SetMaxConcurrent(opts.RTMP.HLSMaxConcurrent)
+		instance.HLSManager.SetOutputOpts(streaming.OutputOpts{
+			LiveSegments:     opts.RTMP.HLSLiveSegments,
+			DVRWindow:        opts.RTMP.HLSDVRWindow,
+			SegmentRetention: opts.RTMP.HLSSegmentRetention,
+		})
+
+		logOpts := streaming.LogOpts{Level: opts.RTMP.FFmpegLogLevel}
+		if opts.RTMP.FFmpegLogFile {
+			logOpts.Dir = opts.DataDirectories.LogDir
+			logOpts.MaxFileSize = opts.RTMP.FFmpegLogMaxSize
+		}
+		instance.HLSManager.SetLogOpts(logOpts)
 	}
 
 	if opts.MQTT != nil {
 		instance.MQTTConnection = mqtt.NewConnection(*opts.MQTT)
+		instance.MQTTConnection.HealthManager = healthManager
+	}
+
+	if opts.Notify != nil {
+		notifier, err := notify.NewNotifier(*opts.Notify)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize webhook notifier: %w", err)
+		}
+		instance.Notifier = notifier
 	}
 
 	// Initialize historical data tracker
-	if historyTracker, err := history.NewTracker(opts.DataDirectories.HistoryDir, opts.History.Enabled); err != nil {
+	historyDBPath := opts.History.DBPath
+	if historyDBPath == "" {
+		historyDBPath = filepath.Join(opts.DataDirectories.HistoryDir, history.DefaultDBFilename)
+	}
+
+	if historyTracker, err := history.NewTrackerAt(historyDBPath, opts.History.Enabled); err != nil {
 		log.Error().Err(err).Msg("Failed to initialize historical data tracker")
 		// Continue without historical tracking
 		instance.HistoryTracker = &history.Tracker{}
 	} else {
+		historyTracker.SetMaxCarryForward(opts.History.DayNightMaxGap)
+		historyTracker.SetCryEpisodeGap(opts.History.CryEpisodeGap)
+		historyTracker.SetStoreRawSensorData(opts.History.StoreRawSensorData)
 		instance.HistoryTracker = historyTracker
 	}
 
@@ -79,70 +209,69 @@ func (app *App) Run(ctx utils.GracefulContext) {
 	
 	// Set up historical data tracking callback
 	app.setupHistoryTracking()
-	// Check if we have valid authentication
-	hasValidAuth := false
-	if app.SessionStore != nil && app.SessionStore.Session != nil && app.SessionStore.Session.RefreshToken != "" {
-		// Try to authorize - if it fails, we'll run in web-only mode
-		defer func() {
-			if r := recover(); r != nil {
-				log.Warn().Interface("error", r).Msg("Authorization failed, running in web-only mode")
-				hasValidAuth = false
-			}
-		}()
-		
-		if err := app.RestClient.MaybeAuthorize(false); err != nil {
-			log.Error().Err(err).Msg("Authentication failed")
-			hasValidAuth = false
-		} else {
-			if _, err := app.RestClient.EnsureBabies(); err != nil {
-				log.Error().Err(err).Msg("Failed to fetch babies")
-				hasValidAuth = false
-			} else {
-				hasValidAuth = true
+
+	// Set up cleanup handler for graceful shutdown, once for all the sign-ins of the app's lifetime
+	ctx.RunAsChild(func(childCtx utils.GracefulContext) {
+		<-childCtx.Done()
+
+		log.Info().Msg("Shutting down application...")
+
+		if app.HistoryTracker != nil {
+			done := app.pendingTasks.Start("close history database")
+			if err := app.HistoryTracker.Close(); err != nil {
+				log.Error().Err(err).Msg("Failed to close history tracker")
 			}
+			done()
 		}
-	} else {
-		log.Info().Msg("No valid authentication found - running in web-only mode for initial setup")
-	}
+		if app.HLSManager != nil {
+			done := app.pendingTasks.Start("stop hls transcoders")
+			app.HLSManager.StopAll()
+			done()
+		}
+		log.Info().Msg("Application cleanup completed")
+	})
+
+	// Periodic cleanup of orphaned HLS files, participates in ordered shutdown
+	app.runAsChild(ctx, "hls cleanup", func(childCtx utils.GracefulContext) {
+		app.HLSManager.RunPeriodicCleanup(childCtx)
+	})
 
-	// Always start HTTP server for web UI (including setup)
-	var babies []baby.Baby
-	if hasValidAuth && app.SessionStore.Session != nil {
-		babies = app.SessionStore.Session.Babies
+	// Camera log retention
+	app.runAsChild(ctx, "camlog prune", func(childCtx utils.GracefulContext) {
+		app.runPeriodicCamLogPrune(childCtx)
+	})
+
+	// Keeps the Nanit session warm, so requests after a long idle don't wait for the token renewal
+	if app.Opts.TokenRefreshLead > 0 {
+		app.runAsChild(ctx, "token refresh", func(childCtx utils.GracefulContext) {
+			app.runProactiveTokenRefresh(childCtx)
+		})
 	}
-	
+
+	// Always start HTTP server for web UI (including setup), it stays reachable while the Nanit API is retried
 	if app.Opts.HTTPEnabled {
-		go ServeReact(babies, app.Opts.DataDirectories, app.BabyStateManager, app)
+		go ServeReact(app.getBabies(), app.Opts.DataDirectories, app.BabyStateManager, app)
 	}
 
 	// Only start RTMP/MQTT/WebSocket if we have valid auth
-	if hasValidAuth {
-		// RTMP
-		if app.Opts.RTMP != nil {
-			go func() {
-				if err := rtmpserver.StartRTMPServer(app.Opts.RTMP.ListenAddr, app.BabyStateManager); err != nil {
-					log.Error().Err(err).Msg("RTMP server failed to start or crashed")
-				}
-			}()
-		}
-
-		// MQTT
-		if app.MQTTConnection != nil {
-			ctx.RunAsChild(func(childCtx utils.GracefulContext) {
-				app.MQTTConnection.Run(app.BabyStateManager, childCtx)
-			})
-		}
+	// Transient Nanit API failures are retried a few times, then in the background until the API recovers
+	if app.SessionStore != nil && app.SessionStore.Session != nil && app.SessionStore.Session.RefreshToken != "" {
+		if err := authorizeWithRetry(ctx, startupAuthRetries, startupAuthDelay, app.authorizeAndFetchBabies); err == nil {
+			if app.startMonitoring() {
+				log.Info().Msg("All services started with authentication")
+			}
+		} else if !isPermanentAuthError(err) && app.Opts.StartupAuthRetryInterval > 0 {
+			log.Error().Err(err).Msg("Startup authorization failed, running in web-only mode until the Nanit API recovers")
 
-		// Start reading the data from the stream
-		for _, babyInfo := range app.SessionStore.Session.Babies {
-			_babyInfo := babyInfo
-			ctx.RunAsChild(func(childCtx utils.GracefulContext) {
-				app.handleBaby(_babyInfo, childCtx)
+			// Promoted to full monitoring once the Nanit API recovers
+			app.runAsChild(ctx, "authorization retry", func(childCtx utils.GracefulContext) {
+				app.retryAuthorizationInBackground(childCtx, app.Opts.StartupAuthRetryInterval, app.authorizeAndFetchBabies)
 			})
+		} else {
+			log.Error().Err(err).Msg("Startup authorization failed, running in web-only mode")
 		}
-		
-		log.Info().Msg("All services started with authentication")
 	} else {
+		log.Info().Msg("No valid authentication found - running in web-only mode for initial setup")
 		log.Info().Msg("Web server started - visit http://localhost:8080/setup to configure authentication")
 	}
 
@@ -153,20 +282,29 @@ func (app *App) handleBaby(baby baby.Baby, ctx utils.GracefulContext) {
 	if app.Opts.RTMP != nil || app.MQTTConnection != nil {
 		// Websocket connection
 		ws := client.NewWebsocketConnectionManager(baby.UID, baby.CameraUID, app.SessionStore.Session, app.RestClient, app.BabyStateManager)
+		ws.KeepaliveInterval = app.Opts.WebsocketKeepalive.Interval
+		ws.KeepaliveTimeout = app.Opts.WebsocketKeepalive.Timeout
 
 		ws.WithReadyConnection(func(conn *client.WebsocketConnection, childCtx utils.GracefulContext) {
 			// Register connection
 			app.registerConnection(baby.UID, conn)
+			// Streaming survived a short disconnect
+			app.cancelPendingStreamStop(baby.UID)
 			defer func() {
 				app.unregisterConnection(baby.UID)
-				// Gracefully stop streaming when WebSocket disconnects
-				if app.Opts.RTMP != nil && app.Opts.RTMP.AutoStart {
-					app.autoStopStreaming(baby.UID, conn)
+				// Gracefully stop streaming when WebSocket disconnects (unless it reconnects within the grace period)
+				// No reconnect is coming while the monitoring is paused
+				if app.Opts.RTMP != nil && app.Opts.RTMP.IsAutoStartEnabled(baby.UID) {
+					if app.isMonitoringPaused() {
+						app.autoStopStreaming(baby.UID, conn)
+					} else {
+						app.scheduleAutoStopStreaming(baby.UID, conn)
+					}
 				}
 			}()
 			
 			// Auto-start streaming if RTMP is enabled and auto-start is configured
-			if app.Opts.RTMP != nil && app.Opts.RTMP.AutoStart {
+			if app.Opts.RTMP != nil && app.Opts.RTMP.IsAutoStartEnabled(baby.UID) && !app.isStreamReleased(baby.UID) {
 				log.Info().Str("baby_uid", baby.UID).Msg("Auto-starting RTMP stream")
 				go app.autoStartStreaming(baby.UID, conn)
 				
@@ -174,14 +312,26 @@ func (app *App) handleBaby(baby baby.Baby, ctx utils.GracefulContext) {
 				go app.startStreamingRetryMonitor(baby.UID, childCtx)
 			}
 			
+			if app.Opts.RTMP != nil && !app.Opts.RTMP.IsAutoStartEnabled(baby.UID) {
+				log.Info().Str("baby_uid", baby.UID).Msg("RTMP auto-start disabled for baby, stream can be started manually")
+			}
+
 			app.runWebsocket(baby.UID, conn, childCtx)
 		})
 
-		if app.Opts.EventPolling.Enabled {
-			go app.pollMessages(baby.UID, app.BabyStateManager)
+		if app.Opts.EventPolling.IsEnabledFor(baby.UID) {
+			app.runAsChild(ctx, "event polling "+baby.UID, func(childCtx utils.GracefulContext) {
+				app.pollMessages(baby.UID, childCtx)
+			})
 		}
 
-		ctx.RunAsChild(func(childCtx utils.GracefulContext) {
+		if app.Opts.PollFallback.Enabled {
+			app.runAsChild(ctx, "poll fallback "+baby.UID, func(childCtx utils.GracefulContext) {
+				app.runPollFallback(baby.UID, childCtx)
+			})
+		}
+
+		app.runAsChild(ctx, "websocket "+baby.UID, func(childCtx utils.GracefulContext) {
 			ws.RunWithinContext(childCtx)
 		})
 	}
@@ -189,114 +339,118 @@ func (app *App) handleBaby(baby baby.Baby, ctx utils.GracefulContext) {
 	<-ctx.Done()
 }
 
-func (app *App) pollMessages(babyUID string, babyStateManager *baby.StateManager) {
-	newMessages, err := app.RestClient.FetchNewMessages(babyUID, app.Opts.EventPolling.MessageTimeout)
-	if err != nil {
-		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to fetch new messages")
-		// Continue with empty messages rather than crash
-		newMessages = []message.Message{}
+// pollMessages - fetches the event messages of the baby every polling interval until the context is cancelled
+func (app *App) pollMessages(babyUID string, ctx utils.GracefulContext) {
+	ticker := time.NewTicker(app.Opts.EventPolling.PollingInterval)
+	defer ticker.Stop()
+
+	for {
+		newMessages, err := app.RestClient.FetchNewMessages(babyUID, app.Opts.EventPolling.MessageTimeout)
+		if err != nil {
+			log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to fetch new messages")
+			// Continue with empty messages rather than crash
+			newMessages = []message.Message{}
+		}
+
+		app.handleMessages(babyUID, newMessages)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
 	}
+}
 
+// handleMessages - dispatches event messages fetched from the REST API
+func (app *App) handleMessages(babyUID string, newMessages []message.Message) {
 	for _, msg := range newMessages {
 		switch msg.Type {
 		case message.SoundEventMessageType:
-			go babyStateManager.NotifySoundSubscribers(babyUID, time.Time(msg.Time))
+			go app.dispatchEvent(babyUID, notify.EventSound, time.Time(msg.Time))
 			break
 		case message.MotionEventMessageType:
-			go babyStateManager.NotifyMotionSubscribers(babyUID, time.Time(msg.Time))
+			go app.dispatchEvent(babyUID, notify.EventMotion, time.Time(msg.Time))
 			break
+		case message.TemperatureEventMessageType:
+			go app.dispatchEvent(babyUID, notify.EventTemperatureAlert, time.Time(msg.Time))
+		case message.HumidityEventMessageType:
+			go app.dispatchEvent(babyUID, notify.EventHumidityAlert, time.Time(msg.Time))
+		case message.CryEventMessageType:
+			go app.dispatchEvent(babyUID, notify.EventCry, time.Time(msg.Time))
+		default:
+			log.Debug().Str("baby_uid", babyUID).Str("type", msg.Type).Int("id", msg.Id).Msg("Ignoring message of unknown type")
 		}
 	}
-
-	// wait for the specified interval
-	time.Sleep(app.Opts.EventPolling.PollingInterval)
-	app.pollMessages(babyUID, babyStateManager)
 }
 
-func (app *App) runWebsocket(babyUID string, conn *client.WebsocketConnection, childCtx utils.GracefulContext) {
+func (app *App) runWebsocket(babyUID string, conn client.Connection, childCtx utils.GracefulContext) {
 	// Reading sensor data
-	conn.RegisterMessageHandler(func(m *client.Message, conn *client.WebsocketConnection) {
-		// Sensor request initiated by us on start (or some other client, we don't care)
-		if *m.Type == client.Message_RESPONSE && m.Response != nil {
-			if *m.Response.RequestType == client.RequestType_GET_SENSOR_DATA && len(m.Response.SensorData) > 0 {
-				processSensorData(babyUID, m.Response.SensorData, app.BabyStateManager)
-			} else if *m.Response.RequestType == client.RequestType_GET_CONTROL && m.Response.Control != nil {
-				processLight(babyUID, m.Response.Control, app.BabyStateManager)
-			} else if *m.Response.RequestType == client.RequestType_GET_SETTINGS && m.Response.Settings != nil {
-				processStandby(babyUID, m.Response.Settings, app.BabyStateManager)
-			} else if *m.Response.RequestType == client.RequestType_GET_STATUS && m.Response.Status != nil {
-				processStatus(babyUID, m.Response.Status, app.BabyStateManager)
-			}
-		} else
-
-		// Communication initiated from a cam
-		// Note: it sends the updates periodically on its own + whenever some significant change occurs
-		if *m.Type == client.Message_REQUEST && m.Request != nil {
-			if *m.Request.Type == client.RequestType_PUT_SENSOR_DATA && len(m.Request.SensorData_) > 0 {
-				processSensorData(babyUID, m.Request.SensorData_, app.BabyStateManager)
-			} else if *m.Request.Type == client.RequestType_PUT_CONTROL && m.Request.Control != nil {
-				processLight(babyUID, m.Request.Control, app.BabyStateManager)
-			} else if *m.Request.Type == client.RequestType_PUT_SETTINGS && m.Request.Settings != nil {
-				processStandby(babyUID, m.Request.Settings, app.BabyStateManager)
-			}
-		}
+	conn.RegisterMessageHandler(func(m *client.Message, conn client.Connection) {
+		app.handleWebsocketMessage(babyUID, m)
 	})
 
 	if app.Opts.MQTT != nil && app.MQTTConnection != nil {
 		app.MQTTConnection.RegisterLightHandler(func(enabled bool) {
-			sendLightCommand(enabled, conn)
+			sendLightCommand(babyUID, enabled, conn)
 		})
 		app.MQTTConnection.RegisterStandyHandler(func(enabled bool) {
-			sendStandbyCommand(enabled, conn)
+			sendStandbyCommand(babyUID, enabled, conn)
 		})
 	}
 
+	// Initial state, responses are processed by the message handler, failures are logged
 	// Get the initial state of the light
-	conn.SendRequest(client.RequestType_GET_CONTROL, &client.Request{GetControl_: &client.GetControl{
+	sendRequestAndLog(babyUID, conn, client.RequestType_GET_CONTROL, &client.Request{GetControl_: &client.GetControl{
 		NightLight: utils.ConstRefBool(true),
 	}})
 
 	// Ask for sensor data (initial request)
-	conn.SendRequest(client.RequestType_GET_SENSOR_DATA, &client.Request{
+	sendRequestAndLog(babyUID, conn, client.RequestType_GET_SENSOR_DATA, &client.Request{
 		GetSensorData: &client.GetSensorData{
 			All: utils.ConstRefBool(true),
 		},
 	})
 
 	// Ask for status
-	conn.SendRequest(client.RequestType_GET_STATUS, &client.Request{
+	sendRequestAndLog(babyUID, conn, client.RequestType_GET_STATUS, &client.Request{
 		GetStatus_: &client.GetStatus{
 			All: utils.ConstRefBool(true),
 		},
 	})
 
 	// Ask for settings to get device configuration
-	conn.SendRequest(client.RequestType_GET_SETTINGS, &client.Request{})
-
-	// Ask for logs
-	// conn.SendRequest(client.RequestType_GET_LOGS, &client.Request{
-	// 	GetLogs: &client.GetLogs{
-	// 		Url: utils.ConstRefStr("http://192.168.3.234:8080/log"),
-	// 	},
-	// })
+	sendRequestAndLog(babyUID, conn, client.RequestType_GET_SETTINGS, &client.Request{})
 
 	var cleanup func()
 
 	// Local streaming
 	if app.Opts.RTMP != nil {
 		initializeLocalStreaming := func() {
-			requestLocalStreaming(babyUID, app.getLocalStreamURL(babyUID), client.Streaming_STARTED, conn, app.BabyStateManager)
+			requestLocalStreaming(babyUID, app.getLocalStreamURL(babyUID), app.streamIdentifier(), client.Streaming_STARTED, conn, app.BabyStateManager)
 		}
 
 		// Watch for stream liveness change
 		unsubscribe := app.BabyStateManager.Subscribe(func(updatedBabyUID string, stateUpdate baby.State) {
 			// Do another streaming request if stream just turned unhealthy
 			if updatedBabyUID == babyUID && stateUpdate.StreamState != nil && *stateUpdate.StreamState == baby.StreamState_Unhealthy {
-				// Prevent duplicate request if we already received failure
-				if app.BabyStateManager.GetBabyState(babyUID).GetStreamRequestState() != baby.StreamRequestState_RequestFailed {
+				// Prevent duplicate request if we already received failure or the stream slot was released
+				if app.BabyStateManager.GetBabyState(babyUID).GetStreamRequestState() != baby.StreamRequestState_RequestFailed && !app.isStreamReleased(babyUID) {
 					go initializeLocalStreaming()
 				}
 			}
+
+			// Retry monitor stops when idle, bring it back for the failure
+			if updatedBabyUID == babyUID && stateUpdate.StreamRequestState != nil && *stateUpdate.StreamRequestState == baby.StreamRequestState_RequestFailed &&
+				app.Opts.RTMP.IsAutoStartEnabled(babyUID) {
+				go app.startStreamingRetryMonitor(babyUID, childCtx)
+			}
+
+			// Black feed in standby, no point in transcoding it
+			standbyChanged := stateUpdate.Standby != nil || (stateUpdate.DeviceInfo != nil && stateUpdate.DeviceInfo.SleepMode != nil)
+			if updatedBabyUID == babyUID && standbyChanged && app.Opts.RTMP.IsAutoStartEnabled(babyUID) {
+				go app.applyStandbyToTranscoding(babyUID)
+			}
 		})
 
 		cleanup = func() {
@@ -306,13 +460,13 @@ func (app *App) runWebsocket(babyUID string, conn *client.WebsocketConnection, c
 			// Stop local streaming
 			state := app.BabyStateManager.GetBabyState(babyUID)
 			if state.GetIsWebsocketAlive() && state.GetStreamState() == baby.StreamState_Alive {
-				requestLocalStreaming(babyUID, app.getLocalStreamURL(babyUID), client.Streaming_STOPPED, conn, app.BabyStateManager)
+				requestLocalStreaming(babyUID, app.getLocalStreamURL(babyUID), app.streamIdentifier(), client.Streaming_STOPPED, conn, app.BabyStateManager)
 			}
 		}
 
 		// Initialize local streaming upon connection if we know that the stream is not alive
 		babyState := app.BabyStateManager.GetBabyState(babyUID)
-		if babyState.GetStreamState() != baby.StreamState_Alive {
+		if babyState.GetStreamState() != baby.StreamState_Alive && !app.isStreamReleased(babyUID) {
 			if babyState.GetStreamRequestState() != baby.StreamRequestState_Requested || babyState.GetStreamState() == baby.StreamState_Unhealthy {
 				go initializeLocalStreaming()
 			}
@@ -325,6 +479,34 @@ func (app *App) runWebsocket(babyUID string, conn *client.WebsocketConnection, c
 	}
 }
 
+// handleWebsocketMessage - applies state carried by a message received from the cam
+func (app *App) handleWebsocketMessage(babyUID string, m *client.Message) {
+	// Sensor request initiated by us on start (or some other client, we don't care)
+	if *m.Type == client.Message_RESPONSE && m.Response != nil {
+		if *m.Response.RequestType == client.RequestType_GET_SENSOR_DATA && len(m.Response.SensorData) > 0 {
+			processSensorData(babyUID, m.Response.SensorData, app.DisplayConfig.Get(babyUID), app.BabyStateManager)
+		} else if *m.Response.RequestType == client.RequestType_GET_CONTROL && m.Response.Control != nil {
+			processLight(babyUID, m.Response.Control, app.BabyStateManager)
+		} else if *m.Response.RequestType == client.RequestType_GET_SETTINGS && m.Response.Settings != nil {
+			processStandby(babyUID, m.Response.Settings, app.BabyStateManager)
+		} else if *m.Response.RequestType == client.RequestType_GET_STATUS && m.Response.Status != nil {
+			processStatus(babyUID, m.Response.Status, app.BabyStateManager)
+		}
+	} else
+
+	// Communication initiated from a cam
+	// Note: it sends the updates periodically on its own + whenever some significant change occurs
+	if *m.Type == client.Message_REQUEST && m.Request != nil {
+		if *m.Request.Type == client.RequestType_PUT_SENSOR_DATA && len(m.Request.SensorData_) > 0 {
+			processSensorData(babyUID, m.Request.SensorData_, app.DisplayConfig.Get(babyUID), app.BabyStateManager)
+		} else if *m.Request.Type == client.RequestType_PUT_CONTROL && m.Request.Control != nil {
+			processLight(babyUID, m.Request.Control, app.BabyStateManager)
+		} else if *m.Request.Type == client.RequestType_PUT_SETTINGS && m.Request.Settings != nil {
+			processStandby(babyUID, m.Request.Settings, app.BabyStateManager)
+		}
+	}
+}
+
 func (app *App) getRemoteStreamURL(babyUID string) string {
 	return fmt.Sprintf("rtmps://media-secured.nanit.com/nanit/%v.%v", babyUID, app.SessionStore.Session.AuthToken)
 }
@@ -338,6 +520,25 @@ func (app *App) getLocalStreamURL(babyUID string) string {
 	return ""
 }
 
+// streamIdentifier returns the camera stream requested for the local RTMP relay
+func (app *App) streamIdentifier() client.StreamIdentifier {
+	if app.Opts.RTMP != nil {
+		return app.Opts.RTMP.StreamIdentifier
+	}
+
+	return client.StreamIdentifier_MOBILE
+}
+
+// streamBlockedError returns the error type and message of a stream request declined by the camera
+// Only the MOBILE stream counts against the Nanit mobile app connection limit, closing the app doesn't help otherwise.
+func streamBlockedError(streamID client.StreamIdentifier) (string, string) {
+	if streamID == client.StreamIdentifier_MOBILE {
+		return "connection_limit", "Too many Nanit mobile apps connected. Close the official Nanit app to enable streaming."
+	}
+
+	return "stream_request_failed", fmt.Sprintf("Camera declined the %v stream request, its firmware may not relay this stream. Set NANIT_STREAM_IDENTIFIER to MOBILE to use the mobile stream.", streamID)
+}
+
 // Connection management methods for WebSocket connections
 func (app *App) registerConnection(babyUID string, conn *client.WebsocketConnection) {
 	app.connectionsMutex.Lock()
@@ -378,6 +579,254 @@ func (app *App) RefreshAuthentication() error {
 	return nil
 }
 
+// restartServicesAfterRelogin restarts the camera connections with the session obtained by signing in to Nanit again
+// All services are started if the monitoring never ran (authorization failed on startup).
+func (app *App) restartServicesAfterRelogin() {
+	app.babiesMutex.Lock()
+	started := app.monitoringStarted
+	app.babiesMutex.Unlock()
+
+	if !started {
+		app.StartMonitoringServices()
+		return
+	}
+
+	for _, babyUID := range app.getMonitoredBabyUIDs() {
+		app.stopMonitoringBaby(babyUID)
+	}
+
+	// Starts monitoring of all babies on the account again
+	if _, err := app.refreshBabies(); err != nil {
+		log.Warn().Err(err).Msg("Failed to refresh babies after relogin, restarting monitoring of the known ones")
+
+		app.babiesMutex.Lock()
+		for _, babyInfo := range app.getBabies() {
+			app.startMonitoringBaby(babyInfo)
+		}
+		app.babiesMutex.Unlock()
+	}
+
+	log.Info().Msg("Services restarted after relogin")
+}
+
+// getBabies returns the current list of babies from the session
+func (app *App) getBabies() []baby.Baby {
+	// Written by the client under its lock when the babies are fetched
+	if app.RestClient != nil && app.RestClient.SessionStore == app.SessionStore {
+		return app.RestClient.Babies()
+	}
+
+	if app.SessionStore == nil || app.SessionStore.Session == nil {
+		return []baby.Baby{}
+	}
+
+	return append([]baby.Baby{}, app.SessionStore.Session.Babies...)
+}
+
+// refreshBabies re-fetches the baby list from the Nanit API and starts monitoring newly discovered babies
+func (app *App) refreshBabies() ([]baby.Baby, error) {
+	app.babiesMutex.Lock()
+	defer app.babiesMutex.Unlock()
+
+	babies, err := app.RestClient.FetchBabies()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Int("babies_count", len(babies)).Msg("Refreshed babies list")
+
+	if app.monitoringStarted {
+		currentBabies := make(map[string]bool)
+		for _, babyInfo := range babies {
+			currentBabies[babyInfo.UID] = true
+			if app.startMonitoringBaby(babyInfo) {
+				log.Info().Str("baby_uid", babyInfo.UID).Str("name", babyInfo.Name).Msg("Started monitoring newly discovered baby")
+			}
+		}
+
+		// Stop monitoring babies which are no longer on the account
+		for _, babyUID := range app.getMonitoredBabyUIDs() {
+			if !currentBabies[babyUID] {
+				log.Info().Str("baby_uid", babyUID).Msg("Baby no longer on the account, stopping monitoring")
+				app.stopMonitoringBaby(babyUID)
+			}
+		}
+	}
+
+	return babies, nil
+}
+
+// startMonitoringBaby launches handleBaby within a child of the main context unless the baby is already monitored.
+// Returns true if the monitoring has been started.
+func (app *App) startMonitoringBaby(babyInfo baby.Baby) bool {
+	app.monitoredBabiesMutex.Lock()
+	defer app.monitoredBabiesMutex.Unlock()
+
+	if _, exists := app.monitoredBabies[babyInfo.UID]; exists || app.monitoringPaused {
+		return false
+	}
+
+	ctx := app.mainContext
+	if ctx == nil {
+		log.Error().Str("baby_uid", babyInfo.UID).Msg("Cannot start monitoring baby: main context not available")
+		return false
+	}
+
+	entry := &monitoredBaby{}
+	app.monitoredBabies[babyInfo.UID] = entry
+
+	entry.runner = app.runAsChild(ctx, "baby "+babyInfo.UID, func(childCtx utils.GracefulContext) {
+		app.handleBaby(babyInfo, childCtx)
+
+		// Forget the baby once its handler finishes so that it can be started again
+		app.monitoredBabiesMutex.Lock()
+		if app.monitoredBabies[babyInfo.UID] == entry {
+			delete(app.monitoredBabies, babyInfo.UID)
+		}
+		app.monitoredBabiesMutex.Unlock()
+	})
+
+	return true
+}
+
+// stopMonitoringBaby cancels the monitoring context of the baby and awaits its clean up
+func (app *App) stopMonitoringBaby(babyUID string) {
+	app.monitoredBabiesMutex.Lock()
+	entry, exists := app.monitoredBabies[babyUID]
+	delete(app.monitoredBabies, babyUID)
+	app.monitoredBabiesMutex.Unlock()
+
+	if exists && entry.runner != nil {
+		entry.runner.Cancel()
+	}
+}
+
+// getMonitoredBabyUIDs returns UIDs of all babies with running monitoring
+func (app *App) getMonitoredBabyUIDs() []string {
+	app.monitoredBabiesMutex.Lock()
+	defer app.monitoredBabiesMutex.Unlock()
+
+	uids := make([]string, 0, len(app.monitoredBabies))
+	for babyUID := range app.monitoredBabies {
+		uids = append(uids, babyUID)
+	}
+
+	return uids
+}
+
+// getMode returns whether the cameras are monitored or only the web UI is served
+func (app *App) getMode() Mode {
+	app.modeMutex.RLock()
+	defer app.modeMutex.RUnlock()
+
+	if app.mode == "" {
+		return Mode_WebOnly
+	}
+	return app.mode
+}
+
+func (app *App) setMode(mode Mode) {
+	app.modeMutex.Lock()
+	app.mode = mode
+	app.modeMutex.Unlock()
+
+	log.Info().Str("mode", string(mode)).Msg("Application mode changed")
+}
+
+// getNanitAPIStatus returns whether the Nanit API was reachable on the last request, with a message
+func (app *App) getNanitAPIStatus() (bool, string) {
+	apiHealth, exists := app.HealthManager.GetServiceHealth(client.NanitAPIService)
+	if !exists {
+		return true, "No requests made to Nanit API yet"
+	}
+
+	return apiHealth.Status != health.StatusUnhealthy, apiHealth.Message
+}
+
+// startMonitoring starts the RTMP server, MQTT and the monitoring of all babies of the session
+// Runs once, returns false if the monitoring was started already (e.g. by signing in while the startup authorization was retried).
+func (app *App) startMonitoring() bool {
+	app.babiesMutex.Lock()
+	defer app.babiesMutex.Unlock()
+
+	if app.monitoringStarted {
+		return false
+	}
+
+	ctx := app.mainContext
+	if ctx == nil {
+		log.Error().Msg("Cannot start monitoring services: main context not available")
+		return false
+	}
+
+	// Start RTMP server if configured
+	if app.Opts.RTMP != nil && !app.rtmpServerStarted {
+		app.rtmpServerStarted = true
+		go func() {
+			if err := rtmpserver.StartRTMPServer(app.Opts.RTMP.ListenAddr, app.BabyStateManager); err != nil {
+				log.Error().Err(err).Msg("RTMP server failed to start or crashed")
+			}
+		}()
+		log.Info().Msg("RTMP server startup initiated")
+	}
+
+	// Start MQTT if configured
+	if app.MQTTConnection != nil {
+		app.mqttRunner = app.runAsChild(ctx, "mqtt", func(childCtx utils.GracefulContext) {
+			app.MQTTConnection.Run(app.BabyStateManager, childCtx)
+		})
+		log.Info().Msg("MQTT connection started")
+	}
+
+	// Restore last known sensor values so the status isn't blank until the camera reports
+	app.seedSensorStateFromHistory()
+
+	// Start baby monitoring for each baby
+	for _, babyInfo := range app.getBabies() {
+		if app.startMonitoringBaby(babyInfo) {
+			log.Info().Str("baby_uid", babyInfo.UID).Str("name", babyInfo.Name).Msg("Started monitoring baby")
+		}
+	}
+	app.monitoringStarted = true
+	app.setMode(Mode_Monitoring)
+
+	return true
+}
+
+// stopMonitoring stops the monitoring of all babies, MQTT and the transcoders and goes back to web-only mode
+// Used when the authentication is reset, the monitoring can be started again by signing in. Returns false if it wasn't running.
+func (app *App) stopMonitoring() bool {
+	app.babiesMutex.Lock()
+	defer app.babiesMutex.Unlock()
+
+	if !app.monitoringStarted {
+		return false
+	}
+
+	app.monitoredBabiesMutex.Lock()
+	app.monitoringPaused = false
+	app.monitoredBabiesMutex.Unlock()
+
+	for _, babyUID := range app.getMonitoredBabyUIDs() {
+		app.stopMonitoringBaby(babyUID)
+	}
+
+	if app.mqttRunner != nil {
+		app.mqttRunner.Cancel()
+		app.mqttRunner = nil
+	}
+
+	if app.HLSManager != nil {
+		app.HLSManager.StopAll()
+	}
+
+	app.monitoringStarted = false
+	app.setMode(Mode_WebOnly)
+
+	log.Info().Msg("Monitoring services stopped")
+	return true
+}
+
 // StartMonitoringServices - start all monitoring services after authentication
 func (app *App) StartMonitoringServices() {
 	// Use the main application context stored during Run()
@@ -386,6 +835,15 @@ func (app *App) StartMonitoringServices() {
 		log.Error().Msg("Cannot start monitoring services: main context not available")
 		return
 	}
+
+	app.babiesMutex.Lock()
+	started := app.monitoringStarted
+	app.babiesMutex.Unlock()
+	if started {
+		log.Info().Msg("Monitoring services already running")
+		return
+	}
+
 	log.Info().Msg("Starting monitoring services after authentication...")
 	
 	// Force refresh authorization and fetch babies (token may have expired since web auth)
@@ -398,64 +856,52 @@ func (app *App) StartMonitoringServices() {
 		return
 	}
 	
-	if app.SessionStore.Session == nil || len(app.SessionStore.Session.Babies) == 0 {
-		log.Warn().Msg("No babies found after authentication")
+	if app.SessionStore.Session == nil {
+		log.Warn().Msg("No session after authentication")
 		return
 	}
 	
-	log.Info().Int("babies_count", len(app.SessionStore.Session.Babies)).Msg("Found babies, starting services")
-	
-	// Start RTMP server if configured
-	if app.Opts.RTMP != nil {
-		go func() {
-			if err := rtmpserver.StartRTMPServer(app.Opts.RTMP.ListenAddr, app.BabyStateManager); err != nil {
-				log.Error().Err(err).Msg("RTMP server failed to start or crashed")
-			}
-		}()
-		log.Info().Msg("RTMP server startup initiated")
-	}
-	
-	// Start MQTT if configured  
-	if app.MQTTConnection != nil {
-		ctx.RunAsChild(func(childCtx utils.GracefulContext) {
-			app.MQTTConnection.Run(app.BabyStateManager, childCtx)
-		})
-		log.Info().Msg("MQTT connection started")
+	// Services start without babies as well, so that cameras added later are picked up by refreshing the babies list
+	if len(app.SessionStore.Session.Babies) == 0 {
+		log.Warn().Msg("No cameras associated with this account, set up the camera in the Nanit app and refresh the babies list")
+	} else {
+		log.Info().Int("babies_count", len(app.SessionStore.Session.Babies)).Msg("Found babies, starting services")
 	}
 	
-	// Start baby monitoring for each baby (use same pattern as original Run method)
-	for _, babyInfo := range app.SessionStore.Session.Babies {
-		_babyInfo := babyInfo
-		ctx.RunAsChild(func(childCtx utils.GracefulContext) {
-			app.handleBaby(_babyInfo, childCtx)
-		})
-		log.Info().Str("baby_uid", _babyInfo.UID).Str("name", _babyInfo.Name).Msg("Started monitoring baby")
+	// Started concurrently (background authorization retry)
+	if !app.startMonitoring() {
+		log.Info().Msg("Monitoring services already running")
+		return
 	}
 	
 	log.Info().Msg("All monitoring services started successfully")
-	
-	// Set up cleanup handler for graceful shutdown
-	ctx.RunAsChild(func(childCtx utils.GracefulContext) {
-		<-childCtx.Done()
-		
-		log.Info().Msg("Shutting down application...")
-		
-		if app.HistoryTracker != nil {
-			if err := app.HistoryTracker.Close(); err != nil {
-				log.Error().Err(err).Msg("Failed to close history tracker")
-			}
-		}
-		if app.HLSManager != nil {
-			app.HLSManager.StopAll()
-		}
-		log.Info().Msg("Application cleanup completed")
+}
+
+// runAsChild runs the callback within a child context of ctx, it is reported as pending under the name until it returns
+func (app *App) runAsChild(ctx utils.GracefulContext, name string, callback func(utils.GracefulContext)) utils.GracefulRunner {
+	return ctx.RunAsChild(func(childCtx utils.GracefulContext) {
+		defer app.pendingTasks.Start(name)()
+		callback(childCtx)
 	})
 }
 
+// PendingTasks returns the child routines and clean up steps that haven't finished yet, for diagnosing a stuck shutdown
+func (app *App) PendingTasks() []string {
+	return app.pendingTasks.List()
+}
+
+// KillTranscoders kills the remaining FFmpeg processes without waiting for the clean up, returns how many were killed
+func (app *App) KillTranscoders() int {
+	if app.HLSManager == nil {
+		return 0
+	}
+	return app.HLSManager.KillProcesses()
+}
+
 // autoStartStreaming automatically starts RTMP streaming and HLS transcoding when a baby comes online
 func (app *App) autoStartStreaming(babyUID string, conn *client.WebsocketConnection) {
 	// Give the WebSocket connection a moment to fully establish
-	time.Sleep(2 * time.Second)
+	time.Sleep(app.Opts.RTMP.StreamStartDelay)
 	
 	// Get the RTMP URL for this baby
 	streamURL := app.getLocalStreamURL(babyUID)
@@ -470,13 +916,25 @@ func (app *App) autoStartStreaming(babyUID string, conn *client.WebsocketConnect
 		Msg("Auto-starting RTMP streaming and HLS transcoding")
 	
 	// Start RTMP streaming first
-	requestLocalStreaming(babyUID, streamURL, client.Streaming_STARTED, conn, app.BabyStateManager)
+	requestLocalStreaming(babyUID, streamURL, app.streamIdentifier(), client.Streaming_STARTED, conn, app.BabyStateManager)
 	
 	// Start HLS transcoding for instant playback
 	if app.HLSManager != nil {
-		// Give RTMP stream a moment to establish before starting HLS transcoding
+		// Wait for the RTMP stream to establish before starting HLS transcoding
 		go func() {
-			time.Sleep(3 * time.Second)
+			app.waitForStreamAlive(babyUID)
+
+			// Transcoder kept running through a disconnect within the grace period
+			if transcoder, exists := app.HLSManager.GetTranscoder(babyUID); exists && transcoder.IsRunning() {
+				log.Debug().Str("baby_uid", babyUID).Msg("HLS transcoding already running, not restarting it")
+				return
+			}
+
+			// Started once the camera wakes up, see applyStandbyToTranscoding
+			if isInStandby(app.BabyStateManager.GetBabyState(babyUID)) {
+				log.Info().Str("baby_uid", babyUID).Msg("Camera is in standby, HLS transcoding starts once it wakes up")
+				return
+			}
 			
 			if err := app.HLSManager.StartTranscoding(babyUID, streamURL); err != nil {
 				log.Error().
@@ -492,8 +950,95 @@ func (app *App) autoStartStreaming(babyUID string, conn *client.WebsocketConnect
 	}
 }
 
+// waitForStreamAlive blocks until the RTMP stream of the baby is reported alive (or the timeout expires)
+// and then waits for the configured HLS lead time, so that FFmpeg does not connect to a not-yet-publishing stream
+func (app *App) waitForStreamAlive(babyUID string) {
+	aliveC := make(chan struct{})
+	var once sync.Once
+
+	unsubscribe := app.BabyStateManager.Subscribe(func(updatedBabyUID string, state baby.State) {
+		if updatedBabyUID == babyUID && state.StreamState != nil && *state.StreamState == baby.StreamState_Alive {
+			once.Do(func() { close(aliveC) })
+		}
+	})
+	defer unsubscribe()
+
+	if app.BabyStateManager.GetBabyState(babyUID).GetStreamState() == baby.StreamState_Alive {
+		once.Do(func() { close(aliveC) })
+	}
+
+	select {
+	case <-aliveC:
+		log.Debug().Str("baby_uid", babyUID).Msg("RTMP stream is alive, starting HLS transcoding after lead time")
+	case <-time.After(app.Opts.RTMP.HLSStartTimeout):
+		log.Warn().
+			Str("baby_uid", babyUID).
+			Dur("timeout", app.Opts.RTMP.HLSStartTimeout).
+			Msg("RTMP stream did not become alive in time, starting HLS transcoding anyway")
+	}
+
+	time.Sleep(app.Opts.RTMP.HLSStartDelay)
+}
+
+// scheduleAutoStopStreaming stops streaming once the disconnect grace period passes without the WebSocket reconnecting
+// Brief network blips then don't tear down and re-request the stream.
+func (app *App) scheduleAutoStopStreaming(babyUID string, conn client.Connection) {
+	gracePeriod := app.Opts.RTMP.DisconnectGracePeriod
+	if gracePeriod <= 0 {
+		app.autoStopStreaming(babyUID, conn)
+		return
+	}
+
+	cancelC := make(chan struct{})
+
+	app.pendingStreamStopsMutex.Lock()
+	if previous, ok := app.pendingStreamStops[babyUID]; ok {
+		close(previous)
+	}
+	app.pendingStreamStops[babyUID] = cancelC
+	app.pendingStreamStopsMutex.Unlock()
+
+	log.Info().Str("baby_uid", babyUID).Dur("grace_period", gracePeriod).Msg("WebSocket disconnected, waiting for reconnect before stopping streaming")
+
+	go func() {
+		select {
+		case <-cancelC:
+			return
+		case <-time.After(gracePeriod):
+		}
+
+		app.pendingStreamStopsMutex.Lock()
+		if app.pendingStreamStops[babyUID] != cancelC {
+			// Cancelled while the timer fired
+			app.pendingStreamStopsMutex.Unlock()
+			return
+		}
+		delete(app.pendingStreamStops, babyUID)
+		app.pendingStreamStopsMutex.Unlock()
+
+		app.autoStopStreaming(babyUID, conn)
+	}()
+}
+
+// cancelPendingStreamStop cancels streaming teardown waiting for the grace period, returns whether there was one
+func (app *App) cancelPendingStreamStop(babyUID string) bool {
+	app.pendingStreamStopsMutex.Lock()
+	defer app.pendingStreamStopsMutex.Unlock()
+
+	cancelC, ok := app.pendingStreamStops[babyUID]
+	if !ok {
+		return false
+	}
+
+	close(cancelC)
+	delete(app.pendingStreamStops, babyUID)
+	log.Info().Str("baby_uid", babyUID).Msg("WebSocket reconnected within the grace period, streaming is kept")
+
+	return true
+}
+
 // autoStopStreaming gracefully stops RTMP streaming and HLS transcoding when WebSocket disconnects
-func (app *App) autoStopStreaming(babyUID string, conn *client.WebsocketConnection) {
+func (app *App) autoStopStreaming(babyUID string, conn client.Connection) {
 	// Get the RTMP URL for this baby
 	streamURL := app.getLocalStreamURL(babyUID)
 	if streamURL == "" {
@@ -513,7 +1058,7 @@ func (app *App) autoStopStreaming(babyUID string, conn *client.WebsocketConnecti
 				log.Debug().Interface("error", r).Msg("Expected error stopping stream on dead connection")
 			}
 		}()
-		requestLocalStreaming(babyUID, streamURL, client.Streaming_STOPPED, conn, app.BabyStateManager)
+		requestLocalStreaming(babyUID, streamURL, app.streamIdentifier(), client.Streaming_STOPPED, conn, app.BabyStateManager)
 	}()
 	
 	// Stop HLS transcoding to prevent orphaned processes
@@ -526,19 +1071,123 @@ func (app *App) autoStopStreaming(babyUID string, conn *client.WebsocketConnecti
 	app.BabyStateManager.Update(babyUID, *baby.NewState().SetStreamState(baby.StreamState_Unhealthy))
 }
 
+// isInStandby returns whether the camera is in standby (sleep mode), reported by the standby state or the device info
+func isInStandby(babyState *baby.State) bool {
+	return babyState.GetStandby() || (babyState.DeviceInfo != nil && boolValue(babyState.DeviceInfo.SleepMode))
+}
+
+// applyStandbyToTranscoding pauses HLS transcoding when the camera enters standby and resumes it when it wakes up
+// Follows the current state rather than the update, so pauses and resumes racing each other settle on the latest one.
+// Auto-start skipped during standby is caught up on waking up.
+func (app *App) applyStandbyToTranscoding(babyUID string) {
+	if app.HLSManager == nil {
+		return
+	}
+
+	if isInStandby(app.BabyStateManager.GetBabyState(babyUID)) {
+		if app.HLSManager.PauseTranscoding(babyUID) {
+			log.Info().Str("baby_uid", babyUID).Msg("Paused HLS transcoding while the camera is in standby")
+		}
+		return
+	}
+
+	if resumed, err := app.HLSManager.ResumeTranscoding(babyUID); err != nil {
+		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to resume HLS transcoding after standby")
+		return
+	} else if resumed {
+		log.Info().Str("baby_uid", babyUID).Msg("Resumed HLS transcoding after standby")
+		return
+	}
+
+	streamURL := app.getLocalStreamURL(babyUID)
+	if transcoder, exists := app.HLSManager.GetTranscoder(babyUID); (exists && transcoder.IsRunning()) || streamURL == "" || app.isStreamReleased(babyUID) {
+		return
+	}
+
+	if err := app.HLSManager.StartTranscoding(babyUID, streamURL); err != nil {
+		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to start HLS transcoding after standby")
+	} else {
+		log.Info().Str("baby_uid", babyUID).Msg("Started HLS transcoding after standby")
+	}
+}
+
+// seedSensorStateFromHistory populates the state manager with the most recent stored sensor readings.
+// Seeded values are marked stale until the camera pushes fresh sensor data.
+func (app *App) seedSensorStateFromHistory() {
+	if !app.HistoryTracker.IsEnabled() {
+		return
+	}
+
+	for _, babyInfo := range app.getBabies() {
+		currentState := app.BabyStateManager.GetBabyState(babyInfo.UID)
+		if currentState.TemperatureMilli != nil || currentState.HumidityMilli != nil {
+			continue
+		}
+
+		reading, err := app.HistoryTracker.GetLatestReading(babyInfo.UID)
+		if err != nil {
+			log.Warn().Err(err).Str("baby_uid", babyInfo.UID).Msg("Failed to load latest sensor reading")
+			continue
+		}
+		if reading == nil {
+			continue
+		}
+
+		stateUpdate := baby.NewState()
+		if reading.TemperatureCelsius != nil {
+			stateUpdate.SetTemperatureMilli(int32(math.Round(*reading.TemperatureCelsius * 1000)))
+		}
+		if reading.HumidityPercent != nil {
+			stateUpdate.SetHumidityMilli(int32(math.Round(*reading.HumidityPercent * 1000)))
+		}
+		if reading.IsNight != nil {
+			stateUpdate.SetIsNight(*reading.IsNight)
+		}
+		stateUpdate.SetSensorDataStale(true)
+		stateUpdate.SetSensorDataTimestamp(reading.Timestamp)
+
+		app.BabyStateManager.Update(babyInfo.UID, *stateUpdate)
+
+		log.Info().
+			Str("baby_uid", babyInfo.UID).
+			Int64("reading_timestamp", reading.Timestamp).
+			Msg("Restored last known sensor state from history")
+	}
+}
+
 // setupHistoryTracking configures historical data tracking for state changes
 func (app *App) setupHistoryTracking() {
-	if !app.HistoryTracker.IsEnabled() {
+	historyEnabled := app.HistoryTracker.IsEnabled()
+	if !historyEnabled {
 		log.Debug().Msg("Historical tracking disabled")
-		return
+		if app.Notifier == nil && app.MQTTConnection == nil {
+			return
+		}
 	}
 
 	// Set up callback to track state changes
 	app.BabyStateManager.SetHistoryCallback(func(babyUID string, state baby.State) {
-		// Track sensor data (temperature, humidity, night mode)
-		if state.TemperatureMilli != nil || state.HumidityMilli != nil || state.IsNight != nil {
-			if err := app.HistoryTracker.TrackSensorData(babyUID, state); err != nil {
-				log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to track sensor data")
+		// Stream health transitions are recorded and propagated like the camera events
+		app.dispatchStreamEvents(babyUID, state, time.Now())
+
+		// Threshold notifications share the event path with history tracking; values restored from history are not new readings
+		if app.Notifier != nil && !state.GetSensorDataStale() && (state.TemperatureMilli != nil || state.HumidityMilli != nil) {
+			app.checkSensorThresholds(babyUID)
+		}
+
+		if !historyEnabled {
+			return
+		}
+
+		// Alerts are recorded whether anyone looks at them or not
+		app.syncDeviceAlerts(babyUID, time.Now())
+
+		// Track sensor data (temperature, humidity, night mode); values restored from history are already stored
+		if !state.GetSensorDataStale() && (state.TemperatureMilli != nil || state.HumidityMilli != nil || state.IsNight != nil) {
+			if sample, store := app.sensorSampler.Sample(babyUID, state, time.Now()); store {
+				if err := app.HistoryTracker.TrackSensorData(babyUID, sample); err != nil {
+					log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to track sensor data")
+				}
 			}
 		}
 
@@ -571,6 +1220,10 @@ func (app *App) setupHistoryTracking() {
 		}
 	})
 
+	if !historyEnabled {
+		return
+	}
+
 	log.Info().Msg("Historical data tracking enabled")
 
 	// Set up periodic cleanup if enabled
@@ -585,7 +1238,7 @@ func (app *App) setupHistoryCleanup() {
 		return
 	}
 
-	app.mainContext.RunAsChild(func(childCtx utils.GracefulContext) {
+	app.runAsChild(app.mainContext, "history cleanup", func(childCtx utils.GracefulContext) {
 		ticker := time.NewTicker(24 * time.Hour) // Run cleanup daily
 		defer ticker.Stop()
 
@@ -608,32 +1261,62 @@ func (app *App) setupHistoryCleanup() {
 }
 
 // startStreamingRetryMonitor continuously monitors and retries failed streaming connections
+// Checks back off while retries keep failing and the monitor stops once nothing needed a retry for the idle timeout,
+// a later stream request failure starts it again (see runWebsocket).
 func (app *App) startStreamingRetryMonitor(babyUID string, ctx utils.GracefulContext) {
-	retryInterval := 60 * time.Second // Retry every 60 seconds
-	ticker := time.NewTicker(retryInterval)
-	defer ticker.Stop()
+	if !app.streamRetryMonitors.start(babyUID, ctx.Done()) {
+		return
+	}
+	defer app.streamRetryMonitors.stop(babyUID, ctx.Done())
+
+	opts := app.Opts.StreamRetry
 
 	log.Info().
 		Str("baby_uid", babyUID).
-		Dur("retry_interval", retryInterval).
+		Dur("retry_interval", opts.Interval).
+		Dur("max_retry_interval", opts.MaxInterval).
 		Msg("Starting streaming retry monitor")
 
+	retries := 0
+	lastNeeded := time.Now()
+
 	for {
+		delay := streamRetryDelay(opts, retries, rand.Float64())
+		app.streamRetryMonitors.setNextRetry(babyUID, ctx.Done(), time.Now().Add(delay))
+		timer := time.NewTimer(delay)
+
 		select {
-		case <-ticker.C:
+		case <-timer.C:
 			// Check if we should retry streaming
 			if app.shouldRetryStreaming(babyUID) {
+				lastNeeded = time.Now()
+
 				conn := app.getConnection(babyUID)
 				if conn != nil {
 					log.Info().
 						Str("baby_uid", babyUID).
+						Int("retry", retries+1).
 						Msg("Retrying streaming connection due to previous failure")
-					
+
+					retries++
 					go app.retryStreaming(babyUID, conn)
 				}
+				continue
+			}
+
+			// Streaming recovered (or isn't wanted), the next failure starts with the base interval again
+			retries = 0
+
+			if opts.IdleTimeout > 0 && time.Since(lastNeeded) >= opts.IdleTimeout && app.streamRetryMonitors.stopIfIdle(babyUID, ctx.Done()) {
+				log.Info().
+					Str("baby_uid", babyUID).
+					Dur("idle_timeout", opts.IdleTimeout).
+					Msg("Streaming retry monitor idle, stopping until streaming fails again")
+				return
 			}
 
 		case <-ctx.Done():
+			timer.Stop()
 			log.Info().
 				Str("baby_uid", babyUID).
 				Msg("Streaming retry monitor stopped")
@@ -645,7 +1328,12 @@ func (app *App) startStreamingRetryMonitor(babyUID string, ctx utils.GracefulCon
 // shouldRetryStreaming determines if we should retry streaming for a baby
 func (app *App) shouldRetryStreaming(babyUID string) bool {
 	// Only retry if RTMP auto-start is enabled
-	if app.Opts.RTMP == nil || !app.Opts.RTMP.AutoStart {
+	if app.Opts.RTMP == nil || !app.Opts.RTMP.IsAutoStartEnabled(babyUID) {
+		return false
+	}
+
+	// Stream slot was deliberately released for another camera
+	if app.isStreamReleased(babyUID) {
 		return false
 	}
 
@@ -677,14 +1365,14 @@ func (app *App) retryStreaming(babyUID string, conn *client.WebsocketConnection)
 	app.BabyStateManager.Update(babyUID, *baby.NewState().SetStreamRequestState(baby.StreamRequestState_NotRequested))
 
 	// Retry RTMP streaming
-	requestLocalStreaming(babyUID, streamURL, client.Streaming_STARTED, conn, app.BabyStateManager)
+	requestLocalStreaming(babyUID, streamURL, app.streamIdentifier(), client.Streaming_STARTED, conn, app.BabyStateManager)
 
 	// Start HLS transcoding if not already running
 	if app.HLSManager != nil {
 		if transcoder, exists := app.HLSManager.GetTranscoder(babyUID); !exists || !transcoder.IsRunning() {
-			// Give RTMP stream a moment to establish before starting HLS transcoding
+			// Wait for the RTMP stream to establish before starting HLS transcoding
 			go func() {
-				time.Sleep(3 * time.Second)
+				app.waitForStreamAlive(babyUID)
 				
 				if err := app.HLSManager.StartTranscoding(babyUID, streamURL); err != nil {
 					log.Error().
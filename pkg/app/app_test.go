@@ -0,0 +1,100 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/indiefan/home_assistant_nanit/pkg/session"
+	"github.com/indiefan/home_assistant_nanit/pkg/streaming"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoStopStreamingWaitsForGracePeriod(t *testing.T) {
+	app := &App{
+		Opts:               Opts{RTMP: &RTMPOpts{PublicAddr: "localhost:1935", AutoStart: true, DisconnectGracePeriod: 50 * time.Millisecond}},
+		BabyStateManager:   baby.NewStateManager(),
+		pendingStreamStops: make(map[string]chan struct{}),
+	}
+	app.BabyStateManager.Update("baby1", *baby.NewState().SetStreamState(baby.StreamState_Alive))
+
+	// Reconnect within the grace period keeps the stream
+	app.scheduleAutoStopStreaming("baby1", newFakeConnection())
+	assert.True(t, app.cancelPendingStreamStop("baby1"))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, baby.StreamState_Alive, app.BabyStateManager.GetBabyState("baby1").GetStreamState())
+	assert.False(t, app.cancelPendingStreamStop("baby1"))
+
+	// Stream is torn down once the grace period passes
+	app.scheduleAutoStopStreaming("baby1", newFakeConnection())
+	assert.Equal(t, baby.StreamState_Alive, app.BabyStateManager.GetBabyState("baby1").GetStreamState())
+	assert.Eventually(t, func() bool {
+		return app.BabyStateManager.GetBabyState("baby1").GetStreamState() == baby.StreamState_Unhealthy
+	}, time.Second, 10*time.Millisecond)
+	assert.False(t, app.cancelPendingStreamStop("baby1"))
+}
+
+func TestGetBabiesReturnsCopy(t *testing.T) {
+	sessionStore := session.NewSessionStore()
+	sessionStore.Session.Babies = append([]baby.Baby{}, testBabies...)
+
+	// Read through the client while it owns the session, and directly without it
+	for _, app := range []*App{
+		{SessionStore: sessionStore, RestClient: &client.NanitClient{SessionStore: sessionStore}},
+		{SessionStore: sessionStore},
+	} {
+		babies := app.getBabies()
+		assert.Equal(t, testBabies, babies)
+
+		babies[0].Name = "Changed"
+		assert.Equal(t, testBabies[0].Name, sessionStore.Session.Babies[0].Name)
+	}
+}
+
+func TestApplyStandbyToTranscodingFollowsSleepMode(t *testing.T) {
+	// FFmpeg stand-in that runs until stopped
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "ffmpeg"), []byte("#!/bin/sh\nexec sleep 30\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	app := &App{
+		Opts:             Opts{RTMP: &RTMPOpts{PublicAddr: "localhost:1935", AutoStart: true}},
+		BabyStateManager: baby.NewStateManager(),
+		HLSManager:       streaming.NewHLSManager(t.TempDir()),
+	}
+	defer app.HLSManager.StopAll()
+
+	if err := app.HLSManager.StartTranscoding("baby1", app.getLocalStreamURL("baby1")); err != nil {
+		t.Skipf("fake ffmpeg not runnable: %v", err)
+	}
+
+	setSleepMode := func(enabled bool) {
+		app.BabyStateManager.Update("baby1", *baby.NewState().SetDeviceInfo(&baby.DeviceInfo{SleepMode: &enabled}))
+		app.applyStandbyToTranscoding("baby1")
+	}
+
+	// Sleep mode reported by the device info alone pauses the transcoder
+	setSleepMode(true)
+	transcoder, _ := app.HLSManager.GetTranscoder("baby1")
+	assert.True(t, transcoder.IsPaused())
+
+	setSleepMode(false)
+	transcoder, _ = app.HLSManager.GetTranscoder("baby1")
+	assert.False(t, transcoder.IsPaused())
+	assert.True(t, transcoder.IsRunning())
+
+	// Transcoding not started during standby (auto-start skipped it) starts on waking up
+	app.HLSManager.StopTranscoding("baby1")
+	setSleepMode(true)
+	_, exists := app.HLSManager.GetTranscoder("baby1")
+	assert.False(t, exists)
+
+	setSleepMode(false)
+	transcoder, exists = app.HLSManager.GetTranscoder("baby1")
+	assert.True(t, exists && transcoder.IsRunning())
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	apperrors "github.com/indiefan/home_assistant_nanit/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// calibrationRequest - sensor calibration offsets of a baby, offsets left out are reset to 0
+type calibrationRequest struct {
+	TempOffset     float64 `json:"temp_offset"`     // Celsius
+	HumidityOffset float64 `json:"humidity_offset"` // Percentage points
+}
+
+// API handler for the sensor calibration of a baby: /api/babies/{baby_uid}/calibration
+// The offsets are added to the temperature/humidity reported by the camera before they are displayed and recorded.
+// PUT body: {"temp_offset": -1.5, "humidity_offset": 2}, {} removes the calibration
+func handleBabyCalibrationAPI(w http.ResponseWriter, r *http.Request, app *App, babyUID string) {
+	if r.Method != "GET" && r.Method != "PUT" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	if findBaby(app.getBabies(), babyUID) == nil {
+		writeBabyNotFound(w, babyUID)
+		return
+	}
+
+	if r.Method == "PUT" {
+		var req calibrationRequest
+		if !app.decodeJSON(w, r, &req) {
+			return
+		}
+
+		if math.Abs(req.TempOffset) > baby.MaxTempOffset {
+			writeError(w, apperrors.NewValidationError("invalid_calibration", fmt.Sprintf("temp_offset must be between -%g and %g", baby.MaxTempOffset, baby.MaxTempOffset), nil).WithContext("temp_offset", req.TempOffset), http.StatusBadRequest)
+			return
+		}
+		if math.Abs(req.HumidityOffset) > baby.MaxHumidityOffset {
+			writeError(w, apperrors.NewValidationError("invalid_calibration", fmt.Sprintf("humidity_offset must be between -%g and %g", baby.MaxHumidityOffset, baby.MaxHumidityOffset), nil).WithContext("humidity_offset", req.HumidityOffset), http.StatusBadRequest)
+			return
+		}
+
+		config := app.DisplayConfig.Get(babyUID)
+		config.TempOffset = req.TempOffset
+		config.HumidityOffset = req.HumidityOffset
+
+		if err := app.DisplayConfig.Set(babyUID, config); err != nil {
+			log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to save calibration")
+			writeError(w, apperrors.NewStorageError("display_config_save_failed", "Failed to save calibration", err), http.StatusInternalServerError)
+			return
+		}
+
+		app.recalibrateSensors(babyUID, config)
+
+		log.Info().
+			Str("baby_uid", babyUID).
+			Float64("temp_offset", config.TempOffset).
+			Float64("humidity_offset", config.HumidityOffset).
+			Msg("Sensor calibration updated")
+	}
+
+	config := app.DisplayConfig.Get(babyUID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"baby_uid":        babyUID,
+		"temp_offset":     config.TempOffset,
+		"humidity_offset": config.HumidityOffset,
+		"calibrated":      config.IsCalibrated(),
+	})
+}
+
+// recalibrateSensors applies the calibration to the current sensor values, so the change shows without waiting for
+// the next reading of the camera. Values restored from history carry no raw values and are left unchanged.
+func (app *App) recalibrateSensors(babyUID string, config baby.DisplayConfig) {
+	babyState := app.BabyStateManager.GetBabyState(babyUID)
+
+	stateUpdate := baby.State{}
+	if babyState.RawTemperatureMilli != nil {
+		stateUpdate.SetTemperatureMilli(*babyState.RawTemperatureMilli)
+	}
+	if babyState.RawHumidityMilli != nil {
+		stateUpdate.SetHumidityMilli(*babyState.RawHumidityMilli)
+	}
+
+	if stateUpdate.TemperatureMilli == nil && stateUpdate.HumidityMilli == nil {
+		return
+	}
+
+	config.CalibrateSensors(&stateUpdate)
+	app.BabyStateManager.Update(babyUID, stateUpdate)
+}
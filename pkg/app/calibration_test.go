@@ -0,0 +1,87 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/indiefan/home_assistant_nanit/pkg/session"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBabyCalibrationAPI(t *testing.T) {
+	sessionStore := session.NewSessionStore()
+	sessionStore.Session.Babies = testBabies
+
+	app := &App{
+		SessionStore:     sessionStore,
+		BabyStateManager: baby.NewStateManager(),
+		DisplayConfig:    baby.NewDisplayConfigStore(filepath.Join(t.TempDir(), "display_config.json")),
+	}
+	assert.NoError(t, app.DisplayConfig.Set("baby1", baby.DisplayConfig{Name: "Nursery"}))
+
+	sensorData := []*client.SensorData{
+		{SensorType: client.SensorType_TEMPERATURE.Enum(), ValueMilli: utils.ConstRefInt32(22400)},
+		{SensorType: client.SensorType_HUMIDITY.Enum(), ValueMilli: utils.ConstRefInt32(45000)},
+	}
+	processSensorData("baby1", sensorData, app.DisplayConfig.Get("baby1"), app.BabyStateManager)
+
+	put := func(path string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handleBabyResourceAPI(w, httptest.NewRequest("PUT", path, strings.NewReader(body)), app)
+		return w
+	}
+
+	w := put("/api/babies/unknown/calibration", `{}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = put("/api/babies/baby1/calibration", `{"temp_offset":-12}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"invalid_calibration"`)
+
+	w = put("/api/babies/baby1/calibration", `{"temp_offset":-1.5,"humidity_offset":2}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, -1.5, response["temp_offset"])
+	assert.Equal(t, 2.0, response["humidity_offset"])
+	assert.Equal(t, true, response["calibrated"])
+
+	// Display config is kept and the current values are calibrated right away
+	assert.Equal(t, "Nursery", app.DisplayConfig.Get("baby1").Name)
+	state := app.BabyStateManager.GetBabyState("baby1")
+	assert.Equal(t, 20.9, state.GetTemperature())
+	assert.Equal(t, 47.0, state.GetHumidity())
+
+	// New readings are calibrated too
+	sensorData[0].ValueMilli = utils.ConstRefInt32(23000)
+	processSensorData("baby1", sensorData, app.DisplayConfig.Get("baby1"), app.BabyStateManager)
+	assert.Equal(t, 21.5, app.BabyStateManager.GetBabyState("baby1").GetTemperature())
+
+	// Status reports calibrated and raw values
+	w = httptest.NewRecorder()
+	handleStatusAPI(w, httptest.NewRequest("GET", "/api/status", nil), app.getBabies(), app.BabyStateManager, app.DisplayConfig, time.Minute, app.getMode())
+	assert.Contains(t, w.Body.String(), `"sensor_calibrated":true`)
+	assert.Contains(t, w.Body.String(), `"raw_temperature":23`)
+	assert.Contains(t, w.Body.String(), `"raw_humidity":45`)
+
+	// Display config updates don't reset the calibration
+	w = httptest.NewRecorder()
+	handleBabyDisplayConfigAPI(w, httptest.NewRequest("PUT", "/api/babies/config/baby1", strings.NewReader(`{"name":"Bedroom"}`)), app)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, -1.5, app.DisplayConfig.Get("baby1").TempOffset)
+
+	// Removing the calibration restores the camera's values
+	w = put("/api/babies/baby1/calibration", `{}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"calibrated":false`)
+	assert.Equal(t, 23.0, app.BabyStateManager.GetBabyState("baby1").GetTemperature())
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	apperrors "github.com/indiefan/home_assistant_nanit/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// camLogUploadTimeout - how long the camera logs endpoint waits for the tarball after the camera accepted the request
+const camLogUploadTimeout = 2 * time.Minute
+
+// camLogRequest - log upload requested from the camera of a baby, completed by the /log handler
+type camLogRequest struct {
+	babyUID  string
+	uploaded chan string // Receives the path of the saved tarball
+}
+
+// camLogRequests - pending log uploads by request token, the zero value is ready to use
+// The camera uploads the tarball in a separate HTTP request, the token in the upload URL ties it to the request.
+type camLogRequests struct {
+	mutex    sync.Mutex
+	requests map[string]*camLogRequest
+}
+
+// start registers a log request of the baby, fails if one is already pending
+func (c *camLogRequests) start(babyUID string) (string, *camLogRequest, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, request := range c.requests {
+		if request.babyUID == babyUID {
+			return "", nil, false
+		}
+	}
+
+	tokenBytes := make([]byte, 16)
+	rand.Read(tokenBytes)
+	token := hex.EncodeToString(tokenBytes)
+
+	if c.requests == nil {
+		c.requests = make(map[string]*camLogRequest)
+	}
+
+	request := &camLogRequest{babyUID: babyUID, uploaded: make(chan string, 1)}
+	c.requests[token] = request
+	return token, request, true
+}
+
+// get returns the pending request of the token
+func (c *camLogRequests) get(token string) (*camLogRequest, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	request, ok := c.requests[token]
+	return request, ok
+}
+
+// finish forgets the request of the token
+func (c *camLogRequests) finish(token string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.requests, token)
+}
+
+// camLogUploadFilename returns where the tarball of an upload is saved, named after the baby if it was requested by the app
+func camLogUploadFilename(logDir string, babyUID string, now time.Time) string {
+	name := "camlogs-" + now.Format(time.RFC3339Nano) + ".tar.gz"
+	if babyUID != "" {
+		name = "camlogs-" + babyUID + "-" + now.Format(time.RFC3339Nano) + ".tar.gz"
+	}
+
+	return filepath.Join(logDir, name)
+}
+
+// API handler asking the camera to upload its logs: POST /api/camera-logs/{baby_uid}
+// The camera uploads the tarball to /log on its own, the handler waits for it and returns the saved file.
+// If the upload takes longer than camLogUploadTimeout, 202 is returned and the file is still saved when it arrives.
+func handleCameraLogsAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "POST" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	babyUID := strings.TrimPrefix(r.URL.Path, "/api/camera-logs/")
+	if babyUID == "" {
+		writeBabyUIDRequired(w)
+		return
+	}
+
+	if findBaby(app.getBabies(), babyUID) == nil {
+		writeBabyNotFound(w, babyUID)
+		return
+	}
+
+	conn := app.getConnection(babyUID)
+	if conn == nil {
+		if writeMonitoringNotStarted(w, app) {
+			return
+		}
+		writeError(w, apperrors.NewNetworkError("camera_not_connected", "WebSocket not connected", nil).WithContext("baby_uid", babyUID), http.StatusServiceUnavailable)
+		return
+	}
+
+	app.requestCameraLogs(w, r, babyUID, conn, camLogUploadTimeout)
+}
+
+// requestCameraLogs sends the GET_LOGS request and waits up to uploadTimeout for the camera to upload the tarball
+func (app *App) requestCameraLogs(w http.ResponseWriter, r *http.Request, babyUID string, conn client.Connection, uploadTimeout time.Duration) {
+	token, request, ok := app.camLogRequests.start(babyUID)
+	if !ok {
+		writeError(w, apperrors.NewValidationError("camera_logs_pending", "Camera logs were already requested and haven't arrived yet", nil).WithContext("baby_uid", babyUID), http.StatusConflict)
+		return
+	}
+	defer app.camLogRequests.finish(token)
+
+	// The camera has to be able to reach the app under this URL, set NANIT_PUBLIC_BASE_URL if it can't
+	uploadURL := absoluteURL(r, app, "/log?request="+url.QueryEscape(token))
+	log.Info().Str("baby_uid", babyUID).Str("url", uploadURL).Msg("Requesting camera logs")
+
+	awaitResponse := conn.SendRequest(client.RequestType_GET_LOGS, &client.Request{
+		GetLogs: &client.GetLogs{Url: &uploadURL},
+	})
+
+	res, err := awaitResponse(client.RequestTimeout(client.RequestType_GET_LOGS))
+	if res == nil {
+		if err != nil && err.Error() == "Request timeout" {
+			writeError(w, apperrors.NewNetworkError("camera_request_timeout", "Camera did not respond in time", err).WithContext("baby_uid", babyUID), http.StatusGatewayTimeout)
+			return
+		}
+		writeError(w, apperrors.NewExternalError("camera_request_failed", "Failed to send the request to the camera", err).WithContext("baby_uid", babyUID), http.StatusBadGateway)
+		return
+	}
+
+	if res.GetStatusCode() != http.StatusOK {
+		writeError(w, apperrors.NewExternalError("camera_request_failed", "Camera refused to upload its logs", nil).
+			WithContext("baby_uid", babyUID).
+			WithContext("status_code", res.GetStatusCode()).
+			WithContext("status_message", res.GetStatusMessage()), http.StatusBadGateway)
+		return
+	}
+
+	timer := time.NewTimer(uploadTimeout)
+	defer timer.Stop()
+
+	select {
+	case filename := <-request.uploaded:
+		response := map[string]interface{}{
+			"baby_uid": babyUID,
+			"status":   "uploaded",
+			"filename": filepath.Base(filename),
+		}
+		if info, err := os.Stat(filename); err == nil {
+			response["size_bytes"] = info.Size()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+
+	case <-timer.C:
+		log.Warn().Str("baby_uid", babyUID).Dur("timeout", uploadTimeout).Msg("Camera logs didn't arrive in time, they are still saved once uploaded")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"baby_uid": babyUID,
+			"status":   "pending",
+			"message":  "Camera accepted the request but hasn't uploaded its logs yet, they are saved to the log directory once they arrive",
+		})
+
+	case <-r.Context().Done():
+	}
+}
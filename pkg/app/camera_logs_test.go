@@ -0,0 +1,89 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestCameraLogs(t *testing.T) {
+	logDir := t.TempDir()
+	app := &App{Opts: Opts{
+		PublicBaseURL:   "http://192.168.1.10:8080/",
+		DataDirectories: DataDirectories{LogDir: logDir},
+	}}
+	conn := newFakeConnection()
+
+	request := func(timeout time.Duration) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		app.requestCameraLogs(w, httptest.NewRequest("POST", "/api/camera-logs/baby1", nil), "baby1", conn, timeout)
+
+		var response map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&response)
+		return w, response
+	}
+
+	// Camera uploads the tarball after answering the request
+	conn.Respond(client.RequestType_GET_LOGS, func(req *client.Request) *client.Response {
+		uploadURL, err := url.Parse(req.GetLogs.GetUrl())
+		assert.NoError(t, err)
+		assert.Equal(t, "192.168.1.10:8080", uploadURL.Host)
+		assert.Equal(t, "/log", uploadURL.Path)
+
+		go handleCamLogUpload(httptest.NewRecorder(), httptest.NewRequest("POST", uploadURL.RequestURI(), strings.NewReader("logs")), app)
+		return &client.Response{}
+	})
+
+	w, response := request(5 * time.Second)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "uploaded", response["status"])
+	assert.Equal(t, float64(4), response["size_bytes"])
+	assert.True(t, strings.HasPrefix(response["filename"].(string), "camlogs-baby1-"))
+	assert.FileExists(t, filepath.Join(logDir, response["filename"].(string)))
+
+	// Camera accepts the request but doesn't upload in time
+	conn.Respond(client.RequestType_GET_LOGS, func(*client.Request) *client.Response {
+		return &client.Response{}
+	})
+	w, response = request(10 * time.Millisecond)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "pending", response["status"])
+
+	// Camera refuses
+	conn.Respond(client.RequestType_GET_LOGS, func(*client.Request) *client.Response {
+		return &client.Response{StatusCode: utils.ConstRefInt32(500)}
+	})
+	w, _ = request(time.Second)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+
+	// Only one pending request per baby
+	_, _, ok := app.camLogRequests.start("baby1")
+	assert.True(t, ok)
+	w, response = request(time.Second)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "camera_logs_pending", response["code"])
+}
+
+func TestCamLogUploadWithoutRequest(t *testing.T) {
+	logDir := t.TempDir()
+	app := &App{Opts: Opts{DataDirectories: DataDirectories{LogDir: logDir}}}
+
+	// Unknown tokens are saved like uploads the app didn't request
+	w := httptest.NewRecorder()
+	handleCamLogUpload(w, httptest.NewRequest("POST", "/log?request=unknown", strings.NewReader("logs")), app)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	files, _ := filepath.Glob(filepath.Join(logDir, camLogPattern))
+	if assert.Len(t, files, 1) {
+		assert.NotContains(t, filepath.Base(files[0]), "unknown")
+	}
+}
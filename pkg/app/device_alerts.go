@@ -0,0 +1,259 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	apperrors "github.com/indiefan/home_assistant_nanit/pkg/errors"
+	"github.com/indiefan/home_assistant_nanit/pkg/history"
+	"github.com/rs/zerolog/log"
+)
+
+// DeviceAlert represents an error or warning for the device
+// ID, FirstSeen and LastSeen are set only when the alert is persisted (historical tracking enabled).
+type DeviceAlert struct {
+	ID           int64  `json:"id,omitempty"`
+	Type         string `json:"type"` // "error" or "warning"
+	Message      string `json:"message"`
+	Category     string `json:"category"`
+	FirstSeen    int64  `json:"first_seen,omitempty"`
+	LastSeen     int64  `json:"last_seen,omitempty"`
+	Acknowledged bool   `json:"acknowledged"`
+}
+
+// deviceAlertRule - returns the alert of a single condition, or nil if it doesn't apply
+// The state and device info are never nil, unset fields have to be read through boolValue/stringValue.
+// streamID is the camera stream requested for the local relay.
+type deviceAlertRule func(state *baby.State, deviceInfo *baby.DeviceInfo, streamID client.StreamIdentifier) *DeviceAlert
+
+// deviceAlertRules - conditions checked by buildDeviceAlerts, in the order of the reported alerts
+var deviceAlertRules = []deviceAlertRule{
+	// Websocket connection issues
+	func(state *baby.State, deviceInfo *baby.DeviceInfo, streamID client.StreamIdentifier) *DeviceAlert {
+		if state.GetIsWebsocketAlive() {
+			return nil
+		}
+		return &DeviceAlert{Type: "error", Message: "Camera is disconnected from Nanit servers", Category: "connectivity"}
+	},
+
+	// Streaming errors reported by the camera
+	func(state *baby.State, deviceInfo *baby.DeviceInfo, streamID client.StreamIdentifier) *DeviceAlert {
+		message := stringValue(deviceInfo.StreamingError)
+		if message == "" {
+			return nil
+		}
+		return &DeviceAlert{Type: "error", Message: message, Category: "streaming"}
+	},
+
+	// Stream state issues, a stream never reported is not an issue
+	func(state *baby.State, deviceInfo *baby.DeviceInfo, streamID client.StreamIdentifier) *DeviceAlert {
+		if state.StreamState == nil {
+			return nil
+		}
+
+		switch *state.StreamState {
+		case baby.StreamState_Unhealthy:
+			return &DeviceAlert{Type: "warning", Message: "Video streaming is experiencing issues", Category: "streaming"}
+		case baby.StreamState_Unknown:
+			return &DeviceAlert{Type: "warning", Message: "Video stream status unknown", Category: "streaming"}
+		}
+		return nil
+	},
+
+	// Connection limit issues (streaming blocked by too many mobile apps), other streams don't count against the limit
+	func(state *baby.State, deviceInfo *baby.DeviceInfo, streamID client.StreamIdentifier) *DeviceAlert {
+		if state.GetStreamRequestState() != baby.StreamRequestState_RequestFailed {
+			return nil
+		}
+		if streamID != client.StreamIdentifier_MOBILE {
+			_, message := streamBlockedError(streamID)
+			return &DeviceAlert{Type: "warning", Message: message, Category: "streaming"}
+		}
+		return &DeviceAlert{
+			Type:     "warning",
+			Message:  "Streaming blocked: Too many Nanit mobile apps connected. Close the official Nanit app on your phone/tablet to enable streaming here.",
+			Category: "connection_limit",
+		}
+	},
+
+	// Device warnings
+	func(state *baby.State, deviceInfo *baby.DeviceInfo, streamID client.StreamIdentifier) *DeviceAlert {
+		if !boolValue(deviceInfo.SleepMode) {
+			return nil
+		}
+		return &DeviceAlert{Type: "warning", Message: "Camera is in sleep mode", Category: "device_state"}
+	},
+
+	func(state *baby.State, deviceInfo *baby.DeviceInfo, streamID client.StreamIdentifier) *DeviceAlert {
+		if !boolValue(deviceInfo.UpgradeDownloaded) {
+			return nil
+		}
+		return &DeviceAlert{Type: "warning", Message: "Firmware update available for installation", Category: "firmware"}
+	},
+}
+
+// buildDeviceAlerts - returns the current alerts of a baby, nil state or device info are treated as all fields unset
+func buildDeviceAlerts(babyState *baby.State, streamID client.StreamIdentifier) []DeviceAlert {
+	state := babyState
+	if state == nil {
+		state = baby.NewState()
+	}
+
+	deviceInfo := state.DeviceInfo
+	if deviceInfo == nil {
+		deviceInfo = &baby.DeviceInfo{}
+	}
+
+	var alerts []DeviceAlert
+	for _, rule := range deviceAlertRules {
+		if alert := rule(state, deviceInfo, streamID); alert != nil {
+			alerts = append(alerts, *alert)
+		}
+	}
+
+	return alerts
+}
+
+func boolValue(value *bool) bool {
+	return value != nil && *value
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// deviceAlertSeenInterval - how often the last seen time of alerts which stay active is refreshed in the database
+const deviceAlertSeenInterval = time.Minute
+
+// deviceAlertSync - alerts last persisted by syncDeviceAlerts per baby, the zero value is ready to use
+type deviceAlertSync struct {
+	mutex sync.Mutex
+	last  map[string]syncedDeviceAlerts
+}
+
+// syncedDeviceAlerts - alerts of a baby as last persisted
+type syncedDeviceAlerts struct {
+	keys []history.AlertKey
+	at   time.Time
+}
+
+// syncDeviceAlerts - persists the current alerts of a baby, called on every state change of the baby
+// The database is written when the set of alerts changed, and every deviceAlertSeenInterval while alerts stay
+// active to move their last seen time. Computed from the current state under the lock, so concurrent state
+// change callbacks settle on the latest alerts.
+func (app *App) syncDeviceAlerts(babyUID string, now time.Time) {
+	app.deviceAlertSync.mutex.Lock()
+	defer app.deviceAlertSync.mutex.Unlock()
+
+	alerts := buildDeviceAlerts(app.BabyStateManager.GetBabyState(babyUID), app.streamIdentifier())
+	keys := make([]history.AlertKey, len(alerts))
+	for i, alert := range alerts {
+		keys[i] = history.AlertKey{AlertType: alert.Type, Category: alert.Category, Message: alert.Message}
+	}
+
+	if last, synced := app.deviceAlertSync.last[babyUID]; synced && slices.Equal(last.keys, keys) {
+		if len(keys) == 0 || now.Sub(last.at) < deviceAlertSeenInterval {
+			return
+		}
+	}
+
+	// Not remembered on failure, so the next state change retries
+	if _, err := app.HistoryTracker.SyncAlerts(babyUID, keys, now.Unix()); err != nil {
+		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to persist device alerts")
+		return
+	}
+
+	if app.deviceAlertSync.last == nil {
+		app.deviceAlertSync.last = make(map[string]syncedDeviceAlerts)
+	}
+	app.deviceAlertSync.last[babyUID] = syncedDeviceAlerts{keys: keys, at: now}
+}
+
+// trackedDeviceAlerts - adds the stored occurrences to the current alerts of a baby, drops acknowledged ones unless includeAcked
+// Read-only, alerts are persisted by syncDeviceAlerts. Alerts not persisted yet (or all of them when historical
+// tracking is disabled or the database fails) are returned as computed.
+func (app *App) trackedDeviceAlerts(babyUID string, alerts []DeviceAlert, includeAcked bool) []DeviceAlert {
+	if !app.HistoryTracker.IsEnabled() {
+		return alerts
+	}
+
+	stored, err := app.HistoryTracker.GetActiveAlerts(babyUID)
+	if err != nil {
+		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to load device alerts")
+		return alerts
+	}
+
+	occurrences := make(map[history.AlertKey]history.Alert, len(stored))
+	for _, alert := range stored {
+		occurrences[history.AlertKey{Category: alert.Category, Message: alert.Message}] = alert
+	}
+
+	var tracked []DeviceAlert
+	for _, alert := range alerts {
+		if occurrence, ok := occurrences[history.AlertKey{Category: alert.Category, Message: alert.Message}]; ok {
+			if occurrence.Acknowledged && !includeAcked {
+				continue
+			}
+
+			alert.ID = occurrence.ID
+			alert.FirstSeen = occurrence.FirstSeen
+			alert.LastSeen = occurrence.LastSeen
+			alert.Acknowledged = occurrence.Acknowledged
+		}
+
+		tracked = append(tracked, alert)
+	}
+
+	return tracked
+}
+
+// API handler acknowledging a device alert: POST /api/alerts/{id}/ack
+func handleAlertAckAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "POST" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	idStr, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/alerts/"), "/ack")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, apperrors.NewValidationError("invalid_alert_id", "Alert ID must be a positive integer", err).WithContext("alert_id", idStr), http.StatusBadRequest)
+		return
+	}
+
+	if !app.HistoryTracker.IsEnabled() {
+		writeHistoryDisabled(w)
+		return
+	}
+
+	alert, err := app.HistoryTracker.AcknowledgeAlert(id)
+	if errors.Is(err, history.ErrAlertNotFound) {
+		writeError(w, apperrors.NewValidationError("alert_not_found", "Alert not found", err).WithContext("alert_id", id), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Error().Err(err).Int64("alert_id", id).Msg("Failed to acknowledge alert")
+		writeError(w, apperrors.NewStorageError("alert_ack_failed", "Failed to acknowledge alert", err), http.StatusInternalServerError)
+		return
+	}
+
+	log.Info().Int64("alert_id", id).Str("baby_uid", alert.BabyUID).Str("category", alert.Category).Msg("Alert acknowledged")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alert)
+}
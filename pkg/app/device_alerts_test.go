@@ -0,0 +1,164 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/indiefan/home_assistant_nanit/pkg/history"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildDeviceAlertsAllNil(t *testing.T) {
+	disconnected := []DeviceAlert{{Type: "error", Message: "Camera is disconnected from Nanit servers", Category: "connectivity"}}
+
+	assert.Equal(t, disconnected, buildDeviceAlerts(nil, client.StreamIdentifier_MOBILE))
+	assert.Equal(t, disconnected, buildDeviceAlerts(baby.NewState(), client.StreamIdentifier_MOBILE))
+	assert.Equal(t, disconnected, buildDeviceAlerts(baby.NewState().SetDeviceInfo(&baby.DeviceInfo{}), client.StreamIdentifier_MOBILE))
+
+	// Device info stays unset, building alerts must not modify the state
+	state := baby.NewState().SetWebsocketAlive(true)
+	assert.Empty(t, buildDeviceAlerts(state, client.StreamIdentifier_MOBILE))
+	assert.Nil(t, state.DeviceInfo)
+}
+
+func TestBuildDeviceAlerts(t *testing.T) {
+	streamingError := "RTMP server unreachable"
+	emptyError := ""
+	enabled := true
+
+	state := baby.NewState().
+		SetWebsocketAlive(true).
+		SetStreamState(baby.StreamState_Unhealthy).
+		SetStreamRequestState(baby.StreamRequestState_RequestFailed).
+		SetDeviceInfo(&baby.DeviceInfo{
+			StreamingError:    &streamingError,
+			SleepMode:         &enabled,
+			UpgradeDownloaded: &enabled,
+		})
+
+	var categories []string
+	for _, alert := range buildDeviceAlerts(state, client.StreamIdentifier_MOBILE) {
+		categories = append(categories, alert.Category)
+	}
+	assert.Equal(t, []string{"streaming", "streaming", "connection_limit", "device_state", "firmware"}, categories)
+
+	// Only the mobile stream counts against the Nanit mobile app connection limit
+	categories = nil
+	for _, alert := range buildDeviceAlerts(state, client.StreamIdentifier_DVR) {
+		assert.NotContains(t, alert.Message, "Nanit app")
+		categories = append(categories, alert.Category)
+	}
+	assert.Equal(t, []string{"streaming", "streaming", "streaming", "device_state", "firmware"}, categories)
+
+	// Empty streaming error and alive stream raise nothing
+	state = baby.NewState().
+		SetWebsocketAlive(true).
+		SetStreamState(baby.StreamState_Alive).
+		SetDeviceInfo(&baby.DeviceInfo{StreamingError: &emptyError})
+	assert.Empty(t, buildDeviceAlerts(state, client.StreamIdentifier_MOBILE))
+}
+
+func TestDeviceInfoAlertAcknowledgment(t *testing.T) {
+	tracker, err := history.NewTracker(t.TempDir(), true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tracker.Close()
+
+	enabled := true
+	stateManager := baby.NewStateManager()
+	stateManager.Update("baby1", *baby.NewState().SetWebsocketAlive(true).SetDeviceInfo(&baby.DeviceInfo{UpgradeDownloaded: &enabled}))
+	app := &App{BabyStateManager: stateManager, HistoryTracker: tracker}
+
+	getAlerts := func(url string) []DeviceAlert {
+		w := httptest.NewRecorder()
+		handleDeviceInfoAPI(w, httptest.NewRequest("GET", url, nil), testBabies, app)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response DeviceInfoResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		return response.Alerts
+	}
+
+	// Reading doesn't persist, the alert is reported as computed until the state change callback records it
+	alerts := getAlerts("/api/device-info/baby1")
+	if !assert.Len(t, alerts, 1) {
+		return
+	}
+	assert.Zero(t, alerts[0].ID)
+
+	stored, err := tracker.GetActiveAlerts("baby1")
+	assert.NoError(t, err)
+	assert.Empty(t, stored)
+
+	now := time.Now()
+	app.syncDeviceAlerts("baby1", now)
+
+	alerts = getAlerts("/api/device-info/baby1")
+	if !assert.Len(t, alerts, 1) {
+		return
+	}
+	assert.Equal(t, "firmware", alerts[0].Category)
+	assert.NotZero(t, alerts[0].ID)
+	assert.False(t, alerts[0].Acknowledged)
+	assert.Equal(t, now.Unix(), alerts[0].LastSeen)
+
+	// Last seen time of an alert which stays active is refreshed, at most every deviceAlertSeenInterval
+	app.syncDeviceAlerts("baby1", now.Add(deviceAlertSeenInterval/2))
+	stored, err = tracker.GetActiveAlerts("baby1")
+	if assert.NoError(t, err) && assert.Len(t, stored, 1) {
+		assert.Equal(t, now.Unix(), stored[0].LastSeen)
+	}
+
+	app.syncDeviceAlerts("baby1", now.Add(deviceAlertSeenInterval))
+	stored, err = tracker.GetActiveAlerts("baby1")
+	if assert.NoError(t, err) && assert.Len(t, stored, 1) {
+		assert.Equal(t, alerts[0].ID, stored[0].ID)
+		assert.Equal(t, now.Unix(), stored[0].FirstSeen)
+		assert.Equal(t, now.Add(deviceAlertSeenInterval).Unix(), stored[0].LastSeen)
+	}
+
+	w := httptest.NewRecorder()
+	handleAlertAckAPI(w, httptest.NewRequest("POST", fmt.Sprintf("/api/alerts/%d/ack", alerts[0].ID), nil), app)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Empty(t, getAlerts("/api/device-info/baby1"))
+
+	acked := getAlerts("/api/device-info/baby1?include_acked=true")
+	if assert.Len(t, acked, 1) {
+		assert.Equal(t, alerts[0].ID, acked[0].ID)
+		assert.True(t, acked[0].Acknowledged)
+	}
+
+	// Cleared alerts are resolved once the state changes
+	disabled := false
+	stateManager.Update("baby1", *baby.NewState().SetDeviceInfo(&baby.DeviceInfo{UpgradeDownloaded: &disabled}))
+	app.syncDeviceAlerts("baby1", now.Add(deviceAlertSeenInterval))
+	stored, err = tracker.GetActiveAlerts("baby1")
+	assert.NoError(t, err)
+	assert.Empty(t, stored)
+
+	for path, code := range map[string]int{
+		"/api/alerts/999/ack": http.StatusNotFound,
+		"/api/alerts/abc/ack": http.StatusBadRequest,
+		"/api/alerts/1":       http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		handleAlertAckAPI(w, httptest.NewRequest("POST", path, nil), app)
+		assert.Equal(t, code, w.Code, path)
+	}
+
+	w = httptest.NewRecorder()
+	handleAlertAckAPI(w, httptest.NewRequest("GET", "/api/alerts/1/ack", nil), app)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	handleAlertAckAPI(w, httptest.NewRequest("POST", "/api/alerts/1/ack", nil), &App{HistoryTracker: &history.Tracker{}})
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,36 @@
+package app
+
+import (
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+)
+
+// deviceInfoRefreshCooldown - minimum time between two settings requests triggered by stale device info of a baby
+const deviceInfoRefreshCooldown = 30 * time.Second
+
+// deviceInfoAge returns the seconds since the camera last confirmed the device info (nil if it never did) and
+// whether the info is stale: older than maxAge (unless 0) or not confirmed since the current connection was established
+func deviceInfoAge(deviceInfo *baby.DeviceInfo, connectedSince *int64, maxAge time.Duration, now time.Time) (*int64, bool) {
+	if deviceInfo == nil || deviceInfo.LastUpdated == nil {
+		return nil, true
+	}
+
+	age := max(now.Unix()-*deviceInfo.LastUpdated, 0)
+	stale := (maxAge > 0 && age > int64(maxAge/time.Second)) || (connectedSince != nil && *deviceInfo.LastUpdated < *connectedSince)
+
+	return &age, stale
+}
+
+// requestDeviceInfoRefresh asks the camera for its settings (and with them the device info) again
+// Returns false if the camera isn't connected or a refresh was requested recently.
+func (app *App) requestDeviceInfoRefresh(babyUID string) bool {
+	conn := app.getConnection(babyUID)
+	if conn == nil || !app.deviceInfoRefresh.Allow(babyUID, "settings", time.Now()) {
+		return false
+	}
+
+	sendRequestAndLog(babyUID, conn, client.RequestType_GET_SETTINGS, &client.Request{})
+	return true
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/indiefan/home_assistant_nanit/pkg/history"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceInfoAge(t *testing.T) {
+	now := time.Unix(10_000, 0)
+	updated := func(timestamp int64) *baby.DeviceInfo { return &baby.DeviceInfo{LastUpdated: &timestamp} }
+
+	age, stale := deviceInfoAge(nil, nil, time.Minute, now)
+	assert.Nil(t, age)
+	assert.True(t, stale)
+
+	age, stale = deviceInfoAge(updated(9_970), nil, time.Minute, now)
+	assert.Equal(t, int64(30), *age)
+	assert.False(t, stale)
+
+	_, stale = deviceInfoAge(updated(9_900), nil, time.Minute, now)
+	assert.True(t, stale)
+
+	// Info from before the current connection is stale regardless of its age
+	connectedSince := int64(9_980)
+	_, stale = deviceInfoAge(updated(9_970), &connectedSince, time.Minute, now)
+	assert.True(t, stale)
+}
+
+func TestDeviceInfoAPIStaleness(t *testing.T) {
+	app := &App{
+		Opts:             Opts{DeviceInfoMaxAge: time.Minute},
+		BabyStateManager: baby.NewStateManager(),
+		HistoryTracker:   &history.Tracker{},
+	}
+
+	deviceInfo := func() DeviceInfoResponse {
+		w := httptest.NewRecorder()
+		handleDeviceInfoAPI(w, httptest.NewRequest("GET", "/api/device-info/baby1", nil), testBabies, app)
+
+		var response DeviceInfoResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		return response
+	}
+
+	// Camera never answered
+	response := deviceInfo()
+	assert.True(t, response.Stale)
+	assert.Nil(t, response.AgeSeconds)
+	assert.False(t, response.RefreshRequested)
+
+	settings := &client.Settings{NightVision: utils.ConstRefBool(true)}
+	processStandby("baby1", settings, app.BabyStateManager)
+
+	response = deviceInfo()
+	assert.False(t, response.Stale)
+	if assert.NotNil(t, response.AgeSeconds) {
+		assert.LessOrEqual(t, *response.AgeSeconds, int64(1))
+	}
+
+	// Repeated identical settings still confirm the info
+	old := time.Now().Add(-time.Hour).Unix()
+	app.BabyStateManager.Update("baby1", *baby.NewState().SetDeviceInfo(&baby.DeviceInfo{Volume: utils.ConstRefInt32(10), LastUpdated: &old}))
+	assert.True(t, deviceInfo().Stale)
+
+	processStandby("baby1", settings, app.BabyStateManager)
+	assert.False(t, deviceInfo().Stale)
+}
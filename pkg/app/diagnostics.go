@@ -0,0 +1,185 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/streaming"
+	"github.com/rs/zerolog/log"
+)
+
+// The diagnostics bundle gathers everything a maintainer usually asks for in an issue report. It has to stay safe
+// to attach publicly: credentials, tokens and webhook paths are redacted, baby names are left out.
+
+// redacted - placeholder of secret values in the diagnostics bundle
+const redacted = "[redacted]"
+
+// redactSecret - hides a secret value, only tells whether it is set
+func redactSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return redacted
+}
+
+// redactURL - keeps scheme and host of the URL, credentials, path and query may contain secrets (e.g. webhook tokens)
+func redactURL(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return redacted
+	}
+
+	result := parsed.Scheme + "://" + parsed.Host
+	if parsed.User != nil || (parsed.Path != "" && parsed.Path != "/") || parsed.RawQuery != "" {
+		result += "/" + redacted
+	}
+
+	return result
+}
+
+// buildDiagnosticsConfig - returns the run options with secrets redacted
+func buildDiagnosticsConfig(opts Opts) map[string]interface{} {
+	config := map[string]interface{}{
+		"nanit_credentials": map[string]interface{}{
+			"email":         redactSecret(opts.NanitCredentials.Email),
+			"password":      redactSecret(opts.NanitCredentials.Password),
+			"refresh_token": redactSecret(opts.NanitCredentials.RefreshToken),
+		},
+		"session_file":         opts.SessionFile,
+		"token_refresh_lead":   opts.TokenRefreshLead.String(),
+		"data_directories":     opts.DataDirectories,
+		"camlog":               opts.CamLog,
+		"health":               opts.Health,
+		"http_port":            opts.HTTPPort,
+		"cors_allowed_origins": opts.CORSAllowedOrigins,
+		"public_base_url":      opts.PublicBaseURL,
+		"event_polling":        opts.EventPolling,
+		"poll_fallback":        opts.PollFallback,
+		"websocket_keepalive":  opts.WebsocketKeepalive,
+		"event_cooldown":       opts.EventCooldown,
+		"event_active_window":  opts.EventActiveWindow.String(),
+		"history":              opts.History,
+		"web_auth_enabled":     opts.WebAuth.Enabled,
+	}
+
+	if opts.RTMP != nil {
+		config["rtmp"] = opts.RTMP
+	}
+
+	if opts.MQTT != nil {
+		config["mqtt"] = map[string]interface{}{
+			"broker_url":   redactURL(opts.MQTT.BrokerURL),
+			"client_id":    opts.MQTT.ClientID,
+			"username":     redactSecret(opts.MQTT.Username),
+			"password":     redactSecret(opts.MQTT.Password),
+			"topic_prefix": opts.MQTT.TopicPrefix,
+			"qos":          opts.MQTT.QoS,
+			"retain_state": opts.MQTT.RetainState,
+		}
+	}
+
+	if opts.Notify != nil {
+		config["notify"] = map[string]interface{}{
+			"webhook_url":      redactURL(opts.Notify.WebhookURL),
+			"payload_template": opts.Notify.PayloadTemplate != "",
+		}
+	}
+
+	return config
+}
+
+// buildDiagnostics - collects the diagnostics bundle
+func buildDiagnostics(app *App) map[string]interface{} {
+	diagnostics := map[string]interface{}{
+		"generated_at": time.Now().Unix(),
+		"version":      app.Opts.Version,
+		"go_version":   runtime.Version(),
+		"mode":         app.getMode(),
+		"config":       buildDiagnosticsConfig(app.Opts),
+	}
+
+	babies := make([]interface{}, 0)
+	if app.BabyStateManager != nil {
+		babyStates := app.BabyStateManager.GetAllBabyStates()
+		for _, b := range app.getBabies() {
+			babyState := babyStates[b.UID]
+			babyDiagnostics := map[string]interface{}{
+				"uid":        b.UID,
+				"camera_uid": b.CameraUID,
+				"state":      babyState.AsMap(true),
+				"connection": app.BabyStateManager.GetConnectionStats(b.UID),
+			}
+
+			if app.HLSManager != nil {
+				if transcoder, exists := app.HLSManager.GetTranscoder(b.UID); exists {
+					babyDiagnostics["hls"] = transcoder.GetDetailedInfo()
+					babyDiagnostics["ffmpeg_stderr"] = transcoder.GetStderrTail()
+				}
+				if transcoder, exists := app.HLSManager.GetTranscoderMode(b.UID, streaming.StreamModeAudio); exists {
+					babyDiagnostics["hls_audio"] = transcoder.GetDetailedInfo()
+					babyDiagnostics["ffmpeg_audio_stderr"] = transcoder.GetStderrTail()
+				}
+			}
+
+			babies = append(babies, babyDiagnostics)
+		}
+	}
+	diagnostics["babies"] = babies
+
+	if app.HealthManager != nil {
+		diagnostics["health"] = app.HealthManager.GetHealthSummary()
+	}
+
+	if app.CircuitBreakers != nil {
+		diagnostics["circuit_breakers"] = app.CircuitBreakers.GetAllStats()
+	}
+
+	historyDiagnostics := map[string]interface{}{"enabled": false}
+	if app.HistoryTracker != nil && app.HistoryTracker.IsEnabled() {
+		historyDiagnostics["enabled"] = true
+
+		if counts, err := app.HistoryTracker.GetRowCounts(); err != nil {
+			historyDiagnostics["error"] = err.Error()
+		} else {
+			historyDiagnostics["row_counts"] = counts
+		}
+
+		if dbSize, err := app.HistoryTracker.GetDBSize(); err == nil {
+			historyDiagnostics["db_bytes"] = dbSize
+		}
+	}
+	diagnostics["history"] = historyDiagnostics
+
+	return diagnostics
+}
+
+// API handler for the diagnostics bundle to attach to issue reports: /api/diagnostics[?format=download]
+func handleDiagnosticsAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "GET" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	diagnostics := buildDiagnostics(app)
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Query().Get("format") == "download" {
+		filename := fmt.Sprintf("nanit-diagnostics-%s.json", time.Now().UTC().Format("20060102-150405"))
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(diagnostics); err != nil {
+		log.Error().Err(err).Msg("Failed to encode diagnostics")
+	}
+}
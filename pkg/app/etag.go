@@ -0,0 +1,51 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// weakETag - returns weak ETag of the JSON serialization of the value
+func weakETag(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// etagMatches - returns whether the If-None-Match header lists the ETag, compared weakly (RFC 9110)
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeJSONWithETag - writes the payload, or 304 Not Modified if the client has the version of etagSource already
+// etagSource is the part of the payload identifying its version, fields like generation timestamps are left out of it.
+// Browsers revalidate with If-None-Match on their own, Cache-Control makes them do it on every request.
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, payload interface{}, etagSource interface{}) {
+	etag, err := weakETag(etagSource)
+	if err == nil {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "no-cache")
+
+		if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payload)
+}
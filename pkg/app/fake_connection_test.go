@@ -0,0 +1,141 @@
+package app
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+)
+
+// fakeResponder - produces the cam response for a request, nil response means the request times out
+type fakeResponder func(request *client.Request) *client.Response
+
+// fakeConnection - in-memory stand-in for the cam websocket connection
+// Records sent requests, answers them using scripted responders and lets tests inject cam-initiated messages.
+type fakeConnection struct {
+	mu         sync.Mutex
+	handlers   []client.WebsocketMessageHandler
+	requests   []*client.Request
+	responders map[client.RequestType]fakeResponder
+}
+
+func newFakeConnection() *fakeConnection {
+	return &fakeConnection{
+		responders: make(map[client.RequestType]fakeResponder),
+	}
+}
+
+// RegisterMessageHandler - implements client.Connection
+func (conn *fakeConnection) RegisterMessageHandler(handler client.WebsocketMessageHandler) {
+	conn.mu.Lock()
+	conn.handlers = append(conn.handlers, handler)
+	conn.mu.Unlock()
+}
+
+// SendRequest - implements client.Connection
+// Like the real connection, responses are passed to the registered message handlers as well.
+func (conn *fakeConnection) SendRequest(reqType client.RequestType, requestData *client.Request) func(time.Duration) (*client.Response, error) {
+	conn.mu.Lock()
+	id := int32(len(conn.requests) + 1)
+	requestData.Id = utils.ConstRefInt32(id)
+	requestData.Type = client.RequestType(reqType).Enum()
+	conn.requests = append(conn.requests, requestData)
+	responder := conn.responders[reqType]
+	conn.mu.Unlock()
+
+	if responder == nil {
+		return func(time.Duration) (*client.Response, error) {
+			return nil, errors.New("Request timeout")
+		}
+	}
+
+	res := responder(requestData)
+	if res == nil {
+		return func(time.Duration) (*client.Response, error) {
+			return nil, errors.New("Request timeout")
+		}
+	}
+
+	res.RequestId = utils.ConstRefInt32(id)
+	res.RequestType = client.RequestType(reqType).Enum()
+	if res.StatusCode == nil {
+		res.StatusCode = utils.ConstRefInt32(200)
+	}
+
+	conn.Inject(&client.Message{
+		Type:     client.Message_Type(client.Message_RESPONSE).Enum(),
+		Response: res,
+	})
+
+	return func(time.Duration) (*client.Response, error) {
+		if *res.StatusCode != 200 {
+			return res, errors.New(res.GetStatusMessage())
+		}
+		return res, nil
+	}
+}
+
+// Respond - scripts the response for given request type
+func (conn *fakeConnection) Respond(reqType client.RequestType, responder fakeResponder) {
+	conn.mu.Lock()
+	conn.responders[reqType] = responder
+	conn.mu.Unlock()
+}
+
+// Inject - delivers the message to all registered handlers as if it was received from the cam
+func (conn *fakeConnection) Inject(m *client.Message) {
+	conn.mu.Lock()
+	handlers := make([]client.WebsocketMessageHandler, len(conn.handlers))
+	copy(handlers, conn.handlers)
+	conn.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(m, conn)
+	}
+}
+
+// InjectRequest - delivers a cam-initiated request (e.g. PUT_SENSOR_DATA)
+func (conn *fakeConnection) InjectRequest(reqType client.RequestType, request *client.Request) {
+	request.Type = client.RequestType(reqType).Enum()
+	conn.Inject(&client.Message{
+		Type:    client.Message_Type(client.Message_REQUEST).Enum(),
+		Request: request,
+	})
+}
+
+// SentRequests - returns types of the requests sent so far, in order
+func (conn *fakeConnection) SentRequests() []client.RequestType {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	types := make([]client.RequestType, 0, len(conn.requests))
+	for _, request := range conn.requests {
+		types = append(types, *request.Type)
+	}
+
+	return types
+}
+
+// LastRequest - returns the last sent request of given type or nil
+func (conn *fakeConnection) LastRequest(reqType client.RequestType) *client.Request {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	for i := len(conn.requests) - 1; i >= 0; i-- {
+		if *conn.requests[i].Type == reqType {
+			return conn.requests[i]
+		}
+	}
+
+	return nil
+}
+
+// sensorReading - builds sensor data entry with milli value
+func sensorReading(sensorType client.SensorType, valueMilli int32) *client.SensorData {
+	return &client.SensorData{
+		SensorType: client.SensorType(sensorType).Enum(),
+		ValueMilli: utils.ConstRefInt32(valueMilli),
+	}
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	apperrors "github.com/indiefan/home_assistant_nanit/pkg/errors"
+)
+
+// ErrorResponse - JSON body of API errors
+// Error duplicates Code, older clients read the machine-readable code from it.
+type ErrorResponse struct {
+	Error     string                 `json:"error"`
+	Type      apperrors.ErrorType    `json:"type"`
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	Retryable bool                   `json:"retryable"`
+	Context   map[string]interface{} `json:"context,omitempty"`
+}
+
+// writeError writes the application error as JSON response, the cause is not exposed to the client
+func writeError(w http.ResponseWriter, appErr *apperrors.AppError, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error:     appErr.Code,
+		Type:      appErr.Type,
+		Code:      appErr.Code,
+		Message:   appErr.Message,
+		Retryable: appErr.Retryable,
+		Context:   appErr.Context,
+	})
+}
+
+// Errors shared by many handlers
+
+func writeMethodNotAllowed(w http.ResponseWriter) {
+	writeError(w, apperrors.NewValidationError("method_not_allowed", "Method not allowed", nil), http.StatusMethodNotAllowed)
+}
+
+func writeBabyUIDRequired(w http.ResponseWriter) {
+	writeError(w, apperrors.NewValidationError("baby_uid_required", "baby_uid is required", nil), http.StatusBadRequest)
+}
+
+func writeBabyNotFound(w http.ResponseWriter, babyUID string) {
+	writeError(w, apperrors.NewValidationError("baby_not_found", "Baby not found", nil).WithContext("baby_uid", babyUID), http.StatusNotFound)
+}
+
+func writeHistoryDisabled(w http.ResponseWriter) {
+	writeError(w, apperrors.NewConfigError("history_disabled", "Historical tracking disabled", nil), http.StatusServiceUnavailable)
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// jsonStreamBufferLimit - responses up to this size are buffered and sent with Content-Length
+	jsonStreamBufferLimit = 64 << 10
+
+	// jsonStreamFlushEvery - number of items between flushes once the response is streamed
+	jsonStreamFlushEvery = 500
+)
+
+// jsonArrayStream writes a JSON object with a single array field item by item, the object is closed with the
+// number of items as "count". Small responses are buffered and sent with an explicit Content-Length, larger ones
+// switch to a chunked response flushed periodically, so memory doesn't grow with the number of items.
+// Until Started returns true the handler can still discard the stream and write an error response.
+type jsonArrayStream struct {
+	w          http.ResponseWriter
+	buf        bytes.Buffer
+	streaming  bool
+	count      int
+	sinceFlush int
+}
+
+// newJSONArrayStream starts the object with the fields and opens the array under arrayKey
+func newJSONArrayStream(w http.ResponseWriter, fields map[string]interface{}, arrayKey string) (*jsonArrayStream, error) {
+	stream := &jsonArrayStream{w: w}
+
+	header, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reopen the marshalled object to append the array
+	stream.buf.Write(bytes.TrimSuffix(header, []byte("}")))
+	if len(fields) > 0 {
+		stream.buf.WriteByte(',')
+	}
+	fmt.Fprintf(&stream.buf, "%q:[", arrayKey)
+
+	return stream, nil
+}
+
+// Started - returns whether the response has been sent already
+func (stream *jsonArrayStream) Started() bool {
+	return stream.streaming
+}
+
+// Write - appends an item to the array
+func (stream *jsonArrayStream) Write(item interface{}) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	if stream.count > 0 {
+		data = append([]byte{','}, data...)
+	}
+	stream.count++
+
+	if !stream.streaming {
+		stream.buf.Write(data)
+		if stream.buf.Len() <= jsonStreamBufferLimit {
+			return nil
+		}
+
+		// Too large to buffer, send what we have and stream the rest
+		stream.streaming = true
+		stream.w.Header().Set("Content-Type", "application/json")
+		stream.w.WriteHeader(http.StatusOK)
+		_, err := stream.buf.WriteTo(stream.w)
+		return err
+	}
+
+	if _, err := stream.w.Write(data); err != nil {
+		return err
+	}
+
+	stream.sinceFlush++
+	if stream.sinceFlush >= jsonStreamFlushEvery {
+		stream.flush()
+	}
+
+	return nil
+}
+
+// Close - closes the array and the object, sends the buffered response
+func (stream *jsonArrayStream) Close() error {
+	footer := fmt.Sprintf("],\"count\":%d}\n", stream.count)
+
+	if stream.streaming {
+		_, err := stream.w.Write([]byte(footer))
+		stream.flush()
+		return err
+	}
+
+	stream.buf.WriteString(footer)
+	stream.w.Header().Set("Content-Type", "application/json")
+	stream.w.Header().Set("Content-Length", strconv.Itoa(stream.buf.Len()))
+	stream.w.WriteHeader(http.StatusOK)
+	_, err := stream.buf.WriteTo(stream.w)
+	return err
+}
+
+func (stream *jsonArrayStream) flush() {
+	stream.sinceFlush = 0
+	if flusher, ok := stream.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
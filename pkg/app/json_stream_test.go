@@ -0,0 +1,59 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONArrayStreamBuffersSmallResponses(t *testing.T) {
+	w := httptest.NewRecorder()
+	stream, err := newJSONArrayStream(w, map[string]interface{}{"baby_uid": "baby1"}, "items")
+	assert.NoError(t, err)
+
+	assert.NoError(t, stream.Write(map[string]int{"value": 1}))
+	assert.NoError(t, stream.Write(map[string]int{"value": 2}))
+	assert.False(t, stream.Started())
+	assert.NoError(t, stream.Close())
+
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.NotEmpty(t, w.Header().Get("Content-Length"))
+	assert.JSONEq(t, `{"baby_uid":"baby1","items":[{"value":1},{"value":2}],"count":2}`, w.Body.String())
+
+	// Empty array rather than null
+	w = httptest.NewRecorder()
+	stream, err = newJSONArrayStream(w, map[string]interface{}{"baby_uid": "baby1"}, "items")
+	assert.NoError(t, err)
+	assert.NoError(t, stream.Close())
+	assert.JSONEq(t, `{"baby_uid":"baby1","items":[],"count":0}`, w.Body.String())
+}
+
+func TestJSONArrayStreamStreamsLargeResponses(t *testing.T) {
+	w := httptest.NewRecorder()
+	stream, err := newJSONArrayStream(w, map[string]interface{}{"baby_uid": "baby1"}, "items")
+	assert.NoError(t, err)
+
+	item := strings.Repeat("x", 1024)
+	count := 2 * jsonStreamBufferLimit / len(item)
+	for i := 0; i < count; i++ {
+		assert.NoError(t, stream.Write(item))
+	}
+	assert.True(t, stream.Started())
+	assert.NoError(t, stream.Close())
+
+	assert.Empty(t, w.Header().Get("Content-Length"))
+	assert.True(t, w.Flushed)
+
+	var response struct {
+		BabyUID string   `json:"baby_uid"`
+		Items   []string `json:"items"`
+		Count   int      `json:"count"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "baby1", response.BabyUID)
+	assert.Len(t, response.Items, count)
+	assert.Equal(t, count, response.Count)
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	apperrors "github.com/indiefan/home_assistant_nanit/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// pauseMonitoring disconnects from all cameras and stops the transcoders, the web UI keeps running
+// Frees the camera connection slots, e.g. for the official app. Returns false if the monitoring isn't running.
+func (app *App) pauseMonitoring() bool {
+	app.babiesMutex.Lock()
+	defer app.babiesMutex.Unlock()
+
+	if !app.monitoringStarted {
+		return false
+	}
+
+	// Stops refreshes and relogins from starting the monitoring of babies again
+	app.monitoredBabiesMutex.Lock()
+	alreadyPaused := app.monitoringPaused
+	app.monitoringPaused = true
+	app.monitoredBabiesMutex.Unlock()
+
+	if alreadyPaused {
+		return true
+	}
+
+	app.setMode(Mode_Paused)
+
+	for _, babyUID := range app.getMonitoredBabyUIDs() {
+		app.stopMonitoringBaby(babyUID)
+	}
+
+	if app.HLSManager != nil {
+		app.HLSManager.StopAll()
+	}
+
+	log.Info().Msg("Camera monitoring paused")
+	return true
+}
+
+// resumeMonitoring reconnects to the cameras after pauseMonitoring, returns false if the monitoring never started
+func (app *App) resumeMonitoring() bool {
+	app.babiesMutex.Lock()
+	defer app.babiesMutex.Unlock()
+
+	if !app.monitoringStarted {
+		return false
+	}
+
+	app.monitoredBabiesMutex.Lock()
+	wasPaused := app.monitoringPaused
+	app.monitoringPaused = false
+	app.monitoredBabiesMutex.Unlock()
+
+	if !wasPaused {
+		return true
+	}
+
+	for _, babyInfo := range app.getBabies() {
+		app.startMonitoringBaby(babyInfo)
+	}
+	app.setMode(Mode_Monitoring)
+
+	log.Info().Msg("Camera monitoring resumed")
+	return true
+}
+
+// isMonitoringPaused returns whether the camera monitoring was paused by the user
+func (app *App) isMonitoringPaused() bool {
+	app.monitoredBabiesMutex.Lock()
+	defer app.monitoredBabiesMutex.Unlock()
+
+	return app.monitoringPaused
+}
+
+// API handler pausing/resuming the camera monitoring: POST /api/monitoring/pause, POST /api/monitoring/resume
+func handleMonitoringPauseAPI(w http.ResponseWriter, r *http.Request, app *App, pause bool) {
+	if r.Method != "POST" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	ok := app.resumeMonitoring
+	if pause {
+		ok = app.pauseMonitoring
+	}
+
+	if !ok() {
+		writeError(w, apperrors.NewConfigError("monitoring_not_started", "Camera monitoring is not running (web-only mode), sign in to Nanit first", nil), http.StatusServiceUnavailable)
+		return
+	}
+
+	message := "Monitoring cameras"
+	if pause {
+		message = "Camera monitoring paused, the cameras are disconnected until it is resumed"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"mode":    app.getMode(),
+		"paused":  app.isMonitoringPaused(),
+		"message": message,
+	})
+}
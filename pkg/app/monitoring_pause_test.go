@@ -0,0 +1,78 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/session"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitoringPauseAPI(t *testing.T) {
+	sessionStore := session.NewSessionStore()
+	sessionStore.Session.Babies = testBabies
+	sessionStore.Session.RefreshToken = "token"
+
+	app := &App{
+		SessionStore:    sessionStore,
+		monitoredBabies: make(map[string]*monitoredBaby),
+		pendingTasks:    utils.NewPendingTasks(),
+	}
+
+	post := func(path string, pause bool) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handleMonitoringPauseAPI(w, httptest.NewRequest("POST", path, nil), app, pause)
+		return w
+	}
+
+	// Nothing to pause in web-only mode
+	w := post("/api/monitoring/pause", true)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "monitoring_not_started")
+
+	mainContext := make(chan utils.GracefulContext)
+	runner := utils.RunWithGracefulCancel(func(ctx utils.GracefulContext) {
+		mainContext <- ctx
+		<-ctx.Done()
+	})
+	defer runner.Cancel()
+	app.mainContext = <-mainContext
+
+	for _, babyInfo := range testBabies {
+		app.startMonitoringBaby(babyInfo)
+	}
+	app.monitoringStarted = true
+	app.setMode(Mode_Monitoring)
+
+	w = post("/api/monitoring/pause", true)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"paused":true`)
+	assert.Empty(t, app.getMonitoredBabyUIDs())
+
+	// Babies aren't picked up again while paused (e.g. by refreshing the babies list)
+	assert.False(t, app.startMonitoringBaby(testBabies[0]))
+
+	// Pausing again is a no-op
+	assert.Equal(t, http.StatusOK, post("/api/monitoring/pause", true).Code)
+
+	w = httptest.NewRecorder()
+	assert.True(t, writeMonitoringNotStarted(w, app))
+	assert.Contains(t, w.Body.String(), "monitoring_paused")
+
+	w = httptest.NewRecorder()
+	handleReadinessAPI(w, httptest.NewRequest("GET", "/ready", nil), app)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"paused"`)
+
+	w = post("/api/monitoring/resume", false)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"mode":"monitoring"`)
+
+	uids := app.getMonitoredBabyUIDs()
+	sort.Strings(uids)
+	assert.Equal(t, []string{"baby1", "baby2", "baby3"}, uids)
+	assert.False(t, writeMonitoringNotStarted(httptest.NewRecorder(), app))
+}
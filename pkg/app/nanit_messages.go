@@ -0,0 +1,62 @@
+package app
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/message"
+)
+
+const (
+	// nanitMessagesCacheTTL - how long fetched cloud messages are reused to avoid Nanit API rate limits
+	nanitMessagesCacheTTL = 30 * time.Second
+
+	// defaultNanitMessagesLimit - number of messages returned when no limit is requested
+	defaultNanitMessagesLimit = 20
+
+	// maxNanitMessagesLimit - upper bound of the requested message count
+	maxNanitMessagesLimit = 100
+)
+
+type nanitMessagesCacheEntry struct {
+	messages  []message.Message
+	fetchedAt time.Time
+}
+
+// nanitMessagesCache - recently fetched cloud messages keyed by baby UID and limit
+type nanitMessagesCache struct {
+	mu      sync.Mutex
+	entries map[string]nanitMessagesCacheEntry
+}
+
+// fetchNanitMessages returns the messages recorded by the Nanit cloud for the baby (cached briefly)
+// Note: unlike FetchNewMessages this does not touch the last seen message time used by event polling
+func (app *App) fetchNanitMessages(babyUID string, limit int) ([]message.Message, time.Time, error) {
+	key := fmt.Sprintf("%v/%v", babyUID, limit)
+
+	app.nanitMessages.mu.Lock()
+	defer app.nanitMessages.mu.Unlock()
+
+	if app.nanitMessages.entries == nil {
+		app.nanitMessages.entries = make(map[string]nanitMessagesCacheEntry)
+	}
+
+	if entry, ok := app.nanitMessages.entries[key]; ok && time.Since(entry.fetchedAt) < nanitMessagesCacheTTL {
+		return entry.messages, entry.fetchedAt, nil
+	}
+
+	messages, err := app.RestClient.FetchMessages(babyUID, limit)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	if messages == nil {
+		messages = []message.Message{}
+	}
+
+	entry := nanitMessagesCacheEntry{messages: messages, fetchedAt: time.Now()}
+	app.nanitMessages.entries[key] = entry
+
+	return entry.messages, entry.fetchedAt, nil
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/notify"
+	"github.com/rs/zerolog/log"
+)
+
+// dispatchEvent records an event (motion, sound, cloud alert) and propagates it to subscribers (MQTT) and webhooks,
+// unless the quiet hours of the baby are active or another event of the same type was propagated within the cooldown window
+func (app *App) dispatchEvent(babyUID string, eventType string, eventTime time.Time) {
+	// Every raw event is recorded, regardless of the cooldown
+	if app.HistoryTracker.IsEnabled() {
+		if err := app.HistoryTracker.TrackEvent(babyUID, eventType, eventTime.Unix()); err != nil {
+			log.Error().Err(err).Str("baby_uid", babyUID).Str("event_type", eventType).Msg("Failed to track event")
+		}
+	}
+
+	// Latest motion/sound drives the "active now" flags, regardless of the cooldown
+	switch eventType {
+	case notify.EventMotion:
+		app.BabyStateManager.RecordMotion(babyUID, eventTime)
+	case notify.EventSound:
+		app.BabyStateManager.RecordSound(babyUID, eventTime)
+	}
+
+	// Checked before the cooldown, so that the first event after the quiet hours is propagated
+	if app.QuietHours.IsActive(babyUID, eventTime) {
+		log.Debug().Str("baby_uid", babyUID).Str("event_type", eventType).Msg("Event suppressed by quiet hours")
+		return
+	}
+
+	if !app.eventCooldown.Allow(babyUID, eventType, eventTime) {
+		log.Debug().Str("baby_uid", babyUID).Str("event_type", eventType).Msg("Event suppressed by cooldown")
+		return
+	}
+
+	babyName := app.getBabyName(babyUID)
+	var message string
+
+	switch eventType {
+	case notify.EventMotion:
+		app.BabyStateManager.NotifyMotionSubscribers(babyUID, eventTime)
+		message = fmt.Sprintf("Motion detected (%s)", babyName)
+	case notify.EventSound:
+		app.BabyStateManager.NotifySoundSubscribers(babyUID, eventTime)
+		message = fmt.Sprintf("Sound detected (%s)", babyName)
+	case notify.EventTemperatureAlert:
+		message = fmt.Sprintf("Temperature alert (%s)", babyName)
+	case notify.EventHumidityAlert:
+		message = fmt.Sprintf("Humidity alert (%s)", babyName)
+	case notify.EventCry:
+		message = fmt.Sprintf("Crying detected (%s)", babyName)
+	}
+
+	if app.Notifier != nil {
+		app.Notifier.Notify(notify.Event{
+			BabyUID:   babyUID,
+			BabyName:  babyName,
+			EventType: eventType,
+			Timestamp: eventTime.Unix(),
+			Message:   message,
+		})
+	}
+}
+
+// checkSensorThresholds notifies when temperature/humidity crosses the thresholds configured on the camera
+func (app *App) checkSensorThresholds(babyUID string) {
+	babyName := app.getBabyName(babyUID)
+	babyState := app.BabyStateManager.GetBabyState(babyUID)
+	if babyState.DeviceInfo == nil {
+		return
+	}
+
+	deviceInfo := babyState.DeviceInfo
+	now := time.Now().Unix()
+
+	check := func(eventType string, value float64, threshold *int32, above bool, label string) {
+		if threshold == nil {
+			return
+		}
+
+		active := value < float64(*threshold)
+		if above {
+			active = value > float64(*threshold)
+		}
+
+		app.Notifier.NotifyCondition(notify.Event{
+			BabyUID:   babyUID,
+			BabyName:  babyName,
+			EventType: eventType,
+			Timestamp: now,
+			Message:   fmt.Sprintf("%s (%s): %.1f", label, babyName, value),
+			Value:     &value,
+		}, active)
+	}
+
+	if babyState.TemperatureMilli != nil {
+		temperature := babyState.GetTemperature()
+		check(notify.EventTemperatureHigh, temperature, deviceInfo.TempHighThreshold, true, "Temperature above threshold")
+		check(notify.EventTemperatureLow, temperature, deviceInfo.TempLowThreshold, false, "Temperature below threshold")
+	}
+
+	if babyState.HumidityMilli != nil {
+		humidity := babyState.GetHumidity()
+		check(notify.EventHumidityHigh, humidity, deviceInfo.HumidityHighThreshold, true, "Humidity above threshold")
+		check(notify.EventHumidityLow, humidity, deviceInfo.HumidityLowThreshold, false, "Humidity below threshold")
+	}
+}
+
+// getBabyName returns the name of the baby from the session, falls back to the UID
+func (app *App) getBabyName(babyUID string) string {
+	for _, b := range app.getBabies() {
+		if b.UID == babyUID {
+			return b.Name
+		}
+	}
+
+	return babyUID
+}
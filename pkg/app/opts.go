@@ -1,20 +1,43 @@
 package app
 
 import (
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
 	"github.com/indiefan/home_assistant_nanit/pkg/mqtt"
+	"github.com/indiefan/home_assistant_nanit/pkg/notify"
+	"strings"
 	"time"
 )
 
 // Opts - application run options
 type Opts struct {
+	Version          string // Build version (git commit), reported in diagnostics
 	NanitCredentials NanitCredentials
 	SessionFile      string
+	TokenRefreshLead time.Duration // Nanit token is renewed in the background this long before its estimated expiry, 0 disables
+	StartupAuthRetryInterval time.Duration // Time between background authorization retries after the startup one failed transiently, 0 disables
 	DataDirectories  DataDirectories
+	CamLog           CamLogOpts
+	Health           HealthOpts
 	HTTPEnabled      bool
 	HTTPPort         int
+	CORSAllowedOrigins []string // Origins allowed to call the API from the browser, same-origin only if empty
+	PublicBaseURL    string // Scheme and host under which clients reach the app (e.g. https://nanit.example.com), derived from the request if empty
+	MaxRequestBodyBytes int64 // Maximum size of JSON API request bodies, unlimited if 0
+	ShutdownTimeout  time.Duration // Clean up is abandoned after this long on interrupt, waits indefinitely if 0
 	MQTT             *mqtt.Opts
+	Notify           *notify.Opts
 	RTMP             *RTMPOpts
 	EventPolling     EventPollingOpts
+	PollFallback     PollFallbackOpts
+	WebsocketKeepalive WebsocketKeepaliveOpts
+	StreamRetry      StreamRetryOpts
+	EventCooldown    EventCooldownOpts
+	EventActiveWindow time.Duration // Motion/sound is reported as active this long after the latest event
+	StreamEventCooldown time.Duration // Minimum time between two propagated stream health events of the same type for a baby
+	DeviceInfoMaxAge time.Duration // Device info not confirmed by the camera for longer is reported stale and requested again
+	RawCommandsEnabled bool // Logged in users may send websocket requests the app doesn't wrap (/api/control/raw)
+	SensorChangeThresholds baby.SensorChangeThresholds // Smaller sensor changes are not recorded to the history nor published
 	History          HistoryOpts
 	WebAuth          WebAuthOpts
 }
@@ -36,7 +59,7 @@ type DataDirectories struct {
 
 // RTMPOpts - options for RTMP streaming
 type RTMPOpts struct {
-	// IP:Port of the interface on which we should listen
+	// IP:Port of the interface on which we should listen (":Port" listens on all interfaces)
 	ListenAddr string
 
 	// IP:Port under which can Cam reach the RTMP server
@@ -44,12 +67,136 @@ type RTMPOpts struct {
 
 	// Automatically start streaming when baby comes online
 	AutoStart bool
+
+	// Per baby UID overrides of AutoStart
+	AutoStartOverrides map[string]bool
+
+	// Delay between the WebSocket connection becoming ready and requesting the RTMP stream
+	StreamStartDelay time.Duration
+
+	// Lead time between the RTMP stream going live and starting HLS transcoding
+	HLSStartDelay time.Duration
+
+	// Maximum time to wait for the RTMP stream to go live before starting HLS transcoding anyway
+	HLSStartTimeout time.Duration
+
+	// Maximum time FFmpeg may block on reading the RTMP input (0 disables)
+	FFmpegRWTimeout time.Duration
+
+	// Let FFmpeg reconnect to the input on errors (HTTP inputs only)
+	FFmpegReconnect bool
+
+	// Maximum number of concurrently running HLS transcoders (0 for unlimited)
+	HLSMaxConcurrent int
+
+	// Number of segments in the live HLS playlist
+	HLSLiveSegments int
+
+	// Length of the DVR playlist for scrubbing backward (0 disables it)
+	HLSDVRWindow time.Duration
+
+	// How long HLS segments are kept on disk, at least the DVR window
+	HLSSegmentRetention time.Duration
+
+	// FFmpeg -loglevel, FFmpeg's default if empty
+	FFmpegLogLevel string
+
+	// Write FFmpeg output of every transcoder to log/ffmpeg-<baby_uid>.log
+	FFmpegLogFile bool
+
+	// Size at which an FFmpeg log file is rotated (0 disables rotation)
+	FFmpegLogMaxSize int64
+
+	// Time the WebSocket may stay disconnected before streaming is torn down and the stream marked unhealthy (0 stops immediately)
+	DisconnectGracePeriod time.Duration
+
+	// Camera stream relayed to the RTMP server, MOBILE counts against the Nanit mobile app connection limit
+	StreamIdentifier client.StreamIdentifier
+}
+
+// WebsocketKeepaliveOpts - options of the camera WebSocket keepalive
+type WebsocketKeepaliveOpts struct {
+	// Time between keepalive messages
+	Interval time.Duration
+
+	// Connection is re-established if nothing is received for this long, 0 disables the check
+	Timeout time.Duration
+}
+
+// StreamRetryOpts - options of the monitor retrying stream requests that failed (e.g. due to the connection limit)
+type StreamRetryOpts struct {
+	// Time between checks, doubled after every retry that didn't bring the stream back
+	Interval time.Duration
+
+	// Upper bound of the backed off interval
+	MaxInterval time.Duration
+
+	// Fraction by which each interval is randomly shortened or lengthened (0-1)
+	Jitter float64
+
+	// Monitor stops after no retry was needed for this long, 0 keeps it running
+	IdleTimeout time.Duration
+}
+
+// PollFallbackOpts - options of the REST polling used while the camera WebSocket is down
+type PollFallbackOpts struct {
+	Enabled bool
+
+	// Time the WebSocket has to be down before polling starts
+	Threshold time.Duration
+
+	// Time between polls while the WebSocket is down
+	Interval time.Duration
 }
 
 type EventPollingOpts struct {
 	Enabled         bool
 	PollingInterval time.Duration
 	MessageTimeout  time.Duration
+
+	// Per baby UID overrides of Enabled
+	PerBaby map[string]bool
+}
+
+// IsEnabledFor - returns whether event messages should be polled for given baby
+func (opts EventPollingOpts) IsEnabledFor(babyUID string) bool {
+	// Per baby keys are lowercased environment variable suffixes
+	if enabled, ok := opts.PerBaby[strings.ToLower(babyUID)]; ok {
+		return enabled
+	}
+
+	return opts.Enabled
+}
+
+// IsEnabledForAny - returns whether polling is enabled globally or for at least one baby
+func (opts EventPollingOpts) IsEnabledForAny() bool {
+	if opts.Enabled {
+		return true
+	}
+
+	for _, enabled := range opts.PerBaby {
+		if enabled {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EventCooldownOpts - options for debouncing motion/sound events before they reach MQTT and webhooks
+type EventCooldownOpts struct {
+	// Minimum time between two propagated events of the same type for a baby
+	Default time.Duration
+
+	// Overrides of the cooldown per event type ("motion", "sound")
+	PerType map[string]time.Duration
+}
+
+// HealthOpts - criteria of the liveness and readiness endpoints
+type HealthOpts struct {
+	RequireMQTT                bool          // Not ready while the MQTT broker is disconnected (if MQTT is configured)
+	RequireNanitAPI            bool          // Not ready while the Nanit API is unreachable
+	LivenessUnhealthyThreshold time.Duration // Liveness fails once a required service is unhealthy for longer, disabled if 0
 }
 
 // HistoryOpts - options for historical data tracking
@@ -57,6 +204,19 @@ type HistoryOpts struct {
 	Enabled        bool
 	RetentionDays  int
 	CleanupEnabled bool
+	SampleInterval time.Duration // Minimum interval between stored sensor readings of a baby, every reading is stored if 0
+	DayNightMaxGap time.Duration // Longest gap after a reading its day/night state is carried forward in analytics, unlimited if 0
+	CryEpisodeGap  time.Duration // Longest gap between sound events merged into one crying episode
+
+	StoreRawSensorData bool   // Store the values reported by the camera next to the calibrated ones
+	DBPath             string // Path of the database file, history.db in the history data directory if empty
+}
+
+// CamLogOpts - retention of log tarballs uploaded by the cam
+type CamLogOpts struct {
+	MaxFiles       int           // Number of newest files to keep, unlimited if 0
+	MaxAge         time.Duration // Files older than this are removed, unlimited if 0
+	MaxUploadBytes int64         // Maximum size of a single upload, unlimited if 0
 }
 
 // WebAuthOpts - options for web interface authentication
@@ -64,3 +224,13 @@ type WebAuthOpts struct {
 	Enabled      bool
 	PasswordFile string
 }
+
+// IsAutoStartEnabled - returns whether streaming should be started automatically for given baby
+func (opts *RTMPOpts) IsAutoStartEnabled(babyUID string) bool {
+	// Override keys are lowercased environment variable suffixes
+	if autoStart, ok := opts.AutoStartOverrides[strings.ToLower(babyUID)]; ok {
+		return autoStart
+	}
+
+	return opts.AutoStart
+}
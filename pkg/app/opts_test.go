@@ -0,0 +1,25 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoStartOverrideIgnoresCase(t *testing.T) {
+	t.Setenv("NANIT_RTMP_AUTO_START_ABC123", "false")
+	opts := &RTMPOpts{AutoStart: true, AutoStartOverrides: utils.EnvVarBoolsWithPrefix("NANIT_RTMP_AUTO_START_")}
+
+	assert.False(t, opts.IsAutoStartEnabled("ABC123"))
+	assert.False(t, opts.IsAutoStartEnabled("abc123"))
+	assert.True(t, opts.IsAutoStartEnabled("def456"))
+}
+
+func TestEventPollingOverrideIgnoresCase(t *testing.T) {
+	t.Setenv("NANIT_EVENTS_POLLING_BABY_ABC123", "true")
+	opts := EventPollingOpts{PerBaby: utils.EnvVarBoolsWithPrefix("NANIT_EVENTS_POLLING_BABY_")}
+
+	assert.True(t, opts.IsEnabledFor("ABC123"))
+	assert.False(t, opts.IsEnabledFor("def456"))
+}
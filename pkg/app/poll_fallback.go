@@ -0,0 +1,80 @@
+package app
+
+import (
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// The Nanit REST API doesn't expose sensor readings, those are pushed over the camera WebSocket only. While the
+// WebSocket is down the fallback polls the event messages instead, so motion/sound/alerts in /api/status and the
+// history keep updating. Sensor values keep their last known reading until the WebSocket recovers.
+
+// pollFallbackDue - returns whether the WebSocket has been down for at least threshold
+// The camera is considered down since watchStart if it never connected.
+func pollFallbackDue(alive bool, stats baby.ConnectionStats, watchStart time.Time, now time.Time, threshold time.Duration) bool {
+	if alive {
+		return false
+	}
+
+	downSince := watchStart
+	if stats.LastDisconnect != nil {
+		downSince = time.Unix(*stats.LastDisconnect, 0)
+	}
+
+	return now.Sub(downSince) >= threshold
+}
+
+// runPollFallback - polls the REST API while the WebSocket of the baby is down, idles while it is up
+func (app *App) runPollFallback(babyUID string, ctx utils.GracefulContext) {
+	opts := app.Opts.PollFallback
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	watchStart := time.Now()
+	active := false
+
+	for {
+		select {
+		case <-ticker.C:
+			alive := app.BabyStateManager.GetBabyState(babyUID).GetIsWebsocketAlive()
+			due := pollFallbackDue(alive, app.BabyStateManager.GetConnectionStats(babyUID), watchStart, time.Now(), opts.Threshold)
+
+			if !due {
+				if active {
+					log.Info().Str("baby_uid", babyUID).Msg("WebSocket recovered, stopping REST polling fallback")
+					active = false
+				}
+				continue
+			}
+
+			if !active {
+				log.Warn().Str("baby_uid", babyUID).Dur("threshold", opts.Threshold).Dur("interval", opts.Interval).Msg("WebSocket down, starting REST polling fallback")
+				active = true
+			}
+
+			app.pollFallbackOnce(babyUID)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pollFallbackOnce - fetches the data available over REST
+func (app *App) pollFallbackOnce(babyUID string) {
+	// Regular event polling fetches the messages already
+	if app.Opts.EventPolling.IsEnabledFor(babyUID) {
+		return
+	}
+
+	newMessages, err := app.RestClient.FetchNewMessages(babyUID, app.Opts.PollFallback.Interval+app.Opts.PollFallback.Threshold)
+	if err != nil {
+		log.Warn().Err(err).Str("baby_uid", babyUID).Msg("REST polling fallback failed")
+		return
+	}
+
+	app.handleMessages(babyUID, newMessages)
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPollFallbackDue(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	threshold := 2 * time.Minute
+
+	recent := now.Add(-time.Minute).Unix()
+	old := now.Add(-5 * time.Minute).Unix()
+
+	// Connected cameras are never polled
+	assert.False(t, pollFallbackDue(true, baby.ConnectionStats{LastDisconnect: &old}, now.Add(-time.Hour), now, threshold))
+
+	// Down for shorter than the threshold
+	assert.False(t, pollFallbackDue(false, baby.ConnectionStats{LastDisconnect: &recent}, now.Add(-time.Hour), now, threshold))
+
+	// Down for longer than the threshold
+	assert.True(t, pollFallbackDue(false, baby.ConnectionStats{LastDisconnect: &old}, now.Add(-time.Hour), now, threshold))
+
+	// Never connected, counted from the start of the watch
+	assert.False(t, pollFallbackDue(false, baby.ConnectionStats{}, now.Add(-time.Minute), now, threshold))
+	assert.True(t, pollFallbackDue(false, baby.ConnectionStats{}, now.Add(-3*time.Minute), now, threshold))
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	apperrors "github.com/indiefan/home_assistant_nanit/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// quietHoursResponse - quiet hours of a baby with whether they are active now
+type quietHoursResponse struct {
+	baby.QuietHours
+	Active bool `json:"active"`
+}
+
+// API handler for the quiet hours of the babies: /api/settings/quiet-hours
+// Events are still recorded to the history during quiet hours, only MQTT publishes and webhooks are suppressed.
+// PUT body: {"baby_uid": "...", "enabled": true, "start": "19:00", "end": "07:00", "timezone": "Europe/Prague"}
+func handleSettingsQuietHoursAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "GET" && r.Method != "PUT" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	if r.Method == "PUT" {
+		var req struct {
+			BabyUID string `json:"baby_uid"`
+			baby.QuietHours
+		}
+		if !app.decodeJSON(w, r, &req) {
+			return
+		}
+
+		if req.BabyUID == "" {
+			writeBabyUIDRequired(w)
+			return
+		}
+
+		if findBaby(app.getBabies(), req.BabyUID) == nil {
+			writeBabyNotFound(w, req.BabyUID)
+			return
+		}
+
+		if err := req.QuietHours.Validate(); err != nil {
+			writeError(w, apperrors.NewValidationError("invalid_quiet_hours", err.Error(), err).WithContext("baby_uid", req.BabyUID), http.StatusBadRequest)
+			return
+		}
+
+		if err := app.QuietHours.Set(req.BabyUID, req.QuietHours); err != nil {
+			log.Error().Err(err).Str("baby_uid", req.BabyUID).Msg("Failed to save quiet hours")
+			writeError(w, apperrors.NewStorageError("quiet_hours_save_failed", "Failed to save quiet hours", err), http.StatusInternalServerError)
+			return
+		}
+
+		log.Info().
+			Str("baby_uid", req.BabyUID).
+			Bool("enabled", req.Enabled).
+			Str("start", req.Start).
+			Str("end", req.End).
+			Str("timezone", req.Timezone).
+			Msg("Quiet hours updated")
+	}
+
+	now := time.Now()
+	babies := make(map[string]quietHoursResponse)
+	for babyUID, quietHours := range app.QuietHours.GetAll() {
+		babies[babyUID] = quietHoursResponse{QuietHours: quietHours, Active: quietHours.IsActive(now)}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"babies": babies,
+	})
+}
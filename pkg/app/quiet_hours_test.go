@@ -0,0 +1,100 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/history"
+	"github.com/indiefan/home_assistant_nanit/pkg/notify"
+	"github.com/indiefan/home_assistant_nanit/pkg/session"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingsQuietHoursAPI(t *testing.T) {
+	sessionStore := session.NewSessionStore()
+	sessionStore.Session.Babies = testBabies
+	app := &App{
+		SessionStore: sessionStore,
+		QuietHours:   baby.NewQuietHoursStore(filepath.Join(t.TempDir(), "quiet_hours.json")),
+	}
+
+	w := httptest.NewRecorder()
+	handleSettingsQuietHoursAPI(w, httptest.NewRequest("PUT", "/api/settings/quiet-hours", strings.NewReader(`{"baby_uid":"baby1","enabled":true,"start":"7pm","end":"07:00"}`)), app)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"invalid_quiet_hours"`)
+
+	w = httptest.NewRecorder()
+	handleSettingsQuietHoursAPI(w, httptest.NewRequest("PUT", "/api/settings/quiet-hours", strings.NewReader(`{"baby_uid":"unknown","enabled":false}`)), app)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	handleSettingsQuietHoursAPI(w, httptest.NewRequest("PUT", "/api/settings/quiet-hours", strings.NewReader(`{"baby_uid":"baby1","enabled":true,"start":"19:00","end":"07:00","timezone":"UTC"}`)), app)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, baby.QuietHours{Enabled: true, Start: "19:00", End: "07:00", Timezone: "UTC"}, app.QuietHours.Get("baby1"))
+
+	w = httptest.NewRecorder()
+	handleSettingsQuietHoursAPI(w, httptest.NewRequest("GET", "/api/settings/quiet-hours", nil), app)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Babies map[string]quietHoursResponse `json:"babies"`
+	}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	if assert.Contains(t, response.Babies, "baby1") {
+		assert.Equal(t, "19:00", response.Babies["baby1"].Start)
+		assert.Equal(t, app.QuietHours.IsActive("baby1", time.Now()), response.Babies["baby1"].Active)
+	}
+
+	w = httptest.NewRecorder()
+	handleSettingsQuietHoursAPI(w, httptest.NewRequest("DELETE", "/api/settings/quiet-hours", nil), app)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestDispatchEventSuppressedDuringQuietHours(t *testing.T) {
+	app := &App{
+		BabyStateManager: baby.NewStateManager(),
+		HistoryTracker:   &history.Tracker{},
+		eventCooldown:    utils.NewCooldown(0, nil),
+		QuietHours:       baby.NewQuietHoursStore(filepath.Join(t.TempDir(), "quiet_hours.json")),
+	}
+	assert.NoError(t, app.QuietHours.Set("baby1", baby.QuietHours{Enabled: true, Start: "19:00", End: "07:00", Timezone: "UTC"}))
+
+	published := make(chan baby.State, 10)
+	unsubscribe := app.BabyStateManager.Subscribe(func(babyUID string, state baby.State) {
+		published <- state
+	})
+	defer unsubscribe()
+
+	// Within the quiet hours the motion counts as activity, but isn't published
+	quiet := time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC)
+	app.dispatchEvent("baby1", notify.EventMotion, quiet)
+	if motion := app.BabyStateManager.GetBabyState("baby1").MotionTimestamp; assert.NotNil(t, motion) {
+		assert.Equal(t, int32(quiet.Unix()), *motion)
+	}
+
+	select {
+	case state := <-published:
+		assert.Nil(t, state.MotionTimestamp, "motion published during quiet hours")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Outside of the quiet hours it is published
+	app.dispatchEvent("baby1", notify.EventMotion, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	for {
+		select {
+		case state := <-published:
+			if state.MotionTimestamp != nil {
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Motion was not published outside of quiet hours")
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	apperrors "github.com/indiefan/home_assistant_nanit/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// rawRequestTypes - request types which may be sent through the raw command endpoint and the request fields
+// they may carry (protobuf names), id and type are always filled in by the app
+// Streaming, log uploads, network/server setup, firmware and credentials are left out, they could take the camera
+// away from the app or leak data. The fields are restricted too, so e.g. PUT_SETTINGS can't smuggle a streaming target.
+var rawRequestTypes = map[client.RequestType][]protoreflect.Name{
+	client.RequestType_GET_SETTINGS:        nil,
+	client.RequestType_PUT_SETTINGS:        {"settings"},
+	client.RequestType_GET_CONTROL:         {"getControl"},
+	client.RequestType_PUT_CONTROL:         {"control"},
+	client.RequestType_GET_STATUS:          {"getStatus"},
+	client.RequestType_GET_SENSOR_DATA:     {"getSensorData"},
+	client.RequestType_GET_PLAYBACK:        nil,
+	client.RequestType_PUT_PLAYBACK:        {"playback"},
+	client.RequestType_GET_SOUNDTRACKS:     nil,
+	client.RequestType_GET_STATUS_NETWORK:  nil,
+	client.RequestType_GET_BANDWIDTH:       nil,
+	client.RequestType_GET_FIRMWARE:        nil,
+	client.RequestType_GET_AUDIO_STREAMING: nil,
+}
+
+// disallowedRawField returns the first field of the request the request type may not carry, empty if there is none
+func disallowedRawField(reqType client.RequestType, request *client.Request) protoreflect.Name {
+	allowed := map[protoreflect.Name]bool{"id": true, "type": true}
+	for _, name := range rawRequestTypes[reqType] {
+		allowed[name] = true
+	}
+
+	var disallowed protoreflect.Name
+	request.ProtoReflect().Range(func(field protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if !allowed[field.Name()] {
+			disallowed = field.Name()
+			return false
+		}
+		return true
+	})
+
+	return disallowed
+}
+
+// rawCommandRequest - body of the raw command endpoint
+type rawCommandRequest struct {
+	BabyUID     string          `json:"baby_uid"`
+	RequestType string          `json:"request_type"`
+	Fields      json.RawMessage `json:"fields"` // client.Request in protobuf JSON, id and type are set by the app
+}
+
+// API handler sending a websocket request the app doesn't wrap to a camera: /api/control/raw
+// Only available with NANIT_RAW_COMMANDS_ENABLED and a web password set, so it is limited to logged in users.
+// POST body: {"baby_uid": "...", "request_type": "PUT_SETTINGS", "fields": {"settings": {"volume": 40}}}
+func handleRawCommandAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "POST" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	if !app.Opts.RawCommandsEnabled {
+		writeError(w, apperrors.NewAuthError("raw_commands_disabled", "Raw commands are disabled, set NANIT_RAW_COMMANDS_ENABLED=true to enable them", nil), http.StatusForbidden)
+		return
+	}
+
+	if !app.Opts.WebAuth.Enabled || app.WebAuth == nil || !app.WebAuth.IsPasswordSet() {
+		writeError(w, apperrors.NewAuthError("password_required", "Raw commands require a web password", nil), http.StatusForbidden)
+		return
+	}
+
+	var req rawCommandRequest
+	if !app.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.BabyUID == "" {
+		writeBabyUIDRequired(w)
+		return
+	}
+
+	if findBaby(app.getBabies(), req.BabyUID) == nil {
+		writeBabyNotFound(w, req.BabyUID)
+		return
+	}
+
+	value, ok := client.RequestType_value[req.RequestType]
+	if !ok {
+		writeError(w, apperrors.NewValidationError("invalid_request_type", "Unknown request_type", nil).WithContext("request_type", req.RequestType), http.StatusBadRequest)
+		return
+	}
+
+	reqType := client.RequestType(value)
+	if _, ok := rawRequestTypes[reqType]; !ok {
+		writeError(w, apperrors.NewValidationError("request_type_not_allowed", "request_type can't be sent as a raw command", nil).WithContext("request_type", req.RequestType), http.StatusBadRequest)
+		return
+	}
+
+	request := &client.Request{}
+	if len(req.Fields) > 0 {
+		// Id and type are required by the schema but filled in when the request is sent
+		if err := (protojson.UnmarshalOptions{AllowPartial: true}).Unmarshal(req.Fields, request); err != nil {
+			writeError(w, apperrors.NewValidationError("invalid_fields", "Invalid fields: "+err.Error(), err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if field := disallowedRawField(reqType, request); field != "" {
+		writeError(w, apperrors.NewValidationError("field_not_allowed", "Field can't be sent with request_type", nil).
+			WithContext("request_type", req.RequestType).
+			WithContext("field", string(field)), http.StatusBadRequest)
+		return
+	}
+
+	conn := app.getConnection(req.BabyUID)
+	if conn == nil {
+		if writeMonitoringNotStarted(w, app) {
+			return
+		}
+		writeError(w, apperrors.NewNetworkError("camera_not_connected", "WebSocket not connected", nil).WithContext("baby_uid", req.BabyUID), http.StatusServiceUnavailable)
+		return
+	}
+
+	log.Info().Str("baby_uid", req.BabyUID).Stringer("request_type", reqType).Msg("Sending raw command")
+	app.sendRawCommand(w, req.BabyUID, reqType, request, conn)
+}
+
+// sendRawCommand sends the request and writes the camera's response, non-200 statuses of the camera included
+func (app *App) sendRawCommand(w http.ResponseWriter, babyUID string, reqType client.RequestType, request *client.Request, conn client.Connection) {
+	awaitResponse := conn.SendRequest(reqType, request)
+	res, err := awaitResponse(client.RequestTimeout(reqType))
+	if res == nil {
+		if err != nil && err.Error() == "Request timeout" {
+			writeError(w, apperrors.NewNetworkError("camera_request_timeout", "Camera did not respond in time", err).WithContext("baby_uid", babyUID), http.StatusGatewayTimeout)
+			return
+		}
+		writeError(w, apperrors.NewExternalError("camera_request_failed", "Failed to send the request to the camera", err).WithContext("baby_uid", babyUID), http.StatusBadGateway)
+		return
+	}
+
+	payload, err := protojson.Marshal(res)
+	if err != nil {
+		writeError(w, apperrors.NewExternalError("invalid_camera_response", "Failed to encode the camera response", err).WithContext("baby_uid", babyUID), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"baby_uid":       babyUID,
+		"request_type":   reqType.String(),
+		"status_code":    res.GetStatusCode(),
+		"status_message": res.GetStatusMessage(),
+		"response":       json.RawMessage(payload),
+	})
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/indiefan/home_assistant_nanit/pkg/session"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/indiefan/home_assistant_nanit/pkg/webauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawCommandAPI(t *testing.T) {
+	sessionStore := session.NewSessionStore()
+	sessionStore.Session.Babies = testBabies
+
+	app := &App{
+		Opts:             Opts{WebAuth: WebAuthOpts{Enabled: true}},
+		SessionStore:     sessionStore,
+		BabyStateManager: baby.NewStateManager(),
+		WebAuth:          webauth.NewWebAuth(filepath.Join(t.TempDir(), "web_password.json")),
+		connections:      make(map[string]*client.WebsocketConnection),
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handleRawCommandAPI(w, httptest.NewRequest("POST", "/api/control/raw", strings.NewReader(body)), app)
+		return w
+	}
+
+	// Disabled by default
+	w := post(`{"baby_uid":"baby1","request_type":"GET_SETTINGS"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "raw_commands_disabled")
+
+	// Not without a web password
+	app.Opts.RawCommandsEnabled = true
+	w = post(`{"baby_uid":"baby1","request_type":"GET_SETTINGS"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "password_required")
+
+	assert.NoError(t, app.WebAuth.SetPassword("secret-password"))
+
+	w = post(`{"baby_uid":"unknown","request_type":"GET_SETTINGS"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = post(`{"baby_uid":"baby1","request_type":"GET_NOTHING"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_request_type")
+
+	w = post(`{"baby_uid":"baby1","request_type":"PUT_STREAMING"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "request_type_not_allowed")
+
+	w = post(`{"baby_uid":"baby1","request_type":"PUT_SETTINGS","fields":{"settings":{"loudness":40}}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_fields")
+
+	// Sub-messages of other request types are rejected, they could redirect the stream or the log upload
+	w = post(`{"baby_uid":"baby1","request_type":"PUT_SETTINGS","fields":{"settings":{"volume":40},"streaming":{"id":"MOBILE","status":"STARTED","rtmpUrl":"rtmp://attacker/live"}}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "field_not_allowed")
+
+	w = post(`{"baby_uid":"baby1","request_type":"GET_SETTINGS","fields":{"getLogs":{"url":"http://attacker/log"}}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "field_not_allowed")
+
+	app.setMode(Mode_Monitoring)
+	w = post(`{"baby_uid":"baby1","request_type":"PUT_SETTINGS","fields":{"settings":{"volume":40}}}`)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "camera_not_connected")
+}
+
+func TestSendRawCommand(t *testing.T) {
+	app := &App{}
+	conn := newFakeConnection()
+
+	send := func(reqType client.RequestType, request *client.Request) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		app.sendRawCommand(w, "baby1", reqType, request, conn)
+
+		var response map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&response)
+		return w, response
+	}
+
+	w, _ := send(client.RequestType_GET_SETTINGS, &client.Request{})
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	conn.Respond(client.RequestType_GET_SETTINGS, func(*client.Request) *client.Response {
+		return &client.Response{Settings: &client.Settings{Volume: utils.ConstRefInt32(40)}}
+	})
+	w, response := send(client.RequestType_GET_SETTINGS, &client.Request{})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "GET_SETTINGS", response["request_type"])
+	assert.Equal(t, float64(200), response["status_code"])
+	assert.Equal(t, map[string]interface{}{"volume": float64(40)}, response["response"].(map[string]interface{})["settings"])
+
+	// Errors of the camera are passed on as they are
+	conn.Respond(client.RequestType_PUT_CONTROL, func(*client.Request) *client.Response {
+		return &client.Response{StatusCode: utils.ConstRefInt32(400), StatusMessage: utils.ConstRefStr("Bad request")}
+	})
+	w, response = send(client.RequestType_PUT_CONTROL, &client.Request{})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(400), response["status_code"])
+	assert.Equal(t, "Bad request", response["status_message"])
+}
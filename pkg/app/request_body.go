@@ -0,0 +1,74 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	apperrors "github.com/indiefan/home_assistant_nanit/pkg/errors"
+)
+
+// errEmptyRequestBody - the request has no body to decode
+var errEmptyRequestBody = errors.New("request body is empty")
+
+// readJSON decodes the JSON request body into v, bodies larger than maxBytes (unlimited if 0) are rejected
+// Unknown fields and trailing data after the JSON value are rejected too, so typos in field names don't go unnoticed.
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}, maxBytes int64) error {
+	body := r.Body
+	if maxBytes > 0 {
+		body = http.MaxBytesReader(w, r.Body, maxBytes)
+	}
+
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); errors.Is(err, io.EOF) {
+		return errEmptyRequestBody
+	} else if err != nil {
+		return err
+	}
+
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return err
+		}
+		return errors.New("unexpected data after the JSON value")
+	}
+
+	return nil
+}
+
+// writeJSONDecodeError writes 413 if the body exceeds the limit, 400 otherwise
+func writeJSONDecodeError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeError(w, apperrors.NewValidationError("request_too_large", fmt.Sprintf("Request body must not be larger than %d bytes", maxBytesErr.Limit), err).WithContext("limit_bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	writeError(w, apperrors.NewValidationError("invalid_request", "Invalid request body: "+strings.TrimPrefix(err.Error(), "json: "), err), http.StatusBadRequest)
+}
+
+// decodeJSON decodes the JSON request body into v, writes the error response and returns false if it isn't valid
+func (app *App) decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := readJSON(w, r, v, app.Opts.MaxRequestBodyBytes); err != nil {
+		writeJSONDecodeError(w, err)
+		return false
+	}
+
+	return true
+}
+
+// decodeOptionalJSON is decodeJSON for requests whose body may be left out, v is left unchanged then
+func (app *App) decodeOptionalJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := readJSON(w, r, v, app.Opts.MaxRequestBodyBytes); err != nil && !errors.Is(err, errEmptyRequestBody) {
+		writeJSONDecodeError(w, err)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	apperrors "github.com/indiefan/home_assistant_nanit/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// sensitivitySettings - motion/sound event thresholds of a camera, nil if unknown (or left unchanged by PUT)
+type sensitivitySettings struct {
+	MotionThreshold *int32 `json:"motion_threshold"`
+	SoundThreshold  *int32 `json:"sound_threshold"`
+}
+
+// API handler for the motion/sound sensitivity of a camera: /api/settings/sensitivity/{baby_uid}
+// Values are the raw event thresholds of the camera, raising them reduces false positive events.
+// PUT body: {"motion_threshold": 40, "sound_threshold": 60}, thresholds left out are not changed
+func handleSettingsSensitivityAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "GET" && r.Method != "PUT" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	babyUID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/settings/sensitivity/"), "/")
+	if babyUID == "" {
+		writeBabyUIDRequired(w)
+		return
+	}
+
+	if findBaby(app.getBabies(), babyUID) == nil {
+		writeBabyNotFound(w, babyUID)
+		return
+	}
+
+	if r.Method == "PUT" {
+		var req sensitivitySettings
+		if !app.decodeJSON(w, r, &req) {
+			return
+		}
+
+		if req.MotionThreshold == nil && req.SoundThreshold == nil {
+			writeError(w, apperrors.NewValidationError("sensitivity_required", "motion_threshold or sound_threshold is required", nil), http.StatusBadRequest)
+			return
+		}
+
+		for name, threshold := range map[string]*int32{"motion_threshold": req.MotionThreshold, "sound_threshold": req.SoundThreshold} {
+			if threshold != nil && *threshold < 0 {
+				writeError(w, apperrors.NewValidationError("invalid_sensitivity", name+" must not be negative", nil).WithContext(name, *threshold), http.StatusBadRequest)
+				return
+			}
+		}
+
+		conn := app.getConnection(babyUID)
+		if conn == nil {
+			if writeMonitoringNotStarted(w, app) {
+				return
+			}
+			writeError(w, apperrors.NewNetworkError("camera_not_connected", "WebSocket not connected", nil).WithContext("baby_uid", babyUID), http.StatusServiceUnavailable)
+			return
+		}
+
+		if err := app.applySensitivity(babyUID, req, conn); err != nil {
+			log.Error().Err(err).Str("baby_uid", babyUID).Msg("Camera rejected sensitivity settings")
+			writeError(w, apperrors.NewExternalError("sensitivity_update_failed", "Camera did not accept the sensitivity settings", err).WithContext("baby_uid", babyUID), http.StatusBadGateway)
+			return
+		}
+
+		log.Info().
+			Str("baby_uid", babyUID).
+			Interface("motion_threshold", req.MotionThreshold).
+			Interface("sound_threshold", req.SoundThreshold).
+			Msg("Sensitivity settings updated")
+	}
+
+	current := sensitivitySettings{}
+	if deviceInfo := app.BabyStateManager.GetBabyState(babyUID).DeviceInfo; deviceInfo != nil {
+		current.MotionThreshold = deviceInfo.MotionThreshold
+		current.SoundThreshold = deviceInfo.SoundThreshold
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"baby_uid":         babyUID,
+		"motion_threshold": current.MotionThreshold,
+		"sound_threshold":  current.SoundThreshold,
+	})
+}
+
+// applySensitivity sends the thresholds to the cam and keeps them in the device info once the cam accepts them
+func (app *App) applySensitivity(babyUID string, settings sensitivitySettings, conn client.Connection) error {
+	awaitResponse := sendSensitivityCommand(settings.MotionThreshold, settings.SoundThreshold, conn)
+	if _, err := awaitResponse(client.RequestTimeout(client.RequestType_PUT_SETTINGS)); err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	app.BabyStateManager.Update(babyUID, *baby.NewState().SetDeviceInfo(&baby.DeviceInfo{
+		MotionThreshold: settings.MotionThreshold,
+		SoundThreshold:  settings.SoundThreshold,
+		LastUpdated:     &timestamp,
+	}))
+
+	return nil
+}
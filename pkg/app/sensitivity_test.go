@@ -0,0 +1,92 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/indiefan/home_assistant_nanit/pkg/session"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingsSensitivityAPI(t *testing.T) {
+	sessionStore := session.NewSessionStore()
+	sessionStore.Session.Babies = testBabies
+
+	app := &App{
+		SessionStore:     sessionStore,
+		BabyStateManager: baby.NewStateManager(),
+		connections:      make(map[string]*client.WebsocketConnection),
+	}
+
+	// Thresholds reported by the cam are surfaced in the device info
+	processStandby("baby1", &client.Settings{Sensors: []*client.Settings_SensorSettings{
+		{SensorType: client.SensorType_MOTION.Enum(), HighThreshold: utils.ConstRefInt32(30)},
+		{SensorType: client.SensorType_SOUND.Enum(), HighThreshold: utils.ConstRefInt32(50)},
+	}}, app.BabyStateManager)
+
+	w := httptest.NewRecorder()
+	handleSettingsSensitivityAPI(w, httptest.NewRequest("GET", "/api/settings/sensitivity/baby1", nil), app)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, float64(30), response["motion_threshold"])
+	assert.Equal(t, float64(50), response["sound_threshold"])
+
+	put := func(path string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handleSettingsSensitivityAPI(w, httptest.NewRequest("PUT", path, strings.NewReader(body)), app)
+		return w
+	}
+
+	w = put("/api/settings/sensitivity/unknown", `{"motion_threshold":40}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = put("/api/settings/sensitivity/baby1", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "sensitivity_required")
+
+	w = put("/api/settings/sensitivity/baby1", `{"sound_threshold":-1}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_sensitivity")
+
+	// Camera has to be connected
+	app.setMode(Mode_Monitoring)
+	w = put("/api/settings/sensitivity/baby1", `{"motion_threshold":40}`)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "camera_not_connected")
+}
+
+func TestApplySensitivity(t *testing.T) {
+	app := &App{BabyStateManager: baby.NewStateManager()}
+	conn := newFakeConnection()
+
+	// Nothing is stored while the cam doesn't confirm
+	assert.Error(t, app.applySensitivity("baby1", sensitivitySettings{MotionThreshold: utils.ConstRefInt32(40)}, conn))
+	assert.Nil(t, app.BabyStateManager.GetBabyState("baby1").DeviceInfo)
+
+	conn.Respond(client.RequestType_PUT_SETTINGS, func(*client.Request) *client.Response {
+		return &client.Response{}
+	})
+	assert.NoError(t, app.applySensitivity("baby1", sensitivitySettings{MotionThreshold: utils.ConstRefInt32(40)}, conn))
+
+	// Only the given threshold is sent
+	if request := conn.LastRequest(client.RequestType_PUT_SETTINGS); assert.NotNil(t, request) && assert.Len(t, request.Settings.Sensors, 1) {
+		sensor := request.Settings.Sensors[0]
+		assert.Equal(t, client.SensorType_MOTION, sensor.GetSensorType())
+		assert.True(t, sensor.GetUseHighThreshold())
+		assert.Equal(t, int32(40), sensor.GetHighThreshold())
+	}
+
+	deviceInfo := app.BabyStateManager.GetBabyState("baby1").DeviceInfo
+	if assert.NotNil(t, deviceInfo) {
+		assert.Equal(t, int32(40), *deviceInfo.MotionThreshold)
+		assert.Nil(t, deviceInfo.SoundThreshold)
+	}
+}
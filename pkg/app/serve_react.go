@@ -3,12 +3,10 @@ package app
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/indiefan/home_assistant_nanit/pkg/baby"
@@ -77,10 +75,57 @@ func ServeReact(babies []baby.Baby, dataDir DataDirectories, stateManager *baby.
 	})
 
 	// API endpoints - keep existing API structure
-	setupAPIRoutes(babies, dataDir, stateManager, app)
+	setupAPIRoutes(dataDir, stateManager, app)
 
 	log.Info().Int("port", port).Msg("Starting HTTP server with React frontend")
-	http.ListenAndServe(fmt.Sprintf(":%v", port), nil)
+	http.ListenAndServe(fmt.Sprintf(":%v", port), corsMiddleware(app.Opts.CORSAllowedOrigins, http.DefaultServeMux))
+}
+
+// corsMiddleware applies the CORS policy to API endpoints and answers preflight requests.
+// Allowed origins are echoed back with credentials (cookie auth), "*" allows any origin without credentials.
+// Without configured origins only same-origin requests are possible.
+func corsMiddleware(allowedOrigins []string, next http.Handler) http.Handler {
+	allowAny := false
+	allowed := make(map[string]bool)
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			allowAny = true
+		} else {
+			allowed[strings.TrimSuffix(origin, "/")] = true
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+
+		if allowed[origin] {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		} else if allowAny {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+
+		// Preflight
+		if r.Method == "OPTIONS" && r.Header.Get("Access-Control-Request-Method") != "" {
+			if allowed[origin] || allowAny {
+				w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, DELETE, OPTIONS")
+				if requestHeaders := r.Header.Get("Access-Control-Request-Headers"); requestHeaders != "" {
+					w.Header().Set("Access-Control-Allow-Headers", requestHeaders)
+				}
+				w.Header().Set("Access-Control-Max-Age", "600")
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
 }
 
 // requireAuth is middleware that checks for web authentication
@@ -108,34 +153,94 @@ func requireAuth(app *App, handler http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-func setupAPIRoutes(babies []baby.Baby, dataDir DataDirectories, stateManager *baby.StateManager, app *App) {
+func setupAPIRoutes(dataDir DataDirectories, stateManager *baby.StateManager, app *App) {
 	// Status and baby data - protected by auth if enabled
 	http.HandleFunc("/api/status", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
-		handleStatusAPI(w, r, babies, stateManager)
+		handleStatusAPI(w, r, app.getBabies(), stateManager, app.DisplayConfig, app.Opts.EventActiveWindow, app.getMode())
 	}))
 
 	http.HandleFunc("/api/babies", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
-		handleBabiesAPI(w, r, babies, stateManager)
+		handleBabiesAPI(w, r, app.getBabies(), app.DisplayConfig)
+	}))
+
+	// Custom display name, order and visibility of a baby (local only, not synced to Nanit)
+	http.HandleFunc("/api/babies/config/", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleBabyDisplayConfigAPI(w, r, app)
+	}))
+
+	// Transcoding profile of a baby: /api/babies/{baby_uid}/stream-config
+	http.HandleFunc("/api/babies/", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleBabyResourceAPI(w, r, app)
+	}))
+
+	// Re-fetch the baby list from Nanit and start monitoring newly added cameras
+	http.HandleFunc("/api/babies/refresh", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleBabiesRefreshAPI(w, r, app)
+	}))
+
+	// Account-wide temperature unit applied to status and history responses
+	http.HandleFunc("/api/settings/units", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleSettingsUnitsAPI(w, r, app)
+	}))
+
+	// Per baby windows during which motion/sound webhooks and MQTT events are suppressed
+	http.HandleFunc("/api/settings/quiet-hours", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleSettingsQuietHoursAPI(w, r, app)
+	}))
+
+	// Motion/sound event thresholds of the cameras, to tune out false positive events
+	http.HandleFunc("/api/settings/sensitivity/", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleSettingsSensitivityAPI(w, r, app)
+	}))
+
+	// Raw event list recorded by the Nanit cloud, for reconciling with the local history
+	http.HandleFunc("/api/nanit/messages/", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleNanitMessagesAPI(w, r, app)
+	}))
+
+	// Real-time state transitions for the dashboard
+	http.HandleFunc("/api/ws/status", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleStatusWebSocketAPI(w, r, app)
+	}))
+
+	// Consolidated dashboard payload (status, device info and health of all babies)
+	http.HandleFunc("/api/dashboard", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleDashboardAPI(w, r, app.getBabies(), app)
 	}))
 
 	// Control endpoints
 	http.HandleFunc("/api/control/night-light", func(w http.ResponseWriter, r *http.Request) {
-		handleControlAPI(w, r, "night-light", babies, stateManager, app)
+		handleControlAPI(w, r, "night-light", app.getBabies(), stateManager, app)
 	})
 
 	http.HandleFunc("/api/control/standby", func(w http.ResponseWriter, r *http.Request) {
-		handleControlAPI(w, r, "standby", babies, stateManager, app)
+		handleControlAPI(w, r, "standby", app.getBabies(), stateManager, app)
 	})
 
+	// Raw camera commands - always protected, see handleRawCommandAPI
+	http.HandleFunc("/api/control/raw", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleRawCommandAPI(w, r, app)
+	}))
+
+	// Camera logs on demand, uploaded by the camera to /log
+	http.HandleFunc("/api/camera-logs/", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleCameraLogsAPI(w, r, app)
+	}))
+
 	// Device info endpoint
 	http.HandleFunc("/api/device-info/", func(w http.ResponseWriter, r *http.Request) {
-		handleDeviceInfoAPI(w, r, babies, stateManager)
+		handleDeviceInfoAPI(w, r, app.getBabies(), app)
 	})
 
+	// Acknowledging a device alert hides it from the device info until it fires again
+	http.HandleFunc("/api/alerts/", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleAlertAckAPI(w, r, app)
+	}))
+
 	// Authentication endpoints (Nanit API)
 	log.Info().Msg("Registering Nanit authentication endpoints")
 	http.HandleFunc("/api/auth/login", func(w http.ResponseWriter, r *http.Request) {
-		handleAuthLoginAPI(w, r)
+		handleAuthLoginAPI(w, r, app)
 	})
 
 	http.HandleFunc("/api/auth/verify-2fa", func(w http.ResponseWriter, r *http.Request) {
@@ -150,6 +255,14 @@ func setupAPIRoutes(babies []baby.Baby, dataDir DataDirectories, stateManager *b
 		handleAuthResetAPI(w, r, app)
 	})
 
+	http.HandleFunc("/api/auth/refresh", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleAuthRefreshAPI(w, r, app)
+	}))
+
+	http.HandleFunc("/api/auth/relogin", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleAuthReloginAPI(w, r, app)
+	}))
+
 	// Web password authentication endpoints
 	log.Info().Msg("Registering web password authentication endpoints")
 	http.HandleFunc("/api/webauth/status", func(w http.ResponseWriter, r *http.Request) {
@@ -189,10 +302,26 @@ func setupAPIRoutes(babies []baby.Baby, dataDir DataDirectories, stateManager *b
 		handleStreamStopAPI(w, r, app)
 	})
 
+	http.HandleFunc("/api/stream/mosaic/", func(w http.ResponseWriter, r *http.Request) {
+		handleStreamMosaicAPI(w, r, app)
+	})
+
+	http.HandleFunc("/api/stream/claim/", func(w http.ResponseWriter, r *http.Request) {
+		handleStreamClaimAPI(w, r, app)
+	})
+
+	http.HandleFunc("/api/stream/release/", func(w http.ResponseWriter, r *http.Request) {
+		handleStreamReleaseAPI(w, r, app)
+	})
+
 	http.HandleFunc("/api/stream/status/", func(w http.ResponseWriter, r *http.Request) {
 		handleStreamStatusAPI(w, r, app)
 	})
 
+	http.HandleFunc("/api/stream/probe/", func(w http.ResponseWriter, r *http.Request) {
+		handleStreamProbeAPI(w, r, app)
+	})
+
 	// Historical data endpoints
 	http.HandleFunc("/api/history/sensor/", func(w http.ResponseWriter, r *http.Request) {
 		handleHistorySensorAPI(w, r, app)
@@ -210,10 +339,41 @@ func setupAPIRoutes(babies []baby.Baby, dataDir DataDirectories, stateManager *b
 		handleHistoryDayNightAPI(w, r, app)
 	})
 
+	http.HandleFunc("/api/history/cry-summary/", func(w http.ResponseWriter, r *http.Request) {
+		handleHistoryCrySummaryAPI(w, r, app)
+	})
+
 	http.HandleFunc("/api/history/reset/", func(w http.ResponseWriter, r *http.Request) {
 		handleHistoryResetAPI(w, r, app)
 	})
 
+	// Selective deletion of the history of a baby, e.g. a day of false motion events: DELETE /api/history/{baby_uid}
+	http.HandleFunc("/api/history/", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleHistoryDeleteAPI(w, r, app)
+	}))
+
+	// Whole history database for backups and offline analysis
+	http.HandleFunc("/api/history/db", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleHistoryDBAPI(w, r, app)
+	}))
+
+	http.HandleFunc("/api/circuit-breakers", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleCircuitBreakersAPI(w, r, app)
+	}))
+
+	// Sanitized bundle (config without secrets, states, transcoders, health) to attach to issue reports
+	http.HandleFunc("/api/diagnostics", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleDiagnosticsAPI(w, r, app)
+	}))
+
+	http.HandleFunc("/api/storage", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleStorageAPI(w, r, app)
+	}))
+
+	http.HandleFunc("/api/storage/cleanup", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleStorageCleanupAPI(w, r, app)
+	}))
+
 	// Health endpoints
 	http.HandleFunc("/api/health/", func(w http.ResponseWriter, r *http.Request) {
 		handleHealthAPI(w, r, app)
@@ -221,7 +381,12 @@ func setupAPIRoutes(babies []baby.Baby, dataDir DataDirectories, stateManager *b
 	
 	// Basic liveness check (no authentication required)
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		handleLivenessAPI(w, r)
+		handleLivenessAPI(w, r, app)
+	})
+
+	// Kubernetes style aliases
+	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		handleLivenessAPI(w, r, app)
 	})
 	
 	// Readiness check with detailed service status (no authentication required)
@@ -229,32 +394,28 @@ func setupAPIRoutes(babies []baby.Baby, dataDir DataDirectories, stateManager *b
 		handleReadinessAPI(w, r, app)
 	})
 
-	// Video files
-	http.Handle("/video/", http.StripPrefix("/video/", http.FileServer(http.Dir(dataDir.VideoDir))))
-
-	// Dummy log handler - useful for receiving logs from cam
-	http.HandleFunc("/log", func(w http.ResponseWriter, r *http.Request) {
-		filename := filepath.Join(dataDir.LogDir, fmt.Sprintf("camlogs-%v.tar.gz", time.Now().Format(time.RFC3339)))
-
-		log.Info().Str("file", filename).Msg("Saving log to file")
-		defer r.Body.Close()
-
-		out, err := os.Create(filename)
-		if err != nil {
-			log.Error().Str("file", filename).Err(err).Msg("Unable to create file")
-		}
+	http.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		handleReadinessAPI(w, r, app)
+	})
 
-		defer out.Close()
+	http.HandleFunc("/api/mode", func(w http.ResponseWriter, r *http.Request) {
+		handleModeAPI(w, r, app)
+	})
 
-		_, err = io.Copy(out, r.Body)
+	// Disconnecting from the cameras temporarily (e.g. to free the connection slot for the official app)
+	http.HandleFunc("/api/monitoring/pause", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleMonitoringPauseAPI(w, r, app, true)
+	}))
+	http.HandleFunc("/api/monitoring/resume", requireAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		handleMonitoringPauseAPI(w, r, app, false)
+	}))
 
-		if err != nil {
-			log.Error().Str("file", filename).Err(err).Msg("Unable to save received log file")
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+	// Video files
+	http.Handle("/video/", http.StripPrefix("/video/", http.FileServer(http.Dir(dataDir.VideoDir))))
 
-		w.WriteHeader(http.StatusNoContent)
+	// Log handler - useful for receiving logs from cam
+	http.HandleFunc("/log", func(w http.ResponseWriter, r *http.Request) {
+		handleCamLogUpload(w, r, app)
 	})
 }
 
@@ -297,8 +458,7 @@ func handleWebAuthLoginAPI(w http.ResponseWriter, r *http.Request, app *App) {
 		Password string `json:"password"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if !app.decodeJSON(w, r, &requestData) {
 		return
 	}
 
@@ -386,8 +546,7 @@ func handleSetPasswordAPI(w http.ResponseWriter, r *http.Request, app *App) {
 		Password string `json:"password"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if !app.decodeJSON(w, r, &requestData) {
 		return
 	}
 
@@ -431,8 +590,7 @@ func handleChangePasswordAPI(w http.ResponseWriter, r *http.Request, app *App) {
 		NewPassword     string `json:"new_password"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if !app.decodeJSON(w, r, &requestData) {
 		return
 	}
 
@@ -487,8 +645,7 @@ func handleRemovePasswordAPI(w http.ResponseWriter, r *http.Request, app *App) {
 		Password string `json:"password"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if !app.decodeJSON(w, r, &requestData) {
 		return
 	}
 
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// startupAuthRetries - retries of the startup authorization before the app falls back to web-only mode
+	startupAuthRetries = 3
+
+	// startupAuthInitialDelay - delay before the first retry of the startup authorization, doubled for every further one
+	startupAuthInitialDelay = 5 * time.Second
+
+	// startupAuthMaxDelay - upper bound of the delay between two startup authorization retries
+	startupAuthMaxDelay = 30 * time.Second
+)
+
+// startupAuthDelay - how long to wait before the given retry (0 based) of the startup authorization
+func startupAuthDelay(retry int) time.Duration {
+	return min(startupAuthInitialDelay<<min(retry, 10), startupAuthMaxDelay)
+}
+
+// isPermanentAuthError - returns whether the authorization failed because the user has to sign in again,
+// retrying won't help then
+func isPermanentAuthError(err error) bool {
+	return errors.Is(err, client.ErrCredentialsRequired) || errors.Is(err, client.ErrInvalidCredentials) || errors.Is(err, client.ErrMFARequired)
+}
+
+// authorizeAndFetchBabies - single attempt to authorize with the stored session and load the babies
+func (app *App) authorizeAndFetchBabies() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("authorization panicked: %v", r)
+		}
+	}()
+
+	if err := app.RestClient.MaybeAuthorize(false); err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	if _, err := app.RestClient.EnsureBabies(); err != nil {
+		return fmt.Errorf("failed to fetch babies: %w", err)
+	}
+
+	return nil
+}
+
+// authorizeWithRetry - runs the attempt until it succeeds, fails permanently, the retries run out or the context is cancelled
+func authorizeWithRetry(ctx utils.GracefulContext, retries int, delay func(int) time.Duration, attempt func() error) error {
+	for retry := 0; ; retry++ {
+		err := attempt()
+		if err == nil || isPermanentAuthError(err) || retry >= retries {
+			return err
+		}
+
+		log.Warn().Err(err).Int("retry", retry+1).Dur("delay", delay(retry)).Msg("Startup authorization failed, retrying")
+
+		timer := time.NewTimer(delay(retry))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// retryAuthorizationInBackground - keeps retrying the authorization after the startup one failed and starts
+// the monitoring once it succeeds (blocking, the web UI is served meanwhile)
+// Gives up if the user has to sign in again, or the monitoring was started by signing in meanwhile.
+func (app *App) retryAuthorizationInBackground(ctx utils.GracefulContext, interval time.Duration, attempt func() error) {
+	log.Info().Dur("interval", interval).Msg("Retrying authorization in the background")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		app.babiesMutex.Lock()
+		started := app.monitoringStarted
+		app.babiesMutex.Unlock()
+
+		if started {
+			log.Info().Msg("Monitoring started meanwhile, background authorization retry stopped")
+			return
+		}
+
+		// Already authorized by the attempt, no need for StartMonitoringServices' forced renewal
+		err := attempt()
+		if err == nil {
+			if app.startMonitoring() {
+				log.Info().Msg("Authorization succeeded, monitoring started")
+			} else {
+				log.Info().Msg("Authorization succeeded, monitoring was started meanwhile")
+			}
+			return
+		}
+
+		if isPermanentAuthError(err) {
+			log.Warn().Err(err).Msg("Authorization requires signing in again, background authorization retry stopped")
+			return
+		}
+
+		log.Warn().Err(err).Msg("Background authorization retry failed")
+	}
+}
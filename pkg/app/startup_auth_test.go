@@ -0,0 +1,131 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/indiefan/home_assistant_nanit/pkg/history"
+	"github.com/indiefan/home_assistant_nanit/pkg/session"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartupAuthDelay(t *testing.T) {
+	assert.Equal(t, 5*time.Second, startupAuthDelay(0))
+	assert.Equal(t, 10*time.Second, startupAuthDelay(1))
+	assert.Equal(t, 20*time.Second, startupAuthDelay(2))
+	assert.Equal(t, 30*time.Second, startupAuthDelay(3))
+	assert.Equal(t, 30*time.Second, startupAuthDelay(100))
+}
+
+func TestAuthorizeWithRetry(t *testing.T) {
+	noDelay := func(int) time.Duration { return time.Millisecond }
+
+	utils.RunWithGracefulCancel(func(ctx utils.GracefulContext) {
+		// Transient failures are retried
+		attempts := 0
+		err := authorizeWithRetry(ctx, 3, noDelay, func() error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("Nanit API unavailable")
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, attempts)
+
+		// Up to the retry limit
+		attempts = 0
+		err = authorizeWithRetry(ctx, 3, noDelay, func() error {
+			attempts++
+			return errors.New("Nanit API unavailable")
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 4, attempts)
+
+		// Not if the user has to sign in again
+		attempts = 0
+		err = authorizeWithRetry(ctx, 3, noDelay, func() error {
+			attempts++
+			return fmt.Errorf("authentication failed: %w", client.ErrInvalidCredentials)
+		})
+		assert.True(t, errors.Is(err, client.ErrInvalidCredentials))
+		assert.Equal(t, 1, attempts)
+	}).Wait()
+}
+
+func TestRetryAuthorizationInBackgroundStops(t *testing.T) {
+	app := &App{}
+
+	// Signing in again is up to the user
+	attempts := 0
+	runner := utils.RunWithGracefulCancel(func(ctx utils.GracefulContext) {
+		app.retryAuthorizationInBackground(ctx, time.Millisecond, func() error {
+			attempts++
+			return client.ErrMFARequired
+		})
+	})
+	runner.Wait()
+	assert.Equal(t, 1, attempts)
+
+	// Monitoring was started by signing in meanwhile
+	app.monitoringStarted = true
+	attempts = 0
+	runner = utils.RunWithGracefulCancel(func(ctx utils.GracefulContext) {
+		app.retryAuthorizationInBackground(ctx, time.Millisecond, func() error {
+			attempts++
+			return nil
+		})
+	})
+	runner.Wait()
+	assert.Equal(t, 0, attempts)
+}
+
+func TestRetryAuthorizationInBackgroundPromotes(t *testing.T) {
+	sessionStore := session.NewSessionStore()
+	sessionStore.Session.Babies = testBabies
+
+	app := &App{
+		SessionStore:     sessionStore,
+		BabyStateManager: baby.NewStateManager(),
+		HistoryTracker:   &history.Tracker{},
+		monitoredBabies:  make(map[string]*monitoredBaby),
+		pendingTasks:     utils.NewPendingTasks(),
+	}
+
+	mainContext := make(chan utils.GracefulContext)
+	runner := utils.RunWithGracefulCancel(func(ctx utils.GracefulContext) {
+		mainContext <- ctx
+		<-ctx.Done()
+	})
+	defer runner.Cancel()
+	app.mainContext = <-mainContext
+
+	// Monitoring starts once the Nanit API recovers
+	attempts := 0
+	utils.RunWithGracefulCancel(func(ctx utils.GracefulContext) {
+		app.retryAuthorizationInBackground(ctx, time.Millisecond, func() error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("Nanit API unavailable")
+			}
+			return nil
+		})
+	}).Wait()
+
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, Mode_Monitoring, app.getMode())
+
+	uids := app.getMonitoredBabyUIDs()
+	sort.Strings(uids)
+	assert.Equal(t, []string{"baby1", "baby2", "baby3"}, uids)
+
+	// Signing in afterwards doesn't start the services a second time (no authorization, RestClient isn't even set)
+	assert.False(t, app.startMonitoring())
+	app.StartMonitoringServices()
+}
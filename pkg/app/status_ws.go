@@ -0,0 +1,197 @@
+package app
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/rs/zerolog/log"
+)
+
+// The status websocket pushes state transitions to the dashboard as they happen, instead of waiting for the next poll.
+
+const (
+	// statusWSPingInterval - how often clients are pinged to keep the connection (and proxies) alive
+	statusWSPingInterval = 30 * time.Second
+
+	// statusWSPongTimeout - client is considered gone when no pong arrives within this time
+	statusWSPongTimeout = 2 * statusWSPingInterval
+
+	// statusWSWriteTimeout - maximum time for writing a single message
+	statusWSWriteTimeout = 10 * time.Second
+
+	// statusWSQueueSize - updates buffered per client, further updates are dropped until the client catches up
+	statusWSQueueSize = 64
+)
+
+// StatusUpdate - message pushed to status websocket clients, only fields present in the update are set
+type StatusUpdate struct {
+	Type               string   `json:"type"`
+	BabyUID            string   `json:"baby_uid"`
+	Timestamp          int64    `json:"timestamp"`
+	StreamState        *string  `json:"stream_state,omitempty"`
+	StreamRequestState *string  `json:"stream_request_state,omitempty"`
+	WebsocketAlive     *bool    `json:"websocket_alive,omitempty"`
+	Temperature        *float64 `json:"temperature,omitempty"`
+	Humidity           *float64 `json:"humidity,omitempty"`
+	IsNight            *bool    `json:"is_night,omitempty"`
+}
+
+// buildStatusUpdate - converts state update to the pushed message, returns false if the update holds nothing of interest
+func buildStatusUpdate(babyUID string, state baby.State) (StatusUpdate, bool) {
+	update := StatusUpdate{
+		Type:      "state",
+		BabyUID:   babyUID,
+		Timestamp: time.Now().Unix(),
+	}
+	relevant := false
+
+	if state.StreamState != nil {
+		streamState := streamStateToString(*state.StreamState)
+		update.StreamState = &streamState
+		relevant = true
+	}
+
+	if state.StreamRequestState != nil {
+		requestState := streamRequestStateToString(*state.StreamRequestState)
+		update.StreamRequestState = &requestState
+		relevant = true
+	}
+
+	if state.IsWebsocketAlive != nil {
+		update.WebsocketAlive = state.IsWebsocketAlive
+		relevant = true
+	}
+
+	if state.TemperatureMilli != nil {
+		temperature := state.GetTemperature()
+		update.Temperature = &temperature
+		relevant = true
+	}
+
+	if state.HumidityMilli != nil {
+		humidity := state.GetHumidity()
+		update.Humidity = &humidity
+		relevant = true
+	}
+
+	if state.IsNight != nil {
+		update.IsNight = state.IsNight
+		relevant = true
+	}
+
+	return update, relevant
+}
+
+// streamRequestStateToString converts a StreamRequestState to its string representation
+func streamRequestStateToString(state baby.StreamRequestState) string {
+	switch state {
+	case baby.StreamRequestState_Requested:
+		return "requested"
+	case baby.StreamRequestState_RequestFailed:
+		return "request_failed"
+	default:
+		return "not_requested"
+	}
+}
+
+// statusWSCheckOrigin - accepts same-origin clients and origins allowed by the CORS policy
+func statusWSCheckOrigin(app *App) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+
+		if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+
+		for _, allowed := range app.Opts.CORSAllowedOrigins {
+			if allowed == "*" || strings.TrimSuffix(allowed, "/") == origin {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+func handleStatusWebSocketAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: statusWSCheckOrigin(app)}
+
+	// Upgrade writes the error response itself
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debug().Err(err).Msg("Status websocket upgrade failed")
+		return
+	}
+	defer conn.Close()
+
+	log.Debug().Str("remote_addr", r.RemoteAddr).Msg("Status websocket client connected")
+
+	updates := make(chan StatusUpdate, statusWSQueueSize)
+	done := make(chan struct{})
+
+	// Subscribe pushes the current state of every baby first, then the updates
+	unsubscribe := app.BabyStateManager.Subscribe(func(babyUID string, state baby.State) {
+		update, relevant := buildStatusUpdate(babyUID, state)
+		if !relevant {
+			return
+		}
+
+		select {
+		case updates <- update:
+		case <-done:
+		default:
+			log.Warn().Str("remote_addr", r.RemoteAddr).Msg("Status websocket client is too slow, dropping update")
+		}
+	})
+	defer unsubscribe()
+
+	// Reader - handles pongs and notices the client going away
+	go func() {
+		defer close(done)
+
+		conn.SetReadDeadline(time.Now().Add(statusWSPongTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(statusWSPongTimeout))
+		})
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(statusWSPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case update := <-updates:
+			conn.SetWriteDeadline(time.Now().Add(statusWSWriteTimeout))
+			if err := conn.WriteJSON(update); err != nil {
+				log.Debug().Err(err).Msg("Failed to write to status websocket")
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(statusWSWriteTimeout)); err != nil {
+				log.Debug().Err(err).Msg("Failed to ping status websocket client")
+				return
+			}
+		case <-done:
+			log.Debug().Str("remote_addr", r.RemoteAddr).Msg("Status websocket client disconnected")
+			return
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusWebSocketPushesUpdates(t *testing.T) {
+	app := &App{BabyStateManager: baby.NewStateManager()}
+	app.BabyStateManager.Update("baby1", *baby.NewState().SetTemperatureMilli(21000))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleStatusWebSocketAPI(w, r, app)
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	readUpdate := func(conn *websocket.Conn) StatusUpdate {
+		var update StatusUpdate
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		assert.NoError(t, conn.ReadJSON(&update))
+		return update
+	}
+
+	clients := make([]*websocket.Conn, 2)
+	for i := range clients {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		clients[i] = conn
+
+		// Current state is sent right after connecting
+		update := readUpdate(conn)
+		assert.Equal(t, "baby1", update.BabyUID)
+		if assert.NotNil(t, update.Temperature) {
+			assert.Equal(t, 21.0, *update.Temperature)
+		}
+	}
+
+	app.BabyStateManager.Update("baby1", *baby.NewState().SetStreamState(baby.StreamState_Alive))
+
+	for _, conn := range clients {
+		update := readUpdate(conn)
+		if assert.NotNil(t, update.StreamState) {
+			assert.Equal(t, "alive", *update.StreamState)
+		}
+		assert.Nil(t, update.Temperature, "only changed fields are pushed")
+	}
+}
+
+func TestStatusWebSocketRejectsForeignOrigin(t *testing.T) {
+	app := &App{
+		BabyStateManager: baby.NewStateManager(),
+		Opts:             Opts{CORSAllowedOrigins: []string{"https://dash.example.com"}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleStatusWebSocketAPI(w, r, app)
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	_, res, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Origin": {"https://evil.example.com"}})
+	assert.Error(t, err)
+	if assert.NotNil(t, res) {
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Origin": {"https://dash.example.com"}})
+	if assert.NoError(t, err) {
+		conn.Close()
+	}
+}
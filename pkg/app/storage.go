@@ -0,0 +1,250 @@
+package app
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// Long-running monitors write HLS segments and receive camera logs continuously, the storage report
+// helps users to keep an eye on disk growth and to clean up without shelling into the container.
+
+const (
+	// camLogPattern - file name pattern of the camera log tarballs received by the /log handler
+	camLogPattern = "camlogs-*.tar.gz"
+
+	// camLogPruneInterval - how often the camera log retention is enforced besides after each upload
+	camLogPruneInterval = time.Hour
+)
+
+// DirUsage - disk usage of a data directory
+type DirUsage struct {
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"size_bytes"`
+	FileCount int    `json:"file_count"`
+}
+
+// StorageCleanupResult - summary of a storage cleanup run
+type StorageCleanupResult struct {
+	HLSDirsRemoved int  `json:"hls_dirs_removed"`
+	CamLogsRemoved int  `json:"camlogs_removed"`
+	HistoryCleaned bool `json:"history_cleaned"`
+}
+
+// measureDir - sums up sizes of regular files under the directory, missing directory reports zero usage
+func measureDir(dir string) (DirUsage, error) {
+	usage := DirUsage{Path: dir}
+	if dir == "" {
+		return usage, nil
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			// File removed in the meantime (e.g. rotated HLS segment)
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		usage.SizeBytes += info.Size()
+		usage.FileCount++
+		return nil
+	})
+
+	return usage, err
+}
+
+// getStorageUsage - returns usage of the data directories keyed by their purpose
+func (app *App) getStorageUsage() map[string]DirUsage {
+	dirs := map[string]string{
+		"video":   app.Opts.DataDirectories.VideoDir,
+		"log":     app.Opts.DataDirectories.LogDir,
+		"history": app.Opts.DataDirectories.HistoryDir,
+	}
+	if app.HLSManager != nil {
+		dirs["hls"] = app.HLSManager.GetBaseDir()
+	}
+
+	usage := make(map[string]DirUsage, len(dirs))
+	for name, dir := range dirs {
+		dirUsage, err := measureDir(dir)
+		if err != nil {
+			log.Warn().Err(err).Str("dir", dir).Msg("Failed to measure directory usage")
+		}
+
+		usage[name] = dirUsage
+	}
+
+	return usage
+}
+
+// pruneCamLogs - enforces camera log retention (newest MaxFiles files not older than MaxAge), returns number of removed files
+func pruneCamLogs(logDir string, opts CamLogOpts) int {
+	if logDir == "" {
+		return 0
+	}
+
+	files, err := filepath.Glob(filepath.Join(logDir, camLogPattern))
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to glob camera logs for cleanup")
+		return 0
+	}
+
+	type camLog struct {
+		path    string
+		modTime time.Time
+	}
+
+	logs := make([]camLog, 0, len(files))
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			logs = append(logs, camLog{path: file, modTime: info.ModTime()})
+		}
+	}
+
+	// Newest first
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].modTime.After(logs[j].modTime)
+	})
+
+	cutoff := time.Now().Add(-opts.MaxAge)
+	removed := 0
+
+	for i, entry := range logs {
+		keep := (opts.MaxFiles <= 0 || i < opts.MaxFiles) && (opts.MaxAge <= 0 || entry.modTime.After(cutoff))
+		if keep {
+			continue
+		}
+
+		if err := os.Remove(entry.path); err != nil {
+			log.Warn().Err(err).Str("file", entry.path).Msg("Failed to remove old camera log")
+			continue
+		}
+
+		removed++
+	}
+
+	if removed > 0 {
+		log.Info().Int("removed", removed).Msg("Pruned old camera logs")
+	}
+
+	return removed
+}
+
+// runPeriodicCamLogPrune - enforces camera log retention until the context is cancelled (blocking)
+func (app *App) runPeriodicCamLogPrune(ctx utils.GracefulContext) {
+	ticker := time.NewTicker(camLogPruneInterval)
+	defer ticker.Stop()
+
+	pruneCamLogs(app.Opts.DataDirectories.LogDir, app.Opts.CamLog)
+
+	for {
+		select {
+		case <-ticker.C:
+			pruneCamLogs(app.Opts.DataDirectories.LogDir, app.Opts.CamLog)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// handleCamLogUpload - receives log tarball from the cam, useful for debugging
+func handleCamLogUpload(w http.ResponseWriter, r *http.Request, app *App) {
+	logDir := app.Opts.DataDirectories.LogDir
+
+	// Uploads requested through /api/camera-logs carry the token of the request
+	request, requested := app.camLogRequests.get(r.URL.Query().Get("request"))
+	babyUID := ""
+	if requested {
+		babyUID = request.babyUID
+	}
+	filename := camLogUploadFilename(logDir, babyUID, time.Now())
+
+	log.Info().Str("file", filename).Msg("Saving log to file")
+	defer r.Body.Close()
+
+	body := io.Reader(r.Body)
+	if app.Opts.CamLog.MaxUploadBytes > 0 {
+		body = http.MaxBytesReader(w, r.Body, app.Opts.CamLog.MaxUploadBytes)
+	}
+
+	out, err := os.Create(filename)
+	if err != nil {
+		log.Error().Str("file", filename).Err(err).Msg("Unable to create file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = io.Copy(out, body)
+	out.Close()
+
+	if err != nil {
+		// Don't keep partial uploads around
+		os.Remove(filename)
+
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			log.Warn().Str("file", filename).Int64("limit", maxBytesErr.Limit).Msg("Received log file exceeds size limit, discarding")
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		log.Error().Str("file", filename).Err(err).Msg("Unable to save received log file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	pruneCamLogs(logDir, app.Opts.CamLog)
+
+	if requested {
+		select {
+		case request.uploaded <- filename:
+		default:
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// cleanupStorage - removes orphaned HLS output, old camera logs and historical data beyond retention
+func (app *App) cleanupStorage() StorageCleanupResult {
+	result := StorageCleanupResult{}
+
+	if app.HLSManager != nil {
+		result.HLSDirsRemoved = app.HLSManager.CleanupOrphanedFiles()
+	}
+
+	result.CamLogsRemoved = pruneCamLogs(app.Opts.DataDirectories.LogDir, app.Opts.CamLog)
+
+	// Zero retention would wipe all history, only clean up when a retention period is configured
+	if app.HistoryTracker != nil && app.HistoryTracker.IsEnabled() && app.Opts.History.RetentionDays > 0 {
+		if err := app.HistoryTracker.Cleanup(app.Opts.History.RetentionDays); err != nil {
+			log.Error().Err(err).Msg("Historical data cleanup failed")
+		} else {
+			result.HistoryCleaned = true
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	apperrors "github.com/indiefan/home_assistant_nanit/pkg/errors"
+	"github.com/indiefan/home_assistant_nanit/pkg/streaming"
+	"github.com/rs/zerolog/log"
+)
+
+// streamEncodeOpts - FFmpeg encoding profile of a stream config
+func streamEncodeOpts(config baby.StreamConfig) streaming.EncodeOpts {
+	return streaming.EncodeOpts{
+		Height:      config.Height(),
+		BitrateKbps: config.BitrateKbps,
+		FPS:         config.FPS,
+	}
+}
+
+// applyStreamConfigs - hands the stored stream configs of all babies to the HLS manager
+func (app *App) applyStreamConfigs() {
+	for babyUID, config := range app.StreamConfig.GetAll() {
+		app.HLSManager.SetEncodeOpts(babyUID, streamEncodeOpts(config))
+	}
+}
+
+// API handler for the baby sub-resources: /api/babies/{baby_uid}/stream-config and /api/babies/{baby_uid}/calibration
+func handleBabyResourceAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	babyUID, resource, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/babies/"), "/")
+	if babyUID == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch strings.TrimSuffix(resource, "/") {
+	case "stream-config":
+		handleBabyStreamConfigAPI(w, r, app, babyUID)
+	case "calibration":
+		handleBabyCalibrationAPI(w, r, app, babyUID)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// API handler for the transcoding profile of a baby
+// PUT replaces the whole profile, {} restores the camera's own. A running video stream is restarted to apply it.
+// PUT body: {"resolution": "720p", "bitrate_kbps": 800, "fps": 15}
+func handleBabyStreamConfigAPI(w http.ResponseWriter, r *http.Request, app *App, babyUID string) {
+	if r.Method != "GET" && r.Method != "PUT" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	if findBaby(app.getBabies(), babyUID) == nil {
+		writeBabyNotFound(w, babyUID)
+		return
+	}
+
+	deviceInfo := app.BabyStateManager.GetBabyState(babyUID).DeviceInfo
+	restarted := false
+
+	if r.Method == "PUT" {
+		var config baby.StreamConfig
+		if !app.decodeJSON(w, r, &config) {
+			return
+		}
+
+		if err := config.Validate(deviceInfo); err != nil {
+			writeError(w, apperrors.NewValidationError("invalid_stream_config", err.Error(), err).WithContext("baby_uid", babyUID), http.StatusBadRequest)
+			return
+		}
+
+		if err := app.StreamConfig.Set(babyUID, config); err != nil {
+			log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to save stream config")
+			writeError(w, apperrors.NewStorageError("stream_config_save_failed", "Failed to save stream config", err), http.StatusInternalServerError)
+			return
+		}
+
+		app.HLSManager.SetEncodeOpts(babyUID, streamEncodeOpts(config))
+
+		log.Info().
+			Str("baby_uid", babyUID).
+			Str("resolution", config.Resolution).
+			Int("bitrate_kbps", config.BitrateKbps).
+			Int("fps", config.FPS).
+			Msg("Stream config updated")
+
+		if transcoder, exists := app.HLSManager.GetTranscoder(babyUID); exists && transcoder.IsRunning() && transcoder.GetMode() == streaming.StreamModeVideo {
+			if err := app.HLSManager.RestartTranscodingMode(babyUID, app.getLocalStreamURL(babyUID), streaming.StreamModeVideo); err != nil {
+				log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to restart HLS transcoding with the new stream config")
+			} else {
+				restarted = true
+			}
+		}
+	}
+
+	capabilities := map[string]interface{}{}
+	if deviceInfo != nil && deviceInfo.MobileBitrate != nil {
+		capabilities["max_bitrate_kbps"] = *deviceInfo.MobileBitrate / 1000
+	}
+	if deviceInfo != nil && deviceInfo.MobileFPS != nil {
+		capabilities["max_fps"] = *deviceInfo.MobileFPS
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"baby_uid":     babyUID,
+		"config":       app.StreamConfig.Get(babyUID),
+		"capabilities": capabilities,
+		"restarted":    restarted,
+	})
+}
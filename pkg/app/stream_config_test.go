@@ -0,0 +1,72 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/session"
+	"github.com/indiefan/home_assistant_nanit/pkg/streaming"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBabyStreamConfigAPI(t *testing.T) {
+	sessionStore := session.NewSessionStore()
+	sessionStore.Session.Babies = testBabies
+
+	bitrate, fps := int32(1_000_000), int32(20)
+	stateManager := baby.NewStateManager()
+	stateManager.Update("baby1", *baby.NewState().SetDeviceInfo(&baby.DeviceInfo{MobileBitrate: &bitrate, MobileFPS: &fps}))
+
+	app := &App{
+		SessionStore:     sessionStore,
+		BabyStateManager: stateManager,
+		HLSManager:       streaming.NewHLSManager(t.TempDir()),
+		StreamConfig:     baby.NewStreamConfigStore(filepath.Join(t.TempDir(), "stream_config.json")),
+	}
+
+	put := func(path string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handleBabyResourceAPI(w, httptest.NewRequest("PUT", path, strings.NewReader(body)), app)
+		return w
+	}
+
+	// Over the camera's bitrate
+	w := put("/api/babies/baby1/stream-config", `{"resolution":"720p","bitrate_kbps":2000}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"invalid_stream_config"`)
+
+	w = put("/api/babies/unknown/stream-config", `{}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = put("/api/babies/baby1/stream-config", `{"resolution":"720p","bitrate_kbps":800,"fps":15}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, baby.StreamConfig{Resolution: "720p", BitrateKbps: 800, FPS: 15}, app.StreamConfig.Get("baby1"))
+
+	w = httptest.NewRecorder()
+	handleBabyResourceAPI(w, httptest.NewRequest("GET", "/api/babies/baby1/stream-config", nil), app)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Config       baby.StreamConfig      `json:"config"`
+		Capabilities map[string]interface{} `json:"capabilities"`
+		Restarted    bool                   `json:"restarted"`
+	}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, 800, response.Config.BitrateKbps)
+	assert.Equal(t, 1000.0, response.Capabilities["max_bitrate_kbps"])
+	assert.Equal(t, 20.0, response.Capabilities["max_fps"])
+	assert.False(t, response.Restarted)
+
+	w = httptest.NewRecorder()
+	handleBabyResourceAPI(w, httptest.NewRequest("GET", "/api/babies/baby1/other", nil), app)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	handleBabyResourceAPI(w, httptest.NewRequest("DELETE", "/api/babies/baby1/stream-config", nil), app)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
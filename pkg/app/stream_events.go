@@ -0,0 +1,132 @@
+package app
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/indiefan/home_assistant_nanit/pkg/notify"
+	"github.com/rs/zerolog/log"
+)
+
+// streamEventTracker - remembers failures of the babies' streams, so that each failure and recovery is reported once
+type streamEventTracker struct {
+	disconnected map[string]bool
+	unhealthy    map[string]bool
+	blocked      map[string]bool
+	mutex        sync.Mutex
+}
+
+func newStreamEventTracker() *streamEventTracker {
+	return &streamEventTracker{
+		disconnected: make(map[string]bool),
+		unhealthy:    make(map[string]bool),
+		blocked:      make(map[string]bool),
+	}
+}
+
+// transitions returns the stream health events of a state update (changed fields only)
+// Coming up is reported only after a failure, so that the initial connection and stream start are not events.
+// Repeated updates to the same state are not events either. Safe to call on nil tracker.
+func (t *streamEventTracker) transitions(babyUID string, state baby.State) []string {
+	if t == nil {
+		return nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var events []string
+
+	if state.IsWebsocketAlive != nil {
+		if !*state.IsWebsocketAlive {
+			if !t.disconnected[babyUID] {
+				t.disconnected[babyUID] = true
+				events = append(events, notify.EventDisconnect)
+			}
+		} else if t.disconnected[babyUID] {
+			delete(t.disconnected, babyUID)
+			events = append(events, notify.EventReconnect)
+		}
+	}
+
+	if state.StreamState != nil {
+		switch *state.StreamState {
+		case baby.StreamState_Unhealthy:
+			if !t.unhealthy[babyUID] {
+				t.unhealthy[babyUID] = true
+				events = append(events, notify.EventStreamUnhealthy)
+			}
+		case baby.StreamState_Alive:
+			if t.unhealthy[babyUID] {
+				delete(t.unhealthy, babyUID)
+				events = append(events, notify.EventStreamAlive)
+			}
+		}
+	}
+
+	// Retries request the stream again, it is blocked until the stream arrives or isn't requested any more
+	if state.StreamRequestState != nil {
+		if *state.StreamRequestState == baby.StreamRequestState_NotRequested {
+			delete(t.blocked, babyUID)
+		} else if *state.StreamRequestState == baby.StreamRequestState_RequestFailed && !t.blocked[babyUID] {
+			t.blocked[babyUID] = true
+			events = append(events, notify.EventStreamBlocked)
+		}
+	}
+
+	return events
+}
+
+// streamEventMessages - human readable webhook messages of the stream health events
+var streamEventMessages = map[string]string{
+	notify.EventDisconnect:      "Camera disconnected",
+	notify.EventReconnect:       "Camera reconnected",
+	notify.EventStreamUnhealthy: "Stream stopped",
+	notify.EventStreamAlive:     "Stream recovered",
+	notify.EventStreamBlocked:   "Stream blocked by the Nanit mobile app connection limit",
+}
+
+// dispatchStreamEvents records the stream health transitions of a state update to the history and propagates them
+// to MQTT (babies/<uid>/stream_event) and webhooks, repeated events of a type are debounced by the stream event cooldown.
+// Quiet hours don't apply, they are about the camera rather than the baby.
+func (app *App) dispatchStreamEvents(babyUID string, state baby.State, eventTime time.Time) {
+	for _, eventType := range app.streamEvents.transitions(babyUID, state) {
+		// Every transition is recorded, so that the stream health can be charted
+		if app.HistoryTracker.IsEnabled() {
+			if err := app.HistoryTracker.TrackEvent(babyUID, eventType, eventTime.Unix()); err != nil {
+				log.Error().Err(err).Str("baby_uid", babyUID).Str("event_type", eventType).Msg("Failed to track stream event")
+			}
+		}
+
+		if app.streamEventCooldown != nil && !app.streamEventCooldown.Allow(babyUID, eventType, eventTime) {
+			log.Debug().Str("baby_uid", babyUID).Str("event_type", eventType).Msg("Stream event suppressed by cooldown")
+			continue
+		}
+
+		log.Info().Str("baby_uid", babyUID).Str("event_type", eventType).Msg("Stream health changed")
+
+		if app.MQTTConnection != nil {
+			app.MQTTConnection.PublishStreamEvent(babyUID, eventType)
+		}
+
+		if app.Notifier != nil {
+			babyName := app.getBabyName(babyUID)
+			message := streamEventMessages[eventType]
+			if eventType == notify.EventStreamBlocked && app.streamIdentifier() != client.StreamIdentifier_MOBILE {
+				// The connection limit only applies to the MOBILE stream
+				message = "Stream request declined by the camera"
+			}
+
+			app.Notifier.Notify(notify.Event{
+				BabyUID:   babyUID,
+				BabyName:  babyName,
+				EventType: eventType,
+				Timestamp: eventTime.Unix(),
+				Message:   fmt.Sprintf("%s (%s)", message, babyName),
+			})
+		}
+	}
+}
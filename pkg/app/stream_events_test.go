@@ -0,0 +1,101 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/history"
+	"github.com/indiefan/home_assistant_nanit/pkg/notify"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamEventTransitions(t *testing.T) {
+	tracker := newStreamEventTracker()
+
+	// Initial connection and stream start are not events
+	assert.Empty(t, tracker.transitions("baby1", *baby.NewState().SetWebsocketAlive(true)))
+	assert.Empty(t, tracker.transitions("baby1", *baby.NewState().SetStreamState(baby.StreamState_Alive)))
+
+	assert.Equal(t, []string{notify.EventDisconnect, notify.EventStreamUnhealthy},
+		tracker.transitions("baby1", *baby.NewState().SetWebsocketAlive(false).SetStreamState(baby.StreamState_Unhealthy)))
+	assert.Empty(t, tracker.transitions("baby1", *baby.NewState().SetStreamState(baby.StreamState_Unhealthy)))
+	assert.Empty(t, tracker.transitions("baby1", *baby.NewState().SetWebsocketAlive(false)))
+	assert.Empty(t, tracker.transitions("baby2", *baby.NewState().SetWebsocketAlive(true)))
+
+	assert.Equal(t, []string{notify.EventReconnect}, tracker.transitions("baby1", *baby.NewState().SetWebsocketAlive(true)))
+	assert.Equal(t, []string{notify.EventStreamBlocked},
+		tracker.transitions("baby1", *baby.NewState().SetStreamRequestState(baby.StreamRequestState_RequestFailed)))
+	assert.Empty(t, tracker.transitions("baby1", *baby.NewState().SetStreamRequestState(baby.StreamRequestState_RequestFailed)))
+
+	// Retried requests stay blocked, until the stream arrives
+	assert.Empty(t, tracker.transitions("baby1", *baby.NewState().SetStreamRequestState(baby.StreamRequestState_Requested)))
+	assert.Empty(t, tracker.transitions("baby1", *baby.NewState().SetStreamRequestState(baby.StreamRequestState_RequestFailed)))
+	assert.Empty(t, tracker.transitions("baby1", *baby.NewState().SetStreamRequestState(baby.StreamRequestState_NotRequested)))
+	assert.Equal(t, []string{notify.EventStreamBlocked},
+		tracker.transitions("baby1", *baby.NewState().SetStreamRequestState(baby.StreamRequestState_RequestFailed)))
+	assert.Equal(t, []string{notify.EventStreamAlive}, tracker.transitions("baby1", *baby.NewState().SetStreamState(baby.StreamState_Alive)))
+
+	var nilTracker *streamEventTracker
+	assert.Empty(t, nilTracker.transitions("baby1", *baby.NewState().SetWebsocketAlive(false)))
+}
+
+func TestDispatchStreamEventsRecordsAndDebounces(t *testing.T) {
+	tracker, err := history.NewTracker(t.TempDir(), true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tracker.Close()
+
+	received := make(chan notify.Event, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event notify.Event
+		json.NewDecoder(r.Body).Decode(&event)
+		received <- event
+	}))
+	defer server.Close()
+
+	notifier, err := notify.NewNotifier(notify.Opts{WebhookURL: server.URL})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	app := &App{
+		HistoryTracker:      tracker,
+		Notifier:            notifier,
+		streamEvents:        newStreamEventTracker(),
+		streamEventCooldown: utils.NewCooldown(time.Minute, nil),
+	}
+
+	now := time.Now()
+	app.dispatchStreamEvents("baby1", *baby.NewState().SetWebsocketAlive(false), now)
+	app.dispatchStreamEvents("baby1", *baby.NewState().SetWebsocketAlive(true), now.Add(time.Second))
+	// Flapping within the cooldown is recorded, but not propagated again
+	app.dispatchStreamEvents("baby1", *baby.NewState().SetWebsocketAlive(false), now.Add(2*time.Second))
+
+	events, err := tracker.GetEvents("baby1", now.Add(-time.Minute).Unix(), now.Add(time.Minute).Unix(), "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+
+	types := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-received:
+			types[event.EventType] = true
+			assert.Contains(t, event.Message, "(baby1)")
+		case <-time.After(2 * time.Second):
+			t.Fatal("Stream event was not delivered to the webhook")
+		}
+	}
+	assert.Equal(t, map[string]bool{notify.EventDisconnect: true, notify.EventReconnect: true}, types)
+
+	select {
+	case event := <-received:
+		t.Fatalf("Debounced stream event delivered: %v", event.EventType)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
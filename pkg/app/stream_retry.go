@@ -0,0 +1,107 @@
+package app
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// streamRetryDelay returns the time until the next streaming retry check after the given number of consecutive
+// retries without success: the interval doubles with every retry up to the maximum and is spread by ±jitter,
+// so cameras blocked by the same connection limit don't retry in lockstep
+// random is a number in [0, 1).
+func streamRetryDelay(opts StreamRetryOpts, retries int, random float64) time.Duration {
+	delay := float64(opts.Interval) * math.Pow(2, float64(min(retries, 30)))
+	if opts.MaxInterval > 0 {
+		delay = min(delay, float64(opts.MaxInterval))
+	}
+
+	delay *= 1 + opts.Jitter*(2*random-1)
+	return max(time.Duration(delay), time.Second)
+}
+
+// streamRetryMonitors - running streaming retry monitors and their next check by baby UID, the zero value is ready to use
+type streamRetryMonitors struct {
+	mutex     sync.Mutex
+	running   map[string]<-chan struct{} // Done channel of the connection context the monitor runs in
+	nextRetry map[string]time.Time
+	requested map[string]bool // Started again while running, keeps the monitor from stopping as idle
+}
+
+// start claims the monitor of the baby for the connection context, false if one already runs for it
+// The running monitor is then kept from stopping as idle, it handles the failure the start was requested for.
+func (m *streamRetryMonitors) start(babyUID string, done <-chan struct{}) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.running[babyUID] == done {
+		if m.requested == nil {
+			m.requested = make(map[string]bool)
+		}
+		m.requested[babyUID] = true
+		return false
+	}
+
+	if m.running == nil {
+		m.running = make(map[string]<-chan struct{})
+	}
+	m.running[babyUID] = done
+	return true
+}
+
+// stop releases the monitor of the baby unless a monitor of a newer connection took over
+func (m *streamRetryMonitors) stop(babyUID string, done <-chan struct{}) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.running[babyUID] == done {
+		delete(m.running, babyUID)
+		delete(m.nextRetry, babyUID)
+		delete(m.requested, babyUID)
+	}
+}
+
+// stopIfIdle releases the monitor of the baby, false if it was started again meanwhile and has to keep running
+// Decided under the same lock as start, so a start landing while the monitor winds down is never skipped.
+// A start request is consumed by this check, so it keeps the monitor for one more check at most.
+func (m *streamRetryMonitors) stopIfIdle(babyUID string, done <-chan struct{}) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.running[babyUID] != done {
+		return true
+	}
+
+	if m.requested[babyUID] {
+		delete(m.requested, babyUID)
+		return false
+	}
+
+	delete(m.running, babyUID)
+	delete(m.nextRetry, babyUID)
+	return true
+}
+
+// setNextRetry records when the monitor of the baby checks next, ignored if it was taken over by a newer connection
+func (m *streamRetryMonitors) setNextRetry(babyUID string, done <-chan struct{}, next time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.running[babyUID] != done {
+		return
+	}
+
+	if m.nextRetry == nil {
+		m.nextRetry = make(map[string]time.Time)
+	}
+	m.nextRetry[babyUID] = next
+}
+
+// getNextRetry returns when the monitor of the baby checks next, false if no monitor runs
+func (m *streamRetryMonitors) getNextRetry(babyUID string) (time.Time, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	next, ok := m.nextRetry[babyUID]
+	return next, ok
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamRetryDelay(t *testing.T) {
+	opts := StreamRetryOpts{Interval: time.Minute, MaxInterval: 10 * time.Minute, Jitter: 0.2}
+
+	// Backs off exponentially up to the maximum
+	assert.Equal(t, time.Minute, streamRetryDelay(opts, 0, 0.5))
+	assert.Equal(t, 2*time.Minute, streamRetryDelay(opts, 1, 0.5))
+	assert.Equal(t, 8*time.Minute, streamRetryDelay(opts, 3, 0.5))
+	assert.Equal(t, 10*time.Minute, streamRetryDelay(opts, 4, 0.5))
+	assert.Equal(t, 10*time.Minute, streamRetryDelay(opts, 1000, 0.5))
+
+	// Jitter spreads the interval both ways
+	assert.Equal(t, 48*time.Second, streamRetryDelay(opts, 0, 0))
+	assert.Equal(t, 72*time.Second, streamRetryDelay(opts, 0, 1))
+}
+
+func TestStreamRetryMonitorStopsWhenIdle(t *testing.T) {
+	app := &App{Opts: Opts{StreamRetry: StreamRetryOpts{Interval: time.Second, MaxInterval: time.Second, IdleTimeout: time.Nanosecond}}}
+
+	stopped := make(chan struct{})
+	runner := utils.RunWithGracefulCancel(func(ctx utils.GracefulContext) {
+		app.startStreamingRetryMonitor("baby1", ctx)
+		close(stopped)
+	})
+	defer runner.Cancel()
+
+	// Next check is reported while running
+	time.Sleep(10 * time.Millisecond)
+	_, running := app.streamRetryMonitors.getNextRetry("baby1")
+	assert.True(t, running)
+
+	select {
+	case <-stopped:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Idle monitor didn't stop")
+	}
+
+	_, running = app.streamRetryMonitors.getNextRetry("baby1")
+	assert.False(t, running)
+}
+
+func TestStreamRetryMonitorsPerConnection(t *testing.T) {
+	var monitors streamRetryMonitors
+	oldConn, newConn := make(chan struct{}), make(chan struct{})
+
+	assert.True(t, monitors.start("baby1", oldConn))
+	assert.False(t, monitors.start("baby1", oldConn))
+
+	// A new connection takes over, the old monitor winding down doesn't affect it
+	assert.True(t, monitors.start("baby1", newConn))
+	monitors.setNextRetry("baby1", newConn, time.Unix(100, 0))
+	monitors.setNextRetry("baby1", oldConn, time.Unix(50, 0))
+	monitors.stop("baby1", oldConn)
+
+	next, ok := monitors.getNextRetry("baby1")
+	assert.True(t, ok)
+	assert.Equal(t, time.Unix(100, 0), next)
+
+	monitors.stop("baby1", newConn)
+	_, ok = monitors.getNextRetry("baby1")
+	assert.False(t, ok)
+}
+
+func TestStreamRetryMonitorKeptByStartWhileStopping(t *testing.T) {
+	var monitors streamRetryMonitors
+	conn := make(chan struct{})
+
+	assert.True(t, monitors.start("baby1", conn))
+
+	// Stream request failed again just as the monitor went idle, it keeps running instead
+	assert.False(t, monitors.start("baby1", conn))
+	assert.False(t, monitors.stopIfIdle("baby1", conn))
+
+	assert.True(t, monitors.stopIfIdle("baby1", conn))
+	assert.True(t, monitors.start("baby1", conn))
+
+	// Taken over by a newer connection, the old monitor just exits
+	assert.True(t, monitors.stopIfIdle("baby1", make(chan struct{})))
+}
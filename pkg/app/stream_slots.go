@@ -0,0 +1,134 @@
+package app
+
+import (
+	"errors"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/rs/zerolog/log"
+)
+
+// Nanit caps the number of concurrent mobile streams per account. Users with multiple cameras can release
+// the stream slot of one camera and claim it for another instead of relying on the background retry monitor.
+
+const (
+	// claimAttempts - number of streaming requests sent when claiming the slot
+	claimAttempts = 3
+
+	// claimRetryDelay - delay between claim attempts rejected by the connection limit
+	claimRetryDelay = 5 * time.Second
+)
+
+var (
+	errStreamNotConfigured = errors.New("RTMP not configured")
+	errCameraNotConnected  = errors.New("camera is not connected")
+)
+
+// setStreamReleased - marks stream of the baby as deliberately released (or clears the mark)
+func (app *App) setStreamReleased(babyUID string, released bool) {
+	app.releasedStreamsMutex.Lock()
+	defer app.releasedStreamsMutex.Unlock()
+
+	if released {
+		app.releasedStreams[babyUID] = true
+	} else {
+		delete(app.releasedStreams, babyUID)
+	}
+}
+
+// isStreamReleased - returns whether the stream slot was released by the user, released streams are not requested automatically
+func (app *App) isStreamReleased(babyUID string) bool {
+	app.releasedStreamsMutex.Lock()
+	defer app.releasedStreamsMutex.Unlock()
+	return app.releasedStreams[babyUID]
+}
+
+// getStreamSlotHolders - returns UIDs of babies currently holding a stream slot
+func (app *App) getStreamSlotHolders() []string {
+	holders := make([]string, 0)
+	for _, b := range app.getBabies() {
+		if app.BabyStateManager.GetBabyState(b.UID).GetStreamRequestState() == baby.StreamRequestState_Requested {
+			holders = append(holders, b.UID)
+		}
+	}
+
+	return holders
+}
+
+// claimStreamSlot - validates that the stream can be claimed and requests it in the background
+func (app *App) claimStreamSlot(babyUID string) error {
+	streamURL := app.getLocalStreamURL(babyUID)
+	if streamURL == "" {
+		return errStreamNotConfigured
+	}
+
+	conn := app.getConnection(babyUID)
+	if conn == nil {
+		return errCameraNotConnected
+	}
+
+	app.setStreamReleased(babyUID, false)
+
+	go app.requestStreamSlot(babyUID, streamURL, conn)
+
+	return nil
+}
+
+// requestStreamSlot - (re)requests streaming until it is accepted or attempts run out, then starts HLS transcoding
+func (app *App) requestStreamSlot(babyUID string, streamURL string, conn client.Connection) {
+	for attempt := 1; attempt <= claimAttempts; attempt++ {
+		log.Info().Str("baby_uid", babyUID).Int("attempt", attempt).Msg("Claiming stream slot")
+
+		app.BabyStateManager.Update(babyUID, *baby.NewState().SetStreamRequestState(baby.StreamRequestState_NotRequested))
+		requestLocalStreaming(babyUID, streamURL, app.streamIdentifier(), client.Streaming_STARTED, conn, app.BabyStateManager)
+
+		if app.BabyStateManager.GetBabyState(babyUID).GetStreamRequestState() == baby.StreamRequestState_Requested {
+			break
+		}
+
+		if attempt == claimAttempts || app.isStreamReleased(babyUID) {
+			log.Warn().Str("baby_uid", babyUID).Msg("Failed to claim stream slot, the background retry monitor will keep trying")
+			return
+		}
+
+		time.Sleep(claimRetryDelay)
+	}
+
+	if app.HLSManager != nil {
+		if transcoder, exists := app.HLSManager.GetTranscoder(babyUID); !exists || !transcoder.IsRunning() {
+			app.waitForStreamAlive(babyUID)
+
+			if err := app.HLSManager.StartTranscoding(babyUID, streamURL); err != nil {
+				log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to start HLS transcoding after claiming stream slot")
+			}
+		}
+	}
+}
+
+// releaseStreamSlot - stops the stream of the baby so that the slot can be used by another camera
+func (app *App) releaseStreamSlot(babyUID string) error {
+	streamURL := app.getLocalStreamURL(babyUID)
+	if streamURL == "" {
+		return errStreamNotConfigured
+	}
+
+	// Mark first, so that the stream isn't re-requested once it turns unhealthy
+	app.setStreamReleased(babyUID, true)
+
+	if app.HLSManager != nil {
+		app.HLSManager.StopTranscoding(babyUID)
+	}
+
+	if conn := app.getConnection(babyUID); conn != nil {
+		requestLocalStreaming(babyUID, streamURL, app.streamIdentifier(), client.Streaming_STOPPED, conn, app.BabyStateManager)
+	}
+
+	app.BabyStateManager.Update(babyUID, *baby.NewState().
+		SetStreamRequestState(baby.StreamRequestState_NotRequested).
+		SetStreamState(baby.StreamState_Unhealthy))
+
+	log.Info().Str("baby_uid", babyUID).Msg("Released stream slot")
+
+	return nil
+}
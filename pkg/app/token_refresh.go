@@ -0,0 +1,64 @@
+package app
+
+import (
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/resilience"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// tokenRefreshBreakerName - circuit breaker guarding the background token renewal
+	tokenRefreshBreakerName = "nanit_token_refresh"
+
+	// tokenRefreshMinInterval - minimum time between two background renewal attempts
+	tokenRefreshMinInterval = time.Minute
+)
+
+// tokenRefreshDelay - how long to wait before the next background renewal attempt
+func tokenRefreshDelay(expiresIn time.Duration) time.Duration {
+	if expiresIn < tokenRefreshMinInterval {
+		return tokenRefreshMinInterval
+	}
+	return expiresIn
+}
+
+// runProactiveTokenRefresh - renews the Nanit token before it expires until the context is cancelled (blocking)
+// Consecutive failures open the circuit breaker, so an unreachable Nanit API isn't hammered every minute.
+func (app *App) runProactiveTokenRefresh(ctx utils.GracefulContext) {
+	breaker := app.CircuitBreakers.Register(resilience.NewCircuitBreaker(tokenRefreshBreakerName, 3, 0, 10*time.Minute, 1, 1))
+
+	for {
+		delay := tokenRefreshMinInterval
+		if app.RestClient != nil && app.RestClient.SessionStore != nil && app.RestClient.SessionStore.Session.AuthToken != "" {
+			delay = tokenRefreshDelay(app.RestClient.TokenExpiresIn())
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		// Not authenticated yet (initial setup), renewal is done by the login flow
+		if app.RestClient == nil || app.RestClient.SessionStore == nil || app.RestClient.SessionStore.Session.AuthToken == "" {
+			continue
+		}
+
+		if app.RestClient.TokenExpiresIn() > 0 {
+			continue
+		}
+
+		err := breaker.Execute(func() error {
+			return app.RestClient.MaybeAuthorize(false)
+		})
+		if err != nil {
+			log.Warn().Err(err).Msg("Background Nanit token refresh failed")
+		} else {
+			log.Debug().Msg("Background Nanit token refresh completed")
+		}
+	}
+}
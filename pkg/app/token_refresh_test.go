@@ -0,0 +1,29 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/indiefan/home_assistant_nanit/pkg/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenRefreshSchedule(t *testing.T) {
+	store := session.NewSessionStore()
+	store.Session.AuthToken = "token"
+	store.Session.AuthTime = time.Now()
+
+	restClient := &client.NanitClient{SessionStore: store, RefreshLead: 5 * time.Minute}
+
+	// Renewal is due the refresh lead before the token lifetime elapses
+	expiresIn := restClient.TokenExpiresIn()
+	assert.InDelta(t, float64(client.AuthTokenTimelife-5*time.Minute), float64(expiresIn), float64(time.Second))
+	assert.Equal(t, expiresIn, tokenRefreshDelay(expiresIn))
+
+	// Overdue or failing renewals are retried at most once per minute
+	store.Session.AuthTime = time.Now().Add(-client.AuthTokenTimelife)
+	assert.True(t, restClient.TokenExpiresIn() < 0)
+	assert.Equal(t, tokenRefreshMinInterval, tokenRefreshDelay(restClient.TokenExpiresIn()))
+	assert.Equal(t, tokenRefreshMinInterval, tokenRefreshDelay(10*time.Second))
+}
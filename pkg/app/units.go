@@ -0,0 +1,89 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	apperrors "github.com/indiefan/home_assistant_nanit/pkg/errors"
+	"github.com/indiefan/home_assistant_nanit/pkg/history"
+	"github.com/rs/zerolog/log"
+)
+
+// Temperatures are stored in Celsius. Responses are converted to the account-wide unit kept in the display config,
+// a single request can override it with ?unit=celsius|fahrenheit.
+
+// resolveTemperatureUnit - returns the unit requested by the ?unit= query parameter, the stored unit otherwise
+// Writes a validation error and returns false if the parameter is invalid.
+func resolveTemperatureUnit(w http.ResponseWriter, r *http.Request, displayConfig *baby.DisplayConfigStore) (baby.TemperatureUnit, bool) {
+	value := r.URL.Query().Get("unit")
+	if value == "" {
+		return displayConfig.GetTemperatureUnit(), true
+	}
+
+	unit, err := baby.ParseTemperatureUnit(value)
+	if err != nil {
+		writeError(w, apperrors.NewValidationError("invalid_unit", err.Error(), nil).WithContext("unit", value), http.StatusBadRequest)
+		return "", false
+	}
+
+	return unit, true
+}
+
+// sensorReadingResponse - sensor reading with temperature in the effective unit, temperature_celsius is kept as stored
+type sensorReadingResponse struct {
+	history.SensorReading
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+// summaryResponse - historical summary with temperatures in the effective unit
+type summaryResponse struct {
+	history.HistoricalSummary
+	TemperatureUnit baby.TemperatureUnit `json:"temperature_unit"`
+}
+
+// convertSummary - returns copy of the summary with temperatures converted to the unit
+func convertSummary(summary history.HistoricalSummary, unit baby.TemperatureUnit) summaryResponse {
+	summary.AvgTemperature = unit.FromCelsiusPtr(summary.AvgTemperature)
+	summary.MinTemperature = unit.FromCelsiusPtr(summary.MinTemperature)
+	summary.MaxTemperature = unit.FromCelsiusPtr(summary.MaxTemperature)
+
+	return summaryResponse{HistoricalSummary: summary, TemperatureUnit: unit}
+}
+
+// API handler for the account-wide units: /api/settings/units
+// PUT body: {"temperature_unit": "fahrenheit"}
+func handleSettingsUnitsAPI(w http.ResponseWriter, r *http.Request, app *App) {
+	if r.Method != "GET" && r.Method != "PUT" {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	if r.Method == "PUT" {
+		var req struct {
+			TemperatureUnit string `json:"temperature_unit"`
+		}
+		if !app.decodeJSON(w, r, &req) {
+			return
+		}
+
+		unit, err := baby.ParseTemperatureUnit(req.TemperatureUnit)
+		if err != nil {
+			writeError(w, apperrors.NewValidationError("invalid_unit", err.Error(), nil).WithContext("unit", req.TemperatureUnit), http.StatusBadRequest)
+			return
+		}
+
+		if err := app.DisplayConfig.SetTemperatureUnit(unit); err != nil {
+			log.Error().Err(err).Msg("Failed to save temperature unit")
+			writeError(w, apperrors.NewStorageError("display_config_save_failed", "Failed to save units", err), http.StatusInternalServerError)
+			return
+		}
+
+		log.Info().Str("temperature_unit", string(unit)).Msg("Temperature unit updated")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"temperature_unit": app.DisplayConfig.GetTemperatureUnit(),
+	})
+}
@@ -9,7 +9,8 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func processSensorData(babyUID string, sensorData []*client.SensorData, stateManager *baby.StateManager) {
+// processSensorData - applies sensor readings of the cam, calibrated by the config of the baby
+func processSensorData(babyUID string, sensorData []*client.SensorData, config baby.DisplayConfig, stateManager *baby.StateManager) {
 	// Parse sensor update
 	stateUpdate := baby.State{}
 	for _, sensorDataSet := range sensorData {
@@ -24,14 +25,20 @@ func processSensorData(babyUID string, sensorData []*client.SensorData, stateMan
 		}
 	}
 
+	// Calibration is applied before the values are displayed and recorded, raw values are kept alongside
+	config.CalibrateSensors(&stateUpdate)
+
+	// Fresh data from the camera replaces any values restored from history
+	stateUpdate.SetSensorDataStale(false)
+
 	stateManager.Update(babyUID, stateUpdate)
 }
 
-func requestLocalStreaming(babyUID string, targetURL string, streamingStatus client.Streaming_Status, conn *client.WebsocketConnection, stateManager *baby.StateManager) {
+func requestLocalStreaming(babyUID string, targetURL string, streamID client.StreamIdentifier, streamingStatus client.Streaming_Status, conn client.Connection, stateManager *baby.StateManager) {
 	for {
 		switch streamingStatus {
 		case client.Streaming_STARTED:
-			log.Info().Str("target", targetURL).Msg("Requesting local streaming")
+			log.Info().Str("target", targetURL).Str("stream_identifier", streamID.String()).Msg("Requesting local streaming")
 		case client.Streaming_PAUSED:
 			log.Info().Str("target", targetURL).Msg("Pausing local streaming")
 		case client.Streaming_STOPPED:
@@ -40,14 +47,14 @@ func requestLocalStreaming(babyUID string, targetURL string, streamingStatus cli
 
 		awaitResponse := conn.SendRequest(client.RequestType_PUT_STREAMING, &client.Request{
 			Streaming: &client.Streaming{
-				Id:       client.StreamIdentifier(client.StreamIdentifier_MOBILE).Enum(),
+				Id:       streamID.Enum(),
 				RtmpUrl:  utils.ConstRefStr(targetURL),
 				Status:   client.Streaming_Status(streamingStatus).Enum(),
 				Attempts: utils.ConstRefInt32(1),
 			},
 		})
 
-		_, err := awaitResponse(30 * time.Second)
+		_, err := awaitResponse(client.RequestTimeout(client.RequestType_PUT_STREAMING))
 
 		if err != nil {
 			if err.Error() == "Forbidden: Number of Mobile App connections above limit, declining connection" {
@@ -82,6 +89,17 @@ func requestLocalStreaming(babyUID string, targetURL string, streamingStatus cli
 	}
 }
 
+// sendRequestAndLog - sends the request without blocking, logs if the cam doesn't answer within the timeout of the request type
+func sendRequestAndLog(babyUID string, conn client.Connection, reqType client.RequestType, request *client.Request) {
+	awaitResponse := conn.SendRequest(reqType, request)
+
+	go func() {
+		if _, err := awaitResponse(client.RequestTimeout(reqType)); err != nil {
+			log.Warn().Err(err).Str("baby_uid", babyUID).Stringer("request_type", reqType).Msg("Camera request failed")
+		}
+	}()
+}
+
 func processLight(babyUID string, control *client.Control, stateManager *baby.StateManager) {
 	if control.NightLight != nil {
 		stateUpdate := baby.State{}
@@ -90,12 +108,12 @@ func processLight(babyUID string, control *client.Control, stateManager *baby.St
 	}
 }
 
-func sendLightCommand(nightLightState bool, conn *client.WebsocketConnection) {
+func sendLightCommand(babyUID string, nightLightState bool, conn client.Connection) {
 	nightLight := client.Control_LIGHT_OFF
 	if nightLightState {
 		nightLight = client.Control_LIGHT_ON
 	}
-	conn.SendRequest(client.RequestType_PUT_CONTROL, &client.Request{
+	sendRequestAndLog(babyUID, conn, client.RequestType_PUT_CONTROL, &client.Request{
 		Control: &client.Control{
 			NightLight: &nightLight,
 		},
@@ -174,6 +192,14 @@ func processStandby(babyUID string, settings *client.Settings, stateManager *bab
 				if sensor.HighThreshold != nil {
 					deviceInfo.HumidityHighThreshold = sensor.HighThreshold
 				}
+			case client.SensorType_MOTION:
+				if sensor.HighThreshold != nil {
+					deviceInfo.MotionThreshold = sensor.HighThreshold
+				}
+			case client.SensorType_SOUND:
+				if sensor.HighThreshold != nil {
+					deviceInfo.SoundThreshold = sensor.HighThreshold
+				}
 			}
 		}
 	}
@@ -208,24 +234,49 @@ func processStandby(babyUID string, settings *client.Settings, stateManager *bab
 	}
 	
 	// Set last updated timestamp
-	timestamp := time.Now().Unix()
+	now := time.Now()
+	timestamp := now.Unix()
 	deviceInfo.LastUpdated = &timestamp
 	
-	// Set device info in state
+	// Set device info in state, unchanged info is still confirmed as up to date
 	stateUpdate.DeviceInfo = deviceInfo
 	stateManager.Update(babyUID, stateUpdate)
+	stateManager.RecordDeviceInfoUpdate(babyUID, now)
 	
 	log.Debug().Str("baby_uid", babyUID).Interface("device_info", deviceInfo).Msg("Updated device info from settings")
 }
 
-func sendStandbyCommand(standbyState bool, conn *client.WebsocketConnection) {
-	conn.SendRequest(client.RequestType_PUT_SETTINGS, &client.Request{
+func sendStandbyCommand(babyUID string, standbyState bool, conn client.Connection) {
+	sendRequestAndLog(babyUID, conn, client.RequestType_PUT_SETTINGS, &client.Request{
 		Settings: &client.Settings{
 			SleepMode: &standbyState,
 		},
 	})
 }
 
+// sendSensitivityCommand sets the motion and sound event thresholds of the cam, nil thresholds are left unchanged
+// Returns the await function of the cam response.
+func sendSensitivityCommand(motionThreshold *int32, soundThreshold *int32, conn client.Connection) func(time.Duration) (*client.Response, error) {
+	var sensors []*client.Settings_SensorSettings
+	addSensor := func(sensorType client.SensorType, threshold *int32) {
+		if threshold != nil {
+			sensors = append(sensors, &client.Settings_SensorSettings{
+				SensorType:       sensorType.Enum(),
+				UseHighThreshold: utils.ConstRefBool(true),
+				HighThreshold:    threshold,
+			})
+		}
+	}
+	addSensor(client.SensorType_MOTION, motionThreshold)
+	addSensor(client.SensorType_SOUND, soundThreshold)
+
+	return conn.SendRequest(client.RequestType_PUT_SETTINGS, &client.Request{
+		Settings: &client.Settings{
+			Sensors: sensors,
+		},
+	})
+}
+
 func processStatus(babyUID string, status *client.Status, stateManager *baby.StateManager) {
 	stateUpdate := baby.State{}
 	deviceInfo := &baby.DeviceInfo{}
@@ -256,12 +307,14 @@ func processStatus(babyUID string, status *client.Status, stateManager *baby.Sta
 	}
 	
 	// Set last updated timestamp
-	timestamp := time.Now().Unix()
+	now := time.Now()
+	timestamp := now.Unix()
 	deviceInfo.LastUpdated = &timestamp
 	
-	// Set device info in state
+	// Set device info in state, unchanged info is still confirmed as up to date
 	stateUpdate.DeviceInfo = deviceInfo
 	stateManager.Update(babyUID, stateUpdate)
+	stateManager.RecordDeviceInfoUpdate(babyUID, now)
 	
 	log.Debug().Str("baby_uid", babyUID).Interface("device_info", deviceInfo).Msg("Updated device info from status")
 }
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/client"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunWebsocketAppliesInitialState(t *testing.T) {
+	app := &App{BabyStateManager: baby.NewStateManager()}
+	conn := newFakeConnection()
+
+	conn.Respond(client.RequestType_GET_SENSOR_DATA, func(*client.Request) *client.Response {
+		return &client.Response{SensorData: []*client.SensorData{
+			sensorReading(client.SensorType_TEMPERATURE, 22500),
+			sensorReading(client.SensorType_HUMIDITY, 48000),
+		}}
+	})
+	conn.Respond(client.RequestType_GET_CONTROL, func(*client.Request) *client.Response {
+		return &client.Response{Control: &client.Control{
+			NightLight: client.Control_NightLight(client.Control_LIGHT_ON).Enum(),
+		}}
+	})
+	conn.Respond(client.RequestType_GET_SETTINGS, func(*client.Request) *client.Response {
+		return &client.Response{Settings: &client.Settings{
+			SleepMode:   utils.ConstRefBool(true),
+			NightVision: utils.ConstRefBool(true),
+		}}
+	})
+	conn.Respond(client.RequestType_GET_STATUS, func(*client.Request) *client.Response {
+		return &client.Response{Status: &client.Status{
+			CurrentVersion: utils.ConstRefStr("1.2.3"),
+		}}
+	})
+
+	runner := utils.RunWithGracefulCancel(func(ctx utils.GracefulContext) {
+		app.runWebsocket("baby1", conn, ctx)
+	})
+
+	assert.Eventually(t, func() bool {
+		return len(conn.SentRequests()) == 4
+	}, time.Second, 10*time.Millisecond)
+	runner.Cancel()
+
+	assert.ElementsMatch(t, []client.RequestType{
+		client.RequestType_GET_CONTROL,
+		client.RequestType_GET_SENSOR_DATA,
+		client.RequestType_GET_STATUS,
+		client.RequestType_GET_SETTINGS,
+	}, conn.SentRequests())
+
+	state := app.BabyStateManager.GetBabyState("baby1")
+	assert.Equal(t, 22.5, state.GetTemperature())
+	assert.Equal(t, 48.0, state.GetHumidity())
+	assert.True(t, state.GetNightLight())
+	assert.True(t, state.GetStandby())
+	if assert.NotNil(t, state.GetDeviceInfo()) {
+		assert.Equal(t, "1.2.3", *state.GetDeviceInfo().FirmwareVersion)
+		assert.True(t, *state.GetDeviceInfo().NightVision)
+	}
+}
+
+func TestHandleWebsocketMessageCamRequests(t *testing.T) {
+	app := &App{BabyStateManager: baby.NewStateManager()}
+	conn := newFakeConnection()
+	conn.RegisterMessageHandler(func(m *client.Message, _ client.Connection) {
+		app.handleWebsocketMessage("baby1", m)
+	})
+
+	conn.InjectRequest(client.RequestType_PUT_SENSOR_DATA, &client.Request{SensorData_: []*client.SensorData{
+		sensorReading(client.SensorType_TEMPERATURE, 19250),
+		{SensorType: client.SensorType(client.SensorType_NIGHT).Enum(), Value: utils.ConstRefInt32(1)},
+	}})
+
+	state := app.BabyStateManager.GetBabyState("baby1")
+	assert.Equal(t, 19.25, state.GetTemperature())
+	assert.True(t, *state.IsNight)
+
+	// Pushed updates replace previously known values
+	conn.InjectRequest(client.RequestType_PUT_CONTROL, &client.Request{Control: &client.Control{
+		NightLight: client.Control_NightLight(client.Control_LIGHT_ON).Enum(),
+	}})
+	assert.True(t, app.BabyStateManager.GetBabyState("baby1").GetNightLight())
+
+	conn.InjectRequest(client.RequestType_PUT_CONTROL, &client.Request{Control: &client.Control{
+		NightLight: client.Control_NightLight(client.Control_LIGHT_OFF).Enum(),
+	}})
+	assert.False(t, app.BabyStateManager.GetBabyState("baby1").GetNightLight())
+
+	conn.InjectRequest(client.RequestType_PUT_SETTINGS, &client.Request{Settings: &client.Settings{
+		SleepMode: utils.ConstRefBool(true),
+	}})
+	assert.True(t, app.BabyStateManager.GetBabyState("baby1").GetStandby())
+
+	// Other babies are not affected
+	assert.Nil(t, app.BabyStateManager.GetBabyState("baby2").TemperatureMilli)
+}
+
+func TestSendCommands(t *testing.T) {
+	conn := newFakeConnection()
+
+	sendLightCommand("baby1", true, conn)
+	if request := conn.LastRequest(client.RequestType_PUT_CONTROL); assert.NotNil(t, request) {
+		assert.Equal(t, client.Control_LIGHT_ON, *request.Control.NightLight)
+	}
+
+	sendStandbyCommand("baby1", false, conn)
+	if request := conn.LastRequest(client.RequestType_PUT_SETTINGS); assert.NotNil(t, request) {
+		assert.False(t, *request.Settings.SleepMode)
+	}
+}
+
+func TestRequestLocalStreamingUpdatesRequestState(t *testing.T) {
+	stateManager := baby.NewStateManager()
+	conn := newFakeConnection()
+
+	conn.Respond(client.RequestType_PUT_STREAMING, func(*client.Request) *client.Response {
+		return &client.Response{}
+	})
+	requestLocalStreaming("baby1", "rtmp://localhost/local/baby1", client.StreamIdentifier_MOBILE, client.Streaming_STARTED, conn, stateManager)
+	assert.Equal(t, baby.StreamRequestState_Requested, stateManager.GetBabyState("baby1").GetStreamRequestState())
+
+	if request := conn.LastRequest(client.RequestType_PUT_STREAMING); assert.NotNil(t, request) {
+		assert.Equal(t, "rtmp://localhost/local/baby1", *request.Streaming.RtmpUrl)
+		assert.Equal(t, client.StreamIdentifier_MOBILE, *request.Streaming.Id)
+	}
+
+	requestLocalStreaming("baby1", "rtmp://localhost/local/baby1", client.StreamIdentifier_DVR, client.Streaming_STARTED, conn, stateManager)
+	if request := conn.LastRequest(client.RequestType_PUT_STREAMING); assert.NotNil(t, request) {
+		assert.Equal(t, client.StreamIdentifier_DVR, *request.Streaming.Id)
+	}
+
+	conn.Respond(client.RequestType_PUT_STREAMING, func(*client.Request) *client.Response {
+		return &client.Response{
+			StatusCode:    utils.ConstRefInt32(403),
+			StatusMessage: utils.ConstRefStr("Forbidden: Number of Mobile App connections above limit, declining connection"),
+		}
+	})
+	requestLocalStreaming("baby1", "rtmp://localhost/local/baby1", client.StreamIdentifier_MOBILE, client.Streaming_STARTED, conn, stateManager)
+	assert.Equal(t, baby.StreamRequestState_RequestFailed, stateManager.GetBabyState("baby1").GetStreamRequestState())
+}
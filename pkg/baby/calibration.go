@@ -0,0 +1,34 @@
+package baby
+
+import "math"
+
+const (
+	// MaxTempOffset - largest temperature calibration offset in either direction (Celsius)
+	MaxTempOffset = 10.0
+
+	// MaxHumidityOffset - largest humidity calibration offset in either direction (percentage points)
+	MaxHumidityOffset = 20.0
+)
+
+// IsCalibrated - returns whether any sensor calibration offset is configured
+func (config DisplayConfig) IsCalibrated() bool {
+	return config.TempOffset != 0 || config.HumidityOffset != 0
+}
+
+// CalibrateSensors - applies the calibration offsets to the sensor values of the update
+// The values reported by the camera are kept in the raw fields, humidity stays within 0-100 %.
+func (config DisplayConfig) CalibrateSensors(update *State) {
+	if update.TemperatureMilli != nil {
+		raw := *update.TemperatureMilli
+		update.RawTemperatureMilli = &raw
+		update.SetTemperatureMilli(raw + int32(math.Round(config.TempOffset*1000)))
+	}
+
+	if update.HumidityMilli != nil {
+		raw := *update.HumidityMilli
+		update.RawHumidityMilli = &raw
+
+		calibrated := raw + int32(math.Round(config.HumidityOffset*1000))
+		update.SetHumidityMilli(min(max(calibrated, 0), 100000))
+	}
+}
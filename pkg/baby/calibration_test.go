@@ -0,0 +1,50 @@
+package baby_test
+
+import (
+	"testing"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisplayConfigCalibrateSensors(t *testing.T) {
+	config := baby.DisplayConfig{TempOffset: -1.5, HumidityOffset: 2}
+	assert.True(t, config.IsCalibrated())
+	assert.False(t, baby.DisplayConfig{Name: "Nursery"}.IsCalibrated())
+
+	update := baby.NewState().SetTemperatureMilli(22400).SetHumidityMilli(45000).SetIsNight(true)
+	config.CalibrateSensors(update)
+
+	assert.Equal(t, int32(20900), *update.TemperatureMilli)
+	assert.Equal(t, int32(22400), *update.RawTemperatureMilli)
+	assert.Equal(t, int32(47000), *update.HumidityMilli)
+	assert.Equal(t, int32(45000), *update.RawHumidityMilli)
+	assert.True(t, *update.IsNight)
+
+	// Humidity stays within 0-100 %
+	update = baby.NewState().SetHumidityMilli(99000)
+	config.CalibrateSensors(update)
+	assert.Equal(t, int32(100000), *update.HumidityMilli)
+
+	// Missing values are left out
+	update = baby.NewState().SetHumidityMilli(50000)
+	config.CalibrateSensors(update)
+	assert.Nil(t, update.TemperatureMilli)
+	assert.Nil(t, update.RawTemperatureMilli)
+
+	// Raw values are internal
+	assert.NotContains(t, update.AsMap(false), "raw_humidity")
+	assert.Equal(t, float64(50), update.AsMap(true)["raw_humidity"])
+}
+
+func TestDisplayConfigStoreCalibrationPersists(t *testing.T) {
+	filename := t.TempDir() + "/display_config.json"
+
+	store := baby.NewDisplayConfigStore(filename)
+	assert.NoError(t, store.Set("baby1", baby.DisplayConfig{Name: "Nursery", TempOffset: -0.8, HumidityOffset: 3.5}))
+
+	reloaded := baby.NewDisplayConfigStore(filename)
+	assert.NoError(t, reloaded.Load())
+	assert.Equal(t, -0.8, reloaded.Get("baby1").TempOffset)
+	assert.Equal(t, 3.5, reloaded.Get("baby1").HumidityOffset)
+}
@@ -0,0 +1,116 @@
+package baby
+
+import (
+	"time"
+)
+
+// UptimeWindow - period over which the connection uptime of a camera is calculated
+const UptimeWindow = 24 * time.Hour
+
+// ConnectionStats - reliability of the websocket connection to a camera
+type ConnectionStats struct {
+	ConnectedSince *int64   `json:"connected_since"` // Unix timestamp the current connection was established, nil while disconnected
+	LastDisconnect *int64   `json:"last_disconnect"` // Unix timestamp of the last connection drop, nil if it never dropped
+	Uptime24h      *float64 `json:"uptime_24h"`      // Percentage of the observed part of the last 24 hours the camera was connected, nil if never observed
+}
+
+type connectionTransition struct {
+	at    time.Time
+	alive bool
+}
+
+// ConnectionTracker - records connection up/down transitions of a single camera
+// Note: not thread safe, StateManager guards it with its state mutex
+type ConnectionTracker struct {
+	transitions    []connectionTransition // Oldest first, only the last one before the uptime window is kept
+	connectedSince *time.Time
+	lastDisconnect *time.Time
+}
+
+// NewConnectionTracker - constructor
+func NewConnectionTracker() *ConnectionTracker {
+	return &ConnectionTracker{}
+}
+
+// Record - records the connection state at given time, repeated states are ignored
+func (tracker *ConnectionTracker) Record(alive bool, at time.Time) {
+	if n := len(tracker.transitions); n > 0 && tracker.transitions[n-1].alive == alive {
+		return
+	}
+
+	if alive {
+		tracker.connectedSince = &at
+	} else {
+		if tracker.connectedSince != nil {
+			tracker.lastDisconnect = &at
+		}
+		tracker.connectedSince = nil
+	}
+
+	tracker.transitions = append(tracker.transitions, connectionTransition{at: at, alive: alive})
+
+	// Transitions ending before the window don't affect the uptime anymore
+	windowStart := at.Add(-UptimeWindow)
+	dropped := 0
+	for dropped+1 < len(tracker.transitions) && !tracker.transitions[dropped+1].at.After(windowStart) {
+		dropped++
+	}
+	tracker.transitions = tracker.transitions[dropped:]
+}
+
+// Stats - returns the connection stats as of given time
+func (tracker *ConnectionTracker) Stats(now time.Time) ConnectionStats {
+	stats := ConnectionStats{}
+
+	if tracker.connectedSince != nil {
+		connectedSince := tracker.connectedSince.Unix()
+		stats.ConnectedSince = &connectedSince
+	}
+
+	if tracker.lastDisconnect != nil {
+		lastDisconnect := tracker.lastDisconnect.Unix()
+		stats.LastDisconnect = &lastDisconnect
+	}
+
+	if len(tracker.transitions) == 0 {
+		return stats
+	}
+
+	// Only the part of the window since the camera was first seen counts
+	windowStart := now.Add(-UptimeWindow)
+	observedStart := tracker.transitions[0].at
+	if observedStart.Before(windowStart) {
+		observedStart = windowStart
+	}
+
+	var aliveDuration time.Duration
+	for i, transition := range tracker.transitions {
+		if !transition.alive {
+			continue
+		}
+
+		from := transition.at
+		if from.Before(observedStart) {
+			from = observedStart
+		}
+
+		to := now
+		if i+1 < len(tracker.transitions) {
+			to = tracker.transitions[i+1].at
+		}
+
+		if to.After(from) {
+			aliveDuration += to.Sub(from)
+		}
+	}
+
+	var uptime float64
+	if observed := now.Sub(observedStart); observed > 0 {
+		uptime = float64(aliveDuration) / float64(observed) * 100
+	} else if tracker.transitions[len(tracker.transitions)-1].alive {
+		uptime = 100
+	}
+	stats.Uptime24h = &uptime
+
+	return stats
+}
@@ -0,0 +1,71 @@
+package baby_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionTrackerStats(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	tracker := baby.NewConnectionTracker()
+
+	stats := tracker.Stats(start)
+	assert.Nil(t, stats.ConnectedSince)
+	assert.Nil(t, stats.LastDisconnect)
+	assert.Nil(t, stats.Uptime24h)
+
+	tracker.Record(true, start)
+	tracker.Record(true, start.Add(time.Hour)) // Repeated state is ignored
+	tracker.Record(false, start.Add(3*time.Hour))
+	tracker.Record(true, start.Add(4*time.Hour))
+
+	stats = tracker.Stats(start.Add(4 * time.Hour))
+	assert.Equal(t, start.Add(4*time.Hour).Unix(), *stats.ConnectedSince)
+	assert.Equal(t, start.Add(3*time.Hour).Unix(), *stats.LastDisconnect)
+	assert.InDelta(t, 75.0, *stats.Uptime24h, 0.001)
+
+	tracker.Record(false, start.Add(6*time.Hour))
+	stats = tracker.Stats(start.Add(8 * time.Hour))
+	assert.Nil(t, stats.ConnectedSince)
+	assert.Equal(t, start.Add(6*time.Hour).Unix(), *stats.LastDisconnect)
+	assert.InDelta(t, 62.5, *stats.Uptime24h, 0.001)
+}
+
+func TestConnectionTrackerRollingWindow(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	tracker := baby.NewConnectionTracker()
+
+	// Down for the first 2 hours, connected ever since
+	tracker.Record(false, start)
+	tracker.Record(true, start.Add(2*time.Hour))
+
+	stats := tracker.Stats(start.Add(4 * time.Hour))
+	assert.Nil(t, stats.LastDisconnect, "Never connected before, nothing dropped")
+	assert.InDelta(t, 50.0, *stats.Uptime24h, 0.001)
+
+	// The outage falls out of the window after 24 hours
+	stats = tracker.Stats(start.Add(30 * time.Hour))
+	assert.InDelta(t, 100.0, *stats.Uptime24h, 0.001)
+
+	tracker.Record(false, start.Add(30*time.Hour))
+	stats = tracker.Stats(start.Add(36 * time.Hour))
+	assert.InDelta(t, 75.0, *stats.Uptime24h, 0.001)
+}
+
+func TestStateManagerTracksConnection(t *testing.T) {
+	manager := baby.NewStateManager()
+	assert.Equal(t, baby.ConnectionStats{}, manager.GetConnectionStats("baby1"))
+
+	manager.Update("baby1", *baby.NewState().SetWebsocketAlive(true))
+	stats := manager.GetConnectionStats("baby1")
+	assert.NotNil(t, stats.ConnectedSince)
+	assert.Nil(t, stats.LastDisconnect)
+
+	manager.Update("baby1", *baby.NewState().SetWebsocketAlive(false))
+	stats = manager.GetConnectionStats("baby1")
+	assert.Nil(t, stats.ConnectedSince)
+	assert.NotNil(t, stats.LastDisconnect)
+}
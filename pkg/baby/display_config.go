@@ -0,0 +1,187 @@
+package baby
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// DisplayConfig - local display preferences of a baby, layered over the data provided by Nanit
+type DisplayConfig struct {
+	Name      string `json:"name,omitempty"` // Custom display name, the Nanit name is used if empty
+	SortOrder int    `json:"sort_order"`     // Babies are listed in ascending order, ties keep the Nanit order
+	Hidden    bool   `json:"hidden"`         // Camera is not shown on the dashboard
+
+	// Sensor calibration, added to the readings of the camera before they are displayed and recorded
+	TempOffset     float64 `json:"temp_offset,omitempty"`     // Celsius
+	HumidityOffset float64 `json:"humidity_offset,omitempty"` // Percentage points
+}
+
+// DisplayedBaby - baby with its display config applied
+type DisplayedBaby struct {
+	Baby
+	NanitName string `json:"nanit_name"`
+	SortOrder int    `json:"sort_order"`
+	Hidden    bool   `json:"hidden"`
+}
+
+type displayConfigFile struct {
+	Babies          map[string]DisplayConfig `json:"babies"`
+	TemperatureUnit TemperatureUnit          `json:"temperature_unit,omitempty"`
+}
+
+// DisplayConfigStore - persisted display configs by baby UID and account-wide display preferences
+type DisplayConfigStore struct {
+	Filename        string
+	configs         map[string]DisplayConfig
+	temperatureUnit TemperatureUnit
+	mutex           sync.RWMutex
+}
+
+// NewDisplayConfigStore - constructor, call Load to read the stored configs
+func NewDisplayConfigStore(filename string) *DisplayConfigStore {
+	return &DisplayConfigStore{
+		Filename: filename,
+		configs:  make(map[string]DisplayConfig),
+	}
+}
+
+// Load - loads stored configs from the file, a missing file means no configs
+func (store *DisplayConfigStore) Load() error {
+	data, err := os.ReadFile(store.Filename)
+	if os.IsNotExist(err) {
+		log.Debug().Str("filename", store.Filename).Msg("No display config file found")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	file := displayConfigFile{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.configs = make(map[string]DisplayConfig, len(file.Babies))
+	for babyUID, config := range file.Babies {
+		store.configs[babyUID] = config
+	}
+
+	store.temperatureUnit = ""
+	if file.TemperatureUnit != "" {
+		if unit, err := ParseTemperatureUnit(string(file.TemperatureUnit)); err != nil {
+			log.Warn().Err(err).Str("filename", store.Filename).Msg("Ignoring stored temperature unit")
+		} else {
+			store.temperatureUnit = unit
+		}
+	}
+
+	return nil
+}
+
+// Get - returns config of a baby, zero config if none is stored
+// Safe to call on nil store.
+func (store *DisplayConfigStore) Get(babyUID string) DisplayConfig {
+	if store == nil {
+		return DisplayConfig{}
+	}
+
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	return store.configs[babyUID]
+}
+
+// Set - stores config of a baby and persists all configs
+// The in-memory config is left unchanged if saving fails.
+func (store *DisplayConfigStore) Set(babyUID string, config DisplayConfig) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	configs := make(map[string]DisplayConfig, len(store.configs)+1)
+	for uid, c := range store.configs {
+		configs[uid] = c
+	}
+	configs[babyUID] = config
+
+	if err := store.save(configs, store.temperatureUnit); err != nil {
+		return err
+	}
+
+	store.configs = configs
+	return nil
+}
+
+// GetTemperatureUnit - returns the account-wide temperature unit, Celsius unless configured
+// Safe to call on nil store.
+func (store *DisplayConfigStore) GetTemperatureUnit() TemperatureUnit {
+	if store == nil {
+		return TemperatureUnitCelsius
+	}
+
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	if store.temperatureUnit == "" {
+		return TemperatureUnitCelsius
+	}
+
+	return store.temperatureUnit
+}
+
+// SetTemperatureUnit - stores the account-wide temperature unit and persists all configs
+// The in-memory unit is left unchanged if saving fails.
+func (store *DisplayConfigStore) SetTemperatureUnit(unit TemperatureUnit) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if err := store.save(store.configs, unit); err != nil {
+		return err
+	}
+
+	store.temperatureUnit = unit
+	return nil
+}
+
+// save - writes the configs to the file, caller must hold the mutex
+func (store *DisplayConfigStore) save(configs map[string]DisplayConfig, unit TemperatureUnit) error {
+	data, err := json.MarshalIndent(displayConfigFile{Babies: configs, TemperatureUnit: unit}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteFileAtomic(store.Filename, data, 0644)
+}
+
+// Apply - returns the babies with their display configs applied, sorted by sort order
+// Safe to call on nil store.
+func (store *DisplayConfigStore) Apply(babies []Baby) []DisplayedBaby {
+	displayed := make([]DisplayedBaby, 0, len(babies))
+	for _, b := range babies {
+		config := store.Get(b.UID)
+
+		d := DisplayedBaby{
+			Baby:      b,
+			NanitName: b.Name,
+			SortOrder: config.SortOrder,
+			Hidden:    config.Hidden,
+		}
+		if config.Name != "" {
+			d.Name = config.Name
+		}
+
+		displayed = append(displayed, d)
+	}
+
+	sort.SliceStable(displayed, func(i, j int) bool {
+		return displayed[i].SortOrder < displayed[j].SortOrder
+	})
+
+	return displayed
+}
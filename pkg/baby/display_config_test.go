@@ -0,0 +1,99 @@
+package baby_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisplayConfigStorePersists(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "display_config.json")
+
+	store := baby.NewDisplayConfigStore(filename)
+	assert.NoError(t, store.Load())
+	assert.Equal(t, baby.DisplayConfig{}, store.Get("baby1"))
+
+	assert.NoError(t, store.Set("baby1", baby.DisplayConfig{Name: "Nursery", SortOrder: 2, Hidden: true}))
+
+	reloaded := baby.NewDisplayConfigStore(filename)
+	assert.NoError(t, reloaded.Load())
+	assert.Equal(t, baby.DisplayConfig{Name: "Nursery", SortOrder: 2, Hidden: true}, reloaded.Get("baby1"))
+}
+
+func TestDisplayConfigStoreLoadCorrupted(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "display_config.json")
+	assert.NoError(t, os.WriteFile(filename, []byte("{"), 0644))
+
+	assert.Error(t, baby.NewDisplayConfigStore(filename).Load())
+}
+
+func TestDisplayConfigStoreApply(t *testing.T) {
+	store := baby.NewDisplayConfigStore(filepath.Join(t.TempDir(), "display_config.json"))
+	assert.NoError(t, store.Set("baby1", baby.DisplayConfig{SortOrder: 1}))
+	assert.NoError(t, store.Set("baby3", baby.DisplayConfig{Name: "Nursery", SortOrder: -1}))
+
+	babies := []baby.Baby{
+		{UID: "baby1", Name: "First"},
+		{UID: "baby2", Name: "Second"},
+		{UID: "baby3", Name: "Third"},
+	}
+
+	displayed := store.Apply(babies)
+	if assert.Len(t, displayed, 3) {
+		assert.Equal(t, "baby3", displayed[0].UID)
+		assert.Equal(t, "Nursery", displayed[0].Name)
+		assert.Equal(t, "Third", displayed[0].NanitName)
+		assert.Equal(t, "baby2", displayed[1].UID)
+		assert.Equal(t, "Second", displayed[1].Name)
+		assert.Equal(t, "baby1", displayed[2].UID)
+	}
+
+	// Original list is not modified
+	assert.Equal(t, "Third", babies[2].Name)
+
+	// Nil store keeps the Nanit names and order
+	displayed = (*baby.DisplayConfigStore)(nil).Apply(babies)
+	assert.Equal(t, "baby1", displayed[0].UID)
+	assert.Equal(t, "Third", displayed[2].Name)
+}
+
+func TestDisplayConfigStoreTemperatureUnit(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "display_config.json")
+
+	store := baby.NewDisplayConfigStore(filename)
+	assert.NoError(t, store.Load())
+	assert.Equal(t, baby.TemperatureUnitCelsius, store.GetTemperatureUnit())
+
+	assert.NoError(t, store.Set("baby1", baby.DisplayConfig{Name: "Nursery"}))
+	assert.NoError(t, store.SetTemperatureUnit(baby.TemperatureUnitFahrenheit))
+
+	reloaded := baby.NewDisplayConfigStore(filename)
+	assert.NoError(t, reloaded.Load())
+	assert.Equal(t, baby.TemperatureUnitFahrenheit, reloaded.GetTemperatureUnit())
+	assert.Equal(t, "Nursery", reloaded.Get("baby1").Name)
+
+	assert.Equal(t, baby.TemperatureUnitCelsius, (*baby.DisplayConfigStore)(nil).GetTemperatureUnit())
+}
+
+func TestParseTemperatureUnit(t *testing.T) {
+	for value, expected := range map[string]baby.TemperatureUnit{
+		"celsius":    baby.TemperatureUnitCelsius,
+		"C":          baby.TemperatureUnitCelsius,
+		"Fahrenheit": baby.TemperatureUnitFahrenheit,
+		"f":          baby.TemperatureUnitFahrenheit,
+	} {
+		unit, err := baby.ParseTemperatureUnit(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, unit, value)
+	}
+
+	_, err := baby.ParseTemperatureUnit("kelvin")
+	assert.Error(t, err)
+
+	assert.Equal(t, 71.6, baby.TemperatureUnitFahrenheit.FromCelsius(22))
+	assert.Equal(t, 22.0, baby.TemperatureUnitCelsius.FromCelsius(22))
+	assert.Nil(t, baby.TemperatureUnitFahrenheit.FromCelsiusPtr(nil))
+}
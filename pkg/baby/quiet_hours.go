@@ -0,0 +1,201 @@
+package baby
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// QuietHours - daily window during which event notifications of a baby are suppressed
+// Start and End are wall clock times (HH:MM) in Timezone, the window spans midnight if End is before Start.
+type QuietHours struct {
+	Enabled  bool   `json:"enabled"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone,omitempty"` // IANA name, local time of the server if empty
+}
+
+// quietHoursTimeLayout - layout of Start and End
+const quietHoursTimeLayout = "15:04"
+
+// Validate - returns error if the window can't be evaluated
+// Disabled quiet hours may be incomplete.
+func (q QuietHours) Validate() error {
+	if !q.Enabled {
+		return nil
+	}
+
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return fmt.Errorf("invalid start: %w", err)
+	}
+
+	end, err := parseClock(q.End)
+	if err != nil {
+		return fmt.Errorf("invalid end: %w", err)
+	}
+
+	if start == end {
+		return fmt.Errorf("start and end must differ")
+	}
+
+	if _, err := q.location(); err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	return nil
+}
+
+// IsActive - returns whether the time falls within the window, false if disabled or invalid
+func (q QuietHours) IsActive(t time.Time) bool {
+	if !q.Enabled {
+		return false
+	}
+
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return false
+	}
+
+	end, err := parseClock(q.End)
+	if err != nil {
+		return false
+	}
+
+	location, err := q.location()
+	if err != nil {
+		return false
+	}
+
+	local := t.In(location)
+	minute := local.Hour()*60 + local.Minute()
+
+	if start < end {
+		return minute >= start && minute < end
+	}
+
+	// Window spans midnight
+	return minute >= start || minute < end
+}
+
+func (q QuietHours) location() (*time.Location, error) {
+	if q.Timezone == "" {
+		return time.Local, nil
+	}
+
+	return time.LoadLocation(q.Timezone)
+}
+
+// parseClock - returns minutes since midnight of HH:MM
+func parseClock(value string) (int, error) {
+	parsed, err := time.Parse(quietHoursTimeLayout, value)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", value)
+	}
+
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+// QuietHoursStore - persisted quiet hours by baby UID
+type QuietHoursStore struct {
+	Filename string
+	configs  map[string]QuietHours
+	mutex    sync.RWMutex
+}
+
+// NewQuietHoursStore - constructor, call Load to read the stored quiet hours
+func NewQuietHoursStore(filename string) *QuietHoursStore {
+	return &QuietHoursStore{
+		Filename: filename,
+		configs:  make(map[string]QuietHours),
+	}
+}
+
+// Load - loads stored quiet hours from the file, a missing file means none are configured
+func (store *QuietHoursStore) Load() error {
+	data, err := os.ReadFile(store.Filename)
+	if os.IsNotExist(err) {
+		log.Debug().Str("filename", store.Filename).Msg("No quiet hours file found")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	configs := make(map[string]QuietHours)
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.configs = configs
+	return nil
+}
+
+// Get - returns quiet hours of a baby, disabled if none are stored
+// Safe to call on nil store.
+func (store *QuietHoursStore) Get(babyUID string) QuietHours {
+	if store == nil {
+		return QuietHours{}
+	}
+
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	return store.configs[babyUID]
+}
+
+// GetAll - returns copy of the quiet hours of all babies
+// Safe to call on nil store.
+func (store *QuietHoursStore) GetAll() map[string]QuietHours {
+	configs := make(map[string]QuietHours)
+	if store == nil {
+		return configs
+	}
+
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for babyUID, config := range store.configs {
+		configs[babyUID] = config
+	}
+
+	return configs
+}
+
+// Set - stores quiet hours of a baby and persists all of them
+// The in-memory quiet hours are left unchanged if saving fails.
+func (store *QuietHoursStore) Set(babyUID string, config QuietHours) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	configs := make(map[string]QuietHours, len(store.configs)+1)
+	for uid, c := range store.configs {
+		configs[uid] = c
+	}
+	configs[babyUID] = config
+
+	data, err := json.MarshalIndent(configs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := utils.WriteFileAtomic(store.Filename, data, 0644); err != nil {
+		return err
+	}
+
+	store.configs = configs
+	return nil
+}
+
+// IsActive - returns whether the quiet hours of the baby are active at the time
+// Safe to call on nil store.
+func (store *QuietHoursStore) IsActive(babyUID string, t time.Time) bool {
+	return store.Get(babyUID).IsActive(t)
+}
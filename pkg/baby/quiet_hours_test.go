@@ -0,0 +1,65 @@
+package baby_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuietHoursIsActive(t *testing.T) {
+	prague, err := time.LoadLocation("Europe/Prague")
+	if err != nil {
+		t.Skipf("Timezone data not available: %v", err)
+	}
+
+	overnight := baby.QuietHours{Enabled: true, Start: "19:00", End: "07:00", Timezone: "Europe/Prague"}
+	assert.NoError(t, overnight.Validate())
+	assert.True(t, overnight.IsActive(time.Date(2024, 1, 1, 19, 0, 0, 0, prague)))
+	assert.True(t, overnight.IsActive(time.Date(2024, 1, 1, 2, 30, 0, 0, prague)))
+	assert.False(t, overnight.IsActive(time.Date(2024, 1, 1, 7, 0, 0, 0, prague)))
+	assert.False(t, overnight.IsActive(time.Date(2024, 1, 1, 12, 0, 0, 0, prague)))
+
+	// Evaluated in the configured timezone, 18:30 UTC is 19:30 in Prague
+	assert.True(t, overnight.IsActive(time.Date(2024, 1, 1, 18, 30, 0, 0, time.UTC)))
+
+	daytime := baby.QuietHours{Enabled: true, Start: "13:00", End: "15:30", Timezone: "Europe/Prague"}
+	assert.True(t, daytime.IsActive(time.Date(2024, 1, 1, 15, 29, 0, 0, prague)))
+	assert.False(t, daytime.IsActive(time.Date(2024, 1, 1, 15, 30, 0, 0, prague)))
+	assert.False(t, daytime.IsActive(time.Date(2024, 1, 1, 12, 59, 0, 0, prague)))
+
+	disabled := overnight
+	disabled.Enabled = false
+	assert.False(t, disabled.IsActive(time.Date(2024, 1, 1, 2, 30, 0, 0, prague)))
+}
+
+func TestQuietHoursValidate(t *testing.T) {
+	assert.NoError(t, baby.QuietHours{}.Validate(), "disabled quiet hours may be incomplete")
+	assert.NoError(t, baby.QuietHours{Enabled: true, Start: "22:00", End: "06:00"}.Validate())
+
+	assert.Error(t, baby.QuietHours{Enabled: true, Start: "7pm", End: "07:00"}.Validate())
+	assert.Error(t, baby.QuietHours{Enabled: true, Start: "19:00", End: "24:00"}.Validate())
+	assert.Error(t, baby.QuietHours{Enabled: true, Start: "07:00", End: "07:00"}.Validate())
+	assert.Error(t, baby.QuietHours{Enabled: true, Start: "19:00", End: "07:00", Timezone: "Mars/Olympus"}.Validate())
+}
+
+func TestQuietHoursStorePersists(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "quiet_hours.json")
+
+	store := baby.NewQuietHoursStore(filename)
+	assert.NoError(t, store.Load())
+	assert.Equal(t, baby.QuietHours{}, store.Get("baby1"))
+
+	quietHours := baby.QuietHours{Enabled: true, Start: "19:00", End: "07:00", Timezone: "UTC"}
+	assert.NoError(t, store.Set("baby1", quietHours))
+
+	reloaded := baby.NewQuietHoursStore(filename)
+	assert.NoError(t, reloaded.Load())
+	assert.Equal(t, quietHours, reloaded.Get("baby1"))
+	assert.Len(t, reloaded.GetAll(), 1)
+
+	// Nil store has no quiet hours
+	assert.False(t, (*baby.QuietHoursStore)(nil).IsActive("baby1", time.Now()))
+}
@@ -48,6 +48,8 @@ type DeviceInfo struct {
 	TempHighThreshold *int32 `json:"temp_high_threshold,omitempty"`
 	HumidityLowThreshold  *int32 `json:"humidity_low_threshold,omitempty"`
 	HumidityHighThreshold *int32 `json:"humidity_high_threshold,omitempty"`
+	MotionThreshold   *int32 `json:"motion_threshold,omitempty"` // Motion level triggering a motion event, lower is more sensitive
+	SoundThreshold    *int32 `json:"sound_threshold,omitempty"`  // Sound level triggering a sound event, lower is more sensitive
 	
 	// Stream configuration
 	MobileBitrate    *int32 `json:"mobile_bitrate,omitempty"`
@@ -67,6 +69,10 @@ type State struct {
 	StreamRequestState *StreamRequestState `internal:"true"`
 	IsWebsocketAlive   *bool               `internal:"true"`
 	LastVideoPacketTime *int64             `internal:"true"` // Unix timestamp of last video packet received
+	SensorDataStale     *bool              `internal:"true"` // Sensor values were restored from history and not yet refreshed by the camera
+	SensorDataTimestamp *int64             `internal:"true"` // Unix timestamp of the restored sensor values
+	RawTemperatureMilli *int32             `internal:"true"` // Temperature reported by the camera, before calibration
+	RawHumidityMilli    *int32             `internal:"true"` // Humidity reported by the camera, before calibration
 
 	MotionTimestamp  *int32 // int32 is used to represent UTC timestamp
 	SoundTimestamp   *int32 // int32 is used to represent UTC timestamp
@@ -107,17 +113,9 @@ func (state *State) Merge(stateUpdate *State) *State {
 			// Special handling for DeviceInfo struct
 			if fieldName == "DeviceInfo" && !patchField.IsNil() {
 				// Merge DeviceInfo fields
-				var mergedDeviceInfo *DeviceInfo
-				if currField.IsNil() {
-					// Current is nil, use patch
-					mergedDeviceInfo = patchField.Interface().(*DeviceInfo)
+				mergedDeviceInfo, deviceInfoChanged := MergeDeviceInfo(currField.Interface().(*DeviceInfo), patchField.Interface().(*DeviceInfo))
+				if deviceInfoChanged {
 					changed = true
-				} else {
-					// Merge non-nil fields from patch into current
-					mergedDeviceInfo = state.mergeDeviceInfo(currField.Interface().(*DeviceInfo), patchField.Interface().(*DeviceInfo))
-					if mergedDeviceInfo != currField.Interface().(*DeviceInfo) {
-						changed = true
-					}
 				}
 				newField.Set(reflect.ValueOf(mergedDeviceInfo))
 			} else if !patchField.IsNil() && (currField.IsNil() || currField.Elem().Interface() != patchField.Elem().Interface()) {
@@ -138,96 +136,120 @@ func (state *State) Merge(stateUpdate *State) *State {
 	return state
 }
 
-// mergeDeviceInfo merges non-nil fields from patch into current DeviceInfo
-func (state *State) mergeDeviceInfo(current *DeviceInfo, patch *DeviceInfo) *DeviceInfo {
+// MergeDeviceInfo merges non-nil fields from patch into current DeviceInfo
+// Returns current and false if no field changed, so callers can skip publishing unchanged device info.
+// LastUpdated is not considered a change, it is only taken over together with a changed field.
+func MergeDeviceInfo(current *DeviceInfo, patch *DeviceInfo) (*DeviceInfo, bool) {
 	if patch == nil {
-		return current
+		return current, false
 	}
 	if current == nil {
-		return patch
+		return patch, true
 	}
-	
+
 	// Create a copy of current
 	merged := *current
-	
-	// Merge non-nil fields from patch
-	if patch.FirmwareVersion != nil {
-		merged.FirmwareVersion = patch.FirmwareVersion
-	}
-	if patch.HardwareVersion != nil {
-		merged.HardwareVersion = patch.HardwareVersion
-	}
-	if patch.DeviceMode != nil {
-		merged.DeviceMode = patch.DeviceMode
-	}
-	if patch.MountingMode != nil {
-		merged.MountingMode = patch.MountingMode
-	}
-	if patch.WiFiNetwork != nil {
-		merged.WiFiNetwork = patch.WiFiNetwork
-	}
-	if patch.WiFiBand != nil {
-		merged.WiFiBand = patch.WiFiBand
-	}
-	if patch.NightVision != nil {
-		merged.NightVision = patch.NightVision
-	}
-	if patch.Volume != nil {
-		merged.Volume = patch.Volume
-	}
-	if patch.SleepMode != nil {
-		merged.SleepMode = patch.SleepMode
-	}
-	if patch.StatusLight != nil {
-		merged.StatusLight = patch.StatusLight
-	}
-	if patch.MicMute != nil {
-		merged.MicMute = patch.MicMute
-	}
-	if patch.AntiFlicker != nil {
-		merged.AntiFlicker = patch.AntiFlicker
-	}
-	if patch.StreamingError != nil {
-		merged.StreamingError = patch.StreamingError
-	}
-	if patch.UpgradeDownloaded != nil {
-		merged.UpgradeDownloaded = patch.UpgradeDownloaded
-	}
-	if patch.AvailableSoundtracks != nil {
-		merged.AvailableSoundtracks = patch.AvailableSoundtracks
-	}
-	if patch.TempLowThreshold != nil {
-		merged.TempLowThreshold = patch.TempLowThreshold
-	}
-	if patch.TempHighThreshold != nil {
-		merged.TempHighThreshold = patch.TempHighThreshold
-	}
-	if patch.HumidityLowThreshold != nil {
-		merged.HumidityLowThreshold = patch.HumidityLowThreshold
-	}
-	if patch.HumidityHighThreshold != nil {
-		merged.HumidityHighThreshold = patch.HumidityHighThreshold
-	}
-	if patch.MobileBitrate != nil {
-		merged.MobileBitrate = patch.MobileBitrate
+	changed := false
+
+	mergedReflect := reflect.ValueOf(&merged).Elem()
+	patchReflect := reflect.ValueOf(patch).Elem()
+
+	for i := 0; i < mergedReflect.NumField(); i++ {
+		if mergedReflect.Type().Field(i).Name == "LastUpdated" {
+			continue
+		}
+
+		mergedField := mergedReflect.Field(i)
+		patchField := patchReflect.Field(i)
+
+		// Fields are pointers or slices, nil means not present in the patch
+		if patchField.IsNil() {
+			continue
+		}
+
+		if !mergedField.IsNil() && reflect.DeepEqual(mergedField.Interface(), patchField.Interface()) {
+			continue
+		}
+
+		mergedField.Set(patchField)
+		changed = true
 	}
-	if patch.MobileFPS != nil {
-		merged.MobileFPS = patch.MobileFPS
+
+	if !changed {
+		return current, false
 	}
-	if patch.DVRBitrate != nil {
-		merged.DVRBitrate = patch.DVRBitrate
+
+	if patch.LastUpdated != nil {
+		merged.LastUpdated = patch.LastUpdated
 	}
-	if patch.DVRFPS != nil {
-		merged.DVRFPS = patch.DVRFPS
+
+	return &merged, true
+}
+
+// DeepCopy - returns a copy of the state sharing no pointers with the original
+func (state *State) DeepCopy() State {
+	copied := State{}
+
+	srcReflect := reflect.ValueOf(state).Elem()
+	dstReflect := reflect.ValueOf(&copied).Elem()
+
+	for i := 0; i < srcReflect.NumField(); i++ {
+		srcField := srcReflect.Field(i)
+		if srcField.Kind() != reflect.Ptr || srcField.IsNil() {
+			continue
+		}
+
+		if deviceInfo, ok := srcField.Interface().(*DeviceInfo); ok {
+			dstReflect.Field(i).Set(reflect.ValueOf(deviceInfo.deepCopy()))
+			continue
+		}
+
+		ptr := reflect.New(srcField.Type().Elem())
+		ptr.Elem().Set(srcField.Elem())
+		dstReflect.Field(i).Set(ptr)
 	}
-	if patch.AnalyticsBitrate != nil {
-		merged.AnalyticsBitrate = patch.AnalyticsBitrate
+
+	return copied
+}
+
+// isEmpty - returns whether no field is set
+func (state *State) isEmpty() bool {
+	r := reflect.ValueOf(state).Elem()
+	for i := 0; i < r.NumField(); i++ {
+		if f := r.Field(i); f.Kind() == reflect.Ptr && !f.IsNil() {
+			return false
+		}
 	}
-	if patch.AnalyticsFPS != nil {
-		merged.AnalyticsFPS = patch.AnalyticsFPS
+
+	return true
+}
+
+// deepCopy - returns a copy of the device info sharing no pointers or slices with the original
+func (info *DeviceInfo) deepCopy() *DeviceInfo {
+	copied := &DeviceInfo{}
+
+	srcReflect := reflect.ValueOf(info).Elem()
+	dstReflect := reflect.ValueOf(copied).Elem()
+
+	for i := 0; i < srcReflect.NumField(); i++ {
+		srcField := srcReflect.Field(i)
+		if srcField.IsNil() {
+			continue
+		}
+
+		switch srcField.Kind() {
+		case reflect.Ptr:
+			ptr := reflect.New(srcField.Type().Elem())
+			ptr.Elem().Set(srcField.Elem())
+			dstReflect.Field(i).Set(ptr)
+		case reflect.Slice:
+			slice := reflect.MakeSlice(srcField.Type(), srcField.Len(), srcField.Len())
+			reflect.Copy(slice, srcField)
+			dstReflect.Field(i).Set(slice)
+		}
 	}
-	
-	return &merged
+
+	return copied
 }
 
 var upperCaseRX = regexp.MustCompile("[A-Z]+")
@@ -361,6 +383,45 @@ func (state *State) IsActivelyStreaming() bool {
 	return time.Since(lastPacketTime) < 10*time.Second
 }
 
+// IsMotionActive checks if motion was detected within the given window
+func (state *State) IsMotionActive(window time.Duration) bool {
+	return isEventActive(state.MotionTimestamp, window)
+}
+
+// IsSoundActive checks if sound was detected within the given window
+func (state *State) IsSoundActive(window time.Duration) bool {
+	return isEventActive(state.SoundTimestamp, window)
+}
+
+func isEventActive(timestamp *int32, window time.Duration) bool {
+	if timestamp == nil {
+		return false
+	}
+
+	return time.Since(time.Unix(int64(*timestamp), 0)) < window
+}
+
+// SetSensorDataStale - mutates field, returns itself
+func (state *State) SetSensorDataStale(value bool) *State {
+	state.SensorDataStale = &value
+	return state
+}
+
+// GetSensorDataStale - safely returns value
+func (state *State) GetSensorDataStale() bool {
+	if state.SensorDataStale != nil {
+		return *state.SensorDataStale
+	}
+
+	return false
+}
+
+// SetSensorDataTimestamp - mutates field, returns itself
+func (state *State) SetSensorDataTimestamp(value int64) *State {
+	state.SensorDataTimestamp = &value
+	return state
+}
+
 // SetIsNight - mutates field, returns itself
 func (state *State) SetIsNight(value bool) *State {
 	state.IsNight = &value
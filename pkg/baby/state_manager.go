@@ -14,6 +14,16 @@ type StateManager struct {
 	stateMutex       sync.RWMutex
 	subscribersMutex sync.RWMutex
 	historyCallback  func(babyUID string, state State) // Callback for historical tracking
+	connections      map[string]*ConnectionTracker      // Websocket up/down transitions by baby UID, guarded by stateMutex
+	sensorThresholds SensorChangeThresholds             // Guarded by stateMutex
+	propagated       map[string]State                   // Last sensor values passed downstream by baby UID, guarded by stateMutex
+}
+
+// SensorChangeThresholds - smallest sensor changes propagated to the history and subscribers, 0 propagates every change
+// The state itself always holds the latest raw values.
+type SensorChangeThresholds struct {
+	TemperatureMilli int32
+	HumidityMilli    int32
 }
 
 // NewStateManager - state manager constructor
@@ -21,10 +31,15 @@ func NewStateManager() *StateManager {
 	return &StateManager{
 		babiesByUID: make(map[string]State),
 		subscribers: make(map[*chan bool]func(babyUID string, state State)),
+		connections: make(map[string]*ConnectionTracker),
+		propagated:  make(map[string]State),
 	}
 }
 
 // Update - updates baby info in thread safe manner
+// Only non-nil fields of the update are merged. When something changed, the history callback and the subscribers
+// receive the update itself (not the merged state), each in its own goroutine. Sensor values which moved less than
+// the change thresholds are stored but left out of the update passed on.
 func (manager *StateManager) Update(babyUID string, stateUpdate State) {
 	var updatedState *State
 
@@ -36,13 +51,28 @@ func (manager *StateManager) Update(babyUID string, stateUpdate State) {
 		if updatedState == &babyState {
 			return
 		}
+
+		// Device info identical to the current one (e.g. a repeated settings response) is not passed on,
+		// MergeDeviceInfo keeps the current pointer when no field differs
+		if stateUpdate.DeviceInfo != nil && updatedState.DeviceInfo == babyState.DeviceInfo {
+			stateUpdate.DeviceInfo = nil
+		}
 	} else {
 		updatedState = NewState().Merge(&stateUpdate)
 	}
 
 	manager.babiesByUID[babyUID] = *updatedState
+
+	if stateUpdate.IsWebsocketAlive != nil {
+		manager.recordConnection(babyUID, *stateUpdate.IsWebsocketAlive)
+	}
 	stateUpdate.EnhanceLogEvent(log.Debug().Str("baby_uid", babyUID)).Msg("Baby state updated")
 
+	stateUpdate = manager.gateSensorChanges(babyUID, stateUpdate)
+	if stateUpdate.isEmpty() {
+		return
+	}
+
 	// Record historical data if callback is set
 	if manager.historyCallback != nil {
 		go manager.historyCallback(babyUID, stateUpdate)
@@ -52,6 +82,8 @@ func (manager *StateManager) Update(babyUID string, stateUpdate State) {
 }
 
 // Subscribe - registers function to be called on every update
+// The callback receives the full current state of every known baby first, then only the changed fields of each update.
+// Callbacks run in their own goroutines, so they may arrive out of order.
 // Returns unsubscribe function
 func (manager *StateManager) Subscribe(callback func(babyUID string, state State)) func() {
 	unsubscribeC := make(chan bool, 1)
@@ -83,6 +115,91 @@ func (manager *StateManager) GetBabyState(babyUID string) *State {
 	return &babyState
 }
 
+// GetAllBabyStates - returns consistent snapshot of all known babies, taken under a single lock
+// States are deep copies, callers may keep or modify them without affecting the manager.
+func (manager *StateManager) GetAllBabyStates() map[string]State {
+	manager.stateMutex.RLock()
+	defer manager.stateMutex.RUnlock()
+
+	states := make(map[string]State, len(manager.babiesByUID))
+	for babyUID, babyState := range manager.babiesByUID {
+		states[babyUID] = babyState.DeepCopy()
+	}
+
+	return states
+}
+
+// RecordMotion - stores time of the latest motion event without notifying anybody
+// Events are propagated separately (NotifyMotionSubscribers), so that cooldown-suppressed ones still count as activity.
+// Events older than the stored one are ignored.
+func (manager *StateManager) RecordMotion(babyUID string, time time.Time) {
+	manager.recordEvent(babyUID, int32(time.Unix()), func(state *State) **int32 { return &state.MotionTimestamp })
+}
+
+// RecordSound - stores time of the latest sound event without notifying anybody
+func (manager *StateManager) RecordSound(babyUID string, time time.Time) {
+	manager.recordEvent(babyUID, int32(time.Unix()), func(state *State) **int32 { return &state.SoundTimestamp })
+}
+
+func (manager *StateManager) recordEvent(babyUID string, timestamp int32, field func(state *State) **int32) {
+	manager.stateMutex.Lock()
+	defer manager.stateMutex.Unlock()
+
+	babyState := manager.babiesByUID[babyUID]
+	if current := *field(&babyState); current != nil && *current >= timestamp {
+		return
+	}
+
+	*field(&babyState) = &timestamp
+	manager.babiesByUID[babyUID] = babyState
+}
+
+// RecordDeviceInfoUpdate - marks the device info as confirmed by the camera at the time without notifying anybody
+// Responses repeating the known device info don't change it, this keeps its LastUpdated accurate for staleness checks.
+func (manager *StateManager) RecordDeviceInfoUpdate(babyUID string, time time.Time) {
+	manager.stateMutex.Lock()
+	defer manager.stateMutex.Unlock()
+
+	babyState, ok := manager.babiesByUID[babyUID]
+	if !ok || babyState.DeviceInfo == nil {
+		return
+	}
+
+	timestamp := time.Unix()
+	if current := babyState.DeviceInfo.LastUpdated; current != nil && *current >= timestamp {
+		return
+	}
+
+	// Device info is shared with earlier snapshots of the state, update a copy
+	deviceInfo := *babyState.DeviceInfo
+	deviceInfo.LastUpdated = &timestamp
+	babyState.DeviceInfo = &deviceInfo
+	manager.babiesByUID[babyUID] = babyState
+}
+
+// recordConnection - records websocket up/down transition, caller must hold the state mutex
+func (manager *StateManager) recordConnection(babyUID string, alive bool) {
+	tracker, ok := manager.connections[babyUID]
+	if !ok {
+		tracker = NewConnectionTracker()
+		manager.connections[babyUID] = tracker
+	}
+
+	tracker.Record(alive, time.Now())
+}
+
+// GetConnectionStats - returns websocket connection stats of a baby (connected since, last drop, 24h uptime)
+func (manager *StateManager) GetConnectionStats(babyUID string) ConnectionStats {
+	manager.stateMutex.RLock()
+	defer manager.stateMutex.RUnlock()
+
+	if tracker, ok := manager.connections[babyUID]; ok {
+		return tracker.Stats(time.Now())
+	}
+
+	return ConnectionStats{}
+}
+
 func (manager *StateManager) NotifyMotionSubscribers(babyUID string, time time.Time) {
 	timestamp := new(int32)
 	*timestamp = int32(time.Unix())
@@ -115,3 +232,48 @@ func (manager *StateManager) SetHistoryCallback(callback func(babyUID string, st
 	defer manager.stateMutex.Unlock()
 	manager.historyCallback = callback
 }
+
+// SetSensorChangeThresholds sets the smallest sensor changes propagated to the history callback and subscribers
+func (manager *StateManager) SetSensorChangeThresholds(thresholds SensorChangeThresholds) {
+	manager.stateMutex.Lock()
+	defer manager.stateMutex.Unlock()
+	manager.sensorThresholds = thresholds
+}
+
+// gateSensorChanges - drops sensor values of the update which moved less than the thresholds since the last
+// propagated ones, caller must hold the state mutex
+// Values restored from history don't become the baseline, so the first reading of the camera is always propagated.
+func (manager *StateManager) gateSensorChanges(babyUID string, stateUpdate State) State {
+	propagated := manager.propagated[babyUID]
+	stale := stateUpdate.GetSensorDataStale()
+
+	if stateUpdate.TemperatureMilli != nil {
+		if !stale && changedLessThan(propagated.TemperatureMilli, stateUpdate.TemperatureMilli, manager.sensorThresholds.TemperatureMilli) {
+			stateUpdate.TemperatureMilli = nil
+			stateUpdate.RawTemperatureMilli = nil
+		} else if !stale {
+			propagated.TemperatureMilli = stateUpdate.TemperatureMilli
+		}
+	}
+
+	if stateUpdate.HumidityMilli != nil {
+		if !stale && changedLessThan(propagated.HumidityMilli, stateUpdate.HumidityMilli, manager.sensorThresholds.HumidityMilli) {
+			stateUpdate.HumidityMilli = nil
+			stateUpdate.RawHumidityMilli = nil
+		} else if !stale {
+			propagated.HumidityMilli = stateUpdate.HumidityMilli
+		}
+	}
+
+	manager.propagated[babyUID] = propagated
+	return stateUpdate
+}
+
+func changedLessThan(previous, current *int32, threshold int32) bool {
+	if previous == nil || threshold <= 0 {
+		return false
+	}
+
+	diff := *current - *previous
+	return diff < threshold && diff > -threshold
+}
@@ -0,0 +1,170 @@
+package baby_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAllBabyStatesReturnsDeepCopies(t *testing.T) {
+	manager := baby.NewStateManager()
+	manager.Update("baby1", *baby.NewState().SetTemperatureMilli(20_000).SetDeviceInfo(&baby.DeviceInfo{
+		FirmwareVersion:      strPtr("1.0"),
+		AvailableSoundtracks: []string{"a"},
+	}))
+	manager.Update("baby2", *baby.NewState().SetIsNight(true))
+
+	states := manager.GetAllBabyStates()
+	assert.Len(t, states, 2)
+	snapshot := states["baby1"]
+	assert.Equal(t, 20.0, snapshot.GetTemperature())
+	assert.True(t, *states["baby2"].IsNight)
+
+	// Modifying the snapshot doesn't affect the manager
+	*snapshot.TemperatureMilli = 30_000
+	*snapshot.DeviceInfo.FirmwareVersion = "2.0"
+	snapshot.DeviceInfo.AvailableSoundtracks[0] = "b"
+
+	current := manager.GetBabyState("baby1")
+	assert.Equal(t, 20.0, current.GetTemperature())
+	assert.Equal(t, "1.0", *current.DeviceInfo.FirmwareVersion)
+	assert.Equal(t, []string{"a"}, current.DeviceInfo.AvailableSoundtracks)
+}
+
+func TestGetAllBabyStatesConcurrentUpdates(t *testing.T) {
+	manager := baby.NewStateManager()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				manager.Update(fmt.Sprintf("baby%d", j%10), *baby.NewState().SetTemperatureMilli(int32(i*1000 + j)))
+			}
+		}(i)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 200; j++ {
+			for _, state := range manager.GetAllBabyStates() {
+				assert.NotNil(t, state.TemperatureMilli)
+			}
+		}
+	}()
+
+	wg.Wait()
+	assert.Len(t, manager.GetAllBabyStates(), 10)
+}
+
+func TestRecordMotionKeepsLatestEvent(t *testing.T) {
+	manager := baby.NewStateManager()
+	notified := make(chan baby.State, 1)
+	unsubscribe := manager.Subscribe(func(babyUID string, state baby.State) { notified <- state })
+	defer unsubscribe()
+
+	now := time.Now()
+	manager.RecordMotion("baby1", now)
+	manager.RecordMotion("baby1", now.Add(-time.Minute))
+	manager.RecordSound("baby1", now.Add(-time.Minute))
+
+	state := manager.GetBabyState("baby1")
+	assert.Equal(t, int32(now.Unix()), *state.MotionTimestamp)
+	assert.True(t, state.IsMotionActive(30*time.Second))
+	assert.False(t, state.IsSoundActive(30*time.Second))
+
+	// Recorded events are not propagated to the subscribers
+	select {
+	case <-notified:
+		t.Fatal("Subscriber should not be notified")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSensorChangeThresholdsGateUpdates(t *testing.T) {
+	manager := baby.NewStateManager()
+	manager.SetSensorChangeThresholds(baby.SensorChangeThresholds{TemperatureMilli: 200, HumidityMilli: 1000})
+
+	notified := make(chan baby.State, 10)
+	manager.SetHistoryCallback(func(babyUID string, state baby.State) { notified <- state })
+
+	receive := func() *baby.State {
+		select {
+		case state := <-notified:
+			return &state
+		case <-time.After(100 * time.Millisecond):
+			return nil
+		}
+	}
+
+	// Values restored from history are propagated but don't become the baseline
+	manager.Update("baby1", *baby.NewState().SetTemperatureMilli(21_000).SetSensorDataStale(true))
+	assert.NotNil(t, receive())
+
+	manager.Update("baby1", *baby.NewState().SetTemperatureMilli(21_100).SetSensorDataStale(false))
+	if state := receive(); assert.NotNil(t, state) {
+		assert.Equal(t, int32(21_100), *state.TemperatureMilli)
+	}
+
+	// Jitter is stored but not propagated
+	manager.Update("baby1", *baby.NewState().SetTemperatureMilli(21_200))
+	assert.Nil(t, receive())
+	assert.Equal(t, 21.2, manager.GetBabyState("baby1").GetTemperature())
+
+	// Changes are measured from the last propagated value, not the last raw one
+	manager.Update("baby1", *baby.NewState().SetTemperatureMilli(21_300).SetHumidityMilli(50_000))
+	if state := receive(); assert.NotNil(t, state) {
+		assert.Equal(t, int32(21_300), *state.TemperatureMilli)
+		assert.Equal(t, int32(50_000), *state.HumidityMilli)
+	}
+
+	// Other fields of the update are propagated without the gated values
+	manager.Update("baby1", *baby.NewState().SetHumidityMilli(50_500).SetIsNight(true))
+	if state := receive(); assert.NotNil(t, state) {
+		assert.Nil(t, state.HumidityMilli)
+		assert.True(t, *state.IsNight)
+	}
+}
+
+func TestIdenticalDeviceInfoNotPropagated(t *testing.T) {
+	manager := baby.NewStateManager()
+
+	notified := make(chan baby.State, 10)
+	unsubscribe := manager.Subscribe(func(babyUID string, state baby.State) { notified <- state })
+	defer unsubscribe()
+
+	// Settings responses carry a fresh device info with a new timestamp every time
+	settings := func(standby bool, timestamp int64) baby.State {
+		return *baby.NewState().SetStandby(standby).SetDeviceInfo(&baby.DeviceInfo{
+			NightVision:          boolPtr(true),
+			Volume:               int32Ptr(50),
+			AvailableSoundtracks: []string{"a", "b"},
+			LastUpdated:          &timestamp,
+		})
+	}
+
+	for i := int64(0); i < 5; i++ {
+		manager.Update("baby1", settings(false, 1000+i))
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, notified, 1)
+	first := <-notified
+	assert.NotNil(t, first.DeviceInfo)
+
+	// Changes of other fields are propagated without the unchanged device info
+	manager.Update("baby1", settings(true, 2000))
+	select {
+	case state := <-notified:
+		assert.True(t, *state.Standby)
+		assert.Nil(t, state.DeviceInfo)
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Subscriber should be notified")
+	}
+}
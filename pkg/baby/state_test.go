@@ -2,6 +2,7 @@ package baby_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/indiefan/home_assistant_nanit/pkg/baby"
@@ -50,3 +51,166 @@ func TestStateMergeDifferent(t *testing.T) {
 	assert.Equal(t, 20.0, s3.GetHumidity())
 	assert.Equal(t, baby.StreamState_Alive, s3.GetStreamState())
 }
+
+func strPtr(value string) *string { return &value }
+func int32Ptr(value int32) *int32 { return &value }
+func int64Ptr(value int64) *int64 { return &value }
+func boolPtr(value bool) *bool    { return &value }
+
+func TestStateMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		current *baby.State
+		patch   *baby.State
+		changed bool
+		check   func(t *testing.T, merged *baby.State)
+	}{
+		{
+			name:    "empty patch",
+			current: baby.NewState().SetTemperatureMilli(10_000),
+			patch:   baby.NewState(),
+			changed: false,
+		},
+		{
+			name:    "same value",
+			current: baby.NewState().SetNightLight(true),
+			patch:   baby.NewState().SetNightLight(true),
+			changed: false,
+		},
+		{
+			name:    "new field",
+			current: baby.NewState().SetTemperatureMilli(10_000),
+			patch:   baby.NewState().SetIsNight(false),
+			changed: true,
+			check: func(t *testing.T, merged *baby.State) {
+				assert.Equal(t, 10.0, merged.GetTemperature())
+				assert.False(t, *merged.IsNight)
+			},
+		},
+		{
+			name:    "changed value of zero value",
+			current: baby.NewState().SetStandby(true),
+			patch:   baby.NewState().SetStandby(false),
+			changed: true,
+			check: func(t *testing.T, merged *baby.State) {
+				assert.False(t, merged.GetStandby())
+			},
+		},
+		{
+			name:    "internal field",
+			current: baby.NewState().SetStreamState(baby.StreamState_Unhealthy),
+			patch:   baby.NewState().SetStreamState(baby.StreamState_Alive),
+			changed: true,
+			check: func(t *testing.T, merged *baby.State) {
+				assert.Equal(t, baby.StreamState_Alive, merged.GetStreamState())
+			},
+		},
+		{
+			name:    "device info added",
+			current: baby.NewState(),
+			patch:   baby.NewState().SetDeviceInfo(&baby.DeviceInfo{FirmwareVersion: strPtr("1.0")}),
+			changed: true,
+			check: func(t *testing.T, merged *baby.State) {
+				assert.Equal(t, "1.0", *merged.GetDeviceInfo().FirmwareVersion)
+			},
+		},
+		{
+			name:    "device info partial update",
+			current: baby.NewState().SetDeviceInfo(&baby.DeviceInfo{FirmwareVersion: strPtr("1.0"), Volume: int32Ptr(50)}),
+			patch:   baby.NewState().SetDeviceInfo(&baby.DeviceInfo{Volume: int32Ptr(60)}),
+			changed: true,
+			check: func(t *testing.T, merged *baby.State) {
+				assert.Equal(t, "1.0", *merged.GetDeviceInfo().FirmwareVersion)
+				assert.Equal(t, int32(60), *merged.GetDeviceInfo().Volume)
+			},
+		},
+		{
+			name:    "device info same values",
+			current: baby.NewState().SetDeviceInfo(&baby.DeviceInfo{FirmwareVersion: strPtr("1.0"), AvailableSoundtracks: []string{"a"}}),
+			patch:   baby.NewState().SetDeviceInfo(&baby.DeviceInfo{FirmwareVersion: strPtr("1.0"), AvailableSoundtracks: []string{"a"}}),
+			changed: false,
+		},
+		{
+			name:    "device info only timestamp",
+			current: baby.NewState().SetDeviceInfo(&baby.DeviceInfo{FirmwareVersion: strPtr("1.0"), LastUpdated: int64Ptr(100)}),
+			patch:   baby.NewState().SetDeviceInfo(&baby.DeviceInfo{FirmwareVersion: strPtr("1.0"), LastUpdated: int64Ptr(200)}),
+			changed: false,
+		},
+		{
+			name:    "device info changed slice",
+			current: baby.NewState().SetDeviceInfo(&baby.DeviceInfo{AvailableSoundtracks: []string{"a"}, LastUpdated: int64Ptr(100)}),
+			patch:   baby.NewState().SetDeviceInfo(&baby.DeviceInfo{AvailableSoundtracks: []string{"a", "b"}, LastUpdated: int64Ptr(200)}),
+			changed: true,
+			check: func(t *testing.T, merged *baby.State) {
+				assert.Equal(t, []string{"a", "b"}, merged.GetDeviceInfo().AvailableSoundtracks)
+				assert.Equal(t, int64(200), *merged.GetDeviceInfo().LastUpdated)
+			},
+		},
+		{
+			name:    "device info kept on other change",
+			current: baby.NewState().SetDeviceInfo(&baby.DeviceInfo{NightVision: boolPtr(true)}),
+			patch:   baby.NewState().SetTemperatureMilli(20_000),
+			changed: true,
+			check: func(t *testing.T, merged *baby.State) {
+				assert.True(t, *merged.GetDeviceInfo().NightVision)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := tt.current.AsMap(true)
+
+			merged := tt.current.Merge(tt.patch)
+			if tt.changed {
+				assert.NotSame(t, tt.current, merged)
+			} else {
+				assert.Same(t, tt.current, merged)
+			}
+
+			// Current state is never modified in place
+			assert.Equal(t, before, tt.current.AsMap(true))
+
+			if tt.check != nil {
+				tt.check(t, merged)
+			}
+		})
+	}
+}
+
+func TestMergeDeviceInfo(t *testing.T) {
+	current := &baby.DeviceInfo{FirmwareVersion: strPtr("1.0")}
+
+	merged, changed := baby.MergeDeviceInfo(current, nil)
+	assert.Same(t, current, merged)
+	assert.False(t, changed)
+
+	merged, changed = baby.MergeDeviceInfo(current, &baby.DeviceInfo{FirmwareVersion: strPtr("1.0")})
+	assert.Same(t, current, merged)
+	assert.False(t, changed)
+
+	merged, changed = baby.MergeDeviceInfo(current, &baby.DeviceInfo{FirmwareVersion: strPtr("1.1")})
+	assert.NotSame(t, current, merged)
+	assert.True(t, changed)
+	assert.Equal(t, "1.1", *merged.FirmwareVersion)
+	assert.Equal(t, "1.0", *current.FirmwareVersion)
+
+	patch := &baby.DeviceInfo{Volume: int32Ptr(10)}
+	merged, changed = baby.MergeDeviceInfo(nil, patch)
+	assert.Same(t, patch, merged)
+	assert.True(t, changed)
+}
+
+func TestStateEventActive(t *testing.T) {
+	s := baby.NewState()
+	assert.False(t, s.IsMotionActive(30*time.Second))
+	assert.False(t, s.IsSoundActive(30*time.Second))
+
+	s.SetMotionTimestamp(int32(time.Now().Add(-10 * time.Second).Unix()))
+	s.SetSoundTimestamp(int32(time.Now().Add(-time.Minute).Unix()))
+
+	assert.True(t, s.IsMotionActive(30*time.Second))
+	assert.False(t, s.IsMotionActive(5*time.Second))
+	assert.False(t, s.IsSoundActive(30*time.Second))
+	assert.True(t, s.IsSoundActive(2*time.Minute))
+}
@@ -0,0 +1,163 @@
+package baby
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// StreamResolutions - heights of the resolutions the video can be transcoded to
+var StreamResolutions = map[string]int{
+	"1080p": 1080,
+	"720p":  720,
+	"480p":  480,
+	"360p":  360,
+}
+
+// maxStreamFPS - highest accepted frame rate
+const maxStreamFPS = 60
+
+// StreamConfig - transcoding profile of a baby's video stream, zero values keep the camera's
+type StreamConfig struct {
+	Resolution  string `json:"resolution,omitempty"`   // One of StreamResolutions, source resolution if empty
+	BitrateKbps int    `json:"bitrate_kbps,omitempty"` // Target video bitrate, encoder default if 0
+	FPS         int    `json:"fps,omitempty"`          // Output frame rate, source frame rate if 0
+}
+
+// Height - returns the output height, 0 for the source resolution
+func (c StreamConfig) Height() int {
+	return StreamResolutions[c.Resolution]
+}
+
+// Validate - returns error if the profile is invalid or exceeds what the camera streams
+// The camera's mobile stream bitrate (bits per second) and frame rate are the limits, transcoding can't add quality.
+// Limits unknown yet (nil device info or fields) are not checked.
+func (c StreamConfig) Validate(deviceInfo *DeviceInfo) error {
+	if _, ok := StreamResolutions[c.Resolution]; c.Resolution != "" && !ok {
+		return fmt.Errorf("unsupported resolution %q, must be one of 1080p, 720p, 480p or 360p", c.Resolution)
+	}
+
+	if c.BitrateKbps < 0 {
+		return fmt.Errorf("bitrate must not be negative")
+	}
+
+	if c.FPS < 0 || c.FPS > maxStreamFPS {
+		return fmt.Errorf("fps must be between 0 (source) and %d", maxStreamFPS)
+	}
+
+	if deviceInfo == nil {
+		return nil
+	}
+
+	if deviceInfo.MobileBitrate != nil && *deviceInfo.MobileBitrate > 0 && int64(c.BitrateKbps)*1000 > int64(*deviceInfo.MobileBitrate) {
+		return fmt.Errorf("bitrate %d kbps exceeds the camera stream bitrate of %d kbps", c.BitrateKbps, *deviceInfo.MobileBitrate/1000)
+	}
+
+	if deviceInfo.MobileFPS != nil && *deviceInfo.MobileFPS > 0 && c.FPS > int(*deviceInfo.MobileFPS) {
+		return fmt.Errorf("fps %d exceeds the camera stream frame rate of %d", c.FPS, *deviceInfo.MobileFPS)
+	}
+
+	return nil
+}
+
+// StreamConfigStore - persisted stream configs by baby UID
+type StreamConfigStore struct {
+	Filename string
+	configs  map[string]StreamConfig
+	mutex    sync.RWMutex
+}
+
+// NewStreamConfigStore - constructor, call Load to read the stored configs
+func NewStreamConfigStore(filename string) *StreamConfigStore {
+	return &StreamConfigStore{
+		Filename: filename,
+		configs:  make(map[string]StreamConfig),
+	}
+}
+
+// Load - loads stored configs from the file, a missing file means none are configured
+func (store *StreamConfigStore) Load() error {
+	data, err := os.ReadFile(store.Filename)
+	if os.IsNotExist(err) {
+		log.Debug().Str("filename", store.Filename).Msg("No stream config file found")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	configs := make(map[string]StreamConfig)
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.configs = configs
+	return nil
+}
+
+// Get - returns stream config of a baby, the source profile if none is stored
+// Safe to call on nil store.
+func (store *StreamConfigStore) Get(babyUID string) StreamConfig {
+	if store == nil {
+		return StreamConfig{}
+	}
+
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	return store.configs[babyUID]
+}
+
+// GetAll - returns copy of the stream configs of all babies
+// Safe to call on nil store.
+func (store *StreamConfigStore) GetAll() map[string]StreamConfig {
+	configs := make(map[string]StreamConfig)
+	if store == nil {
+		return configs
+	}
+
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for babyUID, config := range store.configs {
+		configs[babyUID] = config
+	}
+
+	return configs
+}
+
+// Set - stores stream config of a baby and persists all of them, the zero config removes the baby's entry
+// The in-memory configs are left unchanged if saving fails.
+func (store *StreamConfigStore) Set(babyUID string, config StreamConfig) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	configs := make(map[string]StreamConfig, len(store.configs)+1)
+	for uid, c := range store.configs {
+		configs[uid] = c
+	}
+
+	if config == (StreamConfig{}) {
+		delete(configs, babyUID)
+	} else {
+		configs[babyUID] = config
+	}
+
+	data, err := json.MarshalIndent(configs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := utils.WriteFileAtomic(store.Filename, data, 0644); err != nil {
+		return err
+	}
+
+	store.configs = configs
+	return nil
+}
@@ -0,0 +1,54 @@
+package baby_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamConfigValidate(t *testing.T) {
+	assert.NoError(t, baby.StreamConfig{}.Validate(nil))
+	assert.NoError(t, baby.StreamConfig{Resolution: "720p", BitrateKbps: 800, FPS: 15}.Validate(nil))
+	assert.Equal(t, 720, baby.StreamConfig{Resolution: "720p"}.Height())
+	assert.Equal(t, 0, baby.StreamConfig{}.Height())
+
+	assert.Error(t, baby.StreamConfig{Resolution: "4k"}.Validate(nil))
+	assert.Error(t, baby.StreamConfig{BitrateKbps: -1}.Validate(nil))
+	assert.Error(t, baby.StreamConfig{FPS: 120}.Validate(nil))
+
+	// Camera's mobile stream bounds the profile
+	bitrate, fps := int32(1_000_000), int32(20)
+	deviceInfo := &baby.DeviceInfo{MobileBitrate: &bitrate, MobileFPS: &fps}
+	assert.NoError(t, baby.StreamConfig{BitrateKbps: 1000, FPS: 20}.Validate(deviceInfo))
+	assert.Error(t, baby.StreamConfig{BitrateKbps: 1500}.Validate(deviceInfo))
+	assert.Error(t, baby.StreamConfig{FPS: 25}.Validate(deviceInfo))
+
+	// Unknown capabilities are not checked
+	assert.NoError(t, baby.StreamConfig{BitrateKbps: 5000, FPS: 30}.Validate(&baby.DeviceInfo{}))
+}
+
+func TestStreamConfigStorePersists(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "stream_config.json")
+	store := baby.NewStreamConfigStore(filename)
+	assert.NoError(t, store.Load())
+	assert.Equal(t, baby.StreamConfig{}, store.Get("baby1"))
+
+	config := baby.StreamConfig{Resolution: "480p", BitrateKbps: 500}
+	assert.NoError(t, store.Set("baby1", config))
+	assert.NoError(t, store.Set("baby2", baby.StreamConfig{FPS: 10}))
+
+	loaded := baby.NewStreamConfigStore(filename)
+	assert.NoError(t, loaded.Load())
+	assert.Equal(t, config, loaded.Get("baby1"))
+	assert.Len(t, loaded.GetAll(), 2)
+
+	// The camera's own profile removes the entry
+	assert.NoError(t, loaded.Set("baby2", baby.StreamConfig{}))
+	assert.Len(t, loaded.GetAll(), 1)
+
+	var nilStore *baby.StreamConfigStore
+	assert.Equal(t, baby.StreamConfig{}, nilStore.Get("baby1"))
+	assert.Empty(t, nilStore.GetAll())
+}
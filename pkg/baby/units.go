@@ -0,0 +1,46 @@
+package baby
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// TemperatureUnit - unit in which temperatures are reported, sensor values are always stored in Celsius
+type TemperatureUnit string
+
+const (
+	TemperatureUnitCelsius    TemperatureUnit = "celsius"
+	TemperatureUnitFahrenheit TemperatureUnit = "fahrenheit"
+)
+
+// ParseTemperatureUnit - parses unit name, "c" and "f" are accepted as well (case insensitive)
+func ParseTemperatureUnit(value string) (TemperatureUnit, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "c", string(TemperatureUnitCelsius):
+		return TemperatureUnitCelsius, nil
+	case "f", string(TemperatureUnitFahrenheit):
+		return TemperatureUnitFahrenheit, nil
+	}
+
+	return "", fmt.Errorf("unknown temperature unit '%s', allowed values: %s, %s", value, TemperatureUnitCelsius, TemperatureUnitFahrenheit)
+}
+
+// FromCelsius - converts temperature in Celsius to the unit, rounded to 2 decimals
+func (unit TemperatureUnit) FromCelsius(celsius float64) float64 {
+	if unit != TemperatureUnitFahrenheit {
+		return celsius
+	}
+
+	return math.Round((celsius*9/5+32)*100) / 100
+}
+
+// FromCelsiusPtr - converts optional temperature in Celsius to the unit
+func (unit TemperatureUnit) FromCelsiusPtr(celsius *float64) *float64 {
+	if celsius == nil {
+		return nil
+	}
+
+	converted := unit.FromCelsius(*celsius)
+	return &converted
+}
@@ -5,4 +5,27 @@ import "time"
 const (
 	// AuthTokenTimelife - Time duration after which we assume auth token expired
 	AuthTokenTimelife = 60 * time.Minute
+
+	// DefaultKeepaliveInterval - Time between keepalive messages sent to the cam
+	DefaultKeepaliveInterval = 20 * time.Second
 )
+
+// DefaultRequestTimeout - Time to wait for the response of request types without a specific timeout
+const DefaultRequestTimeout = 10 * time.Second
+
+// requestTimeouts - Time to wait for the response by request type
+var requestTimeouts = map[RequestType]time.Duration{
+	// Cam connects to the RTMP server before answering
+	RequestType_PUT_STREAMING: 30 * time.Second,
+	RequestType_GET_SETTINGS:  15 * time.Second,
+	RequestType_GET_STATUS:    15 * time.Second,
+}
+
+// RequestTimeout - returns how long to wait for the response of the request type
+func RequestTimeout(reqType RequestType) time.Duration {
+	if timeout, ok := requestTimeouts[reqType]; ok {
+		return timeout
+	}
+
+	return DefaultRequestTimeout
+}
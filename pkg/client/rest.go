@@ -7,9 +7,13 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	apperrors "github.com/indiefan/home_assistant_nanit/pkg/errors"
+	"github.com/indiefan/home_assistant_nanit/pkg/health"
 	"github.com/indiefan/home_assistant_nanit/pkg/message"
 	"github.com/indiefan/home_assistant_nanit/pkg/session"
 	"github.com/indiefan/home_assistant_nanit/pkg/utils"
@@ -19,6 +23,18 @@ import (
 var myClient = &http.Client{Timeout: 10 * time.Second}
 var ErrExpiredRefreshToken = errors.New("Refresh token has expired. Relogin required.")
 
+// ErrCredentialsRequired - full login is needed but no e-mail/password is known (web-auth setup)
+var ErrCredentialsRequired = errors.New("E-mail and password are required to login")
+
+// ErrInvalidCredentials - Nanit rejected the e-mail/password
+var ErrInvalidCredentials = errors.New("Credentials have not been accepted by the server")
+
+// ErrMFARequired - the account has 2FA enabled, login has to be completed with the code e-mailed by Nanit
+var ErrMFARequired = errors.New("MFA verification code required")
+
+// NanitAPIService - name under which the Nanit API reachability is tracked in the health manager
+const NanitAPIService = "nanit_api"
+
 // ------------------------------------------
 
 type authResponsePayload struct {
@@ -42,18 +58,91 @@ type NanitClient struct {
 	Password     string
 	RefreshToken string
 	SessionStore *session.Store
+	HealthManager *health.HealthManager // Optional, receives the Nanit API reachability
+	RefreshLead  time.Duration // Token is treated as expired this long before AuthTokenTimelife elapses
+	authMutex    sync.Mutex // Serializes authorizations and the writes of the session
+	reauthRequired atomic.Bool // Refresh token is dead and the login can't be completed without the user
+}
+
+// LoginRequest - credentials of a full login, MFAToken and MFACode complete the login of accounts with 2FA
+type LoginRequest struct {
+	Email    string
+	Password string
+	MFAToken string
+	MFACode  string
+}
+
+// checkAPIResponse - records the Nanit API reachability and classifies 5xx responses as retryable external errors
+func (c *NanitClient) checkAPIResponse(statusCode int) error {
+	if statusCode >= 500 {
+		message := fmt.Sprintf("Nanit API is unavailable (status code %d), it might be under maintenance", statusCode)
+		c.setAPIUnreachable(message, statusCode)
+		return apperrors.NewExternalError("nanit_api_unavailable", message, nil).WithContext("status_code", statusCode)
+	}
+
+	if c.HealthManager != nil {
+		c.HealthManager.SetServiceHealthy(NanitAPIService, "Nanit API reachable")
+	}
+
+	return nil
+}
+
+// networkError - records the Nanit API as unreachable and wraps the network failure as a retryable error
+func (c *NanitClient) networkError(err error) error {
+	message := "Unable to reach Nanit API"
+	c.setAPIUnreachable(message, 0)
+	return apperrors.NewNetworkError("nanit_api_unreachable", message, err)
+}
+
+func (c *NanitClient) setAPIUnreachable(message string, statusCode int) {
+	if c.HealthManager == nil {
+		return
+	}
+
+	var details map[string]interface{}
+	if statusCode != 0 {
+		details = map[string]interface{}{"status_code": statusCode}
+	}
+
+	c.HealthManager.SetServiceUnhealthy(NanitAPIService, message, details)
 }
 
 // MaybeAuthorize - Performs authorization if we don't have token or we assume it is expired
+// Concurrent callers wait for a running authorization instead of starting another one.
 func (c *NanitClient) MaybeAuthorize(force bool) error {
-	if force || c.SessionStore.Session.AuthToken == "" || time.Since(c.SessionStore.Session.AuthTime) > AuthTokenTimelife {
+	c.authMutex.Lock()
+	defer c.authMutex.Unlock()
+
+	if force || c.SessionStore.Session.AuthToken == "" || c.TokenExpiresIn() <= 0 {
 		return c.Authorize()
 	}
 	return nil
 }
 
+// TokenExpiresIn - time left until the token is assumed expired, including the refresh lead
+func (c *NanitClient) TokenExpiresIn() time.Duration {
+	return time.Until(c.SessionStore.Session.AuthTime.Add(AuthTokenTimelife - c.RefreshLead))
+}
+
+// AuthMethod - how the authorization was obtained
+type AuthMethod string
+
+const (
+	// AuthMethodRefreshToken - session renewed using the refresh token
+	AuthMethodRefreshToken AuthMethod = "refresh_token"
+
+	// AuthMethodLogin - full login using e-mail and password
+	AuthMethodLogin AuthMethod = "login"
+)
+
 // Authorize - performs authorization attempt, returns error if it fails
 func (c *NanitClient) Authorize() error {
+	_, err := c.AuthorizeWithMethod()
+	return err
+}
+
+// AuthorizeWithMethod - performs authorization attempt and reports whether the refresh token was used or it fell back to full login
+func (c *NanitClient) AuthorizeWithMethod() (AuthMethod, error) {
 	if len(c.SessionStore.Session.RefreshToken) == 0 {
 		c.SessionStore.Session.RefreshToken = c.RefreshToken
 	}
@@ -61,15 +150,31 @@ func (c *NanitClient) Authorize() error {
 	if len(c.SessionStore.Session.RefreshToken) > 0 {
 		err := c.RenewSession() // We have a refresh token, so we'll use that to extend our session
 		if err == nil {
-			return nil
+			return AuthMethodRefreshToken, nil
 		}
 		if !errors.Is(err, ErrExpiredRefreshToken) {
 			log.Error().Err(err).Msg("Unknown error occurred while trying to refresh the session")
-			return fmt.Errorf("session renewal failed: %w", err)
+			return AuthMethodRefreshToken, fmt.Errorf("session renewal failed: %w", err)
 		}
 	}
 
-	return c.Login() // We don't have a refresh token, e.g. initial login so we need to supply username/password
+	err := c.Login() // We don't have a refresh token, e.g. initial login so we need to supply username/password
+	if isReauthError(err) {
+		c.reauthRequired.Store(true)
+	}
+
+	return AuthMethodLogin, err
+}
+
+// ReauthRequired - returns whether the session can't be renewed without the user signing in again
+// Set when the fallback to full login lacks credentials, is rejected or needs the MFA code, cleared by successful authorization.
+func (c *NanitClient) ReauthRequired() bool {
+	return c.reauthRequired.Load()
+}
+
+// isReauthError - returns whether the login failure needs user's action rather than a retry
+func isReauthError(err error) bool {
+	return errors.Is(err, ErrCredentialsRequired) || errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrMFARequired)
 }
 
 // Renews an existing session using a valid refresh token
@@ -87,10 +192,15 @@ func (c *NanitClient) RenewSession() error {
 	r, clientErr := myClient.Post("https://api.nanit.com/tokens/refresh", "application/json", bytes.NewBuffer(requestBody))
 	if clientErr != nil {
 		log.Error().Err(clientErr).Msg("Unable to renew session")
-		return fmt.Errorf("session renewal request failed: %w", clientErr)
+		return fmt.Errorf("session renewal request failed: %w", c.networkError(clientErr))
 	}
 
 	defer r.Body.Close()
+	if apiErr := c.checkAPIResponse(r.StatusCode); apiErr != nil {
+		log.Error().Int("code", r.StatusCode).Msg("Nanit API unavailable, unable to renew session")
+		return apiErr
+	}
+
 	if r.StatusCode == 404 {
 		log.Warn().Msg("Server responded with code 404. This typically means your refresh token has expired. Will try to login with username/password")
 		return ErrExpiredRefreshToken
@@ -115,46 +225,96 @@ func (c *NanitClient) RenewSession() error {
 	if err := c.SessionStore.Save(); err != nil {
 		log.Warn().Err(err).Msg("Failed to save session after token refresh")
 	}
+	c.reauthRequired.Store(false)
 
 	return nil
 }
 
+// Login - performs full login using the configured e-mail and password
 func (c *NanitClient) Login() error {
-	log.Info().Str("email", c.Email).Str("password", utils.AnonymizeToken(c.Password, 0)).Msg("Authorizing using user credentials")
-	requestBody, requestBodyErr := json.Marshal(map[string]string{
-		"email":    c.Email,
-		"password": c.Password,
-	})
+	_, err := c.login(LoginRequest{Email: c.Email, Password: c.Password})
+	return err
+}
+
+// LoginWithCredentials - performs full login, the credentials are kept for later automatic logins once it succeeds
+// Accounts with 2FA fail with ErrMFARequired first, the returned MFA token and the e-mailed code complete the login.
+func (c *NanitClient) LoginWithCredentials(request LoginRequest) (string, error) {
+	c.authMutex.Lock()
+	defer c.authMutex.Unlock()
 
+	mfaToken, err := c.login(request)
+	if err != nil {
+		return mfaToken, err
+	}
+
+	c.Email = request.Email
+	c.Password = request.Password
+	return "", nil
+}
+
+func (c *NanitClient) login(request LoginRequest) (string, error) {
+	if request.Email == "" || request.Password == "" {
+		log.Warn().Msg("Unable to login, e-mail and password are not configured")
+		return "", ErrCredentialsRequired
+	}
+
+	log.Info().Str("email", request.Email).Str("password", utils.AnonymizeToken(request.Password, 0)).Msg("Authorizing using user credentials")
+	payload := map[string]string{
+		"email":    request.Email,
+		"password": request.Password,
+	}
+	if request.MFACode != "" {
+		payload["mfa_token"] = request.MFAToken
+		payload["mfa_code"] = request.MFACode
+		payload["channel"] = "email"
+	}
+
+	requestBody, requestBodyErr := json.Marshal(payload)
 	if requestBodyErr != nil {
 		log.Error().Err(requestBodyErr).Msg("Unable to marshal auth body")
-		return fmt.Errorf("failed to marshal login request: %w", requestBodyErr)
+		return "", fmt.Errorf("failed to marshal login request: %w", requestBodyErr)
 	}
 
 	//nanit-api-version: 1
 	req, reqErr := http.NewRequest("POST", "https://api.nanit.com/login", bytes.NewBuffer(requestBody))
 	if reqErr != nil {
 		log.Error().Err(reqErr).Msg("Unable to create request")
-		return fmt.Errorf("failed to create login request: %w", reqErr)
+		return "", fmt.Errorf("failed to create login request: %w", reqErr)
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("nanit-api-version", "1") // required if you have MFA enabled or it'll reject the request
 	r, clientErr := myClient.Do(req)
 	if clientErr != nil {
 		log.Error().Err(clientErr).Msg("Unable to fetch auth token")
-		return fmt.Errorf("login request failed: %w", clientErr)
+		return "", fmt.Errorf("login request failed: %w", c.networkError(clientErr))
 	}
 
 	defer r.Body.Close()
 
+	if apiErr := c.checkAPIResponse(r.StatusCode); apiErr != nil {
+		log.Error().Int("code", r.StatusCode).Msg("Nanit API unavailable, unable to login")
+		return "", apiErr
+	}
+
 	if r.StatusCode == 401 {
-		errMsg := "Server responded with code 401. Provided credentials has not been accepted by the server. Please check if your e-mail address and password is entered correctly and that 2FA is disabled on your account."
-		log.Error().Msg(errMsg)
-		return errors.New(errMsg)
+		log.Error().Msg("Server responded with code 401. Provided credentials has not been accepted by the server. Please check if your e-mail address and password is entered correctly.")
+		return "", fmt.Errorf("%w, please check if your e-mail address and password is entered correctly", ErrInvalidCredentials)
+	} else if r.StatusCode == 482 {
+		// Nanit e-mails the verification code, the MFA token pairs it with this login
+		var mfaResponse struct {
+			MFAToken string `json:"mfa_token"`
+		}
+		if jsonErr := json.NewDecoder(r.Body).Decode(&mfaResponse); jsonErr != nil || mfaResponse.MFAToken == "" {
+			log.Error().Err(jsonErr).Msg("Unable to decode MFA token")
+			return "", fmt.Errorf("failed to decode MFA token: %w", ErrMFARequired)
+		}
+
+		log.Warn().Msg("Server responded with code 482, login has to be completed with the MFA code")
+		return mfaResponse.MFAToken, ErrMFARequired
 	} else if r.StatusCode != 201 {
 		errMsg := fmt.Sprintf("Server responded with unexpected status code: %d", r.StatusCode)
 		log.Error().Int("code", r.StatusCode).Msg("Server responded with unexpected status code")
-		return errors.New(errMsg)
+		return "", errors.New(errMsg)
 	}
 
 	authResponse := new(authResponsePayload)
@@ -162,7 +322,7 @@ func (c *NanitClient) Login() error {
 	jsonErr := json.NewDecoder(r.Body).Decode(authResponse)
 	if jsonErr != nil {
 		log.Error().Err(jsonErr).Msg("Unable to decode response")
-		return fmt.Errorf("failed to decode login response: %w", jsonErr)
+		return "", fmt.Errorf("failed to decode login response: %w", jsonErr)
 	}
 
 	log.Info().Str("token", utils.AnonymizeToken(authResponse.AccessToken, 4)).Msg("Authorized")
@@ -173,8 +333,9 @@ func (c *NanitClient) Login() error {
 	if err := c.SessionStore.Save(); err != nil {
 		log.Warn().Err(err).Msg("Failed to save session after login")
 	}
-	
-	return nil
+	c.reauthRequired.Store(false)
+
+	return "", nil
 }
 
 // FetchAuthorized - makes authorized http request
@@ -186,11 +347,16 @@ func (c *NanitClient) FetchAuthorized(req *http.Request, data interface{}) error
 			res, clientErr := myClient.Do(req)
 			if clientErr != nil {
 				log.Error().Err(clientErr).Msg("HTTP request failed")
-				return fmt.Errorf("HTTP request failed: %w", clientErr)
+				return fmt.Errorf("HTTP request failed: %w", c.networkError(clientErr))
 			}
 
 			defer res.Body.Close()
 
+			if apiErr := c.checkAPIResponse(res.StatusCode); apiErr != nil {
+				log.Error().Int("code", res.StatusCode).Msg("Nanit API unavailable")
+				return apiErr
+			}
+
 			if res.StatusCode != 401 {
 				if res.StatusCode != 200 {
 					log.Error().Int("code", res.StatusCode).Msg("Server responded with unexpected status code")
@@ -235,13 +401,25 @@ func (c *NanitClient) FetchBabies() ([]baby.Baby, error) {
 		return nil, fmt.Errorf("failed to fetch babies: %w", err)
 	}
 
+	// Fetched concurrently by the babies refresh and the monitoring start, the session is saved by authorizations too
+	c.authMutex.Lock()
 	c.SessionStore.Session.Babies = data.Babies
 	if err := c.SessionStore.Save(); err != nil {
 		log.Warn().Err(err).Msg("Failed to save session after fetching babies")
 	}
+	c.authMutex.Unlock()
+
 	return data.Babies, nil
 }
 
+// Babies - returns a copy of the babies of the session, safe to call while they are fetched
+func (c *NanitClient) Babies() []baby.Baby {
+	c.authMutex.Lock()
+	defer c.authMutex.Unlock()
+
+	return append([]baby.Baby{}, c.SessionStore.Session.Babies...)
+}
+
 // FetchMessages - fetches message list
 func (c *NanitClient) FetchMessages(babyUID string, limit int) ([]message.Message, error) {
 	req, reqErr := http.NewRequest("GET", fmt.Sprintf("https://api.nanit.com/babies/%s/messages?limit=%d", babyUID, limit), nil)
@@ -261,11 +439,15 @@ func (c *NanitClient) FetchMessages(babyUID string, limit int) ([]message.Messag
 
 // EnsureBabies - fetches baby list if not fetched already
 func (c *NanitClient) EnsureBabies() ([]baby.Baby, error) {
-	if len(c.SessionStore.Session.Babies) == 0 {
+	c.authMutex.Lock()
+	babies := c.SessionStore.Session.Babies
+	c.authMutex.Unlock()
+
+	if len(babies) == 0 {
 		return c.FetchBabies()
 	}
 
-	return c.SessionStore.Session.Babies, nil
+	return babies, nil
 }
 
 // FetchNewMessages - fetches 10 newest messages, ignores any messages which were already fetched or which are older than 5 minutes
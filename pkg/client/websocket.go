@@ -30,6 +30,12 @@ type WebsocketConnectionManager struct {
 	API              *NanitClient
 	BabyStateManager *baby.StateManager
 
+	// Time between keepalive messages sent to the cam
+	KeepaliveInterval time.Duration
+
+	// Connection is closed and re-established if nothing is received from the cam for this long, 0 disables the check
+	KeepaliveTimeout time.Duration
+
 	mu               sync.RWMutex
 	readyState       *readyState
 	readySubscribers []WebsocketConnectionHandler
@@ -38,32 +44,49 @@ type WebsocketConnectionManager struct {
 // NewWebsocketConnectionManager - constructor
 func NewWebsocketConnectionManager(babyUID string, cameraUID string, session *session.Session, api *NanitClient, babyStateManager *baby.StateManager) *WebsocketConnectionManager {
 	manager := &WebsocketConnectionManager{
-		BabyUID:          babyUID,
-		CameraUID:        cameraUID,
-		Session:          session,
-		API:              api,
-		BabyStateManager: babyStateManager,
+		BabyUID:           babyUID,
+		CameraUID:         cameraUID,
+		Session:           session,
+		API:               api,
+		BabyStateManager:  babyStateManager,
+		KeepaliveInterval: DefaultKeepaliveInterval,
 	}
 
-	manager.WithReadyConnection(func(conn *WebsocketConnection, ctx utils.GracefulContext) {
-		ticker := time.NewTicker(20 * time.Second)
-
-		for {
-			select {
-			case <-ctx.Done():
-				ticker.Stop()
-				return
-			case <-ticker.C:
-				if err := conn.SendMessage(&Message{
-					Type: Message_Type(Message_KEEPALIVE).Enum(),
-				}); err != nil {
-					log.Error().Err(err).Msg("Failed to send keepalive message")
+	manager.WithReadyConnection(manager.runKeepalive)
+
+	return manager
+}
+
+// runKeepalive - sends keepalive messages and closes the connection if the cam went silent for KeepaliveTimeout
+// Closing triggers the regular disconnect handling, so the baby is marked offline and a reconnect is attempted.
+func (manager *WebsocketConnectionManager) runKeepalive(conn *WebsocketConnection, ctx utils.GracefulContext) {
+	ticker := time.NewTicker(manager.KeepaliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if manager.KeepaliveTimeout > 0 {
+				if silentFor := time.Since(conn.LastReceived()); silentFor > manager.KeepaliveTimeout {
+					log.Warn().
+						Str("baby_uid", manager.BabyUID).
+						Dur("silent_for", silentFor).
+						Dur("timeout", manager.KeepaliveTimeout).
+						Msg("No traffic from the cam, closing stale websocket")
+					conn.socket.Close()
+					return
 				}
 			}
-		}
-	})
 
-	return manager
+			if err := conn.SendMessage(&Message{
+				Type: Message_Type(Message_KEEPALIVE).Enum(),
+			}); err != nil {
+				log.Error().Err(err).Msg("Failed to send keepalive message")
+			}
+		}
+	}
 }
 
 // WithReadyConnection - registers handler which will be called as a go routine upon ready connection
@@ -172,9 +195,23 @@ func (manager *WebsocketConnectionManager) run(attempt utils.AttemptContext) {
 		readyState := manager.readyState
 		manager.mu.RUnlock()
 
+		readyState.Connection.markReceived()
 		go readyState.Connection.handleMessage(m)
 	}
 
+	// Control frames prove the connection is alive as well
+	markReceived := func(_ string, _ gowebsocket.Socket) {
+		manager.mu.RLock()
+		readyState := manager.readyState
+		manager.mu.RUnlock()
+
+		if readyState != nil {
+			readyState.Connection.markReceived()
+		}
+	}
+	socket.OnPingReceived = markReceived
+	socket.OnPongReceived = markReceived
+
 	log.Trace().Msg("Connecting to websocket")
 	socket.Connect()
 
@@ -15,11 +15,19 @@ import (
 )
 
 // WebsocketMessageHandler - message handler
-type WebsocketMessageHandler func(*Message, *WebsocketConnection)
+type WebsocketMessageHandler func(*Message, Connection)
+
+// Connection - request/message operations of a ready connection
+// Note: implemented by WebsocketConnection, allows substituting the camera connection in tests
+type Connection interface {
+	RegisterMessageHandler(handler WebsocketMessageHandler)
+	SendRequest(reqType RequestType, requestData *Request) func(time.Duration) (*Response, error)
+}
 
 // WebsocketConnection - ready websocket connection
 type WebsocketConnection struct {
-	socket *gowebsocket.Socket
+	socket     *gowebsocket.Socket
+	sendBinary func(data []byte) // Writes to the socket, replaced in tests
 
 	msgHandlersMu sync.RWMutex
 	msgHandlers   []WebsocketMessageHandler
@@ -28,17 +36,31 @@ type WebsocketConnection struct {
 	resHandlers   map[int32]unhandledRequest
 
 	lastRequestID int32
+
+	lastReceived int64 // Unix nano time of the latest frame received from the cam
 }
 
 // NewWebsocketConnection - constructor
 func NewWebsocketConnection(socket *gowebsocket.Socket) *WebsocketConnection {
 	return &WebsocketConnection{
 		socket:        socket,
+		sendBinary:    socket.SendBinary,
 		resHandlers:   make(map[int32]unhandledRequest),
 		lastRequestID: 0,
+		lastReceived:  time.Now().UnixNano(),
 	}
 }
 
+// markReceived - records traffic from the cam, any message or ping/pong counts
+func (conn *WebsocketConnection) markReceived() {
+	atomic.StoreInt64(&conn.lastReceived, time.Now().UnixNano())
+}
+
+// LastReceived - returns time of the latest traffic from the cam (connection time if there was none)
+func (conn *WebsocketConnection) LastReceived() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&conn.lastReceived))
+}
+
 // RegisterMessageHandler - registers handler which will be called whenever new message is received
 func (conn *WebsocketConnection) RegisterMessageHandler(handler WebsocketMessageHandler) {
 	conn.msgHandlersMu.Lock()
@@ -65,7 +87,7 @@ func (conn *WebsocketConnection) SendMessage(m *Message) error {
 	}
 	log.Trace().Bytes("rawdata", bytes).Msg("Sending data")
 
-	conn.socket.SendBinary(bytes)
+	conn.sendBinary(bytes)
 	return nil
 }
 
@@ -83,6 +105,8 @@ func (conn *WebsocketConnection) SendRequest(reqType RequestType, requestData *R
 	}
 
 	// Response handling
+	// Note: the channel is never closed, a response arriving after the timeout lands in the buffer and is dropped
+	// together with the channel. Closing it would race with the send from handleResponse.
 	resC := make(chan *Response, 1)
 
 	conn.resHandlersMu.Lock()
@@ -90,10 +114,9 @@ func (conn *WebsocketConnection) SendRequest(reqType RequestType, requestData *R
 		Request: m.Request,
 		HandleResponse: func(res *Response) {
 			select {
-			case <-resC:
-				return // Channel already closed (ie. timeout)
+			case resC <- res:
 			default:
-				resC <- res
+				// Duplicate response, the first one wins
 			}
 		},
 	}
@@ -102,6 +125,7 @@ func (conn *WebsocketConnection) SendRequest(reqType RequestType, requestData *R
 	// Send request
 	if err := conn.SendMessage(m); err != nil {
 		log.Error().Err(err).Msg("Failed to send websocket message")
+		conn.forgetRequest(id)
 		// Return an awaiter that immediately returns the error
 		return func(timeout time.Duration) (*Response, error) {
 			return nil, fmt.Errorf("failed to send request: %w", err)
@@ -114,10 +138,9 @@ func (conn *WebsocketConnection) SendRequest(reqType RequestType, requestData *R
 
 		select {
 		case <-timer.C:
-			close(resC)
+			conn.forgetRequest(id)
 			return nil, errors.New("Request timeout")
 		case res := <-resC:
-			close(resC)
 			timer.Stop()
 
 			if res.StatusCode == nil {
@@ -135,6 +158,13 @@ func (conn *WebsocketConnection) SendRequest(reqType RequestType, requestData *R
 	}
 }
 
+// forgetRequest - stops waiting for response of the request, late responses are ignored
+func (conn *WebsocketConnection) forgetRequest(id int32) {
+	conn.resHandlersMu.Lock()
+	delete(conn.resHandlers, id)
+	conn.resHandlersMu.Unlock()
+}
+
 type unhandledRequest struct {
 	Request        *Request
 	HandleResponse func(response *Response)
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+// newLoopbackConnection - connection answering every request after a random delay of up to maxDelay
+func newLoopbackConnection(t *testing.T, maxDelay time.Duration) (*WebsocketConnection, *sync.WaitGroup) {
+	conn := &WebsocketConnection{resHandlers: make(map[int32]unhandledRequest)}
+	responses := &sync.WaitGroup{}
+
+	conn.sendBinary = func(data []byte) {
+		m := &Message{}
+		if !assert.NoError(t, proto.Unmarshal(data, m)) {
+			return
+		}
+
+		responses.Add(1)
+		go func() {
+			defer responses.Done()
+			time.Sleep(time.Duration(rand.Int63n(int64(maxDelay))))
+			conn.handleMessage(&Message{
+				Type: Message_Type(Message_RESPONSE).Enum(),
+				Response: &Response{
+					RequestId:   m.Request.Id,
+					RequestType: m.Request.Type,
+					StatusCode:  proto.Int32(200),
+				},
+			})
+		}()
+	}
+
+	return conn, responses
+}
+
+func TestSendRequestConcurrentTimeouts(t *testing.T) {
+	conn, responses := newLoopbackConnection(t, 2*time.Millisecond)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded, timedOut := 0, 0
+
+	for i := 0; i < 2000; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// Timeouts around the response delay, so responses keep arriving right as requests give up
+			timeout := time.Duration(i%4) * time.Millisecond
+			res, err := conn.SendRequest(RequestType_GET_SENSOR_DATA, &Request{})(timeout)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				assert.Equal(t, "Request timeout", err.Error())
+				timedOut++
+			} else {
+				assert.Equal(t, int32(200), res.GetStatusCode())
+				succeeded++
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	responses.Wait()
+
+	assert.Equal(t, 2000, succeeded+timedOut)
+	assert.Greater(t, succeeded, 0)
+
+	// Nothing is left waiting for a response
+	conn.resHandlersMu.RLock()
+	assert.Empty(t, conn.resHandlers)
+	conn.resHandlersMu.RUnlock()
+}
+
+func TestSendRequestDuplicateResponse(t *testing.T) {
+	conn := &WebsocketConnection{resHandlers: make(map[int32]unhandledRequest), sendBinary: func([]byte) {}}
+
+	await := conn.SendRequest(RequestType_GET_SENSOR_DATA, &Request{})
+
+	conn.resHandlersMu.RLock()
+	handler := conn.resHandlers[1]
+	conn.resHandlersMu.RUnlock()
+
+	// A second response doesn't block nor panic
+	handler.HandleResponse(&Response{StatusCode: proto.Int32(200)})
+	handler.HandleResponse(&Response{StatusCode: proto.Int32(500)})
+
+	res, err := await(time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(200), res.GetStatusCode())
+}
+
+func TestRequestTimeout(t *testing.T) {
+	assert.Equal(t, 30*time.Second, RequestTimeout(RequestType_PUT_STREAMING))
+	assert.Equal(t, DefaultRequestTimeout, RequestTimeout(RequestType_GET_CONTROL))
+}
@@ -0,0 +1,188 @@
+package history
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrAlertNotFound - no alert with the ID is stored
+var ErrAlertNotFound = errors.New("alert not found")
+
+// AlertKey identifies an alert condition of a baby, alerts with the same category and message are the same alert
+type AlertKey struct {
+	AlertType string
+	Category  string
+	Message   string
+}
+
+// Alert represents a single occurrence of a device alert, from when it was first seen until it cleared
+type Alert struct {
+	ID           int64  `json:"id"`
+	BabyUID      string `json:"baby_uid"`
+	AlertType    string `json:"alert_type"` // "error" or "warning"
+	Category     string `json:"category"`
+	Message      string `json:"message"`
+	FirstSeen    int64  `json:"first_seen"`
+	LastSeen     int64  `json:"last_seen"`
+	ResolvedAt   *int64 `json:"resolved_at,omitempty"`
+	Acknowledged bool   `json:"acknowledged"`
+}
+
+// SyncAlerts records the currently active alerts of the baby, returns their stored occurrences in the same order
+// Alerts seen before keep their occurrence (ID, first seen time and acknowledgment), new ones start an occurrence and
+// active occurrences missing from current are resolved. An alert firing again after it cleared is a new occurrence.
+func (t *Tracker) SyncAlerts(babyUID string, current []AlertKey, now int64) ([]Alert, error) {
+	if !t.enabled {
+		return nil, fmt.Errorf("historical tracking disabled")
+	}
+
+	tx, err := t.writeDB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id, baby_uid, alert_type, category, message, first_seen, last_seen, resolved_at, acknowledged
+		FROM device_alerts
+		WHERE baby_uid = ? AND resolved_at IS NULL
+	`, babyUID)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[AlertKey]Alert)
+	for rows.Next() {
+		var alert Alert
+		var resolvedAt sql.NullInt64
+		if err := rows.Scan(&alert.ID, &alert.BabyUID, &alert.AlertType, &alert.Category, &alert.Message,
+			&alert.FirstSeen, &alert.LastSeen, &resolvedAt, &alert.Acknowledged); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		active[AlertKey{Category: alert.Category, Message: alert.Message}] = alert
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	alerts := make([]Alert, 0, len(current))
+	for _, key := range current {
+		identity := AlertKey{Category: key.Category, Message: key.Message}
+
+		if alert, ok := active[identity]; ok {
+			if _, err := tx.Exec("UPDATE device_alerts SET last_seen = ?, alert_type = ? WHERE id = ?", now, key.AlertType, alert.ID); err != nil {
+				return nil, err
+			}
+			alert.LastSeen = now
+			alert.AlertType = key.AlertType
+			alerts = append(alerts, alert)
+			delete(active, identity)
+			continue
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO device_alerts (baby_uid, alert_type, category, message, first_seen, last_seen)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, babyUID, key.AlertType, key.Category, key.Message, now, now)
+		if err != nil {
+			return nil, err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+
+		alerts = append(alerts, Alert{
+			ID:        id,
+			BabyUID:   babyUID,
+			AlertType: key.AlertType,
+			Category:  key.Category,
+			Message:   key.Message,
+			FirstSeen: now,
+			LastSeen:  now,
+		})
+	}
+
+	// Whatever is left cleared since the last sync
+	for _, alert := range active {
+		if _, err := tx.Exec("UPDATE device_alerts SET resolved_at = ? WHERE id = ?", now, alert.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// GetActiveAlerts returns the occurrences of the baby's alerts which haven't cleared yet, as of the last SyncAlerts
+func (t *Tracker) GetActiveAlerts(babyUID string) ([]Alert, error) {
+	if !t.enabled {
+		return nil, fmt.Errorf("historical tracking disabled")
+	}
+
+	rows, err := t.db.Query(`
+		SELECT id, baby_uid, alert_type, category, message, first_seen, last_seen, acknowledged
+		FROM device_alerts
+		WHERE baby_uid = ? AND resolved_at IS NULL
+		ORDER BY id
+	`, babyUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []Alert{}
+	for rows.Next() {
+		var alert Alert
+		if err := rows.Scan(&alert.ID, &alert.BabyUID, &alert.AlertType, &alert.Category, &alert.Message,
+			&alert.FirstSeen, &alert.LastSeen, &alert.Acknowledged); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, rows.Err()
+}
+
+// AcknowledgeAlert marks the alert occurrence acknowledged, returns ErrAlertNotFound for unknown IDs
+func (t *Tracker) AcknowledgeAlert(id int64) (*Alert, error) {
+	if !t.enabled {
+		return nil, fmt.Errorf("historical tracking disabled")
+	}
+
+	result, err := t.execWrite("UPDATE device_alerts SET acknowledged = 1 WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if affected == 0 {
+		return nil, ErrAlertNotFound
+	}
+
+	var alert Alert
+	var resolvedAt sql.NullInt64
+	err = t.db.QueryRow(`
+		SELECT id, baby_uid, alert_type, category, message, first_seen, last_seen, resolved_at, acknowledged
+		FROM device_alerts
+		WHERE id = ?
+	`, id).Scan(&alert.ID, &alert.BabyUID, &alert.AlertType, &alert.Category, &alert.Message,
+		&alert.FirstSeen, &alert.LastSeen, &resolvedAt, &alert.Acknowledged)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolvedAt.Valid {
+		alert.ResolvedAt = &resolvedAt.Int64
+	}
+
+	return &alert, nil
+}
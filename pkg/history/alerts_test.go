@@ -0,0 +1,68 @@
+package history_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/history"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncAlertsLifecycle(t *testing.T) {
+	tracker, err := history.NewTracker(t.TempDir(), true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tracker.Close()
+
+	firmware := history.AlertKey{AlertType: "warning", Category: "firmware", Message: "Firmware update available for installation"}
+	sleep := history.AlertKey{AlertType: "warning", Category: "device_state", Message: "Camera is in sleep mode"}
+
+	alerts, err := tracker.SyncAlerts("baby1", []history.AlertKey{firmware, sleep}, 100)
+	if !assert.NoError(t, err) || !assert.Len(t, alerts, 2) {
+		return
+	}
+	firmwareID := alerts[0].ID
+	assert.Equal(t, "firmware", alerts[0].Category)
+	assert.Equal(t, int64(100), alerts[0].FirstSeen)
+
+	acked, err := tracker.AcknowledgeAlert(firmwareID)
+	if assert.NoError(t, err) {
+		assert.True(t, acked.Acknowledged)
+		assert.Equal(t, "baby1", acked.BabyUID)
+	}
+
+	// Still active alerts keep their occurrence, the cleared one is resolved
+	alerts, err = tracker.SyncAlerts("baby1", []history.AlertKey{firmware}, 200)
+	if assert.NoError(t, err) && assert.Len(t, alerts, 1) {
+		assert.Equal(t, firmwareID, alerts[0].ID)
+		assert.Equal(t, int64(100), alerts[0].FirstSeen)
+		assert.Equal(t, int64(200), alerts[0].LastSeen)
+		assert.True(t, alerts[0].Acknowledged)
+	}
+
+	active, err := tracker.GetActiveAlerts("baby1")
+	if assert.NoError(t, err) && assert.Len(t, active, 1) {
+		assert.Equal(t, alerts[0], active[0])
+	}
+
+	// Firing again after it cleared is a new occurrence
+	alerts, err = tracker.SyncAlerts("baby1", []history.AlertKey{sleep}, 300)
+	if assert.NoError(t, err) && assert.Len(t, alerts, 1) {
+		assert.Equal(t, int64(300), alerts[0].FirstSeen)
+		assert.False(t, alerts[0].Acknowledged)
+	}
+
+	// Other babies are independent
+	alerts, err = tracker.SyncAlerts("baby2", nil, 300)
+	assert.NoError(t, err)
+	assert.Empty(t, alerts)
+
+	_, err = tracker.AcknowledgeAlert(12345)
+	assert.True(t, errors.Is(err, history.ErrAlertNotFound))
+
+	_, err = (&history.Tracker{}).SyncAlerts("baby1", nil, 0)
+	assert.Error(t, err)
+	_, err = (&history.Tracker{}).GetActiveAlerts("baby1")
+	assert.Error(t, err)
+}
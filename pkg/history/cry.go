@@ -0,0 +1,184 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultCryEpisodeGap - sound events closer to each other than this are merged into one crying episode
+const DefaultCryEpisodeGap = 5 * time.Minute
+
+// Periods of a crying episode, by the day/night mode of the camera when it started
+const (
+	CryPeriodDay     = "day"
+	CryPeriodNight   = "night"
+	CryPeriodUnknown = "unknown"
+)
+
+// CryEpisode represents sound events merged into a single crying episode
+type CryEpisode struct {
+	StartTime       int64  `json:"start_time"`
+	EndTime         int64  `json:"end_time"`
+	DurationSeconds int64  `json:"duration_seconds"`
+	EventCount      int    `json:"event_count"`
+	Period          string `json:"period"` // "day", "night" or "unknown"
+}
+
+// CryPeriodStats aggregates the crying episodes of a period (day or night)
+type CryPeriodStats struct {
+	EpisodeCount           int   `json:"episode_count"`
+	TotalDurationSeconds   int64 `json:"total_duration_seconds"`
+	LongestDurationSeconds int64 `json:"longest_duration_seconds"`
+}
+
+// CrySummary provides the crying episodes of a time period
+type CrySummary struct {
+	BabyUID              string         `json:"baby_uid"`
+	StartTime            int64          `json:"start_time"`
+	EndTime              int64          `json:"end_time"`
+	GapSeconds           int64          `json:"gap_seconds"`
+	EpisodeCount         int            `json:"episode_count"`
+	TotalDurationSeconds int64          `json:"total_duration_seconds"`
+	Day                  CryPeriodStats `json:"day"`
+	Night                CryPeriodStats `json:"night"`
+	Unknown              CryPeriodStats `json:"unknown"`
+	Episodes             []CryEpisode   `json:"episodes"`
+}
+
+// SetCryEpisodeGap sets the longest gap between sound events of a single crying episode
+func (t *Tracker) SetCryEpisodeGap(gap time.Duration) {
+	t.cryEpisodeGap = gap
+}
+
+// GetCrySummary clusters the sound and cry events of the period into crying episodes
+// Episodes are attributed to day or night by the mode of the camera at their start.
+func (t *Tracker) GetCrySummary(babyUID string, startTime, endTime int64) (*CrySummary, error) {
+	if !t.enabled {
+		return nil, fmt.Errorf("historical tracking disabled")
+	}
+
+	query := `
+		SELECT timestamp
+		FROM events
+		WHERE baby_uid = ? AND timestamp BETWEEN ? AND ? AND event_type IN ('sound', 'cry')
+		ORDER BY timestamp ASC
+	`
+
+	rows, err := t.db.Query(query, babyUID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var timestamps []int64
+	for rows.Next() {
+		var timestamp int64
+		if err := rows.Scan(&timestamp); err != nil {
+			return nil, err
+		}
+		timestamps = append(timestamps, timestamp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	readings, previous, err := t.getNightReadings(babyUID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	gap := t.cryEpisodeGap
+	if gap <= 0 {
+		gap = DefaultCryEpisodeGap
+	}
+
+	summary := computeCrySummary(timestamps, readings, previous, int64(gap/time.Second), int64(t.maxCarryForward/time.Second))
+	summary.BabyUID = babyUID
+	summary.StartTime = startTime
+	summary.EndTime = endTime
+
+	return summary, nil
+}
+
+// clusterCryEpisodes merges ascending event timestamps no more than gap seconds apart into episodes
+func clusterCryEpisodes(timestamps []int64, gap int64) []CryEpisode {
+	var episodes []CryEpisode
+
+	for _, timestamp := range timestamps {
+		if n := len(episodes); n > 0 && timestamp-episodes[n-1].EndTime <= gap {
+			episodes[n-1].EndTime = timestamp
+			episodes[n-1].EventCount++
+			continue
+		}
+
+		episodes = append(episodes, CryEpisode{StartTime: timestamp, EndTime: timestamp, EventCount: 1})
+	}
+
+	for i := range episodes {
+		episodes[i].DurationSeconds = episodes[i].EndTime - episodes[i].StartTime
+	}
+
+	return episodes
+}
+
+// nightStateAt returns the day/night period at the timestamp, by the latest reading at or before it
+// The state is carried at most maxGap seconds past the reading (0 carries it without limit), later it is unknown.
+// Before the first reading of the period the earlier one (previous) is used.
+func nightStateAt(readings []nightReading, previous *nightReading, timestamp int64, maxGap int64) string {
+	i := sort.Search(len(readings), func(i int) bool {
+		return readings[i].timestamp > timestamp
+	})
+
+	var reading *nightReading
+	if i > 0 {
+		reading = &readings[i-1]
+	} else if previous != nil {
+		reading = previous
+	}
+
+	if reading == nil || (maxGap > 0 && timestamp-reading.timestamp > maxGap) {
+		return CryPeriodUnknown
+	}
+
+	if reading.isNight {
+		return CryPeriodNight
+	}
+
+	return CryPeriodDay
+}
+
+// computeCrySummary clusters the events into episodes and aggregates them by day/night period
+func computeCrySummary(timestamps []int64, readings []nightReading, previous *nightReading, gap int64, maxGap int64) *CrySummary {
+	summary := &CrySummary{
+		GapSeconds: gap,
+		Episodes:   clusterCryEpisodes(timestamps, gap),
+	}
+
+	if summary.Episodes == nil {
+		summary.Episodes = []CryEpisode{}
+	}
+
+	for i := range summary.Episodes {
+		episode := &summary.Episodes[i]
+		episode.Period = nightStateAt(readings, previous, episode.StartTime, maxGap)
+
+		stats := &summary.Unknown
+		switch episode.Period {
+		case CryPeriodDay:
+			stats = &summary.Day
+		case CryPeriodNight:
+			stats = &summary.Night
+		}
+
+		stats.EpisodeCount++
+		stats.TotalDurationSeconds += episode.DurationSeconds
+		stats.LongestDurationSeconds = max(stats.LongestDurationSeconds, episode.DurationSeconds)
+
+		summary.EpisodeCount++
+		summary.TotalDurationSeconds += episode.DurationSeconds
+	}
+
+	return summary
+}
@@ -0,0 +1,84 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterCryEpisodes(t *testing.T) {
+	start := int64(1700000000)
+
+	// Two bursts 20 minutes apart, plus a single event
+	timestamps := []int64{start, start + 60, start + 200, start + 1400, start + 1500, start + 3600}
+
+	episodes := clusterCryEpisodes(timestamps, 300)
+	if assert.Len(t, episodes, 3) {
+		assert.Equal(t, CryEpisode{StartTime: start, EndTime: start + 200, DurationSeconds: 200, EventCount: 3}, episodes[0])
+		assert.Equal(t, CryEpisode{StartTime: start + 1400, EndTime: start + 1500, DurationSeconds: 100, EventCount: 2}, episodes[1])
+		assert.Equal(t, CryEpisode{StartTime: start + 3600, EndTime: start + 3600, DurationSeconds: 0, EventCount: 1}, episodes[2])
+	}
+
+	// The gap is measured from the latest event of the episode, not its start
+	assert.Len(t, clusterCryEpisodes([]int64{start, start + 250, start + 500, start + 750}, 300), 1)
+
+	assert.Empty(t, clusterCryEpisodes(nil, 300))
+}
+
+func TestCrySummaryByDayNight(t *testing.T) {
+	const hour = int64(3600)
+	start := int64(1700000000)
+
+	previous := &nightReading{timestamp: start - hour, isNight: true}
+	readings := []nightReading{
+		{timestamp: start + 2*hour, isNight: false},
+	}
+
+	timestamps := []int64{
+		start + 10, start + 70, // Night, carried from the previous reading
+		start + 2*hour + 30, start + 2*hour + 90, start + 2*hour + 150, // Day
+		start + 6*hour, // Day reading too old, unknown
+	}
+
+	summary := computeCrySummary(timestamps, readings, previous, 300, 2*hour)
+	assert.Equal(t, 3, summary.EpisodeCount)
+	assert.Equal(t, int64(180), summary.TotalDurationSeconds)
+	assert.Equal(t, CryPeriodStats{EpisodeCount: 1, TotalDurationSeconds: 60, LongestDurationSeconds: 60}, summary.Night)
+	assert.Equal(t, CryPeriodStats{EpisodeCount: 1, TotalDurationSeconds: 120, LongestDurationSeconds: 120}, summary.Day)
+	assert.Equal(t, 1, summary.Unknown.EpisodeCount)
+	assert.Equal(t, CryPeriodNight, summary.Episodes[0].Period)
+	assert.Equal(t, CryPeriodDay, summary.Episodes[1].Period)
+	assert.Equal(t, CryPeriodUnknown, summary.Episodes[2].Period)
+
+	// Unlimited carry forward keeps the day mode
+	summary = computeCrySummary(timestamps, readings, previous, 300, 0)
+	assert.Equal(t, 2, summary.Day.EpisodeCount)
+	assert.Equal(t, 0, summary.Unknown.EpisodeCount)
+}
+
+func TestGetCrySummary(t *testing.T) {
+	tracker, err := NewTracker(t.TempDir(), true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tracker.Close()
+	tracker.SetCryEpisodeGap(2 * time.Minute)
+
+	now := time.Now().Unix()
+	assert.NoError(t, tracker.TrackEvent("baby1", "sound", now-600))
+	assert.NoError(t, tracker.TrackEvent("baby1", "cry", now-540))
+	assert.NoError(t, tracker.TrackEvent("baby1", "motion", now-500))
+	assert.NoError(t, tracker.TrackEvent("baby1", "sound", now-60))
+	assert.NoError(t, tracker.TrackEvent("baby2", "sound", now-60))
+
+	summary, err := tracker.GetCrySummary("baby1", now-3600, now)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "baby1", summary.BabyUID)
+		assert.Equal(t, int64(120), summary.GapSeconds)
+		assert.Equal(t, 2, summary.EpisodeCount)
+		assert.Equal(t, 2, summary.Episodes[0].EventCount, "motion events are not part of episodes")
+		assert.Equal(t, int64(60), summary.Episodes[0].DurationSeconds)
+		assert.Equal(t, 2, summary.Unknown.EpisodeCount, "no day/night readings stored")
+	}
+}
@@ -0,0 +1,58 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDayNightAnalyticsLongGap(t *testing.T) {
+	const hour = int64(3600)
+	start := int64(1700000000)
+	end := start + 24*hour
+
+	// Night reading at the start, camera offline for 20 hours, day reading for the last 2 hours
+	readings := []nightReading{
+		{timestamp: start, isNight: true},
+		{timestamp: start + 22*hour, isNight: false},
+	}
+
+	// Unlimited carry forward attributes the whole gap to night
+	analytics := computeDayNightAnalytics(readings, nil, start, end, 0)
+	assert.Equal(t, int64(22*60), analytics.NightModeMinutes)
+	assert.Equal(t, int64(2*60), analytics.DayModeMinutes)
+	assert.Equal(t, int64(0), analytics.UnknownModeMinutes)
+
+	// Only the first hour of the gap is carried, the rest is unknown
+	analytics = computeDayNightAnalytics(readings, nil, start, end, hour)
+	assert.Equal(t, int64(60), analytics.NightModeMinutes)
+	assert.Equal(t, int64(60), analytics.DayModeMinutes)
+	assert.Equal(t, int64(22*60), analytics.UnknownModeMinutes)
+	assert.InDelta(t, 91.67, analytics.UnknownModePercentage, 0.01)
+	assert.Equal(t, int64(1), analytics.ModeTransitions)
+}
+
+func TestDayNightAnalyticsStalePreviousReading(t *testing.T) {
+	const hour = int64(3600)
+	start := int64(1700000000)
+	end := start + 24*hour
+
+	// Single reading from two days ago, nothing in the period
+	previous := &nightReading{timestamp: start - 48*hour, isNight: true}
+
+	analytics := computeDayNightAnalytics(nil, previous, start, end, hour)
+	assert.Equal(t, int64(0), analytics.NightModeMinutes)
+	assert.Equal(t, int64(24*60), analytics.UnknownModeMinutes)
+	assert.Equal(t, 100.0, analytics.UnknownModePercentage)
+
+	// Recent previous reading is carried into the period
+	previous = &nightReading{timestamp: start - 30*60, isNight: true}
+	analytics = computeDayNightAnalytics(nil, previous, start, end, hour)
+	assert.Equal(t, int64(30), analytics.NightModeMinutes)
+	assert.Equal(t, int64(24*60-30), analytics.UnknownModeMinutes)
+
+	// Without a previous reading the first reading is carried backward
+	analytics = computeDayNightAnalytics([]nightReading{{timestamp: start + 2*hour, isNight: false}}, nil, start, start+3*hour, hour)
+	assert.Equal(t, int64(120), analytics.DayModeMinutes)
+	assert.Equal(t, int64(60), analytics.UnknownModeMinutes)
+}
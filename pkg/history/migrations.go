@@ -0,0 +1,89 @@
+package history
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// migration - schema change applied once on top of schema.sql
+type migration struct {
+	description string
+	statements  []string
+}
+
+// migrations - applied in order, the number of applied migrations is stored in PRAGMA user_version
+// Note: never reorder or remove entries, only append new ones
+var migrations = []migration{
+	{
+		description: "composite indexes for time range queries",
+		statements: []string{
+			"CREATE INDEX IF NOT EXISTS idx_sensor_readings_baby_time ON sensor_readings(baby_uid, timestamp)",
+			"CREATE INDEX IF NOT EXISTS idx_events_baby_time ON events(baby_uid, timestamp)",
+			"CREATE INDEX IF NOT EXISTS idx_state_changes_baby_time ON state_changes(baby_uid, timestamp)",
+		},
+	},
+	{
+		description: "device alerts",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS device_alerts (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				baby_uid TEXT NOT NULL,
+				alert_type TEXT NOT NULL,  -- 'error' or 'warning'
+				category TEXT NOT NULL,
+				message TEXT NOT NULL,
+				first_seen INTEGER NOT NULL, -- Unix timestamp
+				last_seen INTEGER NOT NULL,  -- Unix timestamp
+				resolved_at INTEGER,         -- Unix timestamp the alert was first seen cleared, NULL while active
+				acknowledged BOOLEAN NOT NULL DEFAULT 0
+			)`,
+			"CREATE INDEX IF NOT EXISTS idx_device_alerts_baby_resolved ON device_alerts(baby_uid, resolved_at)",
+		},
+	},
+	{
+		description: "raw sensor readings",
+		statements: []string{
+			// Values reported by the camera before calibration, NULL unless storing them is enabled
+			"ALTER TABLE sensor_readings ADD COLUMN raw_temperature_celsius REAL",
+			"ALTER TABLE sensor_readings ADD COLUMN raw_humidity_percent REAL",
+		},
+	},
+}
+
+// migrate applies pending migrations, each one in its own transaction
+func (t *Tracker) migrate() error {
+	var version int
+	if err := t.writeDB.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %v", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		m := migrations[i]
+
+		tx, err := t.writeDB.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to start migration %d: %v", i+1, err)
+		}
+
+		for _, statement := range m.statements {
+			if _, err := tx.Exec(statement); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("migration %d (%s) failed: %v", i+1, m.description, err)
+			}
+		}
+
+		// PRAGMA doesn't support bound parameters
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to store schema version %d: %v", i+1, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %v", i+1, err)
+		}
+
+		log.Info().Int("version", i+1).Str("description", m.description).Msg("Applied history database migration")
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package history
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationsAreIdempotent(t *testing.T) {
+	dir := t.TempDir()
+
+	tracker, err := NewTracker(dir, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, tracker.Close())
+
+	// Reopening must not re-apply migrations
+	tracker, err = NewTracker(dir, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tracker.Close()
+
+	var version int
+	assert.NoError(t, tracker.db.QueryRow("PRAGMA user_version").Scan(&version))
+	assert.Equal(t, len(migrations), version)
+}
+
+func TestHistoryQueriesUseIndexes(t *testing.T) {
+	tracker, err := NewTracker(t.TempDir(), true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tracker.Close()
+
+	now := time.Now().Unix()
+
+	queryPlan := func(query string, args []interface{}) string {
+		rows, err := tracker.db.Query("EXPLAIN QUERY PLAN "+query, args...)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer rows.Close()
+
+		var plan []string
+		for rows.Next() {
+			var id, parent, notused int
+			var detail string
+			assert.NoError(t, rows.Scan(&id, &parent, &notused, &detail))
+			plan = append(plan, detail)
+		}
+
+		return strings.Join(plan, "\n")
+	}
+
+	// Every sampling level: raw, 5 min, 1 hour and 6 hour buckets
+	for _, hours := range []int64{1, 12, 72, 720} {
+		query, args, _ := sampledSensorQuery("baby1", now-hours*3600, now)
+		assert.Contains(t, queryPlan(query, args), "USING INDEX idx_sensor_readings_baby_time", "timeframe %dh", hours)
+	}
+
+	for _, eventType := range []string{"", "motion"} {
+		query, args := eventsQuery("baby1", now-3600, now, eventType, 100)
+		assert.Contains(t, queryPlan(query, args), "USING INDEX idx_events_baby_time", "event type %q", eventType)
+	}
+
+	stateQuery := "SELECT COUNT(*) FROM state_changes WHERE baby_uid = ? AND timestamp BETWEEN ? AND ?"
+	assert.Contains(t, queryPlan(stateQuery, []interface{}{"baby1", now - 3600, now}), "idx_state_changes_baby_time")
+}
@@ -0,0 +1,113 @@
+package history
+
+import (
+	"sync"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+)
+
+const (
+	// significantTemperatureMilli - temperature change (0.5 °C) recorded regardless of the sample interval
+	significantTemperatureMilli = 500
+
+	// significantHumidityMilli - humidity change (3 %) recorded regardless of the sample interval
+	significantHumidityMilli = 3000
+)
+
+// sampledReading - last known and last recorded sensor values of a baby
+type sampledReading struct {
+	latest     baby.State
+	recorded   baby.State
+	recordedAt time.Time
+}
+
+// SensorSampler limits the rate of stored sensor readings per baby
+// Updates in between are merged, so the next stored reading carries the latest value of every sensor.
+type SensorSampler struct {
+	interval time.Duration
+	readings map[string]*sampledReading
+	mutex    sync.Mutex
+}
+
+// NewSensorSampler creates a sampler storing at most one reading per interval, 0 stores every reading
+func NewSensorSampler(interval time.Duration) *SensorSampler {
+	return &SensorSampler{
+		interval: interval,
+		readings: make(map[string]*sampledReading),
+	}
+}
+
+// Sample merges sensor update of the baby and returns the reading to store, if it should be stored now
+// Readings are stored when the interval elapsed since the last stored one or when a value changed significantly.
+func (s *SensorSampler) Sample(babyUID string, update baby.State, now time.Time) (baby.State, bool) {
+	if s == nil || s.interval <= 0 {
+		return update, true
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	reading, exists := s.readings[babyUID]
+	if !exists {
+		reading = &sampledReading{}
+		s.readings[babyUID] = reading
+	}
+
+	if update.TemperatureMilli != nil {
+		reading.latest.TemperatureMilli = update.TemperatureMilli
+		reading.latest.RawTemperatureMilli = update.RawTemperatureMilli
+	}
+	if update.HumidityMilli != nil {
+		reading.latest.HumidityMilli = update.HumidityMilli
+		reading.latest.RawHumidityMilli = update.RawHumidityMilli
+	}
+	if update.IsNight != nil {
+		reading.latest.IsNight = update.IsNight
+	}
+
+	if exists && now.Sub(reading.recordedAt) < s.interval && !isSignificantChange(reading.recorded, reading.latest) {
+		return baby.State{}, false
+	}
+
+	sample := baby.State{
+		TemperatureMilli:    reading.latest.TemperatureMilli,
+		HumidityMilli:       reading.latest.HumidityMilli,
+		RawTemperatureMilli: reading.latest.RawTemperatureMilli,
+		RawHumidityMilli:    reading.latest.RawHumidityMilli,
+		IsNight:             reading.latest.IsNight,
+	}
+
+	reading.recorded = sample
+	reading.recordedAt = now
+
+	return sample, true
+}
+
+// isSignificantChange returns whether the values moved enough since the last stored reading to be stored right away
+func isSignificantChange(recorded, latest baby.State) bool {
+	if changedBy(recorded.TemperatureMilli, latest.TemperatureMilli, significantTemperatureMilli) ||
+		changedBy(recorded.HumidityMilli, latest.HumidityMilli, significantHumidityMilli) {
+		return true
+	}
+
+	// Day/night transitions drive the day/night analytics, never delay them
+	if latest.IsNight != nil && (recorded.IsNight == nil || *recorded.IsNight != *latest.IsNight) {
+		return true
+	}
+
+	return false
+}
+
+func changedBy(recorded, latest *int32, threshold int32) bool {
+	if latest == nil {
+		return false
+	}
+
+	if recorded == nil {
+		return true
+	}
+
+	diff := *latest - *recorded
+	return diff >= threshold || diff <= -threshold
+}
@@ -0,0 +1,61 @@
+package history_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/history"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSensorSampler(t *testing.T) {
+	sampler := history.NewSensorSampler(30 * time.Second)
+	now := time.Now()
+
+	// First reading is always stored
+	_, store := sampler.Sample("baby1", *baby.NewState().SetTemperatureMilli(22000), now)
+	assert.True(t, store)
+
+	// Small changes within the interval are skipped
+	_, store = sampler.Sample("baby1", *baby.NewState().SetTemperatureMilli(22100), now.Add(5*time.Second))
+	assert.False(t, store)
+	_, store = sampler.Sample("baby1", *baby.NewState().SetHumidityMilli(45000), now.Add(10*time.Second))
+	assert.True(t, store, "first humidity value is a significant change")
+	_, store = sampler.Sample("baby1", *baby.NewState().SetHumidityMilli(46000), now.Add(15*time.Second))
+	assert.False(t, store)
+
+	// Other babies have their own interval
+	_, store = sampler.Sample("baby2", *baby.NewState().SetTemperatureMilli(20000), now.Add(15*time.Second))
+	assert.True(t, store)
+
+	// Significant change is stored right away
+	sample, store := sampler.Sample("baby1", *baby.NewState().SetTemperatureMilli(22600), now.Add(20*time.Second))
+	assert.True(t, store)
+	assert.Equal(t, 22.6, sample.GetTemperature())
+	assert.Equal(t, 46.0, sample.GetHumidity(), "merged latest humidity")
+
+	// Day/night transition is stored right away
+	_, store = sampler.Sample("baby1", *baby.NewState().SetIsNight(true), now.Add(21*time.Second))
+	assert.True(t, store)
+
+	// Once the interval elapses, the merged latest values are stored
+	_, store = sampler.Sample("baby1", *baby.NewState().SetHumidityMilli(47000), now.Add(30*time.Second))
+	assert.False(t, store)
+	sample, store = sampler.Sample("baby1", *baby.NewState().SetTemperatureMilli(22700), now.Add(52*time.Second))
+	assert.True(t, store)
+	assert.Equal(t, 22.7, sample.GetTemperature())
+	assert.Equal(t, 47.0, sample.GetHumidity())
+	assert.True(t, *sample.IsNight)
+}
+
+func TestSensorSamplerDisabled(t *testing.T) {
+	sampler := history.NewSensorSampler(0)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		sample, store := sampler.Sample("baby1", *baby.NewState().SetTemperatureMilli(22000), now)
+		assert.True(t, store)
+		assert.Nil(t, sample.HumidityMilli)
+	}
+}
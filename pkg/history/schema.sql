@@ -16,7 +16,7 @@ CREATE TABLE IF NOT EXISTS events (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     baby_uid TEXT NOT NULL,
     timestamp INTEGER NOT NULL, -- Unix timestamp from camera
-    event_type TEXT NOT NULL,   -- 'motion' or 'sound'
+    event_type TEXT NOT NULL,   -- 'motion', 'sound', 'temperature_alert', 'humidity_alert' or 'cry'
     created_at INTEGER DEFAULT (strftime('%s', 'now'))
 );
 
@@ -30,10 +30,7 @@ CREATE TABLE IF NOT EXISTS state_changes (
     created_at INTEGER DEFAULT (strftime('%s', 'now'))
 );
 
--- Indexes for efficient querying
-CREATE INDEX IF NOT EXISTS idx_sensor_readings_baby_time ON sensor_readings(baby_uid, timestamp);
-CREATE INDEX IF NOT EXISTS idx_events_baby_time ON events(baby_uid, timestamp);
-CREATE INDEX IF NOT EXISTS idx_state_changes_baby_time ON state_changes(baby_uid, timestamp);
+-- Indexes for querying by baby and time range are created by migrations (see migrations.go)
 
 -- Indexes for cleanup operations (based on created_at)
 CREATE INDEX IF NOT EXISTS idx_sensor_readings_created ON sensor_readings(created_at);
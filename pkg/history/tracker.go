@@ -3,12 +3,13 @@ package history
 import (
 	"database/sql"
 	"embed"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 	"github.com/rs/zerolog/log"
 	"github.com/indiefan/home_assistant_nanit/pkg/baby"
 )
@@ -16,11 +17,31 @@ import (
 //go:embed schema.sql
 var schemaSQL embed.FS
 
+const (
+	// DefaultDBFilename - name of the database file in the history directory, unless a path is configured
+	DefaultDBFilename = "history.db"
+
+	// busyTimeoutMs - how long SQLite waits for a lock held by another connection before returning SQLITE_BUSY
+	busyTimeoutMs = 5000
+
+	// writeAttempts - number of attempts of a write which keeps failing on a busy database
+	writeAttempts = 3
+
+	// writeRetryDelay - base delay between write attempts, multiplied by the attempt number
+	writeRetryDelay = 100 * time.Millisecond
+)
+
 // Tracker manages historical data storage and retrieval
 type Tracker struct {
-	db       *sql.DB
+	db       *sql.DB // Read pool
+	writeDB  *sql.DB // Single connection used for writes, SQLite allows only one writer at a time
 	dbPath   string
 	enabled  bool
+
+	maxCarryForward time.Duration // Longest gap after a reading its is_night state is assumed in day/night analytics, 0 for unlimited
+	cryEpisodeGap   time.Duration // Longest gap between sound events of one crying episode, DefaultCryEpisodeGap if 0
+
+	storeRawSensorData bool // Values reported by the camera are stored next to the calibrated ones
 }
 
 // SensorReading represents a point-in-time sensor measurement
@@ -32,14 +53,18 @@ type SensorReading struct {
 	HumidityPercent   *float64 `json:"humidity_percent,omitempty"`
 	IsNight          *bool     `json:"is_night,omitempty"`
 	CreatedAt        int64     `json:"created_at"`
+
+	// Values reported by the camera before calibration, only stored if enabled
+	RawTemperatureCelsius *float64 `json:"raw_temperature_celsius,omitempty"`
+	RawHumidityPercent    *float64 `json:"raw_humidity_percent,omitempty"`
 }
 
-// Event represents a motion or sound event
+// Event represents a motion, sound or cloud alert event
 type Event struct {
 	ID        int64  `json:"id"`
 	BabyUID   string `json:"baby_uid"`
 	Timestamp int64  `json:"timestamp"`
-	EventType string `json:"event_type"` // "motion" or "sound"
+	EventType string `json:"event_type"` // "motion", "sound", "temperature_alert", "humidity_alert", "cry" or stream health transitions ("disconnect", "reconnect", "stream_unhealthy", "stream_alive", "stream_blocked")
 	CreatedAt int64  `json:"created_at"`
 }
 
@@ -98,35 +123,49 @@ type DayNightChange struct {
 	DurationMins int64 `json:"duration_mins"`
 }
 
-// NewTracker creates a new historical data tracker
+// NewTracker creates a new historical data tracker storing its database in dataDir
 func NewTracker(dataDir string, enabled bool) (*Tracker, error) {
+	return NewTrackerAt(filepath.Join(dataDir, DefaultDBFilename), enabled)
+}
+
+// NewTrackerAt creates a new historical data tracker storing its database at dbPath, parent directories are created
+func NewTrackerAt(dbPath string, enabled bool) (*Tracker, error) {
 	if !enabled {
 		log.Info().Msg("Historical data tracking disabled")
 		return &Tracker{enabled: false}, nil
 	}
 
-	dbPath := filepath.Join(dataDir, "history.db")
-	
 	// Ensure data directory exists
-	if err := os.MkdirAll(dataDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
 		return nil, fmt.Errorf("failed to create data directory: %v", err)
 	}
 
-	// Open database connection
-	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_synchronous=NORMAL&_cache_size=1000")
+	// Open database connections
+	dsn := fmt.Sprintf("%s?_journal_mode=WAL&_synchronous=NORMAL&_cache_size=1000&_busy_timeout=%d", dbPath, busyTimeoutMs)
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %v", err)
 	}
 
+	writeDB, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+
+	// Serialize writes within the process, concurrent readers are fine in WAL mode
+	writeDB.SetMaxOpenConns(1)
+
 	tracker := &Tracker{
 		db:      db,
+		writeDB: writeDB,
 		dbPath:  dbPath,
 		enabled: true,
 	}
 
 	// Initialize database schema
 	if err := tracker.initSchema(); err != nil {
-		db.Close()
+		tracker.closeDBs()
 		return nil, fmt.Errorf("failed to initialize schema: %v", err)
 	}
 
@@ -141,11 +180,16 @@ func (t *Tracker) initSchema() error {
 		return fmt.Errorf("failed to read schema: %v", err)
 	}
 
-	if _, err := t.db.Exec(string(schemaBytes)); err != nil {
+	if _, err := t.writeDB.Exec(string(schemaBytes)); err != nil {
 		return fmt.Errorf("failed to execute schema: %v", err)
 	}
 
-	return nil
+	return t.migrate()
+}
+
+// DBPath returns the path of the database file, empty if tracking is disabled
+func (t *Tracker) DBPath() string {
+	return t.dbPath
 }
 
 // Close closes the database connection
@@ -155,7 +199,47 @@ func (t *Tracker) Close() error {
 	}
 	
 	log.Info().Msg("Closing historical data tracker")
-	return t.db.Close()
+	return t.closeDBs()
+}
+
+// closeDBs closes both connection pools
+func (t *Tracker) closeDBs() error {
+	writeErr := t.writeDB.Close()
+	if err := t.db.Close(); err != nil {
+		return err
+	}
+
+	return writeErr
+}
+
+// isBusyError - returns whether the error is caused by the database being locked by another connection
+func isBusyError(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+
+	return false
+}
+
+// execWrite executes a write statement, retrying shortly while the database is busy
+func (t *Tracker) execWrite(query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	var err error
+
+	for attempt := 1; attempt <= writeAttempts; attempt++ {
+		result, err = t.writeDB.Exec(query, args...)
+		if err == nil || !isBusyError(err) {
+			return result, err
+		}
+
+		if attempt < writeAttempts {
+			log.Warn().Err(err).Int("attempt", attempt).Msg("History database busy, retrying write")
+			time.Sleep(time.Duration(attempt) * writeRetryDelay)
+		}
+	}
+
+	return result, err
 }
 
 // TrackSensorData records sensor readings (temperature, humidity, night mode)
@@ -184,12 +268,20 @@ func (t *Tracker) TrackSensorData(babyUID string, state baby.State) error {
 		humidity = &hum
 	}
 
+	var rawTemperature *float64
+	var rawHumidity *float64
+
+	if t.storeRawSensorData {
+		rawTemperature = milliToFloat(state.RawTemperatureMilli)
+		rawHumidity = milliToFloat(state.RawHumidityMilli)
+	}
+
 	query := `
-		INSERT INTO sensor_readings (baby_uid, timestamp, temperature_celsius, humidity_percent, is_night)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO sensor_readings (baby_uid, timestamp, temperature_celsius, humidity_percent, is_night, raw_temperature_celsius, raw_humidity_percent)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`
 	
-	_, err := t.db.Exec(query, babyUID, timestamp, temperature, humidity, state.IsNight)
+	_, err := t.execWrite(query, babyUID, timestamp, temperature, humidity, state.IsNight, rawTemperature, rawHumidity)
 	if err != nil {
 		log.Error().Err(err).Str("baby_uid", babyUID).Msg("Failed to record sensor data")
 		return err
@@ -205,7 +297,17 @@ func (t *Tracker) TrackSensorData(babyUID string, state baby.State) error {
 	return nil
 }
 
-// TrackEvent records motion or sound events
+// milliToFloat converts a milli value of the state to its floating point value, nil stays nil
+func milliToFloat(value *int32) *float64 {
+	if value == nil {
+		return nil
+	}
+
+	f := float64(*value) / 1000.0
+	return &f
+}
+
+// TrackEvent records motion, sound and cloud alert events
 func (t *Tracker) TrackEvent(babyUID string, eventType string, eventTimestamp int64) error {
 	if !t.enabled {
 		return nil
@@ -216,7 +318,7 @@ func (t *Tracker) TrackEvent(babyUID string, eventType string, eventTimestamp in
 		VALUES (?, ?, ?)
 	`
 	
-	_, err := t.db.Exec(query, babyUID, eventTimestamp, eventType)
+	_, err := t.execWrite(query, babyUID, eventTimestamp, eventType)
 	if err != nil {
 		log.Error().Err(err).
 			Str("baby_uid", babyUID).
@@ -247,7 +349,7 @@ func (t *Tracker) TrackStateChange(babyUID string, stateType string, value bool)
 		VALUES (?, ?, ?, ?)
 	`
 	
-	_, err := t.db.Exec(query, babyUID, timestamp, stateType, value)
+	_, err := t.execWrite(query, babyUID, timestamp, stateType, value)
 	if err != nil {
 		log.Error().Err(err).
 			Str("baby_uid", babyUID).
@@ -272,7 +374,8 @@ func (t *Tracker) GetSensorReadings(babyUID string, startTime, endTime int64, li
 	}
 
 	query := `
-		SELECT id, baby_uid, timestamp, temperature_celsius, humidity_percent, is_night, created_at
+		SELECT id, baby_uid, timestamp, temperature_celsius, humidity_percent, is_night, created_at,
+			raw_temperature_celsius, raw_humidity_percent
 		FROM sensor_readings
 		WHERE baby_uid = ? AND timestamp BETWEEN ? AND ?
 		ORDER BY timestamp DESC
@@ -289,7 +392,7 @@ func (t *Tracker) GetSensorReadings(babyUID string, startTime, endTime int64, li
 	for rows.Next() {
 		var r SensorReading
 		err := rows.Scan(&r.ID, &r.BabyUID, &r.Timestamp, &r.TemperatureCelsius, 
-			&r.HumidityPercent, &r.IsNight, &r.CreatedAt)
+			&r.HumidityPercent, &r.IsNight, &r.CreatedAt, &r.RawTemperatureCelsius, &r.RawHumidityPercent)
 		if err != nil {
 			return nil, err
 		}
@@ -299,108 +402,129 @@ func (t *Tracker) GetSensorReadings(babyUID string, startTime, endTime int64, li
 	return readings, nil
 }
 
-// GetSensorReadingsWithSampling retrieves sensor data with intelligent time-based sampling
-func (t *Tracker) GetSensorReadingsWithSampling(babyUID string, startTime, endTime int64) ([]SensorReading, error) {
+// GetLatestReading retrieves the most recent stored value of each sensor field.
+// Fields are looked up independently since readings may only carry some of them.
+// Returns nil if no readings exist for the baby.
+func (t *Tracker) GetLatestReading(babyUID string) (*SensorReading, error) {
 	if !t.enabled {
 		return nil, fmt.Errorf("historical tracking disabled")
 	}
 
-	// Determine sampling strategy based on timeframe
-	var query string
-	var args []interface{}
-	
-	timeframeDuration := endTime - startTime
-	timeframeHours := timeframeDuration / 3600
-	
+	query := `
+		SELECT
+			(SELECT MAX(timestamp) FROM sensor_readings WHERE baby_uid = ?),
+			(SELECT temperature_celsius FROM sensor_readings WHERE baby_uid = ? AND temperature_celsius IS NOT NULL ORDER BY timestamp DESC LIMIT 1),
+			(SELECT humidity_percent FROM sensor_readings WHERE baby_uid = ? AND humidity_percent IS NOT NULL ORDER BY timestamp DESC LIMIT 1),
+			(SELECT is_night FROM sensor_readings WHERE baby_uid = ? AND is_night IS NOT NULL ORDER BY timestamp DESC LIMIT 1)
+	`
+
+	var timestamp sql.NullInt64
+	r := SensorReading{BabyUID: babyUID}
+	err := t.db.QueryRow(query, babyUID, babyUID, babyUID, babyUID).Scan(&timestamp, &r.TemperatureCelsius, &r.HumidityPercent, &r.IsNight)
+	if err != nil {
+		return nil, err
+	}
+
+	if !timestamp.Valid {
+		return nil, nil
+	}
+	r.Timestamp = timestamp.Int64
+
+	return &r, nil
+}
+
+// sampledSensorQuery builds the sensor readings query, longer timeframes are averaged into coarser buckets
+func sampledSensorQuery(babyUID string, startTime, endTime int64) (string, []interface{}, bool) {
+	timeframeHours := (endTime - startTime) / 3600
+
+	var bucketSeconds int
 	if timeframeHours <= 6 {
 		// ≤ 6 hours: Raw data (every reading)
-		query = `
-			SELECT id, baby_uid, timestamp, temperature_celsius, humidity_percent, is_night, created_at
+		query := `
+			SELECT id, baby_uid, timestamp, temperature_celsius, humidity_percent, is_night, created_at,
+				raw_temperature_celsius, raw_humidity_percent
 			FROM sensor_readings
 			WHERE baby_uid = ? AND timestamp BETWEEN ? AND ?
 			ORDER BY timestamp ASC
 		`
-		args = []interface{}{babyUID, startTime, endTime}
-		
+		return query, []interface{}{babyUID, startTime, endTime}, false
 	} else if timeframeHours <= 24 {
 		// 6-24 hours: 5-minute averages
-		query = `
-			SELECT 
-				0 as id,
-				? as baby_uid,
-				(timestamp / 300) * 300 as timestamp,
-				AVG(temperature_celsius) as temperature_celsius,
-				AVG(humidity_percent) as humidity_percent,
-				CASE WHEN AVG(CASE WHEN is_night THEN 1.0 ELSE 0.0 END) > 0.5 THEN 1 ELSE 0 END as is_night,
-				MIN(created_at) as created_at
-			FROM sensor_readings
-			WHERE baby_uid = ? AND timestamp BETWEEN ? AND ?
-			GROUP BY (timestamp / 300)
-			ORDER BY timestamp ASC
-		`
-		args = []interface{}{babyUID, babyUID, startTime, endTime}
-		
+		bucketSeconds = 300
 	} else if timeframeHours <= 168 { // 7 days
-		// 1-7 days: 1-hour averages  
-		query = `
-			SELECT 
-				0 as id,
-				? as baby_uid,
-				(timestamp / 3600) * 3600 as timestamp,
-				AVG(temperature_celsius) as temperature_celsius,
-				AVG(humidity_percent) as humidity_percent,
-				CASE WHEN AVG(CASE WHEN is_night THEN 1.0 ELSE 0.0 END) > 0.5 THEN 1 ELSE 0 END as is_night,
-				MIN(created_at) as created_at
-			FROM sensor_readings
-			WHERE baby_uid = ? AND timestamp BETWEEN ? AND ?
-			GROUP BY (timestamp / 3600)
-			ORDER BY timestamp ASC
-		`
-		args = []interface{}{babyUID, babyUID, startTime, endTime}
-		
+		// 1-7 days: 1-hour averages
+		bucketSeconds = 3600
 	} else {
 		// > 7 days: 6-hour averages
-		query = `
-			SELECT 
-				0 as id,
-				? as baby_uid,
-				(timestamp / 21600) * 21600 as timestamp,
-				AVG(temperature_celsius) as temperature_celsius,
-				AVG(humidity_percent) as humidity_percent,
-				CASE WHEN AVG(CASE WHEN is_night THEN 1.0 ELSE 0.0 END) > 0.5 THEN 1 ELSE 0 END as is_night,
-				MIN(created_at) as created_at
-			FROM sensor_readings
-			WHERE baby_uid = ? AND timestamp BETWEEN ? AND ?
-			GROUP BY (timestamp / 21600)
-			ORDER BY timestamp ASC
-		`
-		args = []interface{}{babyUID, babyUID, startTime, endTime}
+		bucketSeconds = 21600
 	}
-	
-	rows, err := t.db.Query(query, args...)
+
+	query := fmt.Sprintf(`
+		SELECT 
+			0 as id,
+			? as baby_uid,
+			(timestamp / %[1]d) * %[1]d as timestamp,
+			AVG(temperature_celsius) as temperature_celsius,
+			AVG(humidity_percent) as humidity_percent,
+			CASE WHEN AVG(CASE WHEN is_night THEN 1.0 ELSE 0.0 END) > 0.5 THEN 1 ELSE 0 END as is_night,
+			MIN(created_at) as created_at,
+			AVG(raw_temperature_celsius) as raw_temperature_celsius,
+			AVG(raw_humidity_percent) as raw_humidity_percent
+		FROM sensor_readings
+		WHERE baby_uid = ? AND timestamp BETWEEN ? AND ?
+		GROUP BY (timestamp / %[1]d)
+		ORDER BY timestamp ASC
+	`, bucketSeconds)
+
+	return query, []interface{}{babyUID, babyUID, startTime, endTime}, true
+}
+
+// GetSensorReadingsWithSampling retrieves sensor data with intelligent time-based sampling
+func (t *Tracker) GetSensorReadingsWithSampling(babyUID string, startTime, endTime int64) ([]SensorReading, error) {
+	var readings []SensorReading
+	err := t.StreamSensorReadingsWithSampling(babyUID, startTime, endTime, func(r SensorReading) error {
+		readings = append(readings, r)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+
+	return readings, nil
+}
+
+// StreamSensorReadingsWithSampling calls fn for every reading of GetSensorReadingsWithSampling as it is read from the
+// database cursor, so memory use doesn't grow with the time range. Stops at the first error returned by fn.
+func (t *Tracker) StreamSensorReadingsWithSampling(babyUID string, startTime, endTime int64, fn func(SensorReading) error) error {
+	if !t.enabled {
+		return fmt.Errorf("historical tracking disabled")
+	}
+
+	query, args, aggregated := sampledSensorQuery(babyUID, startTime, endTime)
+
+	rows, err := t.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 
-	var readings []SensorReading
 	for rows.Next() {
 		var r SensorReading
 		
-		if timeframeHours <= 6 {
+		if !aggregated {
 			// Raw data - is_night is boolean
 			err := rows.Scan(&r.ID, &r.BabyUID, &r.Timestamp, &r.TemperatureCelsius, 
-				&r.HumidityPercent, &r.IsNight, &r.CreatedAt)
+				&r.HumidityPercent, &r.IsNight, &r.CreatedAt, &r.RawTemperatureCelsius, &r.RawHumidityPercent)
 			if err != nil {
-				return nil, err
+				return err
 			}
 		} else {
 			// Aggregated data - is_night is integer, convert to boolean
 			var isNightInt *int64
 			err := rows.Scan(&r.ID, &r.BabyUID, &r.Timestamp, &r.TemperatureCelsius, 
-				&r.HumidityPercent, &isNightInt, &r.CreatedAt)
+				&r.HumidityPercent, &isNightInt, &r.CreatedAt, &r.RawTemperatureCelsius, &r.RawHumidityPercent)
 			if err != nil {
-				return nil, err
+				return err
 			}
 			
 			// Convert is_night integer back to boolean pointer
@@ -410,18 +534,16 @@ func (t *Tracker) GetSensorReadingsWithSampling(babyUID string, startTime, endTi
 			}
 		}
 		
-		readings = append(readings, r)
+		if err := fn(r); err != nil {
+			return err
+		}
 	}
 
-	return readings, nil
+	return rows.Err()
 }
 
-// GetEvents retrieves events for a time range
-func (t *Tracker) GetEvents(babyUID string, startTime, endTime int64, eventType string, limit int) ([]Event, error) {
-	if !t.enabled {
-		return nil, fmt.Errorf("historical tracking disabled")
-	}
-
+// eventsQuery builds the events query, optionally filtered by event type
+func eventsQuery(babyUID string, startTime, endTime int64, eventType string, limit int) (string, []interface{}) {
 	var query string
 	var args []interface{}
 
@@ -444,24 +566,52 @@ func (t *Tracker) GetEvents(babyUID string, startTime, endTime int64, eventType
 		`
 		args = []interface{}{babyUID, startTime, endTime, limit}
 	}
-	
-	rows, err := t.db.Query(query, args...)
+
+	return query, args
+}
+
+// GetEvents retrieves events for a time range
+func (t *Tracker) GetEvents(babyUID string, startTime, endTime int64, eventType string, limit int) ([]Event, error) {
+	var events []Event
+	err := t.StreamEvents(babyUID, startTime, endTime, eventType, limit, func(e Event) error {
+		events = append(events, e)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+
+	return events, nil
+}
+
+// StreamEvents calls fn for every event of GetEvents as it is read from the database cursor
+// Stops at the first error returned by fn.
+func (t *Tracker) StreamEvents(babyUID string, startTime, endTime int64, eventType string, limit int, fn func(Event) error) error {
+	if !t.enabled {
+		return fmt.Errorf("historical tracking disabled")
+	}
+
+	query, args := eventsQuery(babyUID, startTime, endTime, eventType, limit)
+
+	rows, err := t.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 
-	var events []Event
 	for rows.Next() {
 		var e Event
 		err := rows.Scan(&e.ID, &e.BabyUID, &e.Timestamp, &e.EventType, &e.CreatedAt)
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		if err := fn(e); err != nil {
+			return err
 		}
-		events = append(events, e)
 	}
 
-	return events, nil
+	return rows.Err()
 }
 
 // GetSummary provides aggregated statistics for a time period
@@ -543,13 +693,26 @@ func (t *Tracker) GetDayNightAnalytics(babyUID string, startTime, endTime int64)
 		return nil, fmt.Errorf("historical tracking disabled")
 	}
 
-	analytics := &DayNightAnalytics{
-		BabyUID:   babyUID,
-		StartTime: startTime,
-		EndTime:   endTime,
-		TotalMinutes: (endTime - startTime) / 60,
+	readings, previous, err := t.getNightReadings(babyUID, startTime, endTime)
+	if err != nil {
+		return nil, err
 	}
 
+	analytics := computeDayNightAnalytics(readings, previous, startTime, endTime, int64(t.maxCarryForward/time.Second))
+	analytics.BabyUID = babyUID
+
+	return analytics, nil
+}
+
+// nightReading - is_night state reported at a point in time
+type nightReading struct {
+	timestamp int64
+	isNight   bool
+}
+
+// getNightReadings returns the readings with is_night state of the period in ascending order, and the last one
+// before startTime (nil if none)
+func (t *Tracker) getNightReadings(babyUID string, startTime, endTime int64) ([]nightReading, *nightReading, error) {
 	// Get all sensor readings with is_night data ordered by timestamp
 	query := `
 		SELECT timestamp, is_night
@@ -560,124 +723,124 @@ func (t *Tracker) GetDayNightAnalytics(babyUID string, startTime, endTime int64)
 
 	rows, err := t.db.Query(query, babyUID, startTime, endTime)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
-	var readings []struct {
-		timestamp int64
-		isNight   bool
-	}
-
+	var readings []nightReading
 	for rows.Next() {
-		var reading struct {
-			timestamp int64
-			isNight   bool
-		}
+		var reading nightReading
 		err := rows.Scan(&reading.timestamp, &reading.isNight)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		readings = append(readings, reading)
 	}
 
-	if len(readings) == 0 {
-		// No readings in this time period, check for last known state before this period
-		lastKnownQuery := `
-			SELECT is_night
-			FROM sensor_readings
-			WHERE baby_uid = ? AND timestamp < ? AND is_night IS NOT NULL
-			ORDER BY timestamp DESC
-			LIMIT 1
-		`
-		
-		var lastKnownState bool
-		err := t.db.QueryRow(lastKnownQuery, babyUID, startTime).Scan(&lastKnownState)
-		if err != nil {
-			// No previous state found, mark as unknown
-			analytics.UnknownModeMinutes = analytics.TotalMinutes
-			analytics.UnknownModePercentage = 100.0
-			return analytics, nil
-		}
-		
-		// Carry forward the last known state for the entire period
-		if lastKnownState {
-			analytics.NightModeMinutes = analytics.TotalMinutes
-			analytics.NightModePercentage = 100.0
-		} else {
-			analytics.DayModeMinutes = analytics.TotalMinutes
-			analytics.DayModePercentage = 100.0
-		}
-		
-		return analytics, nil
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
 	}
 
-	// Calculate time spent in each mode and transitions
-	var dayModeSeconds int64
-	var nightModeSeconds int64
-	var transitions int64
-	var changes []DayNightChange
-
-	// Determine initial mode - check for last known state before this period
-	var currentMode bool
-	
+	// Last known state before this period
 	lastKnownQuery := `
-		SELECT is_night
+		SELECT timestamp, is_night
 		FROM sensor_readings
 		WHERE baby_uid = ? AND timestamp < ? AND is_night IS NOT NULL
 		ORDER BY timestamp DESC
 		LIMIT 1
 	`
-	
-	err = t.db.QueryRow(lastKnownQuery, babyUID, startTime).Scan(&currentMode)
-	if err != nil {
-		// No previous state, use the first reading's state
-		currentMode = readings[0].isNight
+
+	var previous *nightReading
+	var lastKnown nightReading
+	if err := t.db.QueryRow(lastKnownQuery, babyUID, startTime).Scan(&lastKnown.timestamp, &lastKnown.isNight); err == nil {
+		previous = &lastKnown
 	}
-	
-	currentModeStart := startTime
 
-	// Add time from startTime to first reading
-	firstReadingDuration := readings[0].timestamp - startTime
-	if currentMode {
-		nightModeSeconds += firstReadingDuration
-	} else {
-		dayModeSeconds += firstReadingDuration
-	}
-
-	// Process all readings
-	for i, reading := range readings {
-		// Check for mode transition
-		if reading.isNight != currentMode {
-			// Record the transition
-			change := DayNightChange{
-				Timestamp:    reading.timestamp,
-				FromNight:    currentMode,
-				ToNight:      reading.isNight,
-				DurationMins: (reading.timestamp - currentModeStart) / 60,
-			}
-			changes = append(changes, change)
-			
-			transitions++
-			currentMode = reading.isNight
-			currentModeStart = reading.timestamp
+	return readings, previous, nil
+}
+
+// carriedSeconds - returns how much of [from, to) the state observed at anchor covers
+// A state is carried forward at most maxGap seconds past the reading (backward for the period before the first
+// reading), 0 carries it without limit.
+func carriedSeconds(from, to, anchor, maxGap int64, backward bool) int64 {
+	if maxGap > 0 {
+		if backward {
+			from = max(from, anchor-maxGap)
+		} else {
+			to = min(to, anchor+maxGap)
 		}
+	}
+
+	return max(to-from, 0)
+}
+
+// computeDayNightAnalytics - attributes the time between readings to the mode of the preceding reading
+// Time the mode is not carried to (see carriedSeconds) is reported as unknown. previous is the last reading before
+// startTime, if any.
+func computeDayNightAnalytics(readings []nightReading, previous *nightReading, startTime, endTime int64, maxGap int64) *DayNightAnalytics {
+	analytics := &DayNightAnalytics{
+		StartTime:    startTime,
+		EndTime:      endTime,
+		TotalMinutes: (endTime - startTime) / 60,
+	}
+
+	// Calculate time spent in each mode and transitions
+	var dayModeSeconds int64
+	var nightModeSeconds int64
+	var transitions int64
+	var changes []DayNightChange
 
-		// Calculate duration until next reading (or end time)
-		var duration int64
-		if i < len(readings)-1 {
-			// Duration until next reading
-			duration = readings[i+1].timestamp - reading.timestamp
+	attribute := func(isNight bool, seconds int64) {
+		if isNight {
+			nightModeSeconds += seconds
 		} else {
-			// Duration from last reading to end time
-			duration = endTime - reading.timestamp
+			dayModeSeconds += seconds
 		}
+	}
 
-		// Attribute this duration to the current mode
-		if currentMode {
-			nightModeSeconds += duration
+	if len(readings) == 0 {
+		// No readings in this time period, carry forward the last known state before this period
+		if previous != nil {
+			attribute(previous.isNight, carriedSeconds(startTime, endTime, previous.timestamp, maxGap, false))
+		}
+	} else {
+		// Determine initial mode, time from startTime to the first reading
+		var currentMode bool
+		if previous != nil {
+			currentMode = previous.isNight
+			attribute(currentMode, carriedSeconds(startTime, readings[0].timestamp, previous.timestamp, maxGap, false))
 		} else {
-			dayModeSeconds += duration
+			// No previous state, use the first reading's state
+			currentMode = readings[0].isNight
+			attribute(currentMode, carriedSeconds(startTime, readings[0].timestamp, readings[0].timestamp, maxGap, true))
+		}
+
+		currentModeStart := startTime
+
+		// Process all readings
+		for i, reading := range readings {
+			// Check for mode transition
+			if reading.isNight != currentMode {
+				// Record the transition
+				changes = append(changes, DayNightChange{
+					Timestamp:    reading.timestamp,
+					FromNight:    currentMode,
+					ToNight:      reading.isNight,
+					DurationMins: (reading.timestamp - currentModeStart) / 60,
+				})
+
+				transitions++
+				currentMode = reading.isNight
+				currentModeStart = reading.timestamp
+			}
+
+			// Duration until next reading (or end time)
+			next := endTime
+			if i < len(readings)-1 {
+				next = readings[i+1].timestamp
+			}
+
+			attribute(currentMode, carriedSeconds(reading.timestamp, next, reading.timestamp, maxGap, false))
 		}
 	}
 
@@ -694,7 +857,18 @@ func (t *Tracker) GetDayNightAnalytics(babyUID string, startTime, endTime int64)
 		analytics.UnknownModePercentage = float64(analytics.UnknownModeMinutes) / float64(analytics.TotalMinutes) * 100
 	}
 
-	return analytics, nil
+	return analytics
+}
+
+// SetMaxCarryForward sets the longest gap after a reading its is_night state is assumed in day/night analytics
+// Longer gaps (e.g. while the camera was offline) are reported as unknown, 0 carries the state without limit.
+func (t *Tracker) SetMaxCarryForward(maxGap time.Duration) {
+	t.maxCarryForward = maxGap
+}
+
+// SetStoreRawSensorData sets whether the values reported by the camera are stored next to the calibrated ones
+func (t *Tracker) SetStoreRawSensorData(enabled bool) {
+	t.storeRawSensorData = enabled
 }
 
 // calculateDayNightStats is a helper method for summary calculations
@@ -723,7 +897,7 @@ func (t *Tracker) Cleanup(retentionDays int) error {
 	
 	for _, table := range tables {
 		query := fmt.Sprintf("DELETE FROM %s WHERE created_at < ?", table)
-		result, err := t.db.Exec(query, cutoffTime)
+		result, err := t.execWrite(query, cutoffTime)
 		if err != nil {
 			log.Error().Err(err).Str("table", table).Msg("Failed to cleanup old data")
 			continue
@@ -735,9 +909,17 @@ func (t *Tracker) Cleanup(retentionDays int) error {
 		}
 	}
 	
+	// Alerts which are still active are kept regardless of their age
+	if result, err := t.execWrite("DELETE FROM device_alerts WHERE resolved_at < ?", cutoffTime); err != nil {
+		log.Error().Err(err).Str("table", "device_alerts").Msg("Failed to cleanup old data")
+	} else if deleted, err := result.RowsAffected(); err == nil {
+		totalDeleted += int(deleted)
+		log.Debug().Str("table", "device_alerts").Int64("deleted", deleted).Msg("Cleaned up old records")
+	}
+
 	if totalDeleted > 0 {
 		// Vacuum database to reclaim space
-		if _, err := t.db.Exec("VACUUM"); err != nil {
+		if _, err := t.execWrite("VACUUM"); err != nil {
 			log.Warn().Err(err).Msg("Failed to vacuum database after cleanup")
 		}
 		
@@ -754,12 +936,12 @@ func (t *Tracker) ResetData(babyUID string) (int, error) {
 		return 0, fmt.Errorf("historical tracking disabled")
 	}
 
-	tables := []string{"sensor_readings", "events", "state_changes"}
+	tables := []string{"sensor_readings", "events", "state_changes", "device_alerts"}
 	totalDeleted := 0
 	
 	for _, table := range tables {
 		query := fmt.Sprintf("DELETE FROM %s WHERE baby_uid = ?", table)
-		result, err := t.db.Exec(query, babyUID)
+		result, err := t.execWrite(query, babyUID)
 		if err != nil {
 			log.Error().Err(err).Str("table", table).Str("baby_uid", babyUID).Msg("Failed to reset data from table")
 			return totalDeleted, err
@@ -773,7 +955,7 @@ func (t *Tracker) ResetData(babyUID string) (int, error) {
 	
 	if totalDeleted > 0 {
 		// Vacuum database to reclaim space
-		if _, err := t.db.Exec("VACUUM"); err != nil {
+		if _, err := t.execWrite("VACUUM"); err != nil {
 			log.Warn().Err(err).Msg("Failed to vacuum database after reset")
 		}
 		
@@ -784,6 +966,115 @@ func (t *Tracker) ResetData(babyUID string) (int, error) {
 	return totalDeleted, nil
 }
 
+// Time series tables of the history, deletable by time range
+const (
+	TableSensorReadings = "sensor_readings"
+	TableEvents         = "events"
+	TableStateChanges   = "state_changes"
+)
+
+// ErrUnknownTable - the table is not a time series table of the history
+var ErrUnknownTable = errors.New("unknown history table")
+
+// DeleteRange deletes the rows of a baby with timestamps between start and end (inclusive) from one table
+// Returns the number of deleted rows. Unlike ResetData it doesn't vacuum the database.
+func (t *Tracker) DeleteRange(babyUID string, table string, start, end int64) (int, error) {
+	if !t.enabled {
+		return 0, fmt.Errorf("historical tracking disabled")
+	}
+
+	switch table {
+	case TableSensorReadings, TableEvents, TableStateChanges:
+	default:
+		return 0, fmt.Errorf("%w: %s", ErrUnknownTable, table)
+	}
+
+	// Table name is checked above, not user input
+	result, err := t.execWrite("DELETE FROM "+table+" WHERE baby_uid = ? AND timestamp BETWEEN ? AND ?", babyUID, start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	log.Info().Str("table", table).Str("baby_uid", babyUID).Int64("start", start).Int64("end", end).Int64("deleted", deleted).
+		Msg("Deleted historical data range")
+
+	return int(deleted), nil
+}
+
+// GetDBSize returns size of the database in bytes, including the WAL and shared memory files
+func (t *Tracker) GetDBSize() (int64, error) {
+	if !t.enabled {
+		return 0, nil
+	}
+
+	var size int64
+	for _, path := range []string{t.dbPath, t.dbPath + "-wal", t.dbPath + "-shm"} {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+
+		size += info.Size()
+	}
+
+	return size, nil
+}
+
+// CreateBackup writes a consistent copy of the database into a new file next to it, returns its path
+// VACUUM INTO reads a single snapshot, so writes going to the WAL meanwhile don't tear the copy. The caller
+// removes the file.
+func (t *Tracker) CreateBackup() (string, error) {
+	if !t.enabled {
+		return "", fmt.Errorf("historical tracking disabled")
+	}
+
+	// Same directory keeps the copy on the volume of the database instead of a possibly small tmpfs
+	f, err := os.CreateTemp(filepath.Dir(t.dbPath), ".history-backup-*.db")
+	if err != nil {
+		return "", err
+	}
+	filename := f.Name()
+	f.Close()
+
+	// VACUUM INTO refuses to overwrite an existing file, only the unique name is kept
+	if err := os.Remove(filename); err != nil {
+		return "", err
+	}
+
+	if _, err := t.db.Exec("VACUUM INTO ?", filename); err != nil {
+		os.Remove(filename)
+		return "", err
+	}
+
+	return filename, nil
+}
+
+// GetRowCounts returns number of stored rows per table
+func (t *Tracker) GetRowCounts() (map[string]int64, error) {
+	if !t.enabled {
+		return nil, fmt.Errorf("historical tracking disabled")
+	}
+
+	counts := make(map[string]int64)
+	for _, table := range []string{"sensor_readings", "events", "state_changes"} {
+		var count int64
+		// Table names are fixed, not user input
+		if err := t.db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+			return nil, err
+		}
+		counts[table] = count
+	}
+
+	return counts, nil
+}
+
 // IsEnabled returns whether historical tracking is enabled
 func (t *Tracker) IsEnabled() bool {
 	return t.enabled
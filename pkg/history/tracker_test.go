@@ -0,0 +1,160 @@
+package history_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/history"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentWritesAndReads(t *testing.T) {
+	tracker, err := history.NewTracker(t.TempDir(), true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tracker.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, tracker.TrackEvent("baby1", "motion", time.Now().Unix()))
+			assert.NoError(t, tracker.TrackStateChange("baby1", "night_light", true))
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := tracker.GetEvents("baby1", 0, time.Now().Unix()+1, "", 100)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	events, err := tracker.GetEvents("baby1", 0, time.Now().Unix()+1, "", 100)
+	assert.NoError(t, err)
+	assert.Len(t, events, 20)
+}
+
+func TestGetRowCounts(t *testing.T) {
+	tracker, err := history.NewTracker(t.TempDir(), true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tracker.Close()
+
+	assert.NoError(t, tracker.TrackEvent("baby1", "motion", time.Now().Unix()))
+	assert.NoError(t, tracker.TrackEvent("baby1", "sound", time.Now().Unix()))
+	assert.NoError(t, tracker.TrackStateChange("baby1", "standby", true))
+
+	counts, err := tracker.GetRowCounts()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"sensor_readings": 0, "events": 2, "state_changes": 1}, counts)
+
+	_, err = (&history.Tracker{}).GetRowCounts()
+	assert.Error(t, err)
+}
+
+func TestTrackSensorDataRawValues(t *testing.T) {
+	tracker, err := history.NewTracker(t.TempDir(), true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tracker.Close()
+
+	state := baby.NewState().SetTemperatureMilli(21000).SetHumidityMilli(48000)
+	state.RawTemperatureMilli = utils.ConstRefInt32(22500)
+	state.RawHumidityMilli = utils.ConstRefInt32(45000)
+
+	// Raw values are only stored if enabled
+	assert.NoError(t, tracker.TrackSensorData("baby1", *state))
+	tracker.SetStoreRawSensorData(true)
+	assert.NoError(t, tracker.TrackSensorData("baby2", *state))
+
+	readings, err := tracker.GetSensorReadings("baby1", 0, time.Now().Unix()+1, 10)
+	if assert.NoError(t, err) && assert.Len(t, readings, 1) {
+		assert.Equal(t, 21.0, *readings[0].TemperatureCelsius)
+		assert.Nil(t, readings[0].RawTemperatureCelsius)
+		assert.Nil(t, readings[0].RawHumidityPercent)
+	}
+
+	readings, err = tracker.GetSensorReadingsWithSampling("baby2", time.Now().Unix()-60, time.Now().Unix()+1)
+	if assert.NoError(t, err) && assert.Len(t, readings, 1) {
+		assert.Equal(t, 48.0, *readings[0].HumidityPercent)
+		assert.Equal(t, 22.5, *readings[0].RawTemperatureCelsius)
+		assert.Equal(t, 45.0, *readings[0].RawHumidityPercent)
+	}
+}
+
+func TestDeleteRange(t *testing.T) {
+	tracker, err := history.NewTracker(t.TempDir(), true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tracker.Close()
+
+	assert.NoError(t, tracker.TrackEvent("baby1", "motion", 1000))
+	assert.NoError(t, tracker.TrackEvent("baby1", "motion", 2000))
+	assert.NoError(t, tracker.TrackEvent("baby1", "sound", 3000))
+	assert.NoError(t, tracker.TrackEvent("baby2", "motion", 2000))
+	assert.NoError(t, tracker.TrackStateChange("baby1", "standby", true))
+
+	deleted, err := tracker.DeleteRange("baby1", history.TableEvents, 1500, 3000)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	// Other babies, tables and times are kept
+	events, err := tracker.GetEvents("baby1", 0, 5000, "", 100)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, int64(1000), events[0].Timestamp)
+	}
+
+	counts, err := tracker.GetRowCounts()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"sensor_readings": 0, "events": 2, "state_changes": 1}, counts)
+
+	_, err = tracker.DeleteRange("baby1", "device_alerts", 0, 5000)
+	assert.True(t, errors.Is(err, history.ErrUnknownTable))
+}
+
+func TestCreateBackup(t *testing.T) {
+	tracker, err := history.NewTracker(t.TempDir(), true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tracker.Close()
+
+	assert.NoError(t, tracker.TrackEvent("baby1", "motion", time.Now().Unix()))
+	assert.NoError(t, tracker.TrackStateChange("baby1", "standby", true))
+
+	filename, err := tracker.CreateBackup()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.Remove(filename)
+
+	// The copy opens as a regular history database
+	data, err := os.ReadFile(filename)
+	if !assert.NoError(t, err) {
+		return
+	}
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "history.db"), data, 0644))
+
+	restored, err := history.NewTracker(dir, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer restored.Close()
+
+	counts, err := restored.GetRowCounts()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"sensor_readings": 0, "events": 1, "state_changes": 1}, counts)
+}
@@ -7,4 +7,8 @@ const (
 	MotionEventMessageType = "MOTION"
 	// TemperatureEventMessageType is for working with temperature event messages
 	TemperatureEventMessageType = "TEMPERATURE"
+	// HumidityEventMessageType is for working with humidity event messages
+	HumidityEventMessageType = "HUMIDITY"
+	// CryEventMessageType is for working with cry detection event messages
+	CryEventMessageType = "CRY_DETECTION"
 )
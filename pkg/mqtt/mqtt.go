@@ -3,14 +3,46 @@ package mqtt
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	MQTT "github.com/eclipse/paho.mqtt.golang"
 	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/health"
+	"github.com/indiefan/home_assistant_nanit/pkg/resilience"
 	"github.com/indiefan/home_assistant_nanit/pkg/utils"
 	"github.com/rs/zerolog/log"
 )
 
+// HealthService - name under which the broker connection is tracked in the health manager
+const HealthService = "mqtt"
+
+// ConnectionState - state of the broker connection
+type ConnectionState string
+
+const (
+	ConnectionState_Disconnected ConnectionState = "disconnected"
+	ConnectionState_Connected    ConnectionState = "connected"
+	ConnectionState_Reconnecting ConnectionState = "reconnecting"
+)
+
+// reconnectRetryConfig - backoff used when (re)connecting to the broker
+// Note: once exhausted, the runner cooldown applies before trying again
+var reconnectRetryConfig = resilience.RetryConfig{
+	MaxRetries:    5,
+	InitialDelay:  time.Second,
+	MaxDelay:      30 * time.Second,
+	BackoffFactor: 2.0,
+	Jitter:        true,
+}
+
+// eventKeys - state keys representing momentary events, these are never retained
+var eventKeys = map[string]bool{
+	"motion_timestamp": true,
+	"sound_timestamp":  true,
+	"stream_event":     true,
+}
+
 type SendLightCommandHandler func(nightLightState bool)
 type SendStandbyCommandHandler func(standbyState bool)
 
@@ -21,15 +53,58 @@ type Connection struct {
 	client                    MQTT.Client
 	sendLightCommandHandler   SendLightCommandHandler
 	sendStandbyCommandHandler SendStandbyCommandHandler
+	HealthManager             *health.HealthManager
+
+	stateMutex     sync.RWMutex
+	state          ConnectionState
+	connectionLost chan error
 }
 
 // NewConnection - constructor
 func NewConnection(opts Opts) *Connection {
 	return &Connection{
-		Opts: opts,
+		Opts:           opts,
+		state:          ConnectionState_Disconnected,
+		connectionLost: make(chan error, 1),
+	}
+}
+
+// GetState - returns current state of the broker connection
+func (conn *Connection) GetState() ConnectionState {
+	conn.stateMutex.RLock()
+	defer conn.stateMutex.RUnlock()
+	return conn.state
+}
+
+func (conn *Connection) setState(state ConnectionState, err error) {
+	conn.stateMutex.Lock()
+	conn.state = state
+	conn.stateMutex.Unlock()
+
+	if conn.HealthManager == nil {
+		return
+	}
+
+	details := map[string]interface{}{"broker_url": conn.Opts.BrokerURL}
+	if err != nil {
+		details["error"] = err.Error()
+	}
+
+	switch state {
+	case ConnectionState_Connected:
+		conn.HealthManager.SetServiceHealthy(HealthService, "Connected to MQTT broker")
+	case ConnectionState_Reconnecting:
+		conn.HealthManager.SetServiceDegraded(HealthService, "Reconnecting to MQTT broker", details)
+	default:
+		conn.HealthManager.SetServiceUnhealthy(HealthService, "Disconnected from MQTT broker", details)
 	}
 }
 
+// availabilityTopic - topic holding online/offline status of the integration
+func (conn *Connection) availabilityTopic() string {
+	return fmt.Sprintf("%v/status", conn.Opts.TopicPrefix)
+}
+
 // Run - runs the mqtt connection handler
 func (conn *Connection) Run(manager *baby.StateManager, ctx utils.GracefulContext) {
 	conn.StateManager = manager
@@ -41,6 +116,16 @@ func (conn *Connection) Run(manager *baby.StateManager, ctx utils.GracefulContex
 	opts.SetPassword(conn.Opts.Password)
 	opts.SetCleanSession(false)
 
+	// Reconnection is handled by runMqtt so that it is visible in logs and health status
+	opts.SetAutoReconnect(false)
+	opts.SetWill(conn.availabilityTopic(), "offline", conn.Opts.QoS, true)
+	opts.SetConnectionLostHandler(func(client MQTT.Client, err error) {
+		select {
+		case conn.connectionLost <- err:
+		default:
+		}
+	})
+
 	conn.client = MQTT.NewClient(opts)
 
 	utils.RunWithPerseverance(func(attempt utils.AttemptContext) {
@@ -99,7 +184,7 @@ func (conn *Connection) subscribeToLightCommand() {
 		}
 	}
 
-	if token := conn.client.Subscribe(commandTopic, 0, lightMessageHandler); token.Wait() && token.Error() != nil {
+	if token := conn.client.Subscribe(commandTopic, conn.Opts.QoS, lightMessageHandler); token.Wait() && token.Error() != nil {
 		log.Error().Err(token.Error()).Str("topic", commandTopic).Msg("Failed to subscribe to command topic")
 	}
 }
@@ -147,49 +232,145 @@ func (conn *Connection) subscribeToStandbyCommand() {
 		}
 	}
 
-	if token := conn.client.Subscribe(commandTopic, 0, standbyMessageHandler); token.Wait() && token.Error() != nil {
+	if token := conn.client.Subscribe(commandTopic, conn.Opts.QoS, standbyMessageHandler); token.Wait() && token.Error() != nil {
 		log.Error().Err(token.Error()).Str("topic", commandTopic).Msg("Failed to subscribe to command topic")
 	}
 }
 
 func runMqtt(conn *Connection, attempt utils.AttemptContext) {
-
-	if token := conn.client.Connect(); token.Wait() && token.Error() != nil {
-		log.Error().Str("broker_url", conn.Opts.BrokerURL).Err(token.Error()).Msg("Unable to connect to MQTT broker")
-		attempt.Fail(token.Error())
-		return
+	// Drop connection loss reported by the previous attempt
+	select {
+	case <-conn.connectionLost:
+	default:
 	}
 
-	log.Info().Str("broker_url", conn.Opts.BrokerURL).Msg("Successfully connected to MQTT broker")
-
+	// Updates while disconnected are dropped, connect publishes the full state once the broker is back
 	unsubscribe := conn.StateManager.Subscribe(func(babyUID string, state baby.State) {
-		publish := func(key string, value interface{}) {
-			topic := fmt.Sprintf("%v/babies/%v/%v", conn.Opts.TopicPrefix, babyUID, key)
-			log.Trace().Str("topic", topic).Interface("value", value).Msg("MQTT publish")
+		if !conn.client.IsConnected() {
+			return
+		}
 
-			token := conn.client.Publish(topic, 0, false, fmt.Sprintf("%v", value))
-			if token.Wait(); token.Error() != nil {
-				log.Error().Err(token.Error()).Msgf("Unable to publish %v update", key)
+		conn.publishState(babyUID, state)
+	})
+	defer unsubscribe()
+
+	for {
+		if err := conn.connect(attempt); err != nil {
+			conn.setState(ConnectionState_Disconnected, err)
+			attempt.Fail(err)
+			return
+		}
+
+		select {
+		case <-attempt.Done():
+			log.Debug().Msg("Closing MQTT connection on interrupt")
+			if conn.client.IsConnected() {
+				conn.publishAvailability("offline")
+				conn.client.Disconnect(250)
 			}
+			conn.setState(ConnectionState_Disconnected, nil)
+			return
+
+		case err := <-conn.connectionLost:
+			log.Warn().Err(err).Str("broker_url", conn.Opts.BrokerURL).Msg("Lost connection to MQTT broker, reconnecting")
+			conn.setState(ConnectionState_Reconnecting, err)
 		}
+	}
+}
+
+// publishState - publishes the values of the baby set in the state
+func (conn *Connection) publishState(babyUID string, state baby.State) {
+	for key, value := range state.AsMap(false) {
+		conn.publish(babyUID, key, value)
+	}
+
+	if state.StreamState != nil && *state.StreamState != baby.StreamState_Unknown {
+		conn.publish(babyUID, "is_stream_alive", *state.StreamState == baby.StreamState_Alive)
+	}
+}
+
+// publishSnapshot - publishes the current state of all babies, so retained topics catch up with changes
+// made while the broker was unreachable
+func (conn *Connection) publishSnapshot() {
+	for babyUID, state := range conn.StateManager.GetAllBabyStates() {
+		conn.publishState(babyUID, state)
+	}
+}
+
+// publish - publishes a value of the baby, event keys are never retained
+func (conn *Connection) publish(babyUID string, key string, value interface{}) {
+	topic := fmt.Sprintf("%v/babies/%v/%v", conn.Opts.TopicPrefix, babyUID, key)
+	retain := conn.Opts.RetainState && !eventKeys[key]
+	log.Trace().Str("topic", topic).Interface("value", value).Bool("retain", retain).Msg("MQTT publish")
 
-		for key, value := range state.AsMap(false) {
-			publish(key, value)
+	token := conn.client.Publish(topic, conn.Opts.QoS, retain, fmt.Sprintf("%v", value))
+	if token.Wait(); token.Error() != nil {
+		log.Error().Err(token.Error()).Msgf("Unable to publish %v update", key)
+	}
+}
+
+// PublishStreamEvent - publishes a stream health transition of the baby (e.g. "disconnect") to babies/<uid>/stream_event
+// Events are dropped while the broker is disconnected.
+func (conn *Connection) PublishStreamEvent(babyUID string, eventType string) {
+	if conn.client == nil || !conn.client.IsConnected() {
+		return
+	}
+
+	conn.publish(babyUID, "stream_event", eventType)
+}
+
+// connect - connects to the broker with exponential backoff, then announces availability and subscribes to commands
+func (conn *Connection) connect(attempt utils.AttemptContext) error {
+	connectAttempt := 0
+	err := resilience.RetryWithExponentialBackoff("mqtt_connect", reconnectRetryConfig, func() error {
+		// Stop retrying on interrupt
+		select {
+		case <-attempt.Done():
+			return nil
+		default:
 		}
 
-		if state.StreamState != nil && *state.StreamState != baby.StreamState_Unknown {
-			publish("is_stream_alive", *state.StreamState == baby.StreamState_Alive)
+		connectAttempt++
+		log.Debug().Str("broker_url", conn.Opts.BrokerURL).Int("attempt", connectAttempt).Msg("Connecting to MQTT broker")
+
+		if token := conn.client.Connect(); token.Wait() && token.Error() != nil {
+			log.Error().Str("broker_url", conn.Opts.BrokerURL).Err(token.Error()).Msg("Unable to connect to MQTT broker")
+			if conn.GetState() != ConnectionState_Reconnecting {
+				conn.setState(ConnectionState_Disconnected, token.Error())
+			}
+			return token.Error()
 		}
+
+		return nil
 	})
 
+	if err != nil {
+		return err
+	}
+
+	// Interrupted before connecting, runMqtt handles the shutdown
+	if !conn.client.IsConnected() {
+		return nil
+	}
+
+	log.Info().Str("broker_url", conn.Opts.BrokerURL).Msg("Successfully connected to MQTT broker")
+	conn.setState(ConnectionState_Connected, nil)
+	conn.publishAvailability("online")
+
 	// Subscribe to accept light mqtt messages
 	conn.subscribeToLightCommand()
 	conn.subscribeToStandbyCommand()
 
-	// Wait until interrupt signal is received
-	<-attempt.Done()
+	// Auto-reconnect is disabled, so this runs on the initial connection and on every reconnection
+	conn.publishSnapshot()
+
+	return nil
+}
 
-	log.Debug().Msg("Closing MQTT connection on interrupt")
-	unsubscribe()
-	conn.client.Disconnect(250)
+// publishAvailability - publishes retained online/offline status (broker publishes offline on unexpected disconnect)
+func (conn *Connection) publishAvailability(status string) {
+	token := conn.client.Publish(conn.availabilityTopic(), conn.Opts.QoS, true, status)
+	if token.Wait(); token.Error() != nil {
+		log.Error().Err(token.Error()).Str("status", status).Msg("Unable to publish MQTT availability")
+	}
 }
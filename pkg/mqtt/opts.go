@@ -9,4 +9,7 @@ type Opts struct {
 	Password string
 
 	TopicPrefix string
+
+	QoS         byte // Delivery guarantee for published and subscribed topics (0, 1 or 2)
+	RetainState bool // Publish state topics as retained so that subscribers get the last value on (re)connect
 }
@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/resilience"
+	"github.com/rs/zerolog/log"
+)
+
+// Event types
+const (
+	EventMotion          = "motion"
+	EventSound           = "sound"
+	EventTemperatureHigh = "temperature_high"
+	EventTemperatureLow  = "temperature_low"
+	EventHumidityHigh    = "humidity_high"
+	EventHumidityLow     = "humidity_low"
+
+	// Alerts reported by the Nanit cloud (event polling)
+	EventTemperatureAlert = "temperature_alert"
+	EventHumidityAlert    = "humidity_alert"
+	EventCry              = "cry"
+
+	// Stream health transitions
+	EventDisconnect      = "disconnect"       // Camera WebSocket went down
+	EventReconnect       = "reconnect"        // Camera WebSocket is back after a disconnect
+	EventStreamUnhealthy = "stream_unhealthy" // RTMP stream stopped delivering video
+	EventStreamAlive     = "stream_alive"     // RTMP stream recovered after being unhealthy
+	EventStreamBlocked   = "stream_blocked"   // Stream request declined by the Nanit mobile app connection limit
+)
+
+// Event - payload describing a single notification
+type Event struct {
+	BabyUID   string   `json:"baby_uid"`
+	BabyName  string   `json:"baby_name"`
+	EventType string   `json:"event_type"`
+	Timestamp int64    `json:"timestamp"`
+	Message   string   `json:"message"`
+	Value     *float64 `json:"value,omitempty"`
+}
+
+// Notifier - sends events to the configured webhook
+type Notifier struct {
+	Opts       Opts
+	httpClient *http.Client
+	template   *template.Template
+	retry      resilience.RetryConfig
+
+	activeConds map[string]bool
+	mutex       sync.Mutex
+}
+
+// NewNotifier - constructor
+func NewNotifier(opts Opts) (*Notifier, error) {
+	notifier := &Notifier{
+		Opts:        opts,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		retry:       resilience.DefaultRetryConfig(),
+		activeConds: make(map[string]bool),
+	}
+
+	if opts.PayloadTemplate != "" {
+		tpl, err := template.New("payload").Funcs(template.FuncMap{
+			// json - encodes a value so it can be safely embedded in a JSON template
+			"json": func(v interface{}) (string, error) {
+				b, err := json.Marshal(v)
+				return string(b), err
+			},
+		}).Parse(opts.PayloadTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook payload template: %w", err)
+		}
+		notifier.template = tpl
+	}
+
+	return notifier, nil
+}
+
+// Notify - sends the event in the background
+// Repeated events are throttled by the caller (see the app's event cooldowns), not here.
+func (n *Notifier) Notify(event Event) {
+	go n.send(event)
+}
+
+// NotifyCondition - sends the event only when the condition becomes active (e.g. threshold crossed).
+// The condition has to clear before the event can fire again.
+func (n *Notifier) NotifyCondition(event Event, active bool) {
+	key := event.BabyUID + ":" + event.EventType
+
+	n.mutex.Lock()
+	wasActive := n.activeConds[key]
+	n.activeConds[key] = active
+	n.mutex.Unlock()
+
+	if active && !wasActive {
+		n.Notify(event)
+	}
+}
+
+func (n *Notifier) send(event Event) {
+	body, err := n.renderPayload(event)
+	if err != nil {
+		log.Error().Err(err).Str("event_type", event.EventType).Msg("Failed to render webhook payload")
+		return
+	}
+
+	err = resilience.RetryWithExponentialBackoff("webhook", n.retry, func() error {
+		resp, err := n.httpClient.Post(n.Opts.WebhookURL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		log.Error().Err(err).Str("baby_uid", event.BabyUID).Str("event_type", event.EventType).Msg("Failed to deliver webhook notification")
+		return
+	}
+
+	log.Debug().Str("baby_uid", event.BabyUID).Str("event_type", event.EventType).Msg("Webhook notification sent")
+}
+
+func (n *Notifier) renderPayload(event Event) ([]byte, error) {
+	if n.template == nil {
+		return json.Marshal(event)
+	}
+
+	var buf bytes.Buffer
+	if err := n.template.Execute(&buf, event); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
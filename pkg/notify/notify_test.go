@@ -0,0 +1,95 @@
+package notify_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/notify"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, chan string) {
+	bodies := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, bodies
+}
+
+func receive(t *testing.T, bodies chan string) string {
+	select {
+	case body := <-bodies:
+		return body
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not called")
+		return ""
+	}
+}
+
+func assertNothingReceived(t *testing.T, bodies chan string) {
+	select {
+	case body := <-bodies:
+		t.Fatalf("unexpected webhook call: %s", body)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestNotifyDefaultPayload(t *testing.T) {
+	server, bodies := newTestServer(t)
+
+	notifier, err := notify.NewNotifier(notify.Opts{WebhookURL: server.URL})
+	assert.NoError(t, err)
+
+	notifier.Notify(notify.Event{BabyUID: "abc", BabyName: "Baby", EventType: notify.EventMotion, Timestamp: 1700000000})
+
+	assert.JSONEq(t, `{"baby_uid":"abc","baby_name":"Baby","event_type":"motion","timestamp":1700000000,"message":""}`, receive(t, bodies))
+}
+
+func TestNotifyTemplatePayload(t *testing.T) {
+	server, bodies := newTestServer(t)
+
+	notifier, err := notify.NewNotifier(notify.Opts{
+		WebhookURL:      server.URL,
+		PayloadTemplate: `{"content":{{json .Message}}}`,
+	})
+	assert.NoError(t, err)
+
+	notifier.Notify(notify.Event{BabyUID: "abc", EventType: notify.EventSound, Message: `Sound "detected"`})
+
+	assert.JSONEq(t, `{"content":"Sound \"detected\""}`, receive(t, bodies))
+}
+
+func TestNotifyInvalidTemplate(t *testing.T) {
+	_, err := notify.NewNotifier(notify.Opts{WebhookURL: "http://localhost", PayloadTemplate: "{{.Broken"})
+	assert.Error(t, err)
+}
+
+func TestNotifyCondition(t *testing.T) {
+	server, bodies := newTestServer(t)
+
+	notifier, err := notify.NewNotifier(notify.Opts{WebhookURL: server.URL})
+	assert.NoError(t, err)
+
+	event := notify.Event{BabyUID: "abc", EventType: notify.EventTemperatureHigh}
+
+	notifier.NotifyCondition(event, false)
+	assertNothingReceived(t, bodies)
+
+	notifier.NotifyCondition(event, true)
+	receive(t, bodies)
+
+	// Still above threshold, no repeated notification
+	notifier.NotifyCondition(event, true)
+	assertNothingReceived(t, bodies)
+
+	// Cleared and crossed again
+	notifier.NotifyCondition(event, false)
+	notifier.NotifyCondition(event, true)
+	receive(t, bodies)
+}
@@ -0,0 +1,10 @@
+package notify
+
+// Opts - holds configuration of webhook notifications
+type Opts struct {
+	// URL to which the event payloads are POSTed
+	WebhookURL string
+
+	// Optional text/template of the request body, the JSON encoded Event is sent if empty
+	PayloadTemplate string
+}
@@ -2,6 +2,7 @@ package resilience
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -38,69 +39,116 @@ type CircuitBreaker struct {
 	failures       int
 	requests       int
 	lastFailTime   time.Time
+	openedAt       time.Time // When the breaker tripped last, it stays open for resetTimeout since
+	successes      int       // consecutive successes while half-open
+	probes         int       // Half-open probes in flight
+	halfOpens      int       // Transitions to half-open, tells probes of an earlier half-open period apart
 	mutex          sync.RWMutex
 	
 	// Configuration
-	maxFailures    int
-	timeout        time.Duration
-	resetTimeout   time.Duration
+	maxFailures      int
+	timeout          time.Duration
+	resetTimeout     time.Duration
+	successThreshold int
+	maxProbes        int
 }
 
 // NewCircuitBreaker creates a new circuit breaker
-func NewCircuitBreaker(name string, maxFailures int, timeout, resetTimeout time.Duration) *CircuitBreaker {
+// While half-open, at most maxProbes calls are let through at a time and successThreshold consecutive
+// successful probes are needed to close the breaker again. Values below 1 are treated as 1.
+func NewCircuitBreaker(name string, maxFailures int, timeout, resetTimeout time.Duration, successThreshold, maxProbes int) *CircuitBreaker {
+	if successThreshold < 1 {
+		successThreshold = 1
+	}
+	if maxProbes < 1 {
+		maxProbes = 1
+	}
+
 	return &CircuitBreaker{
-		name:          name,
-		state:         StateClosed,
-		maxFailures:   maxFailures,
-		timeout:       timeout,
-		resetTimeout:  resetTimeout,
+		name:             name,
+		state:            StateClosed,
+		maxFailures:      maxFailures,
+		timeout:          timeout,
+		resetTimeout:     resetTimeout,
+		successThreshold: successThreshold,
+		maxProbes:        maxProbes,
 	}
 }
 
 // Execute runs the given function if the circuit breaker allows it
-func (cb *CircuitBreaker) Execute(fn func() error) error {
+// The lock is only held while checking and updating the state, so slow calls do not serialize the callers.
+// A panicking call is recorded as a failure before the panic is passed on, so its probe slot is released.
+func (cb *CircuitBreaker) Execute(fn func() error) (err error) {
+	// Check if we should attempt the call
 	cb.mutex.Lock()
-	defer cb.mutex.Unlock()
+	allowed, probe := cb.canExecute()
+	halfOpens := cb.halfOpens
+	cb.mutex.Unlock()
 
-	// Check if we should attempt the call
-	if !cb.canExecute() {
+	if !allowed {
 		return fmt.Errorf("circuit breaker '%s' is open", cb.name)
 	}
 
-	// Execute the function
-	err := fn()
-	
 	// Record the result
-	cb.recordResult(err == nil)
-	
+	completed := false
+	defer func() {
+		cb.mutex.Lock()
+		defer cb.mutex.Unlock()
+
+		// Probes of an earlier half-open period were released when the breaker tripped
+		probe = probe && halfOpens == cb.halfOpens
+		if probe {
+			cb.probes--
+		}
+		cb.recordResult(completed && err == nil, probe)
+	}()
+
+	// Execute the function
+	err = fn()
+	completed = true
+
 	return err
 }
 
 // canExecute determines if the circuit breaker should allow execution
-func (cb *CircuitBreaker) canExecute() bool {
+// Returns whether the call is a half-open probe, probes are counted until their result is recorded.
+func (cb *CircuitBreaker) canExecute() (allowed bool, probe bool) {
 	switch cb.state {
 	case StateClosed:
-		return true
+		return true, false
 	case StateOpen:
 		// Check if enough time has passed to try again
-		if time.Since(cb.lastFailTime) > cb.resetTimeout {
-			cb.state = StateHalfOpen
-			log.Info().
-				Str("circuit_breaker", cb.name).
-				Msg("Circuit breaker moving to half-open state")
-			return true
+		if time.Since(cb.openedAt) < cb.resetTimeout {
+			return false, false
 		}
-		return false
+		cb.state = StateHalfOpen
+		cb.successes = 0
+		cb.probes = 1
+		cb.halfOpens++
+		log.Info().
+			Str("circuit_breaker", cb.name).
+			Msg("Circuit breaker moving to half-open state")
+		return true, true
 	case StateHalfOpen:
-		return true
+		if cb.probes >= cb.maxProbes {
+			return false, false
+		}
+		cb.probes++
+		return true, true
 	default:
-		return false
+		return false, false
 	}
 }
 
 // recordResult records the success or failure of an operation
-func (cb *CircuitBreaker) recordResult(success bool) {
+// Results of calls started before the breaker tripped are not probes, they neither close nor trip it again.
+// Neither do results of probes finishing after another probe tripped or closed the breaker.
+func (cb *CircuitBreaker) recordResult(success bool, probe bool) {
 	cb.requests++
+
+	if cb.state == StateOpen || (cb.state == StateHalfOpen && !probe) {
+		return
+	}
 	
 	if success {
 		cb.onSuccess()
@@ -112,11 +160,17 @@ func (cb *CircuitBreaker) recordResult(success bool) {
 // onSuccess handles a successful operation
 func (cb *CircuitBreaker) onSuccess() {
 	if cb.state == StateHalfOpen {
+		cb.successes++
+		if cb.successes < cb.successThreshold {
+			return
+		}
+
 		// Reset the circuit breaker
 		cb.reset()
 		log.Info().
 			Str("circuit_breaker", cb.name).
-			Msg("Circuit breaker reset to closed state after successful call")
+			Int("successes", cb.successThreshold).
+			Msg("Circuit breaker reset to closed state after successful calls")
 	}
 	// Reset failure count on success
 	cb.failures = 0
@@ -127,14 +181,18 @@ func (cb *CircuitBreaker) onFailure() {
 	cb.failures++
 	cb.lastFailTime = time.Now()
 	
-	if cb.failures >= cb.maxFailures {
+	// A failed probe means the backend has not recovered yet
+	if cb.state == StateHalfOpen || cb.failures >= cb.maxFailures {
 		cb.trip()
 	}
 }
 
-// trip opens the circuit breaker
+// trip opens the circuit breaker, the reset timeout starts over
 func (cb *CircuitBreaker) trip() {
 	cb.state = StateOpen
+	cb.openedAt = time.Now()
+	cb.successes = 0
+	cb.probes = 0
 	log.Warn().
 		Str("circuit_breaker", cb.name).
 		Int("failures", cb.failures).
@@ -146,6 +204,7 @@ func (cb *CircuitBreaker) reset() {
 	cb.state = StateClosed
 	cb.failures = 0
 	cb.requests = 0
+	cb.successes = 0
 }
 
 // GetState returns the current state of the circuit breaker
@@ -161,10 +220,69 @@ func (cb *CircuitBreaker) GetStats() map[string]interface{} {
 	defer cb.mutex.RUnlock()
 	
 	return map[string]interface{}{
-		"name":           cb.name,
-		"state":          cb.state.String(),
-		"failures":       cb.failures,
-		"requests":       cb.requests,
-		"last_fail_time": cb.lastFailTime,
+		"name":                  cb.name,
+		"state":                 cb.state.String(),
+		"failures":              cb.failures,
+		"requests":              cb.requests,
+		"last_fail_time":        cb.lastFailTime,
+		"consecutive_successes": cb.successes,
+		"success_threshold":     cb.successThreshold,
+		"probes":                cb.probes,
+		"max_probes":            cb.maxProbes,
 	}
-}
\ No newline at end of file
+}
+
+// Name returns the name of the circuit breaker
+func (cb *CircuitBreaker) Name() string {
+	return cb.name
+}
+
+// CircuitBreakerRegistry keeps track of circuit breakers, so that their state can be inspected
+type CircuitBreakerRegistry struct {
+	breakers map[string]*CircuitBreaker
+	mutex    sync.RWMutex
+}
+
+// NewCircuitBreakerRegistry creates an empty registry
+func NewCircuitBreakerRegistry() *CircuitBreakerRegistry {
+	return &CircuitBreakerRegistry{
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// Register adds the circuit breaker to the registry, replacing a breaker of the same name
+func (r *CircuitBreakerRegistry) Register(cb *CircuitBreaker) *CircuitBreaker {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.breakers[cb.name] = cb
+	return cb
+}
+
+// Get returns the circuit breaker of given name
+func (r *CircuitBreakerRegistry) Get(name string) (*CircuitBreaker, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	cb, exists := r.breakers[name]
+	return cb, exists
+}
+
+// GetAllStats returns statistics of all registered circuit breakers, sorted by name
+func (r *CircuitBreakerRegistry) GetAllStats() []map[string]interface{} {
+	r.mutex.RLock()
+	breakers := make([]*CircuitBreaker, 0, len(r.breakers))
+	for _, cb := range r.breakers {
+		breakers = append(breakers, cb)
+	}
+	r.mutex.RUnlock()
+
+	sort.Slice(breakers, func(i, j int) bool {
+		return breakers[i].name < breakers[j].name
+	})
+
+	stats := make([]map[string]interface{}, 0, len(breakers))
+	for _, cb := range breakers {
+		stats = append(stats, cb.GetStats())
+	}
+
+	return stats
+}
@@ -0,0 +1,224 @@
+package resilience
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerExecuteDoesNotSerializeCallers(t *testing.T) {
+	cb := NewCircuitBreaker("test", 3, time.Second, time.Minute, 1, 1)
+
+	const callers = 5
+	started := make(chan struct{}, callers)
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, cb.Execute(func() error {
+				started <- struct{}{}
+				<-release
+				return nil
+			}))
+		}()
+	}
+
+	// Every caller must be inside fn at the same time
+	for i := 0; i < callers; i++ {
+		select {
+		case <-started:
+		case <-time.After(2 * time.Second):
+			close(release)
+			t.Fatalf("only %d of %d callers entered fn concurrently", i, callers)
+		}
+	}
+
+	// State stays readable while calls are in flight
+	assert.Equal(t, StateClosed, cb.GetState())
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, callers, cb.GetStats()["requests"])
+}
+
+func TestCircuitBreakerTripsAndRecovers(t *testing.T) {
+	cb := NewCircuitBreaker("test", 2, time.Second, 10*time.Millisecond, 1, 1)
+
+	assert.Error(t, cb.Execute(func() error { return assert.AnError }))
+	assert.Equal(t, StateClosed, cb.GetState())
+	assert.Error(t, cb.Execute(func() error { return assert.AnError }))
+	assert.Equal(t, StateOpen, cb.GetState())
+
+	// Open breaker rejects calls without running them
+	called := false
+	assert.Error(t, cb.Execute(func() error { called = true; return nil }))
+	assert.False(t, called)
+
+	// After the reset timeout a probe is let through and closes the breaker
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, cb.Execute(func() error { return nil }))
+	assert.Equal(t, StateClosed, cb.GetState())
+}
+
+func TestCircuitBreakerHalfOpenSuccessThreshold(t *testing.T) {
+	cb := NewCircuitBreaker("test", 1, time.Second, 10*time.Millisecond, 3, 1)
+
+	assert.Error(t, cb.Execute(func() error { return assert.AnError }))
+	assert.Equal(t, StateOpen, cb.GetState())
+	time.Sleep(20 * time.Millisecond)
+
+	// Breaker stays half-open until enough consecutive successes
+	for i := 1; i < 3; i++ {
+		assert.NoError(t, cb.Execute(func() error { return nil }))
+		assert.Equal(t, StateHalfOpen, cb.GetState())
+		assert.Equal(t, i, cb.GetStats()["consecutive_successes"])
+	}
+
+	// A failure while half-open trips the breaker again
+	assert.Error(t, cb.Execute(func() error { return assert.AnError }))
+	assert.Equal(t, StateOpen, cb.GetState())
+	assert.Equal(t, 0, cb.GetStats()["consecutive_successes"])
+	time.Sleep(20 * time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, cb.Execute(func() error { return nil }))
+	}
+	assert.Equal(t, StateClosed, cb.GetState())
+	assert.Equal(t, 0, cb.GetStats()["consecutive_successes"])
+}
+
+func TestCircuitBreakerHalfOpenSingleProbe(t *testing.T) {
+	cb := NewCircuitBreaker("test", 1, time.Second, 10*time.Millisecond, 1, 1)
+
+	assert.Error(t, cb.Execute(func() error { return assert.AnError }))
+	time.Sleep(20 * time.Millisecond)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, cb.Execute(func() error {
+			close(started)
+			<-release
+			return nil
+		}))
+	}()
+	<-started
+
+	// Probe is in flight, further calls are rejected
+	called := false
+	assert.Error(t, cb.Execute(func() error { called = true; return nil }))
+	assert.False(t, called)
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, StateClosed, cb.GetState())
+	assert.NoError(t, cb.Execute(func() error { return nil }))
+}
+
+func TestCircuitBreakerHalfOpenMaxProbes(t *testing.T) {
+	cb := NewCircuitBreaker("test", 1, time.Second, 10*time.Millisecond, 1, 2)
+	assert.Equal(t, 2, cb.GetStats()["max_probes"])
+
+	assert.Error(t, cb.Execute(func() error { return assert.AnError }))
+	time.Sleep(20 * time.Millisecond)
+
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, cb.Execute(func() error {
+				started <- struct{}{}
+				<-release
+				return nil
+			}))
+		}()
+	}
+	<-started
+	<-started
+	assert.Equal(t, 2, cb.GetStats()["probes"])
+
+	// Both probe slots are taken, further calls are rejected
+	called := false
+	assert.Error(t, cb.Execute(func() error { called = true; return nil }))
+	assert.False(t, called)
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, StateClosed, cb.GetState())
+	assert.Equal(t, 0, cb.GetStats()["probes"])
+}
+
+func TestCircuitBreakerFailedProbeRearmsTimeout(t *testing.T) {
+	cb := NewCircuitBreaker("test", 1, time.Second, 50*time.Millisecond, 1, 1)
+
+	// Call started before the breaker tripped
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cb.Execute(func() error {
+			close(started)
+			<-release
+			return assert.AnError
+		})
+	}()
+	<-started
+
+	assert.Error(t, cb.Execute(func() error { return assert.AnError }))
+	assert.Equal(t, StateOpen, cb.GetState())
+	time.Sleep(30 * time.Millisecond)
+
+	// Its late failure doesn't keep the breaker open any longer
+	close(release)
+	<-done
+	time.Sleep(30 * time.Millisecond)
+
+	// Failed probe opens the breaker for the full reset timeout again
+	assert.Error(t, cb.Execute(func() error { return assert.AnError }))
+	assert.Equal(t, StateOpen, cb.GetState())
+
+	time.Sleep(30 * time.Millisecond)
+	called := false
+	assert.Error(t, cb.Execute(func() error { called = true; return nil }))
+	assert.False(t, called)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, cb.Execute(func() error { return nil }))
+	assert.Equal(t, StateClosed, cb.GetState())
+}
+
+func TestCircuitBreakerPanickingProbeReleasesSlot(t *testing.T) {
+	cb := NewCircuitBreaker("test", 1, time.Second, 10*time.Millisecond, 1, 1)
+
+	assert.Error(t, cb.Execute(func() error { return assert.AnError }))
+	time.Sleep(20 * time.Millisecond)
+
+	// Panic is passed on and counted as a failed probe
+	assert.Panics(t, func() {
+		cb.Execute(func() error { panic("probe panicked") })
+	})
+	assert.Equal(t, StateOpen, cb.GetState())
+	assert.Equal(t, 0, cb.GetStats()["probes"])
+
+	// Next probe is let through once the breaker is half-open again
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, cb.Execute(func() error { return nil }))
+	assert.Equal(t, StateClosed, cb.GetState())
+}
@@ -3,6 +3,8 @@ package resilience
 import (
 	"math"
 	"math/rand"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -18,15 +20,33 @@ type RetryConfig struct {
 	RetryableErrors []string
 }
 
-// DefaultRetryConfig returns sensible defaults for retry configuration
-func DefaultRetryConfig() RetryConfig {
-	return RetryConfig{
+var (
+	defaultRetryConfig = RetryConfig{
 		MaxRetries:    3,
 		InitialDelay:  time.Second,
 		MaxDelay:      30 * time.Second,
 		BackoffFactor: 2.0,
 		Jitter:        true,
 	}
+	defaultRetryConfigMutex sync.RWMutex
+)
+
+// DefaultRetryConfig returns sensible defaults for retry configuration, see SetDefaultRetryConfig
+func DefaultRetryConfig() RetryConfig {
+	defaultRetryConfigMutex.RLock()
+	defer defaultRetryConfigMutex.RUnlock()
+
+	config := defaultRetryConfig
+	config.RetryableErrors = append([]string(nil), defaultRetryConfig.RetryableErrors...)
+	return config
+}
+
+// SetDefaultRetryConfig overrides the defaults returned by DefaultRetryConfig
+// Note: should be called during startup, before the components using retries are created
+func SetDefaultRetryConfig(config RetryConfig) {
+	defaultRetryConfigMutex.Lock()
+	defer defaultRetryConfigMutex.Unlock()
+	defaultRetryConfig = config
 }
 
 // RetryWithExponentialBackoff retries a function with exponential backoff
@@ -118,5 +138,5 @@ func isRetryableError(err error, retryableErrors []string) bool {
 
 // contains checks if a string contains a substring (case-insensitive)
 func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(substr) == 0 || s[len(s)-len(substr):] == substr || s[:len(substr)] == substr)
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
 }
\ No newline at end of file
@@ -0,0 +1,50 @@
+package resilience
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		s, substr string
+		expected  bool
+	}{
+		{"connection refused", "connection refused", true},
+		{"connection refused", "", true},
+		{"connection refused: retry later", "connection refused", true},
+		{"dial tcp: connection refused", "connection refused", true},
+		{"dial tcp: connection refused: timeout", "connection refused", true},
+		{"dial tcp: Connection Refused", "connection refused", true},
+		{"i/o TIMEOUT while reading", "timeout", true},
+		{"connection reset", "connection refused", false},
+		{"refused", "connection refused", false},
+		{"", "timeout", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, contains(test.s, test.substr), "contains(%q, %q)", test.s, test.substr)
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	retryable := []string{"connection refused", "timeout"}
+
+	tests := []struct {
+		err      string
+		expected bool
+	}{
+		{"dial tcp 127.0.0.1:1883: connect: connection refused (after 3 attempts)", true},
+		{"read tcp: i/o Timeout", true},
+		{"401 unauthorized", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, isRetryableError(errors.New(test.err), retryable), test.err)
+	}
+
+	// Without configured errors everything is retried
+	assert.True(t, isRetryableError(errors.New("401 unauthorized"), nil))
+}
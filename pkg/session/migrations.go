@@ -0,0 +1,44 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// rawSession - session file contents keyed by JSON field, so older structures can be upgraded before decoding
+type rawSession map[string]json.RawMessage
+
+// migrations - upgrade a session file of revision N (the key) to revision N+1
+// Note: add an entry whenever Revision is incremented, so the refresh token survives the upgrade
+var migrations = map[int]func(raw rawSession) error{
+	1: dropCachedData,
+	2: dropCachedData,
+}
+
+// dropCachedData removes data which is cheap to fetch again and may have changed its structure,
+// the refresh token and last seen message time are kept.
+func dropCachedData(raw rawSession) error {
+	delete(raw, "authToken")
+	delete(raw, "authTime")
+	delete(raw, "babies")
+	return nil
+}
+
+// migrate upgrades raw session contents from given revision to the current one
+func migrate(raw rawSession, revision int) error {
+	for r := revision; r < Revision; r++ {
+		m, ok := migrations[r]
+		if !ok {
+			return fmt.Errorf("no migration from session revision %d", r)
+		}
+
+		if err := m(raw); err != nil {
+			return fmt.Errorf("session migration from revision %d failed: %v", r, err)
+		}
+	}
+
+	revisionJSON, _ := json.Marshal(Revision)
+	raw["revision"] = revisionJSON
+
+	return nil
+}
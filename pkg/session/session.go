@@ -8,10 +8,11 @@ import (
 
 	"github.com/rs/zerolog/log"
 	"github.com/indiefan/home_assistant_nanit/pkg/baby"
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
 )
 
-// Revision - marks the version of the structure of a session file. Only files with equal revision will be loaded
-// Note: you should increment this whenever you change the Session structure
+// Revision - marks the version of the structure of a session file. Older files are upgraded by migrations on load
+// Note: you should increment this whenever you change the Session structure and add a migration for the previous revision
 const Revision = 3
 
 // Session - application session data container
@@ -38,38 +39,83 @@ func NewSessionStore() *Store {
 }
 
 // Load - loads previous state from a file
+// Files of an older revision are migrated, corrupted files are backed up to <filename>.bak and replaced by a default session.
 func (store *Store) Load() error {
 	if _, err := os.Stat(store.Filename); os.IsNotExist(err) {
 		log.Info().Str("filename", store.Filename).Msg("No app session file found")
 		return nil
 	}
 
-	f, err := os.Open(store.Filename)
+	data, err := os.ReadFile(store.Filename)
 	if err != nil {
 		log.Error().Str("filename", store.Filename).Err(err).Msg("Unable to open app session file")
 		return err
 	}
 
-	defer f.Close()
+	raw := rawSession{}
+	var revision int
+	jsonErr := json.Unmarshal(data, &raw)
+	if jsonErr == nil {
+		jsonErr = json.Unmarshal(raw["revision"], &revision)
+	}
 
-	session := &Session{}
-	jsonErr := json.NewDecoder(f).Decode(session)
 	if jsonErr != nil {
 		log.Error().Str("filename", store.Filename).Err(jsonErr).Msg("Unable to decode app session file, using default session")
+		store.backupCorrupted()
 		// Don't return error for corrupted session files, just use default
 		return nil
 	}
 
-	if session.Revision == Revision {
-		store.Session = session
-		log.Info().Str("filename", store.Filename).Msg("Loaded app session from the file")
+	if revision > Revision {
+		log.Warn().Str("filename", store.Filename).Int("revision", revision).Msg("App session file contains newer revision of the state, ignoring")
+		return nil
+	}
+
+	migrated := false
+	if revision < Revision {
+		if err := migrate(raw, revision); err != nil {
+			log.Warn().Str("filename", store.Filename).Err(err).Msg("Unable to migrate app session file, ignoring")
+			return nil
+		}
+
+		if data, err = json.Marshal(raw); err != nil {
+			log.Error().Str("filename", store.Filename).Err(err).Msg("Unable to encode migrated app session")
+			return nil
+		}
+		migrated = true
+	}
+
+	session := &Session{}
+	if jsonErr := json.Unmarshal(data, session); jsonErr != nil {
+		log.Error().Str("filename", store.Filename).Err(jsonErr).Msg("Unable to decode app session file, using default session")
+		store.backupCorrupted()
+		return nil
+	}
+
+	store.Session = session
+
+	if migrated {
+		log.Info().Str("filename", store.Filename).Int("from_revision", revision).Int("to_revision", Revision).Msg("Migrated app session file")
+		// Persist the upgrade, so the migration runs once
+		store.Save()
 	} else {
-		log.Warn().Str("filename", store.Filename).Msg("App session file contains older revision of the state, ignoring")
+		log.Info().Str("filename", store.Filename).Msg("Loaded app session from the file")
 	}
 
 	return nil
 }
 
+// backupCorrupted keeps a copy of an undecodable session file for inspection before it gets overwritten
+func (store *Store) backupCorrupted() {
+	backupFilename := store.Filename + ".bak"
+	if err := os.Rename(store.Filename, backupFilename); err != nil {
+		log.Error().Str("filename", store.Filename).Err(err).Msg("Unable to back up corrupted app session file")
+		return
+	}
+
+	log.Warn().Str("filename", backupFilename).Msg("Corrupted app session file backed up")
+}
+
 // Save - stores current data in a file
 func (store *Store) Save() error {
 	if store.Filename == "" {
@@ -78,21 +124,14 @@ func (store *Store) Save() error {
 
 	log.Trace().Str("filename", store.Filename).Msg("Storing app session to the file")
 
-	f, err := os.OpenFile(store.Filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
-	if err != nil {
-		log.Error().Str("filename", store.Filename).Err(err).Msg("Unable to open app session file for writing")
-		return err
-	}
-
-	defer f.Close()
-
 	data, jsonErr := json.Marshal(store.Session)
 	if jsonErr != nil {
 		log.Error().Str("filename", store.Filename).Err(jsonErr).Msg("Unable to marshal contents of app session file")
 		return jsonErr
 	}
 
-	_, writeErr := f.Write(data)
+	// Written atomically, a crash mid-write must not lose the refresh token
+	writeErr := utils.WriteFileAtomic(store.Filename, data, 0644)
 	if writeErr != nil {
 		log.Error().Str("filename", store.Filename).Err(writeErr).Msg("Unable to write to app session file")
 		return writeErr
@@ -0,0 +1,61 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigratesOlderRevision(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "session.json")
+	old := `{"revision":2,"authToken":"token","babies":[{"uid":"baby1"}],"refreshToken":"refresh","lastSeenMessageTime":"2024-01-02T03:04:05Z"}`
+	assert.NoError(t, os.WriteFile(filename, []byte(old), 0644))
+
+	store := NewSessionStore()
+	store.Filename = filename
+	assert.NoError(t, store.Load())
+
+	assert.Equal(t, Revision, store.Session.Revision)
+	assert.Equal(t, "refresh", store.Session.RefreshToken)
+	assert.Equal(t, 2024, store.Session.LastSeenMessageTime.Year())
+	assert.Empty(t, store.Session.AuthToken)
+	assert.Empty(t, store.Session.Babies)
+
+	// Upgraded file is stored, so it loads without migration next time
+	store = NewSessionStore()
+	store.Filename = filename
+	assert.NoError(t, store.Load())
+	assert.Equal(t, "refresh", store.Session.RefreshToken)
+	assert.NoFileExists(t, filename+".bak")
+}
+
+func TestLoadBacksUpCorruptedFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "session.json")
+	assert.NoError(t, os.WriteFile(filename, []byte(`{"revision":3,"refreshToken":`), 0644))
+
+	store := NewSessionStore()
+	store.Filename = filename
+	assert.NoError(t, store.Load())
+
+	assert.Equal(t, Revision, store.Session.Revision)
+	assert.Empty(t, store.Session.RefreshToken)
+	assert.NoFileExists(t, filename)
+
+	backup, err := os.ReadFile(filename + ".bak")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"revision":3,"refreshToken":`, string(backup))
+}
+
+func TestLoadIgnoresNewerRevision(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "session.json")
+	assert.NoError(t, os.WriteFile(filename, []byte(`{"revision":99,"refreshToken":"refresh"}`), 0644))
+
+	store := NewSessionStore()
+	store.Filename = filename
+	assert.NoError(t, store.Load())
+
+	assert.Empty(t, store.Session.RefreshToken)
+	assert.FileExists(t, filename)
+}
@@ -1,14 +1,18 @@
 package streaming
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
 	"github.com/rs/zerolog/log"
 )
 
@@ -21,8 +25,62 @@ const (
 	StatusStreaming       StreamStatus = "streaming"
 	StatusError           StreamStatus = "error"
 	StatusStopped         StreamStatus = "stopped"
+	StatusPausedStandby   StreamStatus = "paused_standby"
 )
 
+// StreamMode represents what a transcoder produces
+type StreamMode string
+
+const (
+	StreamModeVideo StreamMode = "video" // Video with audio
+	StreamModeAudio StreamMode = "audio" // Audio only, the video is not decoded (much lower CPU usage)
+)
+
+// ParseStreamMode validates the stream mode, empty value means video
+func ParseStreamMode(value string) (StreamMode, error) {
+	switch StreamMode(value) {
+	case "", StreamModeVideo:
+		return StreamModeVideo, nil
+	case StreamModeAudio:
+		return StreamModeAudio, nil
+	}
+
+	return "", fmt.Errorf("unknown stream mode '%s', allowed values: %s, %s", value, StreamModeVideo, StreamModeAudio)
+}
+
+// PlaylistName returns the HLS playlist file name of the mode
+func (mode StreamMode) PlaylistName() string {
+	if mode == StreamModeAudio {
+		return "audio.m3u8"
+	}
+
+	return "playlist.m3u8"
+}
+
+// segmentPattern returns the HLS segment file name pattern of the mode
+func (mode StreamMode) segmentPattern() string {
+	if mode == StreamModeAudio {
+		return "audio_%d.ts"
+	}
+
+	return "segment_%d.ts"
+}
+
+// filePatterns returns glob patterns of the files written by a transcoder of the mode
+// Transcoders of both modes share the baby's HLS directory, each one only touches its own files.
+func (mode StreamMode) filePatterns() []string {
+	return []string{mode.PlaylistName() + "*", strings.Replace(mode.segmentPattern(), "%d", "*", 1)}
+}
+
+// ModeOfFile returns the mode of the transcoder producing the HLS file (playlist or segment)
+func ModeOfFile(fileName string) StreamMode {
+	if fileName == StreamModeAudio.PlaylistName() || strings.HasPrefix(fileName, "audio_") {
+		return StreamModeAudio
+	}
+
+	return StreamModeVideo
+}
+
 // StreamError represents different types of streaming errors
 type StreamError struct {
 	Type    string `json:"type"`
@@ -36,17 +94,187 @@ const (
 	ErrorTypeRTMPTimeout    = "rtmp_timeout" 
 	ErrorTypeFFmpegFailed   = "ffmpeg_failed"
 	ErrorTypeNetworkError   = "network_error"
+	ErrorTypeStorage        = "storage"
 	ErrorTypeUnknown        = "unknown"
 )
 
+// InputOpts - FFmpeg options applied to the input stream
+type InputOpts struct {
+	// Maximum time a read/write on the input may block before FFmpeg gives up (0 disables)
+	// Note: makes FFmpeg exit on a dead RTMP source instead of hanging, the transcoder then retries
+	RWTimeout time.Duration
+
+	// Let FFmpeg reconnect internally on input errors
+	// Note: FFmpeg supports this for HTTP(S) inputs only, it's not passed for RTMP
+	Reconnect bool
+}
+
+// DefaultInputOpts returns the input options used unless configured otherwise
+func DefaultInputOpts() InputOpts {
+	return InputOpts{
+		RWTimeout: 10 * time.Second,
+		Reconnect: true,
+	}
+}
+
+// OutputOpts - HLS playlist windows and on-disk segment retention
+type OutputOpts struct {
+	// Number of segments in the live playlist, small to keep players at the live edge
+	LiveSegments int
+
+	// Length of the DVR playlist for scrubbing backward, DVR playlist is disabled if 0
+	DVRWindow time.Duration
+
+	// How long segments are kept on disk, at least the DVR window (or the live playlist if no DVR)
+	SegmentRetention time.Duration
+}
+
+// DefaultOutputOpts returns the output options used unless configured otherwise
+func DefaultOutputOpts() OutputOpts {
+	return OutputOpts{
+		LiveSegments: 5,
+	}
+}
+
+// EncodeOpts - video encoding profile of a single camera, zero values keep the source's
+type EncodeOpts struct {
+	Height      int // Output height, the width keeps the aspect ratio
+	BitrateKbps int // Target video bitrate
+	FPS         int // Output frame rate
+}
+
+// args returns the FFmpeg video encoding options of the profile
+func (opts EncodeOpts) args() []string {
+	args := []string{}
+
+	if opts.Height > 0 {
+		// -2 keeps the width even, as libx264 requires
+		args = append(args, "-vf", fmt.Sprintf("scale=-2:%d", opts.Height))
+	}
+
+	if opts.FPS > 0 {
+		args = append(args, "-r", fmt.Sprintf("%d", opts.FPS))
+	}
+
+	if opts.BitrateKbps > 0 {
+		// Capped at the target, so a distant camera never exceeds its budget
+		args = append(args,
+			"-b:v", fmt.Sprintf("%dk", opts.BitrateKbps),
+			"-maxrate", fmt.Sprintf("%dk", opts.BitrateKbps),
+			"-bufsize", fmt.Sprintf("%dk", opts.BitrateKbps*2),
+		)
+	}
+
+	return args
+}
+
+// LogOpts - FFmpeg logging
+type LogOpts struct {
+	// FFmpeg -loglevel (e.g. "warning", "info", "debug"), FFmpeg's default if empty
+	// Note: storage errors are detected from the logged errors, "quiet" and "panic" hide them
+	Level string
+
+	// Directory FFmpeg output of every transcoder is written to as ffmpeg-<baby_uid>.log, no log files if empty
+	Dir string
+
+	// Size at which a log file is rotated, only the previous file is kept (0 disables rotation)
+	MaxFileSize int64
+}
+
+// hlsSegmentDuration - target duration of the HLS segments
+const hlsSegmentDuration = 2 * time.Second
+
+// DVRPlaylistName - file name of the DVR playlist, served next to the live playlist
+const DVRPlaylistName = "dvr.m3u8"
+
+// segmentsFor returns number of segments covering the duration, rounded up
+func segmentsFor(duration time.Duration) int {
+	return int((duration + hlsSegmentDuration - 1) / hlsSegmentDuration)
+}
+
+// liveSegments returns number of segments in the live playlist
+func (opts OutputOpts) liveSegments() int {
+	if opts.LiveSegments <= 0 {
+		return DefaultOutputOpts().LiveSegments
+	}
+
+	return opts.LiveSegments
+}
+
+// playlistSegments returns number of segments in the playlist written by FFmpeg, the DVR window if enabled
+// The live playlist is cut from it when served.
+func (opts OutputOpts) playlistSegments() int {
+	return max(opts.liveSegments(), segmentsFor(opts.DVRWindow))
+}
+
+// deleteThreshold returns number of segments kept on disk after they drop out of the FFmpeg playlist
+func (opts OutputOpts) deleteThreshold() int {
+	return max(segmentsFor(opts.SegmentRetention)-opts.playlistSegments(), 1)
+}
+
+// fileCheckInterval - how often a starting transcoder checks whether FFmpeg produces HLS files
+const fileCheckInterval = 5 * time.Second
+
+// ffmpegStderrLines - number of last FFmpeg stderr lines kept for diagnostics
+const ffmpegStderrLines = 50
+
+// maxStderrLineLength - longer stderr lines are truncated
+const maxStderrLineLength = 1024
+
+// stderrTail - keeps the last lines written by FFmpeg to stderr, safe for concurrent use
+// Progress updates are terminated by carriage returns, so both \r and \n end a line.
+type stderrTail struct {
+	mutex   sync.Mutex
+	tailer  *utils.LogTailer
+	partial []byte
+}
+
+func newStderrTail(maxNumLines int) *stderrTail {
+	return &stderrTail{tailer: utils.NewLogTailer(maxNumLines)}
+}
+
+// Write - implements io.Writer
+func (t *stderrTail) Write(p []byte) (int, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, b := range p {
+		if b == '\n' || b == '\r' {
+			if len(t.partial) > 0 {
+				t.tailer.Append(string(t.partial))
+				t.partial = t.partial[:0]
+			}
+		} else if len(t.partial) < maxStderrLineLength {
+			t.partial = append(t.partial, b)
+		}
+	}
+
+	return len(p), nil
+}
+
+// Lines - returns the kept lines, oldest first
+func (t *stderrTail) Lines() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.tailer.GetLines()
+}
+
 // HLSTranscoder manages FFmpeg processes for RTMP to HLS conversion
 type HLSTranscoder struct {
 	babyUID      string
 	rtmpURL      string
+	mode         StreamMode
 	hlsDir       string
+	inputOpts    InputOpts
+	outputOpts   OutputOpts
+	logOpts      LogOpts
+	encodeOpts   EncodeOpts
+	logFile      *utils.RotatingFile // Open while the transcoder runs if log files are enabled
 	cmd          *exec.Cmd
+	exited       chan struct{} // Closed once the current FFmpeg process has been waited for by the monitor
 	mutex        sync.RWMutex
 	isRunning    bool
+	isPaused     bool // Stopped because the camera is in standby, resumed by the manager
 	stopChan     chan struct{}
 	status       StreamStatus
 	lastError    *StreamError
@@ -54,22 +282,121 @@ type HLSTranscoder struct {
 	retryCount   int
 	maxRetries   int
 	retryDelay   time.Duration
+	stderr       *stderrTail
+	tiles        []MosaicTile // Inputs of a mosaic transcoder, nil for a single camera
+	processes    *sync.Map    // Running FFmpeg processes of the manager by PID, nil if not started by a manager
 }
 
 // NewHLSTranscoder creates a new HLS transcoder for a baby
-func NewHLSTranscoder(babyUID, rtmpURL, baseHLSDir string) *HLSTranscoder {
+func NewHLSTranscoder(babyUID, rtmpURL, baseHLSDir string, inputOpts InputOpts) *HLSTranscoder {
 	hlsDir := filepath.Join(baseHLSDir, babyUID)
 	
 	return &HLSTranscoder{
 		babyUID:    babyUID,
 		rtmpURL:    rtmpURL,
+		mode:       StreamModeVideo,
 		hlsDir:     hlsDir,
+		inputOpts:  inputOpts,
+		outputOpts: DefaultOutputOpts(),
 		stopChan:   make(chan struct{}),
 		isRunning:  false,
 		status:     StatusStopped,
 		maxRetries: 5,
 		retryDelay: 10 * time.Second,
+		stderr:     newStderrTail(ffmpegStderrLines),
+	}
+}
+
+// buildFFmpegArgs builds FFmpeg arguments, input options have to precede the input
+func (h *HLSTranscoder) buildFFmpegArgs() []string {
+	args := []string{}
+	if h.logOpts.Level != "" {
+		args = append(args, "-loglevel", h.logOpts.Level)
+	}
+
+	if h.tiles != nil {
+		return append(args, h.buildMosaicArgs()...)
+	}
+
+	args = append(args, h.buildInputArgs(h.rtmpURL)...)
+
+	if h.mode == StreamModeAudio {
+		args = append(args,
+			"-vn",                          // Drop video
+			"-c:a", "aac",                  // Audio codec
+		)
+		return append(args, h.buildOutputArgs()...)
+	}
+
+	args = append(args,
+		"-c:v", "libx264",                  // Video codec
+		"-preset", "ultrafast",             // Fast encoding
+		"-tune", "zerolatency",             // Low latency
+	)
+	args = append(args, h.encodeOpts.args()...)
+	args = append(args,
+		"-c:a", "aac",                      // Audio codec
+	)
+
+	return append(args, h.buildOutputArgs()...)
+}
+
+// buildInputArgs builds FFmpeg input options followed by the input itself
+func (h *HLSTranscoder) buildInputArgs(inputURL string) []string {
+	args := []string{}
+
+	if h.inputOpts.RWTimeout > 0 {
+		// Protocol level timeout in microseconds, works for RTMP as well
+		args = append(args, "-rw_timeout", fmt.Sprintf("%d", h.inputOpts.RWTimeout.Microseconds()))
+	}
+
+	if h.inputOpts.Reconnect && (strings.HasPrefix(inputURL, "http://") || strings.HasPrefix(inputURL, "https://")) {
+		args = append(args,
+			"-reconnect", "1",
+			"-reconnect_streamed", "1",
+			"-reconnect_delay_max", "5",
+		)
+	}
+
+	return append(args, "-i", inputURL)
+}
+
+// buildOutputArgs builds FFmpeg HLS output options followed by the playlist path
+func (h *HLSTranscoder) buildOutputArgs() []string {
+	playlistPath := h.GetPlaylistPath()
+	segmentPath := filepath.Join(h.hlsDir, h.mode.segmentPattern())
+
+	args := []string{
+		"-f", "hls",                        // HLS format
+		"-hls_time", fmt.Sprintf("%d", int(hlsSegmentDuration/time.Second)),
+		"-hls_list_size", fmt.Sprintf("%d", h.outputOpts.playlistSegments()),
+		"-hls_flags", "delete_segments",    // Auto-delete old segments
+	}
+
+	if threshold := h.outputOpts.deleteThreshold(); threshold > 1 {
+		// Segments dropped from the playlist stay on disk for the retention
+		args = append(args, "-hls_delete_threshold", fmt.Sprintf("%d", threshold))
 	}
+
+	return append(args,
+		"-hls_segment_filename", segmentPath,
+		"-y",                               // Overwrite output
+		playlistPath,
+	)
+}
+
+// LivePlaylistSegments returns number of segments the live playlist is cut to, 0 if FFmpeg writes it as served
+func (h *HLSTranscoder) LivePlaylistSegments() int {
+	if h.outputOpts.playlistSegments() == h.outputOpts.liveSegments() {
+		return 0
+	}
+
+	return h.outputOpts.liveSegments()
+}
+
+// HasDVRPlaylist returns whether the DVR playlist is available
+func (h *HLSTranscoder) HasDVRPlaylist() bool {
+	return h.outputOpts.DVRWindow > 0
 }
 
 // Start begins the HLS transcoding process
@@ -89,38 +416,20 @@ func (h *HLSTranscoder) Start() error {
 
 	// Ensure HLS directory exists
 	if err := os.MkdirAll(h.hlsDir, 0755); err != nil {
-		h.setError(ErrorTypeFFmpegFailed, "Failed to create HLS directory", err.Error())
-		return fmt.Errorf("failed to create HLS directory: %v", err)
+		h.setError(ErrorTypeStorage, storageErrorMessage(err), err.Error())
+		return fmt.Errorf("%w: failed to create HLS directory: %v", ErrHLSStorage, err)
 	}
 
 	// Clean up any existing files
 	h.cleanupFiles()
 
 	// Build FFmpeg command
-	playlistPath := filepath.Join(h.hlsDir, "playlist.m3u8")
-	segmentPath := filepath.Join(h.hlsDir, "segment_%d.ts")
-
-	args := []string{
-		"-i", h.rtmpURL,                    // Input RTMP stream
-		"-c:v", "libx264",                  // Video codec
-		"-preset", "ultrafast",             // Fast encoding
-		"-tune", "zerolatency",             // Low latency
-		"-c:a", "aac",                      // Audio codec
-		"-f", "hls",                        // HLS format
-		"-hls_time", "2",                   // 2 second segments
-		"-hls_list_size", "5",              // Keep 5 segments in playlist
-		"-hls_flags", "delete_segments",    // Auto-delete old segments
-		"-hls_segment_filename", segmentPath,
-		"-y",                               // Overwrite output
-		playlistPath,
-	}
-
-	h.cmd = exec.Command("ffmpeg", args...)
+	h.cmd = exec.Command("ffmpeg", h.buildFFmpegArgs()...)
 	h.cmd.Dir = h.hlsDir
 
 	// Set up logging
-	h.cmd.Stdout = nil // Suppress stdout
-	h.cmd.Stderr = nil // Suppress stderr for now - could add logging if needed
+	h.cmd.Stdout = nil              // Suppress stdout
+	h.cmd.Stderr = h.stderrWriter() // Last lines are kept for diagnostics
 
 	log.Info().
 		Str("baby_uid", h.babyUID).
@@ -133,11 +442,13 @@ func (h *HLSTranscoder) Start() error {
 		h.setError(ErrorTypeFFmpegFailed, "Failed to start FFmpeg process", err.Error())
 		return fmt.Errorf("failed to start FFmpeg: %v", err)
 	}
+	h.trackProcess(h.cmd.Process)
 
 	h.isRunning = true
 	h.status = StatusConnecting
 
 	// Monitor the process
+	h.exited = make(chan struct{})
 	go h.monitor()
 
 	return nil
@@ -147,6 +458,7 @@ func (h *HLSTranscoder) Start() error {
 func (h *HLSTranscoder) Stop() {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
+	defer h.closeLogFile()
 
 	if !h.isRunning {
 		return
@@ -159,15 +471,90 @@ func (h *HLSTranscoder) Stop() {
 	h.isRunning = false
 
 	// Terminate FFmpeg process
+	// Note: the monitor waits for the process, a second concurrent Wait could block forever
 	if h.cmd != nil && h.cmd.Process != nil {
 		h.cmd.Process.Kill()
-		h.cmd.Wait() // Wait for process to exit
+		if h.exited != nil {
+			<-h.exited
+		}
 	}
 
 	// Clean up files
 	h.cleanupFiles()
 }
 
+// logFileWriter - writes FFmpeg output to the log file, failed writes are logged once and swallowed
+// FFmpeg's stderr has to keep draining when the file can't be written (disk full, closed by Stop), FFmpeg could
+// otherwise die on a broken pipe. Written by the single goroutine copying the stderr of the process.
+type logFileWriter struct {
+	babyUID string
+	file    *utils.RotatingFile
+	failed  bool
+}
+
+// Write - implements io.Writer, never fails
+func (w *logFileWriter) Write(p []byte) (int, error) {
+	if _, err := w.file.Write(p); err != nil && !w.failed && !errors.Is(err, os.ErrClosed) {
+		w.failed = true
+		log.Warn().Err(err).Str("baby_uid", w.babyUID).Msg("Failed to write FFmpeg log file, further output is only kept in the diagnostics")
+	}
+
+	return len(p), nil
+}
+
+// stderrWriter returns the writer of FFmpeg stderr, the log file is opened on first use, caller must hold the mutex
+// Failing to open the log file doesn't prevent transcoding, the output is only kept in the diagnostics tail.
+func (h *HLSTranscoder) stderrWriter() io.Writer {
+	if h.logOpts.Dir == "" {
+		return h.stderr
+	}
+
+	if h.logFile == nil {
+		filename := filepath.Join(h.logOpts.Dir, fmt.Sprintf("ffmpeg-%s.log", h.babyUID))
+		logFile, err := utils.OpenRotatingFile(filename, h.logOpts.MaxFileSize)
+		if err != nil {
+			log.Warn().Err(err).Str("baby_uid", h.babyUID).Str("file", filename).Msg("Failed to open FFmpeg log file")
+			return h.stderr
+		}
+		h.logFile = logFile
+	}
+
+	return io.MultiWriter(h.stderr, &logFileWriter{babyUID: h.babyUID, file: h.logFile})
+}
+
+// closeLogFile closes the FFmpeg log file once no process writes to it anymore, caller must hold the mutex
+func (h *HLSTranscoder) closeLogFile() {
+	if h.logFile == nil {
+		return
+	}
+
+	if err := h.logFile.Close(); err != nil {
+		log.Warn().Err(err).Str("baby_uid", h.babyUID).Msg("Failed to close FFmpeg log file")
+	}
+	h.logFile = nil
+}
+
+// Pause stops the FFmpeg process because the camera is in standby (black feed)
+// The transcoder is reported as paused_standby until it is replaced by HLSManager.ResumeTranscoding.
+func (h *HLSTranscoder) Pause() {
+	h.mutex.Lock()
+	h.isPaused = true
+	h.mutex.Unlock()
+
+	h.Stop()
+
+	h.mutex.Lock()
+	h.status = StatusPausedStandby
+	h.mutex.Unlock()
+}
+
+// IsPaused returns whether the transcoder was paused due to standby
+func (h *HLSTranscoder) IsPaused() bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.isPaused
+}
+
 // IsRunning returns whether the transcoder is currently running
 func (h *HLSTranscoder) IsRunning() bool {
 	h.mutex.RLock()
@@ -177,7 +564,12 @@ func (h *HLSTranscoder) IsRunning() bool {
 
 // GetPlaylistPath returns the path to the HLS playlist
 func (h *HLSTranscoder) GetPlaylistPath() string {
-	return filepath.Join(h.hlsDir, "playlist.m3u8")
+	return filepath.Join(h.hlsDir, h.mode.PlaylistName())
+}
+
+// GetMode returns what the transcoder produces, fixed for the lifetime of the transcoder
+func (h *HLSTranscoder) GetMode() StreamMode {
+	return h.mode
 }
 
 // GetHLSDir returns the HLS directory path
@@ -186,38 +578,41 @@ func (h *HLSTranscoder) GetHLSDir() string {
 }
 
 // monitor watches the FFmpeg process and handles cleanup
+// A transcoder waiting for its retry stays running, so it keeps counting towards the limit and can be stopped.
 func (h *HLSTranscoder) monitor() {
+	retrying := false
 	defer func() {
+		if retrying {
+			return
+		}
+
 		h.mutex.Lock()
 		h.isRunning = false
-		if h.status != StatusError {
+		if h.isPaused {
+			h.status = StatusPausedStandby
+		} else if h.status != StatusError {
 			h.status = StatusStopped
 		}
 		h.mutex.Unlock()
 	}()
 
 	// Check if HLS files are being generated (indicates successful connection)
-	checkTicker := time.NewTicker(5 * time.Second)
-	defer checkTicker.Stop()
-	
-	connected := false
-	go func() {
-		for range checkTicker.C {
-			if h.hasHLSFiles() && !connected {
-				h.mutex.Lock()
-				h.status = StatusStreaming
-				connected = true
-				h.mutex.Unlock()
-				log.Info().Str("baby_uid", h.babyUID).Msg("HLS transcoding producing files successfully")
-				break
-			}
-		}
-	}()
+	// Note: the check has to end with the monitor, FFmpeg may exit (or be killed) before producing any files
+	monitorDone := make(chan struct{})
+	defer close(monitorDone)
+	go h.watchForFiles(monitorDone)
 
 	// Wait for process to finish or stop signal
+	exited := h.exited
+	process := h.cmd.Process
 	done := make(chan error, 1)
 	go func() {
-		done <- h.cmd.Wait()
+		err := h.cmd.Wait()
+		h.untrackProcess(process)
+		if exited != nil {
+			close(exited)
+		}
+		done <- err
 	}()
 
 	select {
@@ -235,6 +630,7 @@ func (h *HLSTranscoder) monitor() {
 			
 			// Attempt retry for connection issues
 			if h.shouldRetry() {
+				retrying = true
 				h.scheduleRetry()
 				return
 			}
@@ -244,120 +640,455 @@ func (h *HLSTranscoder) monitor() {
 	}
 }
 
-// cleanupFiles removes HLS files from the directory
-func (h *HLSTranscoder) cleanupFiles() {
-	pattern := filepath.Join(h.hlsDir, "*")
-	matches, err := filepath.Glob(pattern)
-	if err != nil {
-		log.Warn().Err(err).Str("baby_uid", h.babyUID).Msg("Failed to glob HLS files for cleanup")
-		return
+// trackProcess registers a started FFmpeg process with the manager, see HLSManager.KillProcesses
+func (h *HLSTranscoder) trackProcess(process *os.Process) {
+	if h.processes != nil {
+		h.processes.Store(process.Pid, process)
 	}
+}
+
+// untrackProcess forgets an FFmpeg process once it has been waited for
+func (h *HLSTranscoder) untrackProcess(process *os.Process) {
+	if h.processes != nil {
+		h.processes.CompareAndDelete(process.Pid, process)
+	}
+}
+
+// watchForFiles marks the transcoder as streaming once HLS files appear, returns early when done is closed
+func (h *HLSTranscoder) watchForFiles(done <-chan struct{}) {
+	checkTicker := time.NewTicker(fileCheckInterval)
+	defer checkTicker.Stop()
+
+	for {
+		select {
+		case <-checkTicker.C:
+			if h.hasHLSFiles() {
+				h.mutex.Lock()
+				h.status = StatusStreaming
+				h.mutex.Unlock()
+				log.Info().Str("baby_uid", h.babyUID).Msg("HLS transcoding producing files successfully")
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
 
-	for _, file := range matches {
-		if err := os.Remove(file); err != nil {
-			log.Warn().Err(err).Str("file", file).Msg("Failed to remove HLS file")
+// cleanupFiles removes HLS files of the transcoder's mode from the directory
+func (h *HLSTranscoder) cleanupFiles() {
+	for _, pattern := range h.mode.filePatterns() {
+		matches, err := filepath.Glob(filepath.Join(h.hlsDir, pattern))
+		if err != nil {
+			log.Warn().Err(err).Str("baby_uid", h.babyUID).Msg("Failed to glob HLS files for cleanup")
+			return
+		}
+
+		for _, file := range matches {
+			if err := os.Remove(file); err != nil {
+				log.Warn().Err(err).Str("file", file).Msg("Failed to remove HLS file")
+			}
 		}
 	}
 }
 
+// ErrTranscoderLimitReached - returned when starting another transcoder would exceed the configured maximum
+var ErrTranscoderLimitReached = errors.New("maximum number of concurrent transcoders reached")
+
+// ErrHLSStorage - returned when the HLS directory can't be created or written (disk full, read-only, permissions)
+var ErrHLSStorage = errors.New("HLS storage unavailable")
+
+// transcoderKey - registry key of a transcoder, a baby can have a video and an audio-only transcoder at the same time
+type transcoderKey struct {
+	babyUID string
+	mode    StreamMode
+}
+
 // HLSManager manages multiple HLS transcoders
 type HLSManager struct {
-	transcoders   map[string]*HLSTranscoder
+	transcoders   map[transcoderKey]*HLSTranscoder
 	baseHLSDir    string
+	inputOpts     InputOpts
+	outputOpts    OutputOpts
+	logOpts       LogOpts
+	encodeOpts    map[string]EncodeOpts // Video encoding profiles by baby UID
+	maxConcurrent int // Maximum number of running transcoders, unlimited if 0
+	babyLocks     map[transcoderKey]*sync.Mutex // Serialize starting and stopping the transcoder of a baby
+	starting      map[transcoderKey]bool // Transcoders being started, they count towards the limit before FFmpeg runs
+	processes     sync.Map // Running FFmpeg processes of all transcoders by PID, see KillProcesses
 	mutex         sync.RWMutex
-	cleanupTicker *time.Ticker
-	stopCleanup   chan struct{}
 }
 
 // NewHLSManager creates a new HLS manager
+// Note: periodic cleanup of orphaned files is started separately via RunPeriodicCleanup
 func NewHLSManager(baseHLSDir string) *HLSManager {
-	manager := &HLSManager{
-		transcoders: make(map[string]*HLSTranscoder),
+	return &HLSManager{
+		transcoders: make(map[transcoderKey]*HLSTranscoder),
+		encodeOpts:  make(map[string]EncodeOpts),
+		babyLocks:   make(map[transcoderKey]*sync.Mutex),
+		starting:    make(map[transcoderKey]bool),
 		baseHLSDir:  baseHLSDir,
-		stopCleanup: make(chan struct{}),
+		inputOpts:   DefaultInputOpts(),
+		outputOpts:  DefaultOutputOpts(),
 	}
-	
-	// Start periodic cleanup of orphaned files
-	manager.startPeriodicCleanup()
-	
-	return manager
 }
 
-// StartTranscoding starts HLS transcoding for a baby
-func (m *HLSManager) StartTranscoding(babyUID, rtmpURL string) error {
+// SetInputOpts sets FFmpeg input options used by transcoders started afterwards
+func (m *HLSManager) SetInputOpts(opts InputOpts) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
+	m.inputOpts = opts
+}
 
-	// Stop existing transcoder if running
-	if existing, exists := m.transcoders[babyUID]; exists {
-		existing.Stop()
+// SetOutputOpts sets HLS playlist windows and segment retention used by transcoders started afterwards
+func (m *HLSManager) SetOutputOpts(opts OutputOpts) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.outputOpts = opts
+}
+
+// SetLogOpts sets FFmpeg logging used by transcoders started afterwards
+func (m *HLSManager) SetLogOpts(opts LogOpts) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.logOpts = opts
+}
+
+// SetEncodeOpts sets the video encoding profile of a baby used by its transcoders started afterwards
+func (m *HLSManager) SetEncodeOpts(babyUID string, opts EncodeOpts) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if opts == (EncodeOpts{}) {
+		delete(m.encodeOpts, babyUID)
+		return
 	}
+	m.encodeOpts[babyUID] = opts
+}
 
-	// Create new transcoder
-	transcoder := NewHLSTranscoder(babyUID, rtmpURL, m.baseHLSDir)
-	if err := transcoder.Start(); err != nil {
+// SetMaxConcurrent limits the number of running transcoders (0 for unlimited)
+// Transcoders already running are not stopped when the limit is lowered.
+func (m *HLSManager) SetMaxConcurrent(maxConcurrent int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.maxConcurrent = maxConcurrent
+}
+
+// activeCount returns number of running (including retrying) and starting transcoders apart from the one of given key,
+// caller must hold the mutex
+func (m *HLSManager) activeCount(except transcoderKey) int {
+	count := 0
+	for key := range m.starting {
+		if key != except {
+			count++
+		}
+	}
+
+	for key, transcoder := range m.transcoders {
+		if key != except && !m.starting[key] && transcoder.IsRunning() {
+			count++
+		}
+	}
+
+	return count
+}
+
+// reserve checks the limit and counts the transcoder of the key as starting until it is registered by replace
+// Concurrent starts of different babies can't exceed the limit while their FFmpeg processes come up.
+func (m *HLSManager) reserve(key transcoderKey) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := m.checkLimit(key); err != nil {
 		return err
 	}
 
-	m.transcoders[babyUID] = transcoder
+	m.starting[key] = true
 	return nil
 }
 
-// StopTranscoding stops HLS transcoding for a baby
-func (m *HLSManager) StopTranscoding(babyUID string) {
+// checkLimit returns ErrTranscoderLimitReached if the transcoder of the key can't be started, caller must hold the mutex
+// The transcoder registered under the key doesn't count, it is replaced.
+func (m *HLSManager) checkLimit(key transcoderKey) error {
+	if m.maxConcurrent > 0 && m.activeCount(key) >= m.maxConcurrent {
+		log.Warn().Str("baby_uid", key.babyUID).Str("mode", string(key.mode)).Int("max_concurrent", m.maxConcurrent).Msg("Not starting HLS transcoding, limit of concurrent transcoders reached")
+		return ErrTranscoderLimitReached
+	}
+
+	return nil
+}
+
+// babyLock returns the lock serializing the operations on the transcoder of a baby in the mode
+// The manager mutex is only held for the registry, so stopping FFmpeg of one baby doesn't block the others.
+func (m *HLSManager) babyLock(key transcoderKey) *sync.Mutex {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	lock, exists := m.babyLocks[key]
+	if !exists {
+		lock = &sync.Mutex{}
+		m.babyLocks[key] = lock
+	}
+
+	return lock
+}
+
+// isHealthy returns whether the transcoder is producing the stream of the URL in the mode
+func (h *HLSTranscoder) isHealthy(rtmpURL string, mode StreamMode) bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	return h.isRunning && !h.isPaused && h.status != StatusError && h.rtmpURL == rtmpURL && h.mode == mode
+}
+
+// StartTranscoding starts HLS transcoding (video) for a baby
+// Returns ErrTranscoderLimitReached if the maximum of concurrent transcoders is running already.
+func (m *HLSManager) StartTranscoding(babyUID, rtmpURL string) error {
+	return m.StartTranscodingMode(babyUID, rtmpURL, StreamModeVideo)
+}
+
+// StartTranscodingMode starts HLS transcoding in given mode for a baby, a transcoder in the other mode keeps running
+// A healthy transcoder of the same stream and mode is kept, so concurrent starts (auto-start and the user) don't restart FFmpeg.
+// Returns ErrTranscoderLimitReached if the maximum of concurrent transcoders is running already.
+func (m *HLSManager) StartTranscodingMode(babyUID, rtmpURL string, mode StreamMode) error {
+	return m.startTranscoding(babyUID, rtmpURL, mode, false)
+}
+
+// RestartTranscodingMode replaces the transcoder of a baby even if it is healthy, e.g. to apply new encoding options
+// Returns ErrTranscoderLimitReached if the maximum of concurrent transcoders is running already.
+func (m *HLSManager) RestartTranscodingMode(babyUID, rtmpURL string, mode StreamMode) error {
+	return m.startTranscoding(babyUID, rtmpURL, mode, true)
+}
+
+func (m *HLSManager) startTranscoding(babyUID, rtmpURL string, mode StreamMode, restart bool) error {
+	key := transcoderKey{babyUID, mode}
+	lock := m.babyLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	existing, exists := m.GetTranscoderMode(babyUID, mode)
+	if exists && !restart && existing.isHealthy(rtmpURL, mode) {
+		log.Debug().Str("baby_uid", babyUID).Str("mode", string(mode)).Msg("HLS transcoding already running, keeping it")
+		return nil
+	}
+
+	// Checked before anything is stopped, the running transcoder is kept if the new one can't be started
+	if err := m.reserve(key); err != nil {
+		return err
+	}
+
+	m.mutex.RLock()
+	transcoder := m.newTranscoder(key, rtmpURL)
+	m.mutex.RUnlock()
+
+	// Previous FFmpeg has to exit before the new one writes to the same HLS directory
+	if exists {
+		existing.Stop()
+	}
+
+	err := transcoder.Start()
+
+	// Keep the failed transcoder registered, so its storage error shows up in the stream status and health
+	if err != nil && !errors.Is(err, ErrHLSStorage) {
+		transcoder = nil
+	}
+	m.replace(key, existing, transcoder)
+
+	return err
+}
+
+// newTranscoder creates a transcoder of the key with the current options, caller must hold the mutex
+func (m *HLSManager) newTranscoder(key transcoderKey, rtmpURL string) *HLSTranscoder {
+	transcoder := NewHLSTranscoder(key.babyUID, rtmpURL, m.baseHLSDir, m.inputOpts)
+	transcoder.mode = key.mode
+	transcoder.outputOpts = m.outputOpts
+	transcoder.logOpts = m.logOpts
+	transcoder.encodeOpts = m.encodeOpts[key.babyUID]
+	transcoder.processes = &m.processes
+	return transcoder
+}
+
+// replace registers the transcoder under the key in place of the replaced one (nil removes it) and releases
+// the reservation of the key, caller must hold the baby lock
+// FFmpeg is started and stopped outside of the manager mutex, so a slow process doesn't block the other babies.
+func (m *HLSManager) replace(key transcoderKey, replaced, transcoder *HLSTranscoder) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	if transcoder, exists := m.transcoders[babyUID]; exists {
+	delete(m.starting, key)
+
+	if transcoder != nil {
+		m.transcoders[key] = transcoder
+	} else if replaced != nil && m.transcoders[key] == replaced {
+		delete(m.transcoders, key)
+	}
+}
+
+// streamModes - modes a baby can have a transcoder in
+var streamModes = []StreamMode{StreamModeVideo, StreamModeAudio}
+
+// StopTranscoding stops HLS transcoding of a baby in all modes
+func (m *HLSManager) StopTranscoding(babyUID string) {
+	for _, mode := range streamModes {
+		m.StopTranscodingMode(babyUID, mode)
+	}
+}
+
+// StopTranscodingMode stops HLS transcoding of a baby in given mode, a transcoder in the other mode keeps running
+func (m *HLSManager) StopTranscodingMode(babyUID string, mode StreamMode) {
+	key := transcoderKey{babyUID, mode}
+	lock := m.babyLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	m.mutex.Lock()
+	transcoder, exists := m.transcoders[key]
+	delete(m.transcoders, key)
+	m.mutex.Unlock()
+
+	if exists {
 		transcoder.Stop()
-		delete(m.transcoders, babyUID)
 	}
 }
 
-// GetTranscoder returns the transcoder for a baby
+// PauseTranscoding pauses running HLS transcoding of a baby in all modes, returns false if there was nothing to pause
+// The paused transcoders stay registered, so that their status can be reported and transcoding resumed.
+func (m *HLSManager) PauseTranscoding(babyUID string) bool {
+	paused := false
+	for _, mode := range streamModes {
+		if m.pauseTranscoding(transcoderKey{babyUID, mode}) {
+			paused = true
+		}
+	}
+
+	return paused
+}
+
+// pauseTranscoding pauses the running transcoder of the key, returns false if there was nothing to pause
+func (m *HLSManager) pauseTranscoding(key transcoderKey) bool {
+	lock := m.babyLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	transcoder, exists := m.GetTranscoderMode(key.babyUID, key.mode)
+	if !exists || !transcoder.IsRunning() {
+		return false
+	}
+
+	transcoder.Pause()
+	return true
+}
+
+// ResumeTranscoding restarts paused HLS transcoding of a baby with fresh transcoders, returns false if none was paused
+func (m *HLSManager) ResumeTranscoding(babyUID string) (bool, error) {
+	resumed := false
+	for _, mode := range streamModes {
+		ok, err := m.resumeTranscoding(transcoderKey{babyUID, mode})
+		resumed = resumed || ok
+		if err != nil {
+			return resumed, err
+		}
+	}
+
+	return resumed, nil
+}
+
+// resumeTranscoding replaces the paused transcoder of the key, returns false if it wasn't paused
+func (m *HLSManager) resumeTranscoding(key transcoderKey) (bool, error) {
+	lock := m.babyLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	paused, exists := m.GetTranscoderMode(key.babyUID, key.mode)
+	if !exists || !paused.IsPaused() {
+		return false, nil
+	}
+
+	if err := m.reserve(key); err != nil {
+		return true, err
+	}
+
+	m.mutex.RLock()
+	transcoder := m.newTranscoder(key, paused.rtmpURL)
+	transcoder.outputOpts = paused.outputOpts
+	transcoder.logOpts = paused.logOpts
+	m.mutex.RUnlock()
+
+	if err := transcoder.Start(); err != nil {
+		// Paused transcoder stays registered, so resuming can be retried
+		m.replace(key, paused, paused)
+		return true, err
+	}
+
+	m.replace(key, paused, transcoder)
+	return true, nil
+}
+
+// GetTranscoder returns the (video) transcoder of a baby
 func (m *HLSManager) GetTranscoder(babyUID string) (*HLSTranscoder, bool) {
+	return m.GetTranscoderMode(babyUID, StreamModeVideo)
+}
+
+// GetTranscoderMode returns the transcoder of a baby in given mode
+func (m *HLSManager) GetTranscoderMode(babyUID string, mode StreamMode) (*HLSTranscoder, bool) {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
-	transcoder, exists := m.transcoders[babyUID]
+	transcoder, exists := m.transcoders[transcoderKey{babyUID, mode}]
 	return transcoder, exists
 }
 
 // StopAll stops all transcoders
+// Safe to call repeatedly (e.g. auth reset followed by shutdown), the cleanup routine is bound to its context instead
 func (m *HLSManager) StopAll() {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-
-	// Stop cleanup ticker
-	if m.cleanupTicker != nil {
-		m.cleanupTicker.Stop()
-		close(m.stopCleanup)
+	m.mutex.RLock()
+	keys := make([]transcoderKey, 0, len(m.transcoders))
+	for key := range m.transcoders {
+		keys = append(keys, key)
 	}
+	m.mutex.RUnlock()
 
-	for babyUID, transcoder := range m.transcoders {
-		transcoder.Stop()
-		delete(m.transcoders, babyUID)
+	for _, key := range keys {
+		m.StopTranscodingMode(key.babyUID, key.mode)
 	}
 }
 
-// startPeriodicCleanup starts a background routine to clean up orphaned HLS files
-func (m *HLSManager) startPeriodicCleanup() {
-	m.cleanupTicker = time.NewTicker(30 * time.Minute) // Clean up every 30 minutes
-	
-	go func() {
-		for {
-			select {
-			case <-m.cleanupTicker.C:
-				m.cleanupOrphanedFiles()
-			case <-m.stopCleanup:
-				return
-			}
+// KillProcesses kills the FFmpeg processes of all transcoders, returns how many were killed
+// Last resort on a forced shutdown: neither takes a lock nor waits, so it can't hang on a stuck transcoder.
+func (m *HLSManager) KillProcesses() int {
+	killed := 0
+	m.processes.Range(func(_, process any) bool {
+		if process.(*os.Process).Kill() == nil {
+			killed++
 		}
-	}()
+		return true
+	})
+	return killed
+}
+
+// RunPeriodicCleanup cleans up orphaned HLS files until the context is cancelled (blocking)
+func (m *HLSManager) RunPeriodicCleanup(ctx utils.GracefulContext) {
+	ticker := time.NewTicker(30 * time.Minute) // Clean up every 30 minutes
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.CleanupOrphanedFiles()
+		case <-ctx.Done():
+			log.Debug().Msg("Stopping periodic HLS cleanup")
+			return
+		}
+	}
+}
+
+// GetBaseDir returns the directory holding HLS output of all babies
+func (m *HLSManager) GetBaseDir() string {
+	return m.baseHLSDir
 }
 
-// cleanupOrphanedFiles removes HLS files for babies that are no longer being transcoded
-func (m *HLSManager) cleanupOrphanedFiles() {
+// CleanupOrphanedFiles removes HLS files for babies that are no longer being transcoded, returns number of removed directories
+func (m *HLSManager) CleanupOrphanedFiles() int {
 	log.Debug().Msg("Starting periodic HLS cleanup")
 	
 	// Get list of all baby directories
@@ -365,13 +1096,13 @@ func (m *HLSManager) cleanupOrphanedFiles() {
 	matches, err := filepath.Glob(pattern)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to glob baby directories for cleanup")
-		return
+		return 0
 	}
 	
 	m.mutex.RLock()
 	activeTranscoders := make(map[string]bool)
-	for babyUID := range m.transcoders {
-		activeTranscoders[babyUID] = true
+	for key := range m.transcoders {
+		activeTranscoders[key.babyUID] = true
 	}
 	m.mutex.RUnlock()
 	
@@ -398,6 +1129,8 @@ func (m *HLSManager) cleanupOrphanedFiles() {
 	if cleanedCount > 0 {
 		log.Info().Int("cleaned_count", cleanedCount).Msg("Completed HLS cleanup")
 	}
+
+	return cleanedCount
 }
 
 // hasOldFiles checks if a directory contains files older than the specified duration
@@ -441,6 +1174,12 @@ func (h *HLSTranscoder) classifyAndSetError(err error) {
 	
 	errStr := err.Error()
 	
+	// FFmpeg reports failed writes on stderr only, the exit status doesn't tell them apart from RTMP issues
+	if line, ok := findStorageError(h.stderr.Lines()); ok {
+		h.setError(ErrorTypeStorage, storageErrorMessage(errors.New(line)), line)
+		return
+	}
+	
 	// Check for common RTMP connection issues
 	if strings.Contains(errStr, "Connection refused") || 
 	   strings.Contains(errStr, "Connection reset") ||
@@ -465,9 +1204,45 @@ func (h *HLSTranscoder) classifyAndSetError(err error) {
 	}
 }
 
+// storageErrorPatterns - FFmpeg stderr messages of failed writes to the HLS directory, lower case
+var storageErrorPatterns = []string{
+	"no space left on device",
+	"disk quota exceeded",
+	"read-only file system",
+	"permission denied",
+}
+
+// findStorageError returns the last stderr line reporting a failed write to the HLS directory
+func findStorageError(lines []string) (string, bool) {
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.ToLower(lines[i])
+		for _, pattern := range storageErrorPatterns {
+			if strings.Contains(line, pattern) {
+				return lines[i], true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// storageErrorMessage returns the user facing message of an HLS storage error
+func storageErrorMessage(err error) string {
+	if errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EDQUOT) {
+		return "Disk full, cannot write HLS files"
+	}
+
+	errStr := strings.ToLower(err.Error())
+	if strings.Contains(errStr, "no space left on device") || strings.Contains(errStr, "disk quota exceeded") {
+		return "Disk full, cannot write HLS files"
+	}
+
+	return "HLS directory not writable"
+}
+
 // hasHLSFiles checks if HLS files are being generated
 func (h *HLSTranscoder) hasHLSFiles() bool {
-	playlistPath := filepath.Join(h.hlsDir, "playlist.m3u8")
+	playlistPath := h.GetPlaylistPath()
 	if _, err := os.Stat(playlistPath); err == nil {
 		// Check if playlist was recently modified (within last 10 seconds)
 		if info, err := os.Stat(playlistPath); err == nil {
@@ -484,32 +1259,69 @@ func (h *HLSTranscoder) GetStatus() (StreamStatus, *StreamError) {
 	return h.status, h.lastError
 }
 
+// GetStderrTail returns the last lines FFmpeg wrote to stderr, across retries
+func (h *HLSTranscoder) GetStderrTail() []string {
+	return h.stderr.Lines()
+}
+
 // GetDetailedInfo returns comprehensive transcoder information
 func (h *HLSTranscoder) GetDetailedInfo() map[string]interface{} {
 	h.mutex.RLock()
 	defer h.mutex.RUnlock()
 	
 	info := map[string]interface{}{
-		"baby_uid":     h.babyUID,
-		"status":       string(h.status),
-		"is_running":   h.isRunning,
-		"start_time":   h.startTime,
-		"retry_count":  h.retryCount,
-		"max_retries":  h.maxRetries,
+		"baby_uid":       h.babyUID,
+		"mode":           string(h.mode),
+		"status":         string(h.status),
+		"is_running":     h.isRunning,
+		"is_paused":      h.isPaused,
+		"start_time":     unixOrZero(h.startTime), // Unix seconds like the rest of the API
+		"uptime_seconds": h.uptimeSeconds(),
+		"retry_count":    h.retryCount,
+		"max_retries":    h.maxRetries,
 	}
 	
 	if h.lastError != nil {
 		info["error"] = h.lastError
 	}
+
+	if h.tiles != nil {
+		info["tiles"] = h.tiles
+	}
 	
 	if h.isRunning {
-		info["uptime"] = time.Since(h.startTime).Seconds()
 		info["has_files"] = h.hasHLSFiles()
 	}
 	
 	return info
 }
 
+// GetStartTime returns when the transcoder was started (Unix seconds, 0 if never) and for how long it has been
+// running (whole seconds, 0 if not running)
+func (h *HLSTranscoder) GetStartTime() (int64, int64) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return unixOrZero(h.startTime), h.uptimeSeconds()
+}
+
+// uptimeSeconds returns whole seconds since the start while running, 0 otherwise, caller must hold the mutex
+func (h *HLSTranscoder) uptimeSeconds() int64 {
+	if !h.isRunning || h.startTime.IsZero() {
+		return 0
+	}
+
+	return int64(time.Since(h.startTime).Seconds())
+}
+
+// unixOrZero returns Unix seconds of the time, 0 for the zero time
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.Unix()
+}
+
 // shouldRetry determines if we should retry based on error type and retry count
 func (h *HLSTranscoder) shouldRetry() bool {
 	if h.retryCount >= h.maxRetries {
@@ -565,6 +1377,10 @@ func (h *HLSTranscoder) scheduleRetry() {
 					Err(err).
 					Str("baby_uid", h.babyUID).
 					Msg("Failed to restart FFmpeg during retry")
+
+				h.mutex.Lock()
+				h.isRunning = false
+				h.mutex.Unlock()
 			}
 		case <-h.stopChan:
 			return
@@ -573,42 +1389,44 @@ func (h *HLSTranscoder) scheduleRetry() {
 }
 
 // restartFFmpeg restarts the FFmpeg process for retries
+// The process is started and published under the lock, so a concurrent Stop either prevents the restart
+// or kills and waits for the new process.
 func (h *HLSTranscoder) restartFFmpeg() error {
+	// The directory may have been removed or its filesystem remounted read-only meanwhile
+	if err := os.MkdirAll(h.hlsDir, 0755); err != nil {
+		h.mutex.Lock()
+		h.setError(ErrorTypeStorage, storageErrorMessage(err), err.Error())
+		h.mutex.Unlock()
+		return fmt.Errorf("%w: failed to create HLS directory: %v", ErrHLSStorage, err)
+	}
+
 	// Clean up any existing files
 	h.cleanupFiles()
 
 	// Build FFmpeg command
-	playlistPath := filepath.Join(h.hlsDir, "playlist.m3u8")
-	segmentPath := filepath.Join(h.hlsDir, "segment_%d.ts")
+	cmd := exec.Command("ffmpeg", h.buildFFmpegArgs()...)
+	cmd.Dir = h.hlsDir
+	cmd.Stdout = nil // Suppress stdout
 
-	args := []string{
-		"-i", h.rtmpURL,                    // Input RTMP stream
-		"-c:v", "libx264",                  // Video codec
-		"-preset", "ultrafast",             // Fast encoding
-		"-tune", "zerolatency",             // Low latency
-		"-c:a", "aac",                      // Audio codec
-		"-f", "hls",                        // HLS format
-		"-hls_time", "2",                   // 2 second segments
-		"-hls_list_size", "5",              // Keep 5 segments in playlist
-		"-hls_flags", "delete_segments",    // Auto-delete old segments
-		"-hls_segment_filename", segmentPath,
-		"-y",                               // Overwrite output
-		playlistPath,
-	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
 
-	h.cmd = exec.Command("ffmpeg", args...)
-	h.cmd.Dir = h.hlsDir
+	// Stopped while the directory was prepared
+	if !h.isRunning {
+		log.Debug().Str("baby_uid", h.babyUID).Msg("HLS transcoding stopped before the retry, not restarting FFmpeg")
+		return nil
+	}
 
-	// Set up logging
-	h.cmd.Stdout = nil // Suppress stdout
-	h.cmd.Stderr = nil // Suppress stderr for now - could add logging if needed
+	cmd.Stderr = h.stderrWriter() // Last lines are kept for diagnostics
 
-	if err := h.cmd.Start(); err != nil {
-		h.mutex.Lock()
+	if err := cmd.Start(); err != nil {
 		h.setError(ErrorTypeFFmpegFailed, "Failed to restart FFmpeg process", err.Error())
-		h.mutex.Unlock()
 		return fmt.Errorf("failed to restart FFmpeg: %v", err)
 	}
+	h.trackProcess(cmd.Process)
+
+	h.cmd = cmd
+	h.exited = make(chan struct{})
 
 	// Monitor the process
 	go h.monitor()
@@ -0,0 +1,598 @@
+package streaming
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startFakeFFmpeg starts the command in place of FFmpeg, as Start would
+func startFakeFFmpeg(t *testing.T, name string, args ...string) *HLSTranscoder {
+	h := NewHLSTranscoder("baby1", "rtmp://localhost/local/baby1", t.TempDir(), DefaultInputOpts())
+	h.cmd = exec.Command(name, args...)
+	if err := h.cmd.Start(); err != nil {
+		t.Skipf("%s not available: %v", name, err)
+	}
+	h.isRunning = true
+
+	return h
+}
+
+// waitForGoroutines waits until the number of goroutines drops to the limit, returns the last count
+func waitForGoroutines(limit int) int {
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		count := runtime.NumGoroutine()
+		if count <= limit || time.Now().After(deadline) {
+			return count
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMonitorDoesNotLeakWhenProcessExitsWithoutFiles(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 20; i++ {
+		h := startFakeFFmpeg(t, "true")
+		h.monitor()
+
+		assert.False(t, h.IsRunning())
+		status, _ := h.GetStatus()
+		assert.Equal(t, StatusStopped, status)
+	}
+
+	assert.LessOrEqual(t, waitForGoroutines(before), before)
+}
+
+func TestMonitorDoesNotLeakWhenStopped(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 5; i++ {
+		h := startFakeFFmpeg(t, "sleep", "10")
+
+		monitorDone := make(chan struct{})
+		go func() {
+			h.monitor()
+			close(monitorDone)
+		}()
+
+		h.Stop()
+
+		select {
+		case <-monitorDone:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Monitor did not return after stop")
+		}
+	}
+
+	assert.LessOrEqual(t, waitForGoroutines(before), before)
+}
+
+func TestStopWaitsForMonitoredProcess(t *testing.T) {
+	h := startFakeFFmpeg(t, "sleep", "10")
+	h.exited = make(chan struct{})
+	cmd := h.cmd
+
+	monitorDone := make(chan struct{})
+	go func() {
+		h.monitor()
+		close(monitorDone)
+	}()
+
+	// The process is reaped by the monitor, Stop returns once it has been
+	h.Stop()
+	assert.NotNil(t, cmd.ProcessState)
+	assert.Error(t, syscall.Kill(cmd.Process.Pid, 0))
+
+	select {
+	case <-monitorDone:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Monitor did not return after stop")
+	}
+}
+
+func TestStartTranscodingRespectsMaxConcurrent(t *testing.T) {
+	manager := NewHLSManager(t.TempDir())
+	manager.SetMaxConcurrent(1)
+
+	running := startFakeFFmpeg(t, "sleep", "10")
+	defer running.Stop()
+	manager.transcoders[transcoderKey{"baby1", StreamModeVideo}] = running
+
+	err := manager.StartTranscoding("baby2", "rtmp://localhost/local/baby2")
+	assert.Equal(t, ErrTranscoderLimitReached, err)
+
+	_, exists := manager.GetTranscoder("baby2")
+	assert.False(t, exists)
+
+	// Restarting the running baby replaces its transcoder, so the limit doesn't apply
+	assert.NoError(t, manager.checkLimit(transcoderKey{"baby1", StreamModeVideo}))
+
+	// Unlimited when disabled
+	manager.SetMaxConcurrent(0)
+	assert.NoError(t, manager.checkLimit(transcoderKey{"baby2", StreamModeVideo}))
+}
+
+func TestLimitCountsStartingAndRetryingTranscoders(t *testing.T) {
+	manager := NewHLSManager(t.TempDir())
+	manager.SetMaxConcurrent(1)
+
+	// Reserved while its FFmpeg comes up
+	assert.NoError(t, manager.reserve(transcoderKey{"baby1", StreamModeVideo}))
+	assert.Equal(t, ErrTranscoderLimitReached, manager.StartTranscoding("baby2", "rtmp://localhost/local/baby2"))
+	manager.replace(transcoderKey{"baby1", StreamModeVideo}, nil, nil)
+	assert.NoError(t, manager.checkLimit(transcoderKey{"baby2", StreamModeVideo}))
+
+	// FFmpeg exited with a connection error, the retry is pending
+	retrying := startFakeFFmpeg(t, "false")
+	retrying.monitor()
+	manager.transcoders[transcoderKey{"baby1", StreamModeVideo}] = retrying
+
+	assert.True(t, retrying.IsRunning())
+	assert.Equal(t, ErrTranscoderLimitReached, manager.checkLimit(transcoderKey{"baby2", StreamModeVideo}))
+
+	// Stopping cancels the retry
+	manager.StopTranscoding("baby1")
+	assert.False(t, retrying.IsRunning())
+	assert.NoError(t, manager.checkLimit(transcoderKey{"baby2", StreamModeVideo}))
+}
+
+func TestConcurrentStartTranscodingKeepsHealthyTranscoder(t *testing.T) {
+	manager := NewHLSManager(t.TempDir())
+
+	running := startFakeFFmpeg(t, "sleep", "10")
+	defer running.Stop()
+	running.status = StatusStreaming
+	manager.transcoders[transcoderKey{"baby1", StreamModeVideo}] = running
+
+	// Auto-start and the user starting the stream at the same time
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- manager.StartTranscoding("baby1", "rtmp://localhost/local/baby1")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	transcoder, exists := manager.GetTranscoder("baby1")
+	assert.True(t, exists)
+	assert.Same(t, running, transcoder)
+	assert.True(t, running.IsRunning())
+
+	// Failed or differently configured transcoders are not reused
+	assert.False(t, running.isHealthy("rtmp://localhost/local/baby1", StreamModeAudio))
+	assert.False(t, running.isHealthy("rtmp://localhost/local/other", StreamModeVideo))
+	running.mutex.Lock()
+	running.status = StatusError
+	running.mutex.Unlock()
+	assert.False(t, running.isHealthy("rtmp://localhost/local/baby1", StreamModeVideo))
+
+	// Stopping waits for a start in progress of the same baby
+	lock := manager.babyLock(transcoderKey{"baby1", StreamModeVideo})
+	assert.Same(t, lock, manager.babyLock(transcoderKey{"baby1", StreamModeVideo}))
+	lock.Lock()
+	stopped := make(chan struct{})
+	go func() {
+		manager.StopTranscoding("baby1")
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatal("Stop did not wait for the baby lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+	lock.Unlock()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop did not finish")
+	}
+	assert.False(t, running.IsRunning())
+	_, exists = manager.GetTranscoder("baby1")
+	assert.False(t, exists)
+}
+
+// startedPIDs returns PIDs of the processes that appended themselves to the file
+func startedPIDs(t *testing.T, filename string) []int {
+	data, _ := os.ReadFile(filename)
+	pids := []int{}
+	for _, line := range strings.Fields(string(data)) {
+		pid, err := strconv.Atoi(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pids = append(pids, pid)
+	}
+
+	return pids
+}
+
+func TestConcurrentStartsLeaveSingleFFmpeg(t *testing.T) {
+	// FFmpeg stand-in recording its PID, then running until stopped
+	binDir := t.TempDir()
+	pidFile := filepath.Join(binDir, "pids")
+	script := fmt.Sprintf("#!/bin/sh\necho $$ >> %s\nexec sleep 30\n", pidFile)
+	if err := os.WriteFile(filepath.Join(binDir, "ffmpeg"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	manager := NewHLSManager(t.TempDir())
+	manager.SetMaxConcurrent(1)
+	defer manager.StopAll()
+
+	// Auto-start, the user and an encoding change starting the stream at the same time
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%4 == 0 {
+				errs <- manager.RestartTranscodingMode("baby1", "rtmp://localhost/local/baby1", StreamModeVideo)
+			} else {
+				errs <- manager.StartTranscoding("baby1", "rtmp://localhost/local/baby1")
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	transcoder, exists := manager.GetTranscoder("baby1")
+	if !assert.True(t, exists) || !assert.True(t, transcoder.IsRunning()) {
+		return
+	}
+	runningPID := transcoder.cmd.Process.Pid
+
+	// Every replaced FFmpeg exited before the next one was started, only the registered one is alive
+	assert.Eventually(t, func() bool {
+		pids := startedPIDs(t, pidFile)
+		return len(pids) > 0 && pids[len(pids)-1] == runningPID
+	}, 2*time.Second, 10*time.Millisecond)
+	for _, pid := range startedPIDs(t, pidFile) {
+		alive := syscall.Kill(pid, 0) == nil
+		assert.Equal(t, pid == runningPID, alive, "pid %d", pid)
+	}
+
+	// The limit still applies to the other babies
+	assert.Equal(t, ErrTranscoderLimitReached, manager.StartTranscoding("baby2", "rtmp://localhost/local/baby2"))
+
+	manager.StopAll()
+	assert.Error(t, syscall.Kill(runningPID, 0))
+}
+
+func TestKillProcessesDoesNotWaitForLocks(t *testing.T) {
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "ffmpeg"), []byte("#!/bin/sh\nexec sleep 30\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	manager := NewHLSManager(t.TempDir())
+	defer manager.StopAll()
+
+	assert.NoError(t, manager.StartTranscoding("baby1", "rtmp://localhost/local/baby1"))
+	transcoder, _ := manager.GetTranscoder("baby1")
+	pid := transcoder.cmd.Process.Pid
+
+	// Clean up hanging with the locks held
+	manager.mutex.Lock()
+	transcoder.mutex.Lock()
+	assert.Equal(t, 1, manager.KillProcesses())
+	assert.Eventually(t, func() bool {
+		return syscall.Kill(pid, 0) != nil
+	}, 2*time.Second, 10*time.Millisecond)
+	transcoder.mutex.Unlock()
+	manager.mutex.Unlock()
+
+	// Exited processes are forgotten
+	assert.Eventually(t, func() bool {
+		return manager.KillProcesses() == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestRestartFFmpegRacingStop(t *testing.T) {
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "ffmpeg"), []byte("#!/bin/sh\nexec sleep 30\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	// Stopped while the retry was preparing, no FFmpeg is started
+	h := startFakeFFmpeg(t, "sleep", "10")
+	h.Stop()
+	cmd := h.cmd
+	assert.NoError(t, h.restartFFmpeg())
+	assert.Same(t, cmd, h.cmd)
+	assert.False(t, h.IsRunning())
+
+	// Stop right after the restart kills and waits for the new process
+	h = startFakeFFmpeg(t, "true")
+	h.cmd.Wait()
+	assert.NoError(t, h.restartFFmpeg())
+	restarted := h.cmd
+	h.Stop()
+	assert.NotNil(t, restarted.ProcessState)
+	assert.Error(t, syscall.Kill(restarted.Process.Pid, 0))
+}
+
+func TestStderrTailKeepsLastLines(t *testing.T) {
+	tail := newStderrTail(3)
+
+	tail.Write([]byte("line 1\nline 2\nframe=1\rframe=2\r"))
+	tail.Write([]byte("partial"))
+	assert.Equal(t, []string{"line 2", "frame=1", "frame=2"}, tail.Lines())
+
+	tail.Write([]byte(" line\n"))
+	assert.Equal(t, []string{"frame=1", "frame=2", "partial line"}, tail.Lines())
+}
+
+func TestParseStreamMode(t *testing.T) {
+	for value, expected := range map[string]StreamMode{"": StreamModeVideo, "video": StreamModeVideo, "audio": StreamModeAudio} {
+		mode, err := ParseStreamMode(value)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, mode)
+	}
+
+	_, err := ParseStreamMode("silent")
+	assert.Error(t, err)
+}
+
+func TestAudioModeArgs(t *testing.T) {
+	h := NewHLSTranscoder("baby1", "rtmp://localhost/local/baby1", t.TempDir(), InputOpts{})
+	h.mode = StreamModeAudio
+	args := strings.Join(h.buildFFmpegArgs(), " ")
+
+	assert.Contains(t, args, "-i rtmp://localhost/local/baby1 -vn -c:a aac ")
+	assert.NotContains(t, args, "libx264")
+	assert.Contains(t, args, "audio_%d.ts")
+	assert.True(t, strings.HasSuffix(args, "audio.m3u8"))
+	assert.Equal(t, "audio.m3u8", filepath.Base(h.GetPlaylistPath()))
+}
+
+func TestAudioTranscoderRunsNextToVideo(t *testing.T) {
+	manager := NewHLSManager(t.TempDir())
+
+	video := startFakeFFmpeg(t, "sleep", "10")
+	defer video.Stop()
+	manager.transcoders[transcoderKey{"baby1", StreamModeVideo}] = video
+
+	audio := startFakeFFmpeg(t, "sleep", "10")
+	audio.mode = StreamModeAudio
+	audio.hlsDir = video.hlsDir
+	manager.transcoders[transcoderKey{"baby1", StreamModeAudio}] = audio
+
+	transcoder, _ := manager.GetTranscoder("baby1")
+	assert.Same(t, video, transcoder)
+	transcoder, _ = manager.GetTranscoderMode("baby1", StreamModeAudio)
+	assert.Same(t, audio, transcoder)
+
+	// Both share the HLS directory, the audio transcoder only removes its own files
+	assert.NoError(t, os.MkdirAll(video.hlsDir, 0755))
+	for _, name := range []string{"playlist.m3u8", "segment_1.ts", "audio.m3u8", "audio_1.ts"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(video.hlsDir, name), nil, 0644))
+	}
+
+	manager.StopTranscodingMode("baby1", StreamModeAudio)
+	assert.False(t, audio.IsRunning())
+	assert.True(t, video.IsRunning())
+
+	files, _ := filepath.Glob(filepath.Join(video.hlsDir, "*"))
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+	assert.ElementsMatch(t, []string{"playlist.m3u8", "segment_1.ts"}, files)
+
+	_, exists := manager.GetTranscoderMode("baby1", StreamModeAudio)
+	assert.False(t, exists)
+
+	assert.Equal(t, StreamModeAudio, ModeOfFile("audio.m3u8"))
+	assert.Equal(t, StreamModeAudio, ModeOfFile("audio_12.ts"))
+	assert.Equal(t, StreamModeVideo, ModeOfFile("segment_12.ts"))
+	assert.Equal(t, StreamModeVideo, ModeOfFile(DVRPlaylistName))
+}
+
+func TestStartReportsStorageErrorWhenHLSDirNotWritable(t *testing.T) {
+	// A file in place of the base directory makes creating the HLS directory fail
+	baseDir := filepath.Join(t.TempDir(), "hls")
+	assert.NoError(t, os.WriteFile(baseDir, nil, 0644))
+
+	manager := NewHLSManager(baseDir)
+	err := manager.StartTranscoding("baby1", "rtmp://localhost/local/baby1")
+	assert.True(t, errors.Is(err, ErrHLSStorage))
+
+	transcoder, exists := manager.GetTranscoder("baby1")
+	assert.True(t, exists, "failed transcoder should stay registered for status reporting")
+	assert.False(t, transcoder.IsRunning())
+
+	status, streamError := transcoder.GetStatus()
+	assert.Equal(t, StatusError, status)
+	if assert.NotNil(t, streamError) {
+		assert.Equal(t, ErrorTypeStorage, streamError.Type)
+		assert.Equal(t, "HLS directory not writable", streamError.Message)
+	}
+
+	// Not running, so it doesn't count towards the limit
+	manager.SetMaxConcurrent(1)
+	assert.NoError(t, manager.checkLimit(transcoderKey{"baby2", StreamModeVideo}))
+}
+
+func TestClassifyAndSetErrorDetectsStorageErrors(t *testing.T) {
+	h := NewHLSTranscoder("baby1", "rtmp://localhost/local/baby1", t.TempDir(), DefaultInputOpts())
+	h.startTime = time.Now()
+	h.stderr.Write([]byte("[hls @ 0x1] Opening 'segment_3.ts' for writing\n"))
+	h.stderr.Write([]byte("[hls @ 0x1] Failed to open file 'segment_3.ts': No space left on device\n"))
+
+	h.classifyAndSetError(errors.New("exit status 1"))
+
+	_, streamError := h.GetStatus()
+	assert.Equal(t, ErrorTypeStorage, streamError.Type)
+	assert.Equal(t, "Disk full, cannot write HLS files", streamError.Message)
+	assert.False(t, h.shouldRetry())
+
+	// Without write errors on stderr the exit is still classified as an RTMP issue
+	h = NewHLSTranscoder("baby1", "rtmp://localhost/local/baby1", t.TempDir(), DefaultInputOpts())
+	h.startTime = time.Now()
+	h.classifyAndSetError(errors.New("exit status 1"))
+
+	_, streamError = h.GetStatus()
+	assert.Equal(t, ErrorTypeRTMPConnection, streamError.Type)
+}
+
+func TestStorageErrorMessage(t *testing.T) {
+	assert.Equal(t, "Disk full, cannot write HLS files", storageErrorMessage(&os.PathError{Op: "mkdir", Path: "/hls", Err: syscall.ENOSPC}))
+	assert.Equal(t, "Disk full, cannot write HLS files", storageErrorMessage(errors.New("av_interleaved_write_frame(): Disk quota exceeded")))
+	assert.Equal(t, "HLS directory not writable", storageErrorMessage(&os.PathError{Op: "mkdir", Path: "/hls", Err: syscall.EROFS}))
+	assert.Equal(t, "HLS directory not writable", storageErrorMessage(&os.PathError{Op: "mkdir", Path: "/hls", Err: syscall.EACCES}))
+}
+
+func TestOutputOptsArgs(t *testing.T) {
+	h := NewHLSTranscoder("baby1", "rtmp://localhost/local/baby1", t.TempDir(), InputOpts{})
+	args := strings.Join(h.buildFFmpegArgs(), " ")
+
+	// Defaults keep 5 segments, deleted right after they drop out of the playlist
+	assert.Contains(t, args, "-hls_time 2 -hls_list_size 5 -hls_flags delete_segments ")
+	assert.NotContains(t, args, "-hls_delete_threshold")
+	assert.Equal(t, 0, h.LivePlaylistSegments())
+	assert.False(t, h.HasDVRPlaylist())
+
+	// FFmpeg writes the DVR window, the live playlist is cut from it, segments are kept for the retention
+	h.outputOpts = OutputOpts{LiveSegments: 3, DVRWindow: 2 * time.Minute, SegmentRetention: 5 * time.Minute}
+	args = strings.Join(h.buildFFmpegArgs(), " ")
+	assert.Contains(t, args, "-hls_list_size 60 ")
+	assert.Contains(t, args, "-hls_delete_threshold 90 ")
+	assert.Equal(t, 3, h.LivePlaylistSegments())
+	assert.True(t, h.HasDVRPlaylist())
+
+	// Retention alone keeps segments on disk without a DVR playlist
+	h.outputOpts = OutputOpts{LiveSegments: 5, SegmentRetention: time.Minute}
+	args = strings.Join(h.buildFFmpegArgs(), " ")
+	assert.Contains(t, args, "-hls_list_size 5 ")
+	assert.Contains(t, args, "-hls_delete_threshold 25 ")
+	assert.Equal(t, 0, h.LivePlaylistSegments())
+}
+
+func TestLogOptsWriteFFmpegOutputToFile(t *testing.T) {
+	// FFmpeg stand-in logging its arguments and a warning, then running until stopped
+	binDir := t.TempDir()
+	script := "#!/bin/sh\necho \"args: $*\" >&2\necho 'frame dropped' >&2\nexec sleep 30\n"
+	if err := os.WriteFile(filepath.Join(binDir, "ffmpeg"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	logDir := t.TempDir()
+	h := NewHLSTranscoder("baby1", "rtmp://localhost/local/baby1", t.TempDir(), InputOpts{})
+	h.logOpts = LogOpts{Level: "warning", Dir: logDir, MaxFileSize: 1 << 20}
+
+	assert.True(t, strings.HasPrefix(strings.Join(h.buildFFmpegArgs(), " "), "-loglevel warning -i "))
+
+	if !assert.NoError(t, h.Start()) {
+		return
+	}
+
+	logFile := filepath.Join(logDir, "ffmpeg-baby1.log")
+	assert.Eventually(t, func() bool {
+		data, _ := os.ReadFile(logFile)
+		return strings.Contains(string(data), "frame dropped")
+	}, 2*time.Second, 10*time.Millisecond)
+
+	h.Stop()
+	assert.Nil(t, h.logFile)
+
+	data, err := os.ReadFile(logFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "args: -loglevel warning ")
+
+	// The diagnostics tail still receives the output
+	assert.Contains(t, h.stderr.Lines(), "frame dropped")
+}
+
+func TestStderrWriterSurvivesLogFileErrors(t *testing.T) {
+	h := NewHLSTranscoder("baby1", "rtmp://localhost/local/baby1", t.TempDir(), InputOpts{})
+	h.logOpts = LogOpts{Dir: t.TempDir()}
+
+	h.mutex.Lock()
+	writer := h.stderrWriter()
+	h.closeLogFile()
+	h.mutex.Unlock()
+
+	// Log file closed under the running process, the output still reaches the diagnostics tail
+	n, err := writer.Write([]byte("frame dropped\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 14, n)
+	assert.Equal(t, []string{"frame dropped"}, h.stderr.Lines())
+}
+
+func TestGetDetailedInfoReportsUnixStartTime(t *testing.T) {
+	h := NewHLSTranscoder("baby1", "rtmp://localhost/local/baby1", t.TempDir(), InputOpts{})
+
+	info := h.GetDetailedInfo()
+	assert.Equal(t, int64(0), info["start_time"])
+	assert.Equal(t, int64(0), info["uptime_seconds"])
+
+	h.startTime = time.Now().Add(-90 * time.Second)
+	h.isRunning = true
+
+	info = h.GetDetailedInfo()
+	assert.Equal(t, h.startTime.Unix(), info["start_time"])
+	assert.Equal(t, int64(90), info["uptime_seconds"])
+
+	startTime, uptime := h.GetStartTime()
+	assert.Equal(t, h.startTime.Unix(), startTime)
+	assert.Equal(t, int64(90), uptime)
+
+	// Stopped transcoders keep their start time, but have no uptime
+	h.isRunning = false
+	startTime, uptime = h.GetStartTime()
+	assert.Equal(t, h.startTime.Unix(), startTime)
+	assert.Equal(t, int64(0), uptime)
+}
+
+func TestEncodeOptsArgs(t *testing.T) {
+	h := NewHLSTranscoder("baby1", "rtmp://localhost/local/baby1", t.TempDir(), InputOpts{})
+	args := strings.Join(h.buildFFmpegArgs(), " ")
+	assert.NotContains(t, args, "-vf")
+	assert.NotContains(t, args, "-b:v")
+
+	h.encodeOpts = EncodeOpts{Height: 480, BitrateKbps: 600, FPS: 15}
+	args = strings.Join(h.buildFFmpegArgs(), " ")
+	assert.Contains(t, args, "-tune zerolatency -vf scale=-2:480 -r 15 -b:v 600k -maxrate 600k -bufsize 1200k -c:a aac ")
+
+	// Audio only streams have no video to encode
+	h.mode = StreamModeAudio
+	assert.NotContains(t, strings.Join(h.buildFFmpegArgs(), " "), "-b:v")
+
+	// Transcoders pick up the profile of their baby
+	manager := NewHLSManager(t.TempDir())
+	manager.SetEncodeOpts("baby1", EncodeOpts{Height: 720})
+	assert.Equal(t, EncodeOpts{Height: 720}, manager.encodeOpts["baby1"])
+	manager.SetEncodeOpts("baby1", EncodeOpts{})
+	assert.NotContains(t, manager.encodeOpts, "baby1")
+}
@@ -0,0 +1,132 @@
+package streaming
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// MosaicUID - key of the mosaic transcoder, its HLS output is stored in a directory of this name
+const MosaicUID = "mosaic"
+
+const (
+	mosaicTileWidth  = 640
+	mosaicTileHeight = 360
+	mosaicFrameRate  = 15
+)
+
+// MosaicTile - single camera of the mosaic
+type MosaicTile struct {
+	BabyUID string `json:"baby_uid"`
+	URL     string `json:"-"`    // RTMP stream of the camera, empty if it isn't publishing
+	Live    bool   `json:"live"` // False if the tile shows a placeholder
+}
+
+// NewMosaicTranscoder creates a transcoder composing the cameras into a single HLS stream laid out as a grid
+// Tiles without URL are rendered as black placeholders, the set of live cameras is fixed until the mosaic is restarted.
+func NewMosaicTranscoder(tiles []MosaicTile, baseHLSDir string, inputOpts InputOpts) *HLSTranscoder {
+	h := NewHLSTranscoder(MosaicUID, "", baseHLSDir, inputOpts)
+	h.tiles = tiles
+	return h
+}
+
+// mosaicGrid returns number of columns and rows of a grid fitting given number of tiles
+func mosaicGrid(numTiles int) (int, int) {
+	cols := int(math.Ceil(math.Sqrt(float64(numTiles))))
+	if cols < 1 {
+		cols = 1
+	}
+
+	rows := (numTiles + cols - 1) / cols
+	return cols, rows
+}
+
+// buildMosaicArgs builds FFmpeg arguments scaling every tile and stacking them with xstack (video only)
+func (h *HLSTranscoder) buildMosaicArgs() []string {
+	args := []string{}
+	filters := []string{}
+	labels := ""
+	layout := []string{}
+
+	cols, _ := mosaicGrid(len(h.tiles))
+
+	for i, tile := range h.tiles {
+		if tile.URL != "" {
+			args = append(args, h.buildInputArgs(tile.URL)...)
+		} else {
+			args = append(args,
+				"-f", "lavfi",
+				"-i", fmt.Sprintf("color=c=black:s=%dx%d:r=%d", mosaicTileWidth, mosaicTileHeight, mosaicFrameRate),
+			)
+		}
+
+		// Letterbox the camera into the tile, keeping its aspect ratio
+		filters = append(filters, fmt.Sprintf(
+			"[%d:v]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%d[v%d]",
+			i, mosaicTileWidth, mosaicTileHeight, mosaicTileWidth, mosaicTileHeight, mosaicFrameRate, i,
+		))
+		labels += fmt.Sprintf("[v%d]", i)
+		layout = append(layout, fmt.Sprintf("%d_%d", (i%cols)*mosaicTileWidth, (i/cols)*mosaicTileHeight))
+	}
+
+	if len(h.tiles) == 1 {
+		filters = append(filters, "[v0]null[out]")
+	} else {
+		// Cells left empty by an incomplete last row are filled with black
+		filters = append(filters, fmt.Sprintf("%sxstack=inputs=%d:layout=%s:fill=black[out]", labels, len(h.tiles), strings.Join(layout, "|")))
+	}
+
+	args = append(args,
+		"-filter_complex", strings.Join(filters, ";"),
+		"-map", "[out]",
+		"-an",             // Audio of several cameras would overlap
+		"-c:v", "libx264", // Video codec
+		"-preset", "ultrafast", // Fast encoding
+		"-tune", "zerolatency", // Low latency
+	)
+
+	return append(args, h.buildOutputArgs()...)
+}
+
+// StartMosaic starts (or restarts) the mosaic transcoder
+// Returns ErrTranscoderLimitReached if the maximum of concurrent transcoders is running already.
+func (m *HLSManager) StartMosaic(tiles []MosaicTile) error {
+	if len(tiles) == 0 {
+		return fmt.Errorf("mosaic needs at least one tile")
+	}
+
+	key := transcoderKey{MosaicUID, StreamModeVideo}
+	lock := m.babyLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	// Checked before anything is stopped, the running mosaic is kept if the new one can't be started
+	if err := m.reserve(key); err != nil {
+		return err
+	}
+
+	m.mutex.RLock()
+	existing := m.transcoders[key]
+	transcoder := NewMosaicTranscoder(tiles, m.baseHLSDir, m.inputOpts)
+	transcoder.outputOpts = m.outputOpts
+	transcoder.logOpts = m.logOpts
+	transcoder.processes = &m.processes
+	m.mutex.RUnlock()
+
+	if existing != nil {
+		existing.Stop()
+	}
+
+	if err := transcoder.Start(); err != nil {
+		m.replace(key, existing, nil)
+		return err
+	}
+
+	m.replace(key, existing, transcoder)
+	return nil
+}
+
+// StopMosaic stops the mosaic transcoder
+func (m *HLSManager) StopMosaic() {
+	m.StopTranscoding(MosaicUID)
+}
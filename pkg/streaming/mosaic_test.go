@@ -0,0 +1,60 @@
+package streaming
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMosaicGrid(t *testing.T) {
+	for numTiles, expected := range map[int][2]int{1: {1, 1}, 2: {2, 1}, 3: {2, 2}, 4: {2, 2}, 5: {3, 2}, 9: {3, 3}} {
+		cols, rows := mosaicGrid(numTiles)
+		assert.Equal(t, expected, [2]int{cols, rows}, "%d tiles", numTiles)
+	}
+}
+
+func TestBuildMosaicArgs(t *testing.T) {
+	h := NewMosaicTranscoder([]MosaicTile{
+		{BabyUID: "baby1", URL: "rtmp://localhost/local/baby1", Live: true},
+		{BabyUID: "baby2"},
+		{BabyUID: "baby3", URL: "rtmp://localhost/local/baby3", Live: true},
+	}, t.TempDir(), InputOpts{})
+	args := strings.Join(h.buildFFmpegArgs(), " ")
+
+	assert.Contains(t, args, "-i rtmp://localhost/local/baby1 ")
+	assert.Contains(t, args, "-f lavfi -i color=c=black:s=640x360:r=15 ")
+	assert.Contains(t, args, "-i rtmp://localhost/local/baby3 ")
+	assert.Contains(t, args, "[v0][v1][v2]xstack=inputs=3:layout=0_0|640_0|0_360:fill=black[out]")
+	assert.Contains(t, args, "-map [out] -an")
+	assert.True(t, strings.HasSuffix(args, "playlist.m3u8"))
+}
+
+func TestStartMosaicRequiresTiles(t *testing.T) {
+	manager := NewHLSManager(t.TempDir())
+	assert.Error(t, manager.StartMosaic(nil))
+
+	_, exists := manager.GetTranscoder(MosaicUID)
+	assert.False(t, exists)
+}
+
+func TestStartMosaicKeepsRunningMosaicAtLimit(t *testing.T) {
+	manager := NewHLSManager(t.TempDir())
+	manager.SetMaxConcurrent(1)
+
+	running := startFakeFFmpeg(t, "sleep", "10")
+	defer running.Stop()
+	manager.transcoders[transcoderKey{"baby1", StreamModeVideo}] = running
+
+	mosaic := startFakeFFmpeg(t, "sleep", "10")
+	defer mosaic.Stop()
+	manager.transcoders[transcoderKey{MosaicUID, StreamModeVideo}] = mosaic
+
+	err := manager.StartMosaic([]MosaicTile{{BabyUID: "baby1", URL: "rtmp://localhost/local/baby1", Live: true}})
+	assert.Equal(t, ErrTranscoderLimitReached, err)
+
+	transcoder, exists := manager.GetTranscoder(MosaicUID)
+	assert.True(t, exists)
+	assert.Same(t, mosaic, transcoder)
+	assert.True(t, mosaic.IsRunning())
+}
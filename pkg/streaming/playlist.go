@@ -0,0 +1,111 @@
+package streaming
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TrimPlaylist keeps the last maxSegments segments of a media playlist, the media (and discontinuity) sequence
+// numbers are advanced by the dropped segments so players keep their position
+// Playlists with at most maxSegments segments are returned unchanged.
+func TrimPlaylist(playlist []byte, maxSegments int) []byte {
+	lines := strings.Split(strings.TrimRight(string(playlist), "\n"), "\n")
+
+	// Header runs until the tags of the first segment, segments end with their URI line
+	var header []string
+	var segments [][]string
+	var footer []string
+	var pending []string
+	inHeader := true
+
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+
+		switch {
+		case line == "#EXT-X-ENDLIST":
+			footer = append(footer, line)
+		case inHeader && strings.HasPrefix(line, "#") && !isSegmentTag(line):
+			header = append(header, line)
+		case strings.HasPrefix(line, "#") || line == "":
+			inHeader = false
+			pending = append(pending, line)
+		default:
+			inHeader = false
+			segments = append(segments, append(pending, line))
+			pending = nil
+		}
+	}
+
+	if maxSegments <= 0 || len(segments) <= maxSegments {
+		return playlist
+	}
+
+	dropped := segments[:len(segments)-maxSegments]
+	droppedDiscontinuities := 0
+	for _, segment := range dropped {
+		for _, line := range segment {
+			if line == "#EXT-X-DISCONTINUITY" {
+				droppedDiscontinuities++
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	for _, line := range header {
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			line = advanceSequence(line, "#EXT-X-MEDIA-SEQUENCE:", len(dropped))
+		case strings.HasPrefix(line, "#EXT-X-DISCONTINUITY-SEQUENCE:"):
+			line = advanceSequence(line, "#EXT-X-DISCONTINUITY-SEQUENCE:", droppedDiscontinuities)
+		}
+		out.WriteString(line + "\n")
+	}
+
+	if droppedDiscontinuities > 0 && !hasTag(header, "#EXT-X-DISCONTINUITY-SEQUENCE:") {
+		out.WriteString(fmt.Sprintf("#EXT-X-DISCONTINUITY-SEQUENCE:%d\n", droppedDiscontinuities))
+	}
+
+	for _, segment := range segments[len(dropped):] {
+		for _, line := range segment {
+			out.WriteString(line + "\n")
+		}
+	}
+
+	for _, line := range append(pending, footer...) {
+		out.WriteString(line + "\n")
+	}
+
+	return out.Bytes()
+}
+
+// isSegmentTag returns whether the tag applies to the following segment rather than the whole playlist
+func isSegmentTag(line string) bool {
+	for _, tag := range []string{"#EXTINF:", "#EXT-X-DISCONTINUITY", "#EXT-X-PROGRAM-DATE-TIME:", "#EXT-X-BYTERANGE:"} {
+		if strings.HasPrefix(line, tag) && !strings.HasPrefix(line, "#EXT-X-DISCONTINUITY-SEQUENCE:") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func advanceSequence(line string, tag string, by int) string {
+	sequence, err := strconv.Atoi(strings.TrimPrefix(line, tag))
+	if err != nil {
+		return line
+	}
+
+	return tag + strconv.Itoa(sequence+by)
+}
+
+func hasTag(lines []string, tag string) bool {
+	for _, line := range lines {
+		if strings.HasPrefix(line, tag) {
+			return true
+		}
+	}
+
+	return false
+}
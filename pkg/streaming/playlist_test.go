@@ -0,0 +1,55 @@
+package streaming
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testPlaylist returns a playlist as written by FFmpeg with the segments starting at sequence
+func testPlaylist(sequence int, segments int, discontinuityAt int) string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:2\n")
+	fmt.Fprintf(&b, "#EXT-X-MEDIA-SEQUENCE:%d\n", sequence)
+	for i := 0; i < segments; i++ {
+		if i == discontinuityAt {
+			b.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		fmt.Fprintf(&b, "#EXTINF:2.000000,\nsegment_%d.ts\n", sequence+i)
+	}
+
+	return b.String()
+}
+
+func TestTrimPlaylist(t *testing.T) {
+	trimmed := string(TrimPlaylist([]byte(testPlaylist(10, 30, -1)), 5))
+
+	assert.Contains(t, trimmed, "#EXT-X-MEDIA-SEQUENCE:35\n")
+	assert.Equal(t, 5, strings.Count(trimmed, "#EXTINF:"))
+	assert.NotContains(t, trimmed, "segment_34.ts")
+	assert.True(t, strings.HasSuffix(trimmed, "#EXTINF:2.000000,\nsegment_39.ts\n"))
+	assert.True(t, strings.HasPrefix(trimmed, "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:2\n"))
+
+	// Short playlists are returned as they are
+	short := testPlaylist(0, 3, -1)
+	assert.Equal(t, short, string(TrimPlaylist([]byte(short), 5)))
+}
+
+func TestTrimPlaylistDiscontinuity(t *testing.T) {
+	// Discontinuity of a dropped segment advances the discontinuity sequence
+	trimmed := string(TrimPlaylist([]byte(testPlaylist(0, 10, 2)), 5))
+	assert.Contains(t, trimmed, "#EXT-X-MEDIA-SEQUENCE:5\n")
+	assert.Contains(t, trimmed, "#EXT-X-DISCONTINUITY-SEQUENCE:1\n")
+	assert.NotContains(t, trimmed, "#EXT-X-DISCONTINUITY\n")
+
+	// Discontinuity of a kept segment stays in front of it
+	trimmed = string(TrimPlaylist([]byte(testPlaylist(0, 10, 7)), 5))
+	assert.NotContains(t, trimmed, "#EXT-X-DISCONTINUITY-SEQUENCE")
+	assert.Contains(t, trimmed, "#EXT-X-DISCONTINUITY\n#EXTINF:2.000000,\nsegment_7.ts\n")
+
+	// End of a finished playlist is kept
+	trimmed = string(TrimPlaylist([]byte(testPlaylist(0, 10, -1)+"#EXT-X-ENDLIST\n"), 5))
+	assert.True(t, strings.HasSuffix(trimmed, "segment_9.ts\n#EXT-X-ENDLIST\n"))
+}
@@ -0,0 +1,203 @@
+package streaming
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// probeTimeout - maximum time ffprobe is allowed to run
+	probeTimeout = 10 * time.Second
+
+	// probeCacheTTL - how long probe results are reused before probing again
+	probeCacheTTL = 30 * time.Second
+)
+
+// ProbeResult describes the streams found in an RTMP source
+type ProbeResult struct {
+	HasVideo   bool    `json:"has_video"`
+	HasAudio   bool    `json:"has_audio"`
+	VideoCodec string  `json:"video_codec,omitempty"`
+	AudioCodec string  `json:"audio_codec,omitempty"`
+	Width      int     `json:"width,omitempty"`
+	Height     int     `json:"height,omitempty"`
+	FPS        float64 `json:"fps,omitempty"`
+	Bitrate    int64   `json:"bitrate,omitempty"` // bits per second
+	ProbedAt   int64   `json:"probed_at"`         // Unix timestamp of the probe
+}
+
+// IsHealthy returns whether the probed stream contains decodable video
+func (p *ProbeResult) IsHealthy() bool {
+	return p.HasVideo && p.Width > 0 && p.Height > 0
+}
+
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType    string `json:"codec_type"`
+		CodecName    string `json:"codec_name"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+		AvgFrameRate string `json:"avg_frame_rate"`
+		BitRate      string `json:"bit_rate"`
+	} `json:"streams"`
+	Format struct {
+		BitRate string `json:"bit_rate"`
+	} `json:"format"`
+}
+
+type probeCacheEntry struct {
+	result *ProbeResult
+	err    error
+	time   time.Time
+}
+
+var (
+	probeCache   = make(map[string]probeCacheEntry)
+	probesActive = make(map[string]chan struct{}) // Probes in flight, closed once the result is cached
+	probeCacheMu sync.Mutex
+)
+
+// ProbeStream runs ffprobe against the RTMP source and returns the stream details.
+// Results (including failures) are cached briefly and concurrent callers share a single ffprobe, so that
+// repeated calls don't spawn ffprobe each time.
+func ProbeStream(rtmpURL string) (*ProbeResult, error) {
+	probeCacheMu.Lock()
+	if entry, ok := probeCache[rtmpURL]; ok && time.Since(entry.time) < probeCacheTTL {
+		probeCacheMu.Unlock()
+		return entry.result, entry.err
+	}
+	done := startProbe(rtmpURL)
+	probeCacheMu.Unlock()
+
+	<-done
+
+	probeCacheMu.Lock()
+	entry := probeCache[rtmpURL]
+	probeCacheMu.Unlock()
+
+	return entry.result, entry.err
+}
+
+// CachedProbe returns the last successful probe result of the RTMP source without waiting for ffprobe, nil if there is none
+// A missing or expired result is refreshed in the background, by one ffprobe per source at a time.
+func CachedProbe(rtmpURL string) *ProbeResult {
+	probeCacheMu.Lock()
+	defer probeCacheMu.Unlock()
+
+	entry, ok := probeCache[rtmpURL]
+	if !ok || time.Since(entry.time) >= probeCacheTTL {
+		startProbe(rtmpURL)
+	}
+
+	return entry.result
+}
+
+// startProbe runs ffprobe against the source in the background unless it is probed already, probeCacheMu has to be held
+// Returns a channel closed once the result is cached.
+func startProbe(rtmpURL string) <-chan struct{} {
+	if done, ok := probesActive[rtmpURL]; ok {
+		return done
+	}
+
+	done := make(chan struct{})
+	probesActive[rtmpURL] = done
+
+	go func() {
+		result, err := runProbe(rtmpURL)
+
+		probeCacheMu.Lock()
+		probeCache[rtmpURL] = probeCacheEntry{result: result, err: err, time: time.Now()}
+		delete(probesActive, rtmpURL)
+		probeCacheMu.Unlock()
+		close(done)
+	}()
+
+	return done
+}
+
+// runProbe executes ffprobe and parses its JSON output
+func runProbe(rtmpURL string) (*ProbeResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-show_streams",
+		"-show_format",
+		"-of", "json",
+		rtmpURL,
+	)
+
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		log.Debug().Str("rtmp_url", rtmpURL).Msg("Stream probe timed out")
+		return nil, fmt.Errorf("stream probe timed out after %v", probeTimeout)
+	}
+	if err != nil {
+		log.Debug().Err(err).Str("rtmp_url", rtmpURL).Msg("Stream probe failed")
+		return nil, fmt.Errorf("failed to probe stream: %w", err)
+	}
+
+	return parseProbeOutput(out)
+}
+
+// parseProbeOutput converts ffprobe JSON output to ProbeResult
+func parseProbeOutput(out []byte) (*ProbeResult, error) {
+	var parsed ffprobeOutput
+	if err := json.Unmarshal(out, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode ffprobe output: %w", err)
+	}
+
+	result := &ProbeResult{ProbedAt: time.Now().Unix()}
+
+	for _, stream := range parsed.Streams {
+		switch stream.CodecType {
+		case "video":
+			result.HasVideo = true
+			result.VideoCodec = stream.CodecName
+			result.Width = stream.Width
+			result.Height = stream.Height
+			result.FPS = parseFrameRate(stream.AvgFrameRate)
+			if bitrate, err := strconv.ParseInt(stream.BitRate, 10, 64); err == nil {
+				result.Bitrate = bitrate
+			}
+		case "audio":
+			result.HasAudio = true
+			result.AudioCodec = stream.CodecName
+		}
+	}
+
+	// Fall back to the container bitrate if the video stream does not report one
+	if result.Bitrate == 0 {
+		if bitrate, err := strconv.ParseInt(parsed.Format.BitRate, 10, 64); err == nil {
+			result.Bitrate = bitrate
+		}
+	}
+
+	return result, nil
+}
+
+// parseFrameRate parses ffprobe rational frame rate (e.g. "30/1")
+func parseFrameRate(rate string) float64 {
+	parts := strings.Split(rate, "/")
+	if len(parts) != 2 {
+		value, _ := strconv.ParseFloat(rate, 64)
+		return value
+	}
+
+	num, numErr := strconv.ParseFloat(parts[0], 64)
+	den, denErr := strconv.ParseFloat(parts[1], 64)
+	if numErr != nil || denErr != nil || den == 0 {
+		return 0
+	}
+
+	return num / den
+}
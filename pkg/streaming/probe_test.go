@@ -0,0 +1,78 @@
+package streaming
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachedProbeDoesNotWaitForFFprobe(t *testing.T) {
+	// ffprobe stand-in taking a while to report a 640x480 video stream
+	binDir := t.TempDir()
+	script := "#!/bin/sh\nsleep 0.2\necho '{\"streams\":[{\"codec_type\":\"video\",\"codec_name\":\"h264\",\"width\":640,\"height\":480}]}'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "ffprobe"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	rtmpURL := "rtmp://localhost/local/cached_probe"
+
+	start := time.Now()
+	assert.Nil(t, CachedProbe(rtmpURL))
+	assert.Less(t, int64(time.Since(start)), int64(100*time.Millisecond))
+
+	var result *ProbeResult
+	assert.Eventually(t, func() bool {
+		result = CachedProbe(rtmpURL)
+		return result != nil
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.True(t, result.IsHealthy())
+
+	// Reused by the probe endpoint within the TTL
+	probed, err := ProbeStream(rtmpURL)
+	assert.NoError(t, err)
+	assert.Same(t, result, probed)
+}
+
+func TestProbeStreamSharesConcurrentFFprobe(t *testing.T) {
+	// ffprobe stand-in counting its runs
+	binDir := t.TempDir()
+	runs := filepath.Join(binDir, "runs")
+	script := "#!/bin/sh\necho run >> " + runs + "\nsleep 0.2\necho '{\"streams\":[{\"codec_type\":\"video\",\"codec_name\":\"h264\",\"width\":640,\"height\":480}]}'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "ffprobe"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	// Unique per run, results are cached process-wide
+	rtmpURL := fmt.Sprintf("rtmp://localhost/local/shared_probe_%d", time.Now().UnixNano())
+
+	// Burst of status polls
+	results := make([]*ProbeResult, 10)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result, err := ProbeStream(rtmpURL)
+			assert.NoError(t, err)
+			results[i] = result
+		}(i)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		assert.Same(t, results[0], result)
+	}
+	assert.True(t, results[0].IsHealthy())
+
+	data, err := os.ReadFile(runs)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "run"))
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// Cooldown - lets through only the first event of a kind within a time window
+type Cooldown struct {
+	defaultWindow time.Duration
+	windows       map[string]time.Duration
+	lastEvents    map[string]time.Time
+	mutex         sync.Mutex
+}
+
+// NewCooldown - constructor, windows overrides the default window per event type
+func NewCooldown(defaultWindow time.Duration, windows map[string]time.Duration) *Cooldown {
+	if windows == nil {
+		windows = make(map[string]time.Duration)
+	}
+
+	return &Cooldown{
+		defaultWindow: defaultWindow,
+		windows:       windows,
+		lastEvents:    make(map[string]time.Time),
+	}
+}
+
+// Allow - returns whether the event should propagate, events are keyed by source (e.g. baby UID) and type
+func (c *Cooldown) Allow(source string, eventType string, eventTime time.Time) bool {
+	window, ok := c.windows[eventType]
+	if !ok {
+		window = c.defaultWindow
+	}
+
+	if window <= 0 {
+		return true
+	}
+
+	key := source + ":" + eventType
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if last, ok := c.lastEvents[key]; ok {
+		// Events may arrive out of order (e.g. polled in batches), so compare in both directions
+		diff := eventTime.Sub(last)
+		if diff < window && diff > -window {
+			return false
+		}
+
+		if eventTime.Before(last) {
+			return true
+		}
+	}
+
+	c.lastEvents[key] = eventTime
+	return true
+}
@@ -0,0 +1,41 @@
+package utils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCooldown(t *testing.T) {
+	cooldown := utils.NewCooldown(30*time.Second, map[string]time.Duration{
+		"sound": 10 * time.Second,
+	})
+
+	start := time.Unix(1700000000, 0)
+
+	assert.True(t, cooldown.Allow("baby1", "motion", start))
+	assert.False(t, cooldown.Allow("baby1", "motion", start.Add(5*time.Second)))
+	assert.False(t, cooldown.Allow("baby1", "motion", start.Add(29*time.Second)))
+	assert.True(t, cooldown.Allow("baby1", "motion", start.Add(30*time.Second)))
+
+	// Keyed by source and type
+	assert.True(t, cooldown.Allow("baby2", "motion", start.Add(5*time.Second)))
+	assert.True(t, cooldown.Allow("baby1", "sound", start.Add(5*time.Second)))
+
+	// Per type override
+	assert.False(t, cooldown.Allow("baby1", "sound", start.Add(14*time.Second)))
+	assert.True(t, cooldown.Allow("baby1", "sound", start.Add(15*time.Second)))
+
+	// Older events within the window are suppressed as well
+	assert.False(t, cooldown.Allow("baby1", "motion", start.Add(10*time.Second)))
+}
+
+func TestCooldownDisabled(t *testing.T) {
+	cooldown := utils.NewCooldown(0, nil)
+
+	start := time.Unix(1700000000, 0)
+	assert.True(t, cooldown.Allow("baby1", "motion", start))
+	assert.True(t, cooldown.Allow("baby1", "motion", start))
+}
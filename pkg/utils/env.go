@@ -1,9 +1,12 @@
 package utils
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -12,6 +15,31 @@ import (
 
 // TODO: We should probably use some library if there is need for additional functionality
 
+// envVarErrors - invalid variables collected instead of exiting, see CollectEnvVarErrors
+var envVarErrors *[]error
+
+// envVarFail - reports an invalid or missing environment variable, exits unless the errors are being collected
+func envVarFail(err error) {
+	if envVarErrors != nil {
+		*envVarErrors = append(*envVarErrors, err)
+		return
+	}
+
+	log.Fatal().Msg(err.Error())
+}
+
+// CollectEnvVarErrors - runs the callback with the EnvVar* functions returning the default value for invalid variables
+// instead of exiting, returns the problems found (e.g. to report all of them when checking the configuration)
+// Not safe for concurrent use, meant for loading the options on startup.
+func CollectEnvVarErrors(callback func()) []error {
+	errs := make([]error, 0)
+	envVarErrors = &errs
+	defer func() { envVarErrors = nil }()
+
+	callback()
+	return errs
+}
+
 // EnvVarStr - retrieves value of string environment variable, while applying default
 func EnvVarStr(varName string, defaultValue string) string {
 	value := os.Getenv(varName)
@@ -23,12 +51,50 @@ func EnvVarStr(varName string, defaultValue string) string {
 	return value
 }
 
+// EnvVarStrOrFile - retrieves value of string environment variable, or reads it from the file pointed to by the
+// variable with _FILE suffix (Docker/Kubernetes secrets). The file takes precedence, trailing whitespace is trimmed.
+func EnvVarStrOrFile(varName string, defaultValue string) string {
+	filePath := os.Getenv(varName + "_FILE")
+	if filePath == "" {
+		return EnvVarStr(varName, defaultValue)
+	}
+
+	if os.Getenv(varName) != "" {
+		log.Warn().Msgf("Both %v and %v_FILE are set, using %v_FILE", varName, varName, varName)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		envVarFail(fmt.Errorf("unable to read secret file %v for environment variable %v_FILE: %w", filePath, varName, err))
+		return defaultValue
+	}
+
+	value := strings.TrimRight(string(content), " \t\r\n")
+	if value == "" {
+		return defaultValue
+	}
+
+	return value
+}
+
+// EnvVarStrList - retrieves comma separated list environment variable, entries are trimmed and empty entries dropped
+func EnvVarStrList(varName string) []string {
+	values := make([]string, 0)
+	for _, value := range strings.Split(os.Getenv(varName), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
 // EnvVarReqStr - retrieves value of string environment variable, fails if it is not present or empty
 func EnvVarReqStr(varName string) string {
 	value := EnvVarStr(varName, "")
 
 	if value == "" {
-		log.Fatal().Msgf("Missing required environment variable %v. Please set this variable and restart the application.", varName)
+		envVarFail(fmt.Errorf("missing required environment variable %v, please set this variable and restart the application", varName))
 	}
 
 	return value
@@ -45,8 +111,27 @@ func EnvVarBool(varName string, defaultValue bool) bool {
 		return defaultValue
 	}
 
-	log.Fatal().Msgf("Invalid value '%v' for boolean environment variable %v. Allowed values: 'true', 'false'", value, varName)
-	return false
+	envVarFail(fmt.Errorf("invalid value '%v' for boolean environment variable %v, allowed values: 'true', 'false'", value, varName))
+	return defaultValue
+}
+
+// EnvVarBoolsWithPrefix - retrieves all boolean environment variables starting with prefix
+// Returns map keyed by the lowercased remainder of the variable name, fails if any of them contains non-boolean value
+func EnvVarBoolsWithPrefix(prefix string) map[string]bool {
+	values := make(map[string]bool)
+
+	for _, entry := range os.Environ() {
+		name, _, found := strings.Cut(entry, "=")
+		if !found || !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+
+		if value := os.Getenv(name); value != "" {
+			values[strings.ToLower(strings.TrimPrefix(name, prefix))] = EnvVarBool(name, false)
+		}
+	}
+
+	return values
 }
 
 // EnvVarInt - retrieves value of integer environment variable, while applying default
@@ -59,7 +144,25 @@ func EnvVarInt(varName string, defaultValue int) int {
 
 	value, err := strconv.Atoi(valueStr)
 	if err != nil {
-		log.Fatal().Msgf("Invalid value '%v' for integer environment variable %v. Please provide a valid integer.", valueStr, varName)
+		envVarFail(fmt.Errorf("invalid value '%v' for integer environment variable %v, please provide a valid integer", valueStr, varName))
+		return defaultValue
+	}
+
+	return value
+}
+
+// EnvVarFloat - retrieves value of decimal number environment variable, while applying default
+func EnvVarFloat(varName string, defaultValue float64) float64 {
+	valueStr := os.Getenv(varName)
+
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		envVarFail(fmt.Errorf("invalid value '%v' for number environment variable %v, please provide a valid number", valueStr, varName))
+		return defaultValue
 	}
 
 	return value
@@ -75,7 +178,8 @@ func EnvVarSeconds(varName string, defaultValue time.Duration) time.Duration {
 
 	valueInt, err := strconv.ParseInt(valueStr, 10, 64)
 	if err != nil {
-		log.Fatal().Msgf("Invalid value '%v' for duration environment variable %v. Please provide a valid number of seconds.", valueStr, varName)
+		envVarFail(fmt.Errorf("invalid value '%v' for duration environment variable %v, please provide a valid number of seconds", valueStr, varName))
+		return defaultValue
 	}
 
 	value := time.Duration(valueInt) * time.Second
@@ -83,17 +187,83 @@ func EnvVarSeconds(varName string, defaultValue time.Duration) time.Duration {
 	return value
 }
 
-// LoadDotEnvFile - Loads environment variables from .env file in the current working directory (if found)
-func LoadDotEnvFile() {
-	absFilepath, filePathErr := filepath.Abs(".env")
-	if filePathErr != nil {
-		log.Fatal().Str("path", absFilepath).Err(filePathErr).Msg("Unable to retrieve absolute file path for .env file")
+// LoadDotEnvFile - Loads environment variables from the .env file (NANIT_ENV_FILE or .env in the current working directory)
+// and its .local overrides (if found), returns the loaded files
+func LoadDotEnvFile() []string {
+	files, err := LoadDotEnvFiles(os.Getenv("NANIT_ENV_FILE"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to load .env file")
 	}
 
-	// loads values from .env into the system
-	if err := godotenv.Load(absFilepath); err != nil {
-		log.Info().Str("path", absFilepath).Msg("No .env file found. Using only environment variables")
-	} else {
-		log.Info().Str("path", absFilepath).Msg("Additional environment variables loaded from .env file")
+	if len(files) == 0 {
+		log.Info().Msg("No .env file found. Using only environment variables")
+		return files
+	}
+
+	log.Info().Strs("files", files).Msg("Additional environment variables loaded from .env files")
+
+	if empty := EmptyDotEnvVars(files); len(empty) > 0 {
+		log.Warn().Strs("variables", empty).Msg("Variables of the .env files resolved to empty values, check for references to unset variables")
+	}
+
+	return files
+}
+
+// LoadDotEnvFiles - Loads the env file (.env if empty) followed by the file with .local suffix next to it, returns the loaded files
+// Variables of the env file don't replace the ones already set in the environment, the .local file overrides both.
+// Missing files are skipped unless the env file was given explicitly.
+func LoadDotEnvFiles(envFile string) ([]string, error) {
+	explicit := envFile != ""
+	if !explicit {
+		envFile = ".env"
 	}
+
+	absFilepath, err := filepath.Abs(envFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve absolute file path for %s: %w", envFile, err)
+	}
+
+	var loaded []string
+
+	if _, err := os.Stat(absFilepath); err == nil {
+		if err := godotenv.Load(absFilepath); err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", absFilepath, err)
+		}
+		loaded = append(loaded, absFilepath)
+	} else if explicit {
+		return nil, fmt.Errorf("env file %s: %w", absFilepath, err)
+	}
+
+	localFilepath := absFilepath + ".local"
+	if _, err := os.Stat(localFilepath); err == nil {
+		if err := godotenv.Overload(localFilepath); err != nil {
+			return loaded, fmt.Errorf("failed to load %s: %w", localFilepath, err)
+		}
+		loaded = append(loaded, localFilepath)
+	}
+
+	return loaded, nil
+}
+
+// EmptyDotEnvVars - returns sorted names of the variables declared in the files which are empty in the environment
+// e.g. references to unset variables (NANIT_RTMP_ADDR=${HOST_IP}:1935 resolves, NANIT_MQTT_PASSWORD=${SECRET} doesn't).
+func EmptyDotEnvVars(files []string) []string {
+	if len(files) == 0 {
+		return nil
+	}
+
+	declared, err := godotenv.Read(files...)
+	if err != nil {
+		return nil
+	}
+
+	var empty []string
+	for name := range declared {
+		if os.Getenv(name) == "" {
+			empty = append(empty, name)
+		}
+	}
+	sort.Strings(empty)
+
+	return empty
 }
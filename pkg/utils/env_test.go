@@ -0,0 +1,105 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvVarStrOrFile(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	assert.NoError(t, os.WriteFile(secretFile, []byte("from-file\n"), 0600))
+
+	t.Setenv("NANIT_TEST_SECRET", "")
+	t.Setenv("NANIT_TEST_SECRET_FILE", "")
+	assert.Equal(t, "default", utils.EnvVarStrOrFile("NANIT_TEST_SECRET", "default"))
+
+	t.Setenv("NANIT_TEST_SECRET", "from-env")
+	assert.Equal(t, "from-env", utils.EnvVarStrOrFile("NANIT_TEST_SECRET", "default"))
+
+	// File variant takes precedence and is trimmed
+	t.Setenv("NANIT_TEST_SECRET_FILE", secretFile)
+	assert.Equal(t, "from-file", utils.EnvVarStrOrFile("NANIT_TEST_SECRET", "default"))
+}
+
+func TestEnvVarBoolsWithPrefix(t *testing.T) {
+	t.Setenv("NANIT_TEST_FLAG_ABC123", "false")
+	t.Setenv("NANIT_TEST_FLAG_Def_456", "true")
+	t.Setenv("NANIT_TEST_FLAG_EMPTY", "")
+	t.Setenv("NANIT_TEST_FLAG_", "true")
+
+	assert.Equal(t, map[string]bool{
+		"abc123":  false,
+		"def_456": true,
+	}, utils.EnvVarBoolsWithPrefix("NANIT_TEST_FLAG_"))
+}
+
+func TestEnvVarStrList(t *testing.T) {
+	t.Setenv("NANIT_TEST_LIST", "")
+	assert.Empty(t, utils.EnvVarStrList("NANIT_TEST_LIST"))
+
+	t.Setenv("NANIT_TEST_LIST", " https://a.example.com, ,https://b.example.com:8123 ")
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com:8123"}, utils.EnvVarStrList("NANIT_TEST_LIST"))
+}
+
+func TestLoadDotEnvFiles(t *testing.T) {
+	for _, name := range []string{"NANIT_TEST_BASE", "NANIT_TEST_LOCAL", "NANIT_TEST_PROCESS", "NANIT_TEST_EMPTY"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	t.Setenv("NANIT_TEST_PROCESS", "process")
+
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "camera.env")
+	assert.NoError(t, os.WriteFile(envFile, []byte("NANIT_TEST_BASE=base\nNANIT_TEST_LOCAL=base\nNANIT_TEST_PROCESS=base\nNANIT_TEST_EMPTY=${NANIT_TEST_UNSET}\n"), 0644))
+
+	// Explicit env file has to exist
+	_, err := utils.LoadDotEnvFiles(filepath.Join(dir, "missing.env"))
+	assert.Error(t, err)
+
+	files, err := utils.LoadDotEnvFiles(envFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{envFile}, files)
+	assert.Equal(t, "base", os.Getenv("NANIT_TEST_BASE"))
+	assert.Equal(t, "process", os.Getenv("NANIT_TEST_PROCESS"))
+	assert.Equal(t, []string{"NANIT_TEST_EMPTY"}, utils.EmptyDotEnvVars(files))
+
+	// The .local file overrides both the env file and the environment
+	assert.NoError(t, os.WriteFile(envFile+".local", []byte("NANIT_TEST_LOCAL=local\nNANIT_TEST_PROCESS=local\n"), 0644))
+	files, err = utils.LoadDotEnvFiles(envFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{envFile, envFile + ".local"}, files)
+	assert.Equal(t, "local", os.Getenv("NANIT_TEST_LOCAL"))
+	assert.Equal(t, "local", os.Getenv("NANIT_TEST_PROCESS"))
+	assert.Equal(t, "base", os.Getenv("NANIT_TEST_BASE"))
+}
+
+func TestCollectEnvVarErrors(t *testing.T) {
+	t.Setenv("NANIT_TEST_BOOL", "yes")
+	t.Setenv("NANIT_TEST_INT", "ten")
+	t.Setenv("NANIT_TEST_SECONDS", "1m")
+	t.Setenv("NANIT_TEST_REQUIRED", "")
+
+	// Defaults are used and all the problems reported instead of exiting on the first one
+	errs := utils.CollectEnvVarErrors(func() {
+		assert.True(t, utils.EnvVarBool("NANIT_TEST_BOOL", true))
+		assert.Equal(t, 10, utils.EnvVarInt("NANIT_TEST_INT", 10))
+		assert.Equal(t, time.Minute, utils.EnvVarSeconds("NANIT_TEST_SECONDS", time.Minute))
+		utils.EnvVarReqStr("NANIT_TEST_REQUIRED")
+	})
+
+	if assert.Len(t, errs, 4) {
+		assert.Contains(t, errs[0].Error(), "NANIT_TEST_BOOL")
+		assert.Contains(t, errs[1].Error(), "NANIT_TEST_INT")
+		assert.Contains(t, errs[2].Error(), "NANIT_TEST_SECONDS")
+		assert.Contains(t, errs[3].Error(), "NANIT_TEST_REQUIRED")
+	}
+
+	assert.Empty(t, utils.CollectEnvVarErrors(func() {
+		utils.EnvVarInt("NANIT_TEST_UNSET", 1)
+	}))
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic - writes data to a temporary file in the same directory and renames it over the target,
+// so readers and crashes never see a partially written file. Mode of an existing target is preserved.
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	if info, err := os.Stat(filename); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	tmpFilename := f.Name()
+	cleanup := func() {
+		f.Close()
+		os.Remove(tmpFilename)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		cleanup()
+		return err
+	}
+
+	if err := f.Chmod(perm); err != nil {
+		cleanup()
+		return err
+	}
+
+	// Data must reach the disk before the rename makes it visible
+	if err := f.Sync(); err != nil {
+		cleanup()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(tmpFilename)
+		return err
+	}
+
+	if err := os.Rename(tmpFilename, filename); err != nil {
+		os.Remove(tmpFilename)
+		return err
+	}
+
+	// Persist the rename itself, failure to sync the directory is not fatal on every platform
+	if dir, err := os.Open(filepath.Dir(filename)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "session.json")
+
+	assert.NoError(t, utils.WriteFileAtomic(filename, []byte("first"), 0600))
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", string(data))
+
+	info, err := os.Stat(filename)
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	// Overwrite keeps the mode of the existing file
+	assert.NoError(t, os.Chmod(filename, 0640))
+	assert.NoError(t, utils.WriteFileAtomic(filename, []byte("second"), 0600))
+	data, err = os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	info, err = os.Stat(filename)
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// Failed write leaves the existing file untouched
+	assert.Error(t, utils.WriteFileAtomic(filepath.Join(dir, "missing", "session.json"), []byte("x"), 0600))
+}
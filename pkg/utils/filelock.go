@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrLocked - returned by LockFile when another process holds the lock
+var ErrLocked = errors.New("file is locked by another process")
+
+// FileLock - exclusive advisory lock held on a file until Unlock or the process exits
+type FileLock struct {
+	file *os.File
+}
+
+// LockFile - takes an exclusive lock of the file without waiting, creates the file if needed
+// The PID of the holder is written into the file, so that ErrLocked can name the other process.
+func LockFile(filename string) (*FileLock, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+
+		if errors.Is(err, ErrLocked) {
+			if pid := readLockPID(filename); pid != 0 {
+				return nil, fmt.Errorf("%w (PID %d)", ErrLocked, pid)
+			}
+		}
+		return nil, err
+	}
+
+	// The PID is informational only, the lock stays valid if it can't be written
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+
+	return &FileLock{file: f}, nil
+}
+
+// Unlock - releases the lock, the file is left in place
+// Safe to call on nil lock.
+func (lock *FileLock) Unlock() error {
+	if lock == nil || lock.file == nil {
+		return nil
+	}
+
+	err := unlockFile(lock.file)
+	if closeErr := lock.file.Close(); err == nil {
+		err = closeErr
+	}
+	lock.file = nil
+
+	return err
+}
+
+func readLockPID(filename string) int {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return 0
+	}
+
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
+}
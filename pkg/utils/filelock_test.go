@@ -0,0 +1,41 @@
+//go:build unix
+
+package utils_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".lock")
+
+	lock, err := utils.LockFile(filename)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid()), strings.TrimSpace(string(data)))
+
+	// Second lock of the same file fails right away, naming the holder
+	_, err = utils.LockFile(filename)
+	assert.True(t, errors.Is(err, utils.ErrLocked))
+	assert.Contains(t, err.Error(), "PID "+strconv.Itoa(os.Getpid()))
+
+	// Released lock can be taken again
+	assert.NoError(t, lock.Unlock())
+	lock, err = utils.LockFile(filename)
+	assert.NoError(t, err)
+	assert.NoError(t, lock.Unlock())
+
+	assert.NoError(t, (*utils.FileLock)(nil).Unlock())
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// PendingTasks - names of the running tasks, used to report what a shutdown still waits for
+type PendingTasks struct {
+	tasks map[string]int
+	mutex sync.Mutex
+}
+
+// NewPendingTasks - constructor
+func NewPendingTasks() *PendingTasks {
+	return &PendingTasks{tasks: make(map[string]int)}
+}
+
+// Start - marks the task as running, call the returned function once it finishes
+// Tasks may run under the same name several times at once. Safe to call on nil tracker.
+func (p *PendingTasks) Start(name string) func() {
+	if p == nil {
+		return func() {}
+	}
+
+	p.mutex.Lock()
+	p.tasks[name]++
+	p.mutex.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mutex.Lock()
+			defer p.mutex.Unlock()
+
+			if p.tasks[name]--; p.tasks[name] <= 0 {
+				delete(p.tasks, name)
+			}
+		})
+	}
+}
+
+// List - sorted names of the running tasks, with the count of tasks running under the same name
+// Safe to call on nil tracker.
+func (p *PendingTasks) List() []string {
+	names := []string{}
+	if p == nil {
+		return names
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for name, count := range p.tasks {
+		if count > 1 {
+			name = fmt.Sprintf("%s (x%d)", name, count)
+		}
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
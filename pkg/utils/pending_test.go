@@ -0,0 +1,31 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPendingTasks(t *testing.T) {
+	pending := utils.NewPendingTasks()
+	assert.Empty(t, pending.List())
+
+	doneMQTT := pending.Start("mqtt")
+	doneFirst := pending.Start("websocket baby1")
+	doneSecond := pending.Start("websocket baby1")
+	assert.Equal(t, []string{"mqtt", "websocket baby1 (x2)"}, pending.List())
+
+	// Finishing twice counts once
+	doneFirst()
+	doneFirst()
+	assert.Equal(t, []string{"mqtt", "websocket baby1"}, pending.List())
+
+	doneSecond()
+	doneMQTT()
+	assert.Empty(t, pending.List())
+
+	var nilPending *utils.PendingTasks
+	nilPending.Start("noop")()
+	assert.Empty(t, nilPending.List())
+}
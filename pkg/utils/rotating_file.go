@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"os"
+	"sync"
+)
+
+// RotatingFile - append-only log file, renamed to <filename>.1 once it would grow over the size limit
+// Only the previous file is kept, so the logs take at most twice the limit. Safe for concurrent use.
+type RotatingFile struct {
+	filename string
+	maxSize  int64
+	file     *os.File
+	size     int64
+	mutex    sync.Mutex
+}
+
+// OpenRotatingFile - opens the file for appending, maxSize 0 disables rotation
+func OpenRotatingFile(filename string, maxSize int64) (*RotatingFile, error) {
+	f := &RotatingFile{filename: filename, maxSize: maxSize}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write - appends to the file, rotating it first if the data would exceed the size limit
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate - replaces the previous file with the current one and starts a new one, caller must hold the mutex
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	if err := os.Rename(f.filename, f.filename+".1"); err != nil {
+		return err
+	}
+
+	return f.open()
+}
+
+// Close - closes the file, further writes fail
+func (f *RotatingFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,57 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/indiefan/home_assistant_nanit/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotatingFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "ffmpeg.log")
+
+	f, err := utils.OpenRotatingFile(filename, 10)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = f.Write([]byte("12345678\n"))
+	assert.NoError(t, err)
+
+	// Exceeding the limit moves the current content aside
+	_, err = f.Write([]byte("abc\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc\n", string(data))
+
+	data, err = os.ReadFile(filename + ".1")
+	assert.NoError(t, err)
+	assert.Equal(t, "12345678\n", string(data))
+
+	_, err = f.Write([]byte("closed\n"))
+	assert.Error(t, err)
+
+	// Reopening appends and counts the existing size
+	f, err = utils.OpenRotatingFile(filename, 10)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+
+	_, err = f.Write([]byte("def\n"))
+	assert.NoError(t, err)
+	data, err = os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc\ndef\n", string(data))
+
+	_, err = f.Write([]byte("ghi\n"))
+	assert.NoError(t, err)
+	data, err = os.ReadFile(filename + ".1")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc\ndef\n", string(data))
+}